@@ -25,14 +25,21 @@ import (
 	"gopkg.in/yaml.v2"
 
 	"github.com/freifunkMUC/wg-access-server/buildinfo"
+	"github.com/freifunkMUC/wg-access-server/internal/audit"
 	"github.com/freifunkMUC/wg-access-server/internal/config"
 	"github.com/freifunkMUC/wg-access-server/internal/devices"
 	"github.com/freifunkMUC/wg-access-server/internal/dnsproxy"
+	"github.com/freifunkMUC/wg-access-server/internal/ipam"
 	"github.com/freifunkMUC/wg-access-server/internal/network"
+	"github.com/freifunkMUC/wg-access-server/internal/notify"
 	"github.com/freifunkMUC/wg-access-server/internal/services"
 	"github.com/freifunkMUC/wg-access-server/internal/storage"
+	"github.com/freifunkMUC/wg-access-server/internal/tokens"
+	"github.com/freifunkMUC/wg-access-server/internal/traces"
+	"github.com/freifunkMUC/wg-access-server/internal/webhooks"
 	"github.com/freifunkMUC/wg-access-server/pkg/authnz"
 	"github.com/freifunkMUC/wg-access-server/pkg/authnz/authconfig"
+	"github.com/freifunkMUC/wg-access-server/pkg/authnz/authsession"
 )
 
 func Register(app *kingpin.Application) *servecmd {
@@ -48,8 +55,10 @@ func Register(app *kingpin.Application) *servecmd {
 	cli.Flag("enable-device-metrics", "Expose device-level metrics on /metrics (requires enable-metadata)").Envar("WG_ENABLE_DEVICE_METRICS").Default("false").BoolVar(&cmd.AppConfig.EnableDeviceMetrics)
 	cli.Flag("metrics-basic-auth-username", "Require basic auth for /metrics (username)").Envar("WG_METRICS_BASIC_AUTH_USERNAME").StringVar(&cmd.AppConfig.Metrics.BasicAuth.Username)
 	cli.Flag("metrics-basic-auth-password-hash", "Require basic auth for /metrics (bcrypt hash)").Envar("WG_METRICS_BASIC_AUTH_PASSWORD_HASH").StringVar(&cmd.AppConfig.Metrics.BasicAuth.PasswordHash)
+	cli.Flag("metrics-device-limit", "The maximum number of devices with their own metrics, 0 means unlimited").Envar("WG_METRICS_DEVICE_LIMIT").Default("1000").IntVar(&cmd.AppConfig.Metrics.DeviceLimit)
 	cli.Flag("enable-inactive-device-deletion", "Enable inactive device deletion").Envar("WG_ENABLE_INACTIVE_DEVICE_DELETION").Default("false").BoolVar(&cmd.AppConfig.EnableInactiveDeviceDeletion)
 	cli.Flag("inactive-device-grace-period", "Duration after inactive device are deleted").Envar("WG_INACTIVE_DEVICE_GRACE_PERIOD").Default((1 * config.Year).String()).DurationVar(&cmd.AppConfig.InactiveDeviceGracePeriod)
+	cli.Flag("inactive-device-warning-threshold", "Duration after inactive devices are marked as stale and their owner is notified, 0 disables warnings").Envar("WG_INACTIVE_DEVICE_WARNING_THRESHOLD").Default("0s").DurationVar(&cmd.AppConfig.InactiveDeviceWarningThreshold)
 	cli.Flag("filename", "The configuration filename (e.g. WireGuard-Home)").Envar("WG_FILENAME").StringVar(&cmd.AppConfig.Filename)
 	cli.Flag("https-enabled", "Enable HTTPS for the web UI").Envar("WG_HTTPS_ENABLED").Default("true").BoolVar(&cmd.AppConfig.HTTPS.Enabled)
 	cli.Flag("https-cert-file", "Path to the TLS certificate file").Envar("WG_HTTPS_CERT_FILE").StringVar(&cmd.AppConfig.HTTPS.CertFile)
@@ -70,6 +79,8 @@ func Register(app *kingpin.Application) *servecmd {
 	cli.Flag("vpn-nat66-enabled", "Enable or disable NAT of IPv6 traffic leaving through the gateway").Envar("WG_IPV6_NAT_ENABLED").Default("true").BoolVar(&cmd.AppConfig.VPN.NAT66)
 	cli.Flag("vpn-client-isolation", "Block or allow traffic between client devices").Envar("WG_VPN_CLIENT_ISOLATION").Default("false").BoolVar(&cmd.AppConfig.VPN.ClientIsolation)
 	cli.Flag("vpn-disable-iptables", "Disable iptables configuration completely").Envar("WG_VPN_DISABLE_IPTABLES").Default("false").BoolVar(&cmd.AppConfig.VPN.DisableIPTables)
+	cli.Flag("vpn-firewall-backend", "Install the firewall rules with 'iptables' or 'nftables', 'auto' detects the backend").Envar("WG_VPN_FIREWALL_BACKEND").Default(network.FirewallAuto).EnumVar(&cmd.AppConfig.VPN.FirewallBackend, network.FirewallAuto, network.FirewallIPTables, network.FirewallNFTables)
+	cli.Flag("vpn-firewall-reconcile-interval", "How often the firewall rules are checked and re-applied if they drifted, 0 disables it").Envar("WG_VPN_FIREWALL_RECONCILE_INTERVAL").Default("1m").DurationVar(&cmd.AppConfig.VPN.FirewallReconcileInterval)
 	cli.Flag("dns-enabled", "Enable or disable the embedded dns proxy server (useful for development)").Envar("WG_DNS_ENABLED").Default("true").BoolVar(&cmd.AppConfig.DNS.Enabled)
 	cli.Flag("dns-upstream", "An upstream DNS server to proxy DNS traffic to. Defaults to resolvconf with Cloudflare DNS as fallback").Envar("WG_DNS_UPSTREAM").StringsVar(&cmd.AppConfig.DNS.Upstream)
 	cli.Flag("dns-domain", "A domain to serve configured device names authoritatively").Envar("WG_DNS_DOMAIN").StringVar(&cmd.AppConfig.DNS.Domain)
@@ -77,6 +88,24 @@ func Register(app *kingpin.Application) *servecmd {
 	cli.Flag("clientconfig-dns-search-domain", "DNS search domain to write into the client configuration file").Envar("WG_CLIENTCONFIG_DNS_SEARCH_DOMAIN").StringVar(&cmd.AppConfig.ClientConfig.DNSSearchDomain)
 	cli.Flag("clientconfig-mtu", "The maximum transmission unit (MTU) to write into the client configuration file").Envar("WG_CLIENTCONFIG_MTU").IntVar(&cmd.AppConfig.ClientConfig.MTU)
 	cli.Flag("clientconfig-persistent-keepalive", "The default persistent keepalive interval for all clients (in seconds)").Envar("WG_CLIENTCONFIG_PERSISTENT_KEEPALIVE").Default("0").IntVar(&cmd.AppConfig.ClientConfig.PersistentKeepalive)
+	cli.Flag("ipam-reuse-cooldown", "Duration before the address of a deleted device is assigned to another device").Envar("WG_IPAM_REUSE_COOLDOWN").Default("0s").DurationVar(&cmd.AppConfig.IPAM.ReuseCooldown)
+	cli.Flag("ipam-random", "Pick the addresses of subnets with more than 2^16 addresses randomly").Envar("WG_IPAM_RANDOM").Default("false").BoolVar(&cmd.AppConfig.IPAM.Random)
+	cli.Flag("audit-file", "Append audit events as JSON lines to this file").Envar("WG_AUDIT_FILE").StringVar(&cmd.AppConfig.Audit.File)
+	cli.Flag("audit-syslog", "Send audit events to syslog, 'local' or an address like udp://host:514").Envar("WG_AUDIT_SYSLOG").StringVar(&cmd.AppConfig.Audit.Syslog)
+	cli.Flag("device-max-lifetime", "The maximum time a device may be valid, 0 means unlimited").Envar("WG_DEVICE_MAX_LIFETIME").Default("0s").DurationVar(&cmd.AppConfig.DeviceExpiry.MaxLifetime)
+	cli.Flag("expired-device-delete-after", "Delete expired devices after they have been expired for this long, 0 keeps them disabled").Envar("WG_EXPIRED_DEVICE_DELETE_AFTER").Default("0s").DurationVar(&cmd.AppConfig.DeviceExpiry.DeleteAfter)
+	cli.Flag("device-expiry-warning", "Notify owners this long before their devices expire, 0 disables warnings").Envar("WG_DEVICE_EXPIRY_WARNING").Default("168h").DurationVar(&cmd.AppConfig.DeviceExpiry.WarnBefore)
+	cli.Flag("usage-resolution", "The resolution of the recorded traffic of devices").Envar("WG_USAGE_RESOLUTION").Default("5m").DurationVar(&cmd.AppConfig.Usage.Resolution)
+	cli.Flag("usage-retention", "Keep the traffic of devices in full resolution for this long, older traffic is downsampled to days").Envar("WG_USAGE_RETENTION").Default("168h").DurationVar(&cmd.AppConfig.Usage.Retention)
+	cli.Flag("usage-daily-retention", "Keep the daily traffic of devices for this long, 0 keeps it forever").Envar("WG_USAGE_DAILY_RETENTION").Default("8760h").DurationVar(&cmd.AppConfig.Usage.DailyRetention)
+	cli.Flag("device-quota", "The maximum number of devices per user, 0 means unlimited").Envar("WG_DEVICE_QUOTA").Default("0").IntVar(&cmd.AppConfig.DeviceQuota.Default)
+	cli.Flag("traffic-quota", "The number of bytes a user may transfer per month, 0 means unlimited").Envar("WG_TRAFFIC_QUOTA").Default("0").Int64Var(&cmd.AppConfig.TrafficQuota.Default)
+	cli.Flag("traffic-quota-action", "What happens to the devices of users that exceeded their traffic quota, 'suspend' or 'throttle'").Envar("WG_TRAFFIC_QUOTA_ACTION").Default(config.TrafficQuotaSuspend).EnumVar(&cmd.AppConfig.TrafficQuota.Action, config.TrafficQuotaSuspend, config.TrafficQuotaThrottle)
+	cli.Flag("traffic-quota-throttle-rate", "The bandwidth of throttled devices in bits per second").Envar("WG_TRAFFIC_QUOTA_THROTTLE_RATE").Default("1000000").Uint64Var(&cmd.AppConfig.TrafficQuota.ThrottleRate)
+	cli.Flag("tracing-exporter", "Export OpenTelemetry traces, 'otlp' or 'stdout'").Envar("WG_TRACING_EXPORTER").StringVar(&cmd.AppConfig.Tracing.Exporter)
+	cli.Flag("tracing-endpoint", "The address of the OTLP gRPC receiver of the traces").Envar("WG_TRACING_ENDPOINT").StringVar(&cmd.AppConfig.Tracing.Endpoint)
+	cli.Flag("tracing-insecure", "Connect to the OTLP receiver without TLS").Envar("WG_TRACING_INSECURE").Default("false").BoolVar(&cmd.AppConfig.Tracing.Insecure)
+	cli.Flag("tracing-sample-ratio", "The fraction of the traces started by the server that are recorded").Envar("WG_TRACING_SAMPLE_RATIO").Default("1").Float64Var(&cmd.AppConfig.Tracing.SampleRatio)
 	return cmd
 }
 
@@ -103,9 +132,26 @@ func (cmd *servecmd) Run() {
 	// Software banner
 	logrus.Infof("+++ wg-access-server %s (%s)", buildinfo.Version(), buildinfo.ShortCommitHash())
 
+	// Tracing
+	shutdownTracing, err := traces.Setup(traces.Options{
+		Exporter:    conf.Tracing.Exporter,
+		Endpoint:    conf.Tracing.Endpoint,
+		Insecure:    conf.Tracing.Insecure,
+		SampleRatio: conf.Tracing.SampleRatio,
+	})
+	if err != nil {
+		logrus.Fatal(errors.Wrap(err, "failed to set up tracing"))
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logrus.Error(errors.Wrap(err, "failed to flush traces"))
+		}
+	}()
+
 	// Get the server's IP addresses within the VPN
 	var vpnip, vpnipv6 netip.Prefix
-	var err error
 	vpnip, vpnipv6, err = network.ServerVPNIPs(conf.VPN.CIDR, conf.VPN.CIDRv6)
 	if err != nil {
 		logrus.Fatal(err)
@@ -136,6 +182,9 @@ func (cmd *servecmd) Run() {
 
 	// WireGuard Server
 	wg := wgembed.NewNoOpInterface()
+	var firewall *network.PeerFirewall
+	var shaper *network.Shaper
+	var routes *network.Router
 	if conf.WireGuard.Enabled {
 		wgOpts := wgembed.Options{
 			InterfaceName:     conf.WireGuard.Interface,
@@ -146,7 +195,7 @@ func (cmd *servecmd) Run() {
 			logrus.Fatal(errors.Wrap(err, "failed to create WireGuard interface"))
 		}
 		defer wgimpl.Close()
-		wg = wgimpl
+		wg = traces.WireGuard(wgimpl)
 
 		logrus.Infof("Starting WireGuard on :%d", conf.WireGuard.Port)
 
@@ -167,6 +216,7 @@ func (cmd *servecmd) Run() {
 		logrus.Infof("WireGuard VPN network is %s", network.StringJoinIPNets(vpnip, vpnipv6))
 
 		options := network.ForwardingOptions{
+			Backend:         network.DetectFirewallBackend(conf.VPN.FirewallBackend),
 			GatewayIface:    conf.VPN.GatewayInterface,
 			CIDR:            conf.VPN.CIDR,
 			CIDRv6:          conf.VPN.CIDRv6,
@@ -174,13 +224,44 @@ func (cmd *servecmd) Run() {
 			NAT66:           conf.VPN.NAT66,
 			ClientIsolation: conf.VPN.ClientIsolation,
 			AllowedIPs:      conf.VPN.AllowedIPs,
+			Rules:           forwardRules(conf.VPN.Rules),
 			DisableIPTables: conf.VPN.DisableIPTables,
 		}
 
+		if !options.DisableIPTables {
+			logrus.Infof("Configuring the firewall with %s", options.Backend)
+		}
 		if err := network.ConfigureForwarding(options); err != nil {
 			logrus.Error(err)
 			return
 		}
+
+		firewall, err = network.NewPeerFirewall(options)
+		if err != nil {
+			logrus.Error(errors.Wrap(err, "failed to create device firewall"))
+			return
+		}
+		// Remove the rules again on shutdown, the VPN traffic is gone with the interface
+		defer func() {
+			if err := firewall.Close(); err != nil {
+				logrus.Error(errors.Wrap(err, "failed to remove firewall rules"))
+			}
+		}()
+		firewall.StartReconcile(conf.VPN.FirewallReconcileInterval)
+
+		routes, err = network.NewRouter(conf.WireGuard.Interface)
+		if err != nil {
+			logrus.Error(errors.Wrap(err, "failed to set up the routes of gateway devices"))
+			return
+		}
+
+		if conf.TrafficQuota.Action == config.TrafficQuotaThrottle {
+			shaper, err = network.NewShaper(conf.WireGuard.Interface, conf.TrafficQuota.ThrottleRate)
+			if err != nil {
+				logrus.Error(errors.Wrap(err, "failed to set up traffic shaping"))
+				return
+			}
+		}
 	}
 
 	// Storage
@@ -195,10 +276,51 @@ func (cmd *servecmd) Run() {
 	}
 	defer storageBackend.Close()
 
+	// Audit log
+	auditLog, err := audit.New(storageBackend, audit.Options{
+		File:   conf.Audit.File,
+		Syslog: conf.Audit.Syslog,
+	})
+	if err != nil {
+		logrus.Error(errors.Wrap(err, "failed to set up audit log"))
+		return
+	}
+	defer auditLog.Close()
+
+	// IP address management
+	allocator, err := ipam.New(storageBackend, ipam.Options{
+		CIDR:          conf.VPN.CIDR,
+		CIDRv6:        conf.VPN.CIDRv6,
+		ReuseCooldown: conf.IPAM.ReuseCooldown,
+		Reserved:      conf.IPAM.Reserved,
+		Pools:         conf.IPAM.Pools,
+		Random:        conf.IPAM.Random,
+	})
+	if err != nil {
+		logrus.Error(errors.Wrap(err, "invalid ipam configuration"))
+		return
+	}
+
 	// Device manager
-	deviceManager := devices.New(wg, storageBackend, conf.VPN.CIDR, conf.VPN.CIDRv6)
+	deviceManager := devices.New(wg, storageBackend, devices.Options{
+		CIDR:         conf.VPN.CIDR,
+		CIDRv6:       conf.VPN.CIDRv6,
+		AllowedIPs:   conf.VPN.AllowedIPs,
+		Firewall:     firewall,
+		Policies:     conf.Policies,
+		Profiles:     conf.ClientConfig.Profiles,
+		DeviceQuota:  conf.DeviceQuota,
+		DeviceExpiry: conf.DeviceExpiry,
+		Usage:        conf.Usage,
+		TrafficQuota: conf.TrafficQuota,
+		Shaper:       shaper,
+		Router:       routes,
+		IPAM:         allocator,
+		Audit:        auditLog,
+	})
 
 	// DNS Server
+	var dns *dnsproxy.DNSServer
 	if conf.DNS.Enabled {
 		if len(conf.DNS.Upstream) == 0 {
 			conf.DNS.Upstream = detectDNSUpstream(conf.VPN.CIDR != "", conf.VPN.CIDRv6 != "")
@@ -207,7 +329,7 @@ func (cmd *servecmd) Run() {
 		for _, addr := range vpnips {
 			listenAddr = append(listenAddr, net.JoinHostPort(addr.String(), "53"))
 		}
-		dns, err := dnsproxy.New(dnsproxy.DNSServerOpts{
+		dns, err = dnsproxy.New(dnsproxy.DNSServerOpts{
 			Upstream:   conf.DNS.Upstream,
 			Domain:     conf.DNS.Domain,
 			ListenAddr: listenAddr,
@@ -219,27 +341,44 @@ func (cmd *servecmd) Run() {
 		dns.ListenAndServe()
 		defer dns.Close()
 		if conf.DNS.Domain != "" {
-			// Generate initial DNS zone for registered devices
-			zone := generateZone(deviceManager, vpnips)
-			dns.PushAuthZone(zone)
-			// Update the zone in the background whenever a device changes
-			storageBackend.OnAdd(
-				func(_ *storage.Device) {
-					zone := generateZone(deviceManager, vpnips)
-					dns.PushAuthZone(zone)
-				},
-			)
-			storageBackend.OnDelete(
-				func(_ *storage.Device) {
-					zone := generateZone(deviceManager, vpnips)
-					dns.PushAuthZone(zone)
-				},
-			)
+			// Update the zone whenever a device changes, the initial zone is pushed by StartSync
+			deviceManager.OnChange(func() {
+				dns.PushAuthZone(generateZone(deviceManager, vpnips))
+			})
+		}
+	}
+
+	// Webhooks
+	dispatcher, err := webhooks.New(conf.Webhooks.Endpoints, conf.Webhooks.QueueSize, conf.Webhooks.MaxAttempts)
+	if err != nil {
+		logrus.Error(errors.Wrap(err, "invalid webhook configuration"))
+		return
+	}
+	defer dispatcher.Close()
+	dispatcher.Subscribe(storageBackend)
+	deviceManager.OnInactiveDelete(dispatcher.DeviceInactive)
+
+	// Notifications
+	var mailer *notify.Mailer
+	if conf.SMTP.Host != "" {
+		notifier, err := notify.NewSMTPNotifier(conf.SMTP)
+		if err != nil {
+			logrus.Error(errors.Wrap(err, "invalid smtp configuration"))
+			return
 		}
+		mailer, err = notify.NewMailer(notifier, conf.ExternalHost, conf.Notifications)
+		if err != nil {
+			logrus.Error(errors.Wrap(err, "invalid notification configuration"))
+			return
+		}
+		defer mailer.Close()
+		deviceManager.OnStale(mailer.DeviceStale)
+		deviceManager.OnExpiring(mailer.DeviceExpiring)
 	}
 
 	// Services
-	if err := deviceManager.StartSync(conf.EnableMetadata, conf.EnableInactiveDeviceDeletion, conf.InactiveDeviceGracePeriod); err != nil {
+
+	if err := deviceManager.StartSync(conf.EnableMetadata, conf.EnableInactiveDeviceDeletion, conf.InactiveDeviceGracePeriod, conf.InactiveDeviceWarningThreshold); err != nil {
 		logrus.Error(errors.Wrap(err, "failed to sync"))
 		return
 	}
@@ -252,13 +391,54 @@ func (cmd *servecmd) Run() {
 	router.PathPrefix("/health").Handler(services.HealthEndpoint(deviceManager))
 
 	// Prometheus metrics endpoint (optionally basic-auth protected)
+	grpcMetrics := services.NewGRPCMetrics()
 	router.Path("/metrics").Handler(services.MetricsEndpoint(&services.MetricsDeps{
 		Config:        conf,
 		DeviceManager: deviceManager,
+		DNS:           dns,
+		GRPC:          grpcMetrics,
+		Firewall:      firewall,
 	}))
 
 	// Authentication middleware
-	middleware, err := authnz.NewMiddleware(conf.Auth, authnz.ClaimsMiddleware(conf))
+	// API tokens
+	tokenManager := tokens.New(storageBackend)
+
+	claimsMiddleware := authnz.ClaimsMiddleware(conf)
+	middleware, err := authnz.NewMiddleware(conf.Auth, claimsMiddleware, tokenManager.Verify, func(r *http.Request, user *authsession.Identity) {
+		event := audit.Event(user, audit.Login, user.Subject)
+		event.SourceIP, _, _ = net.SplitHostPort(r.RemoteAddr)
+		if mailer.Enabled(notify.NewProviderLogin) {
+			// checked before the login is recorded
+			if newProvider, err := auditLog.NewProvider(user); err != nil {
+				logrus.Error(err)
+			} else if newProvider {
+				mailer.NewProviderLogin(user, event.SourceIP)
+			}
+		}
+		event.Details = fmt.Sprintf("provider %s", user.Provider)
+		auditLog.Record(event)
+
+		// Tokens keep the claims they were created with, revoke them once the claims of the owner changed
+		current := *user
+		current.Claims = append(authsession.Claims{}, user.Claims...)
+		var revoked []*storage.Token
+		var err error
+		if claimsErr := claimsMiddleware(&current); claimsErr != nil {
+			// the user lost access
+			revoked, err = tokenManager.RevokeAll(r.Context(), user.Subject)
+		} else {
+			revoked, err = tokenManager.RevokeChanged(r.Context(), &current)
+		}
+		if err != nil {
+			logrus.Error(errors.Wrapf(err, "failed to revoke the tokens of user %s", user.Subject))
+		}
+		for _, token := range revoked {
+			event := audit.Event(nil, audit.TokenRevoke, token.ID)
+			event.Details = fmt.Sprintf("claims of %s changed", user.Subject)
+			auditLog.Record(event)
+		}
+	})
 	if err != nil {
 		logrus.Error(errors.Wrap(err, "failed to set up authnz middleware"))
 		return
@@ -270,11 +450,20 @@ func (cmd *servecmd) Run() {
 	site.Use(authnz.RequireAuthentication)
 
 	// Grpc api
-	site.PathPrefix("/api").Handler(services.ApiRouter(&services.ApiServices{
+	apiRouter, err := services.ApiRouter(&services.ApiServices{
 		Config:        conf,
 		DeviceManager: deviceManager,
 		Wg:            wg,
-	}))
+		TokenManager:  tokenManager,
+		Audit:         auditLog,
+		Mailer:        mailer,
+		Metrics:       grpcMetrics,
+	})
+	if err != nil {
+		logrus.Error(errors.Wrap(err, "failed to set up the api"))
+		return
+	}
+	site.PathPrefix("/api").Handler(apiRouter)
 
 	// Static website
 	site.PathPrefix("/").Handler(services.WebsiteRouter())
@@ -437,6 +626,40 @@ func (cmd *servecmd) ReadConfig() *config.AppConfig {
 		cmd.AppConfig.DNS.Domain = ""
 	}
 
+	if err := devices.ValidatePolicies(cmd.AppConfig.Policies, cmd.AppConfig.IPAM.Pools); err != nil {
+		logrus.Fatal(errors.Wrap(err, "invalid policies configuration"))
+	}
+
+	if err := devices.ValidateProfiles(cmd.AppConfig.ClientConfig.Profiles); err != nil {
+		logrus.Fatal(errors.Wrap(err, "invalid client config profiles"))
+	}
+
+	if err := devices.ValidateDeviceQuota(cmd.AppConfig.DeviceQuota); err != nil {
+		logrus.Fatal(errors.Wrap(err, "invalid device quota configuration"))
+	}
+
+	if cmd.AppConfig.InactiveDeviceWarningThreshold < 0 || (cmd.AppConfig.InactiveDeviceWarningThreshold > 0 && cmd.AppConfig.InactiveDeviceWarningThreshold >= cmd.AppConfig.InactiveDeviceGracePeriod) {
+		logrus.Fatal("inactive device warning threshold must be shorter than the grace period")
+	}
+
+	if err := devices.ValidateDeviceExpiry(cmd.AppConfig.DeviceExpiry); err != nil {
+		logrus.Fatal(errors.Wrap(err, "invalid device expiry configuration"))
+	}
+
+	if err := devices.ValidateUsage(cmd.AppConfig.Usage); err != nil {
+		logrus.Fatal(errors.Wrap(err, "invalid usage configuration"))
+	}
+
+	if err := devices.ValidateTrafficQuota(cmd.AppConfig.TrafficQuota); err != nil {
+		logrus.Fatal(errors.Wrap(err, "invalid traffic quota configuration"))
+	}
+
+	for _, rule := range forwardRules(cmd.AppConfig.VPN.Rules) {
+		if err := rule.Validate(); err != nil {
+			logrus.Fatal(errors.Wrap(err, "invalid vpn rules configuration"))
+		}
+	}
+
 	// kingpin only splits env vars by \n, let's split at commas as well
 	if len(cmd.AppConfig.VPN.AllowedIPs) == 1 {
 		cmd.AppConfig.VPN.AllowedIPs = splitByCommaAndTrim(cmd.AppConfig.VPN.AllowedIPs[0])
@@ -451,6 +674,20 @@ func (cmd *servecmd) ReadConfig() *config.AppConfig {
 	return &cmd.AppConfig
 }
 
+// forwardRules converts the configured rules of the VPN into firewall rules
+func forwardRules(rules []config.ForwardRule) []network.Rule {
+	converted := make([]network.Rule, 0, len(rules))
+	for _, rule := range rules {
+		converted = append(converted, network.Rule{
+			CIDR:     rule.CIDR,
+			Protocol: rule.Protocol,
+			Ports:    rule.Ports,
+			Action:   rule.Action,
+		})
+	}
+	return converted
+}
+
 func splitByCommaAndTrim(s string) []string {
 	result := strings.Split(s, ",")
 	for i, addr := range result {
@@ -503,11 +740,7 @@ func detectDefaultInterface() string {
 }
 
 func generateZone(deviceManager *devices.DeviceManager, vpnips []netip.Addr) dnsproxy.Zone {
-	devs, err := deviceManager.ListAllDevices()
-	if err != nil {
-		logrus.Error(errors.Wrap(err, "could not query devices to generate the DNS zone"))
-	}
-
+	devs := deviceManager.Snapshot()
 	zone := make(dnsproxy.Zone)
 	for _, device := range devs {
 		owner := device.Owner
@@ -10,4 +10,8 @@ protoc \
   -I proto/ \
   --go_out="$OUT_DIR" --go_opt=paths=source_relative \
   --go-grpc_out="$OUT_DIR" --go-grpc_opt=paths=source_relative \
+  --grpc-gateway_out="$OUT_DIR" --grpc-gateway_opt=paths=source_relative \
+  --grpc-gateway_opt=grpc_api_configuration=proto/api_config.yaml \
+  --openapiv2_out="$OUT_DIR" --openapiv2_opt=allow_merge=true,merge_file_name=api \
+  --openapiv2_opt=grpc_api_configuration=proto/api_config.yaml \
   proto/*.proto
@@ -0,0 +1,167 @@
+package audit
+
+import (
+	"encoding/json"
+	"io"
+	"log/syslog"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/freifunkMUC/wg-access-server/internal/storage"
+	"github.com/freifunkMUC/wg-access-server/pkg/authnz/authsession"
+)
+
+// Actions recorded in the audit log
+const (
+	Login                = "login"
+	DeviceCreate         = "device.create"
+	DeviceUpdate         = "device.update"
+	DeviceDelete         = "device.delete"
+	InactiveDeviceDelete = "device.delete_inactive"
+	ExpiredDeviceDelete  = "device.delete_expired"
+	DeviceRenew          = "device.renew"
+	DeviceSuspend        = "device.suspend"
+	DeviceResume         = "device.resume"
+	UserDelete           = "user.delete"
+	UserSuspend          = "user.suspend"
+	UserResume           = "user.resume"
+	TrafficQuotaExceed   = "user.quota_exceeded"
+	TrafficQuotaRelease  = "user.quota_released"
+	TokenCreate          = "token.create"
+	TokenRevoke          = "token.revoke"
+)
+
+type Options struct {
+	File   string
+	Syslog string
+}
+
+// Log records audit events in the storage backend and the configured sinks.
+// A nil *Log is valid and discards all events.
+type Log struct {
+	storage storage.AuditStorage
+	sinks   []io.WriteCloser
+	lock    sync.Mutex
+}
+
+func New(s storage.AuditStorage, opts Options) (*Log, error) {
+	l := &Log{storage: s}
+	if opts.File != "" {
+		f, err := os.OpenFile(opts.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to open audit log file")
+		}
+		l.sinks = append(l.sinks, f)
+	}
+	if opts.Syslog != "" {
+		network, raddr := "", ""
+		if opts.Syslog != "local" {
+			u, err := url.Parse(opts.Syslog)
+			if err != nil {
+				return nil, errors.Wrap(err, "invalid audit syslog address")
+			}
+			network, raddr = u.Scheme, u.Host
+		}
+		w, err := syslog.Dial(network, raddr, syslog.LOG_INFO|syslog.LOG_AUTH, "wg-access-server")
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to connect to the audit syslog server")
+		}
+		l.sinks = append(l.sinks, w)
+	}
+	return l, nil
+}
+
+// Event creates an event of the actor, nil for actions of the server itself
+func Event(actor *authsession.Identity, action string, target string) *storage.AuditEvent {
+	event := &storage.AuditEvent{
+		Time:   time.Now(),
+		Action: action,
+		Target: target,
+	}
+	if actor != nil {
+		event.Actor = actor.Subject
+		event.ActorName = actor.Name
+		event.ActorProvider = actor.Provider
+		event.ActorEmail = actor.Email
+	}
+	return event
+}
+
+// DeviceTarget is the target of device events
+func DeviceTarget(owner string, name string) string {
+	return owner + "/" + name
+}
+
+// Record saves the event. Failures are logged, they never fail the audited action.
+func (l *Log) Record(event *storage.AuditEvent) {
+	if l == nil {
+		return
+	}
+	if err := l.storage.SaveAuditEvent(event); err != nil {
+		logrus.Error(errors.Wrap(err, "failed to record audit event"))
+	}
+	if len(l.sinks) == 0 {
+		return
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		logrus.Error(errors.Wrap(err, "failed to encode audit event"))
+		return
+	}
+	data = append(data, '\n')
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	for _, sink := range l.sinks {
+		if _, err := sink.Write(data); err != nil {
+			logrus.Error(errors.Wrap(err, "failed to write audit event"))
+		}
+	}
+}
+
+func (l *Log) List(filter storage.AuditFilter) ([]*storage.AuditEvent, error) {
+	if l == nil {
+		return []*storage.AuditEvent{}, nil
+	}
+	return l.storage.ListAuditEvents(filter)
+}
+
+// NewProvider reports whether the user signed in before, but never with their current provider.
+// Users are matched by their email address because the subject differs between providers.
+// It must be called before the login is recorded.
+func (l *Log) NewProvider(user *authsession.Identity) (bool, error) {
+	if l == nil || user.Email == "" {
+		return false, nil
+	}
+	logins, err := l.storage.ListAuditEvents(storage.AuditFilter{ActorEmail: user.Email, ActorProvider: user.Provider, Action: Login, Limit: 1})
+	if err != nil {
+		return false, errors.Wrap(err, "failed to list logins")
+	}
+	if len(logins) > 0 {
+		return false, nil
+	}
+	logins, err = l.storage.ListAuditEvents(storage.AuditFilter{ActorEmail: user.Email, Action: Login, Limit: 1})
+	if err != nil {
+		return false, errors.Wrap(err, "failed to list logins")
+	}
+	return len(logins) > 0, nil
+}
+
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	for _, sink := range l.sinks {
+		if err := sink.Close(); err != nil {
+			return err
+		}
+	}
+	l.sinks = nil
+	return nil
+}
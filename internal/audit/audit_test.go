@@ -0,0 +1,84 @@
+package audit
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/freifunkMUC/wg-access-server/internal/storage"
+	"github.com/freifunkMUC/wg-access-server/pkg/authnz/authsession"
+)
+
+func TestRecordWritesFile(t *testing.T) {
+	require := require.New(t)
+
+	file := filepath.Join(t.TempDir(), "audit.log")
+	l, err := New(storage.NewMemoryStorage(), Options{File: file})
+	require.NoError(err)
+
+	actor := &authsession.Identity{Subject: "alice", Name: "Alice", Provider: "oidc"}
+	l.Record(Event(actor, DeviceCreate, DeviceTarget("alice", "notebook")))
+	l.Record(Event(nil, InactiveDeviceDelete, DeviceTarget("alice", "notebook")))
+	require.NoError(l.Close())
+
+	events, err := l.List(storage.AuditFilter{})
+	require.NoError(err)
+	require.Len(events, 2)
+	require.Equal(InactiveDeviceDelete, events[0].Action)
+	require.Equal("", events[0].Actor)
+
+	data, err := os.ReadFile(file)
+	require.NoError(err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(lines, 2)
+	event := storage.AuditEvent{}
+	require.NoError(json.Unmarshal([]byte(lines[0]), &event))
+	require.Equal("alice", event.Actor)
+	require.Equal(DeviceCreate, event.Action)
+	require.Equal("alice/notebook", event.Target)
+}
+
+func TestNilLog(t *testing.T) {
+	var l *Log
+	l.Record(Event(nil, Login, "alice"))
+	newProvider, err := l.NewProvider(&authsession.Identity{Email: "alice@example.com"})
+	require.NoError(t, err)
+	require.False(t, newProvider)
+	events, err := l.List(storage.AuditFilter{})
+	require.NoError(t, err)
+	require.Empty(t, events)
+	require.NoError(t, l.Close())
+}
+
+func TestNewProvider(t *testing.T) {
+	require := require.New(t)
+
+	l, err := New(storage.NewMemoryStorage(), Options{})
+	require.NoError(err)
+
+	oidc := &authsession.Identity{Subject: "alice", Email: "alice@example.com", Provider: "oidc"}
+	gitlab := &authsession.Identity{Subject: "1234", Email: "alice@example.com", Provider: "gitlab"}
+
+	// the first login isn't reported
+	newProvider, err := l.NewProvider(oidc)
+	require.NoError(err)
+	require.False(newProvider)
+	l.Record(Event(oidc, Login, oidc.Subject))
+
+	newProvider, err = l.NewProvider(oidc)
+	require.NoError(err)
+	require.False(newProvider)
+
+	newProvider, err = l.NewProvider(gitlab)
+	require.NoError(err)
+	require.True(newProvider)
+	l.Record(Event(gitlab, Login, gitlab.Subject))
+
+	newProvider, err = l.NewProvider(gitlab)
+	require.NoError(err)
+	require.False(newProvider)
+}
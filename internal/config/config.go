@@ -49,6 +49,11 @@ type AppConfig struct {
 	// devices are automatically deleted
 	// Defaults to 1 year
 	InactiveDeviceGracePeriod time.Duration `yaml:"inactiveDeviceGracePeriod"`
+	// InactiveDeviceWarningThreshold marks devices as stale after they have been
+	// inactive for this long and notifies their owner about the upcoming deletion.
+	// Must be shorter than the InactiveDeviceGracePeriod.
+	// Defaults to 0 (no warnings)
+	InactiveDeviceWarningThreshold time.Duration `yaml:"inactiveDeviceWarningThreshold"`
 	// The name of the WireGuard configuration file that can
 	// be downloaded through the web UI after adding a device.
 	// Do not include the '.conf' extension
@@ -88,6 +93,11 @@ type AppConfig struct {
 		// to enforce network access.
 		// defaults to ["0.0.0.0/0", "::/0"]
 		AllowedIPs []string `yaml:"allowedIPs"`
+		// Rules narrow down the forwarded traffic to protocols and ports.
+		// They are evaluated in order before the AllowedIPs,
+		// the networks of accepting rules are included in client config files.
+		// Empty by default.
+		Rules []ForwardRule `yaml:"rules"`
 		// CIDR configures a network address space
 		// that client (WireGuard peers) will be allocated
 		// an IP address from
@@ -115,10 +125,79 @@ type AppConfig struct {
 		// ClientIsolation configures whether traffic between client devices will be blocked or allowed
 		// defaults to false
 		ClientIsolation bool `yaml:"clientIsolation"`
-		// DisableIPTables configures whether to disable iptables configuration completely
+		// DisableIPTables configures whether to disable the firewall configuration completely
 		// defaults to false
 		DisableIPTables bool `yaml:"disableIPTables"`
+		// FirewallBackend configures whether the firewall rules are
+		// installed with "iptables" or "nftables"
+		// defaults to "auto", which detects the backend
+		FirewallBackend string `yaml:"firewallBackend"`
+		// FirewallReconcileInterval configures how often the installed firewall
+		// rules are compared with the configuration and re-applied if they drifted
+		// defaults to 1m, 0 disables the reconciliation
+		FirewallReconcileInterval time.Duration `yaml:"firewallReconcileInterval"`
 	} `yaml:"vpn"`
+	// Policies assign network access policies to the devices of users
+	// based on their claims (e.g. from the OIDC claimMapping).
+	// The first matching policy is stamped onto a device when it is added.
+	// Empty by default.
+	Policies []Policy `yaml:"policies"`
+	// Audit configures additional sinks for the audit log,
+	// which is always recorded in the storage backend
+	Audit struct {
+		// File appends the audit events as JSON lines to this file
+		File string `yaml:"file"`
+		// Syslog sends the audit events as JSON to a syslog server,
+		// e.g. "udp://siem.example.com:514", or "local" for the local syslog daemon
+		Syslog string `yaml:"syslog"`
+	} `yaml:"audit"`
+	// Webhooks notify external services (chat, CMDB, ...) about device changes
+	Webhooks struct {
+		// QueueSize is the number of pending deliveries per endpoint,
+		// events are dropped while the queue of an endpoint is full
+		// Defaults to 100
+		QueueSize int `yaml:"queueSize"`
+		// MaxAttempts is the number of delivery attempts per event,
+		// with an exponential backoff between the attempts
+		// Defaults to 5
+		MaxAttempts int `yaml:"maxAttempts"`
+		// Endpoints receive the events as signed JSON POST requests
+		Endpoints []Webhook `yaml:"endpoints"`
+	} `yaml:"webhooks"`
+	// IPAM configures how IP addresses are assigned to devices
+	IPAM struct {
+		// ReuseCooldown keeps the addresses of deleted devices
+		// from being assigned to another device for this long
+		// Defaults to 0 (addresses are reused immediately)
+		ReuseCooldown time.Duration `yaml:"reuseCooldown"`
+		// Random picks the addresses of subnets with more than 2^16 addresses
+		// (e.g. an IPv6 /64) randomly instead of the lowest free address
+		// Defaults to false
+		Random bool `yaml:"random"`
+		// Reserved are address ranges that are never assigned automatically,
+		// either CIDRs or "<first address>-<last address>"
+		Reserved []string `yaml:"reserved"`
+		// Pools are named ranges of vpn.cidr and vpn.cidrv6,
+		// users get addresses from the pool of their policy
+		Pools []Pool `yaml:"pools"`
+	} `yaml:"ipam"`
+	// DeviceQuota limits the number of devices per user.
+	// The deviceQuota of a matching policy takes precedence over the default.
+	DeviceQuota DeviceQuota `yaml:"deviceQuota"`
+	// DeviceExpiry limits the lifetime of devices.
+	// The maxLifetime of a matching policy takes precedence over the default.
+	DeviceExpiry DeviceExpiry `yaml:"deviceExpiry"`
+	// Usage configures the traffic history of devices,
+	// it is recorded if the metadata collection is enabled
+	Usage Usage `yaml:"usage"`
+	// TrafficQuota limits the monthly traffic per user, it requires the metadata collection.
+	// The trafficQuota of a matching policy takes precedence over the default.
+	TrafficQuota TrafficQuota `yaml:"trafficQuota"`
+	// SMTP configures the mail server used to notify users,
+	// e.g. about the upcoming deletion of inactive devices
+	SMTP SMTP `yaml:"smtp"`
+	// Notifications configures the mails sent to users
+	Notifications Notifications `yaml:"notifications"`
 	// Configure the embedded DNS server
 	DNS struct {
 		// Enabled allows you to turn on/off
@@ -159,6 +238,11 @@ type AppConfig struct {
 		// Users can still override this value per device.
 		// Defaults to 0 (disabled)
 		PersistentKeepalive int `yaml:"PersistentKeepalive"`
+		// Profiles are split-tunnel variants of the client config users can pick from
+		// when adding a device. Traffic of these devices is restricted to the
+		// AllowedIPs of their profile by the firewall.
+		// Empty by default.
+		Profiles []Profile `yaml:"profiles"`
 	} `yaml:"clientConfig"`
 	// Metrics configures access to the /metrics endpoint.
 	Metrics struct {
@@ -168,7 +252,13 @@ type AppConfig struct {
 			// Bcrypt hashed password required when accessing /metrics.
 			PasswordHash string `yaml:"passwordHash"`
 		} `yaml:"basicAuth"`
+		// DeviceLimit is the maximum number of devices with their own series
+		// if the device metrics are enabled, 0 means unlimited.
+		// Defaults to 1000
+		DeviceLimit int `yaml:"deviceLimit"`
 	} `yaml:"metrics"`
+	// Tracing configures the export of OpenTelemetry traces
+	Tracing Tracing `yaml:"tracing"`
 	// Auth configures optional authentication backends
 	// to control access to the web ui.
 	// Devices will be managed on a per-user basis if any
@@ -195,3 +285,195 @@ type AppConfig struct {
 		Host string `yaml:"host"`
 	} `yaml:"https"`
 }
+
+// DeviceQuota limits the number of devices users may add
+type DeviceQuota struct {
+	// Default applies to all users without a more specific quota
+	// Defaults to 0 (unlimited)
+	Default int `yaml:"default"`
+	// Users overrides the quota of single users by their subject (user id),
+	// 0 means unlimited. Takes precedence over all other quotas.
+	Users map[string]int `yaml:"users"`
+}
+
+// SMTP configures the connection to a mail server
+type SMTP struct {
+	// Host of the mail server, notifications are disabled if empty
+	Host string `yaml:"host"`
+	// Port of the mail server
+	// Defaults to 587
+	Port int `yaml:"port"`
+	// Username and Password are optional, they are only sent over TLS connections
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	// From is the sender address of the notifications
+	From string `yaml:"from"`
+	// ImplicitTLS connects with TLS (usually port 465) instead of upgrading the connection with STARTTLS
+	// Defaults to false
+	ImplicitTLS bool `yaml:"implicitTLS"`
+}
+
+// Notifications configures the mails sent to users, all events are enabled by default
+type Notifications struct {
+	// Events configures single events by name
+	// (device_added, device_removed, device_expiring, device_stale and new_provider_login)
+	Events map[string]NotificationEvent `yaml:"events"`
+}
+
+type NotificationEvent struct {
+	// Enabled sends the notification
+	// Defaults to true
+	Enabled *bool `yaml:"enabled"`
+	// Template is the path of a Go text/template file replacing the built-in template,
+	// it must define the templates "subject" and "body"
+	Template string `yaml:"template"`
+}
+
+// Usage configures the traffic history of devices
+type Usage struct {
+	// Resolution is the length of the recorded time buckets
+	// Defaults to 5 minutes
+	Resolution time.Duration `yaml:"resolution"`
+	// Retention of the full resolution, older traffic is downsampled to days
+	// Defaults to 7 days
+	Retention time.Duration `yaml:"retention"`
+	// DailyRetention of the downsampled traffic, 0 keeps it forever
+	// Defaults to 365 days
+	DailyRetention time.Duration `yaml:"dailyRetention"`
+}
+
+// Tracing configures the export of OpenTelemetry traces
+type Tracing struct {
+	// Exporter is "otlp" to send the traces to an OTLP gRPC receiver
+	// or "stdout" to print them, e.g. in tests
+	// Defaults to "" (tracing disabled)
+	Exporter string `yaml:"exporter"`
+	// Endpoint is the address of the OTLP receiver, the standard
+	// OTEL_EXPORTER_OTLP_* environment variables apply if it is empty
+	// Defaults to localhost:4317
+	Endpoint string `yaml:"endpoint"`
+	// Insecure disables TLS for the connection to the OTLP receiver
+	Insecure bool `yaml:"insecure"`
+	// SampleRatio is the fraction of the traces started by wg-access-server that are recorded,
+	// traces started by a client follow the decision of the client
+	// Defaults to 1
+	SampleRatio float64 `yaml:"sampleRatio"`
+}
+
+// Actions of users that exceeded their traffic quota
+const (
+	TrafficQuotaSuspend  = "suspend"
+	TrafficQuotaThrottle = "throttle"
+)
+
+// TrafficQuota limits the traffic users may transfer per calendar month (UTC)
+type TrafficQuota struct {
+	// Default is the number of bytes (received and transmitted) of all users
+	// without a more specific quota
+	// Defaults to 0 (unlimited)
+	Default int64 `yaml:"default"`
+	// Users overrides the quota of single users by their subject (user id),
+	// 0 means unlimited. Takes precedence over all other quotas.
+	Users map[string]int64 `yaml:"users"`
+	// Action is applied to the devices of users that exceeded their quota until the month ends,
+	// "suspend" removes their WireGuard peers, "throttle" limits their bandwidth to ThrottleRate
+	// Defaults to "suspend"
+	Action string `yaml:"action"`
+	// ThrottleRate is the bandwidth per device and direction in bits per second
+	// Defaults to 1000000 (1 Mbit/s)
+	ThrottleRate uint64 `yaml:"throttleRate"`
+}
+
+// DeviceExpiry configures the expiry of devices
+type DeviceExpiry struct {
+	// MaxLifetime is the maximum time from now a device may be valid,
+	// devices added without an expiry date expire after this time
+	// Defaults to 0 (devices may be valid forever)
+	MaxLifetime time.Duration `yaml:"maxLifetime"`
+	// DeleteAfter deletes expired devices after they have been expired for this long.
+	// Until then they are disabled and can be renewed by their owner.
+	// Defaults to 0 (expired devices are never deleted)
+	DeleteAfter time.Duration `yaml:"deleteAfter"`
+	// WarnBefore notifies the owner this long before a device expires
+	// Defaults to 7 days
+	WarnBefore time.Duration `yaml:"warnBefore"`
+}
+
+// Pool is a named range of the VPN subnets, addresses of the
+// default pool are assigned from outside of all pools
+type Pool struct {
+	Name string `yaml:"name"`
+	// CIDR must be within vpn.cidr, if empty the default pool is used for IPv4
+	CIDR string `yaml:"cidr"`
+	// CIDRv6 must be within vpn.cidrv6, if empty the default pool is used for IPv6
+	CIDRv6 string `yaml:"cidrv6"`
+}
+
+// Webhook is an HTTP endpoint the device events are posted to
+type Webhook struct {
+	// URL the events are posted to
+	URL string `yaml:"url"`
+	// Secret is the key of the HMAC-SHA256 signature of the payload,
+	// sent in the X-Webhook-Signature header. Optional.
+	Secret string `yaml:"secret"`
+	// Events limits the endpoint to these events, all events are sent by default
+	Events []string `yaml:"events"`
+}
+
+// ForwardRule accepts or rejects the traffic of VPN clients to a destination network,
+// optionally narrowed down to a protocol and ports
+type ForwardRule struct {
+	// CIDR is the destination network
+	CIDR string `yaml:"cidr"`
+	// Protocol is matched if set, "tcp", "udp", "icmp" or "icmpv6"
+	Protocol string `yaml:"protocol"`
+	// Ports is a comma separated list of destination ports or port ranges,
+	// e.g. "443" or "53,8000-8080". Requires the tcp or udp protocol.
+	Ports string `yaml:"ports"`
+	// Action is "accept" or "reject"
+	// Defaults to "accept"
+	Action string `yaml:"action"`
+}
+
+// Profile is a named client config with its own routes, DNS servers and MTU
+type Profile struct {
+	// Name of the profile, stored with the devices that use it
+	Name string `yaml:"name"`
+	// AllowedIPs are the networks routed through the VPN, e.g. ["10.0.0.0/24"]
+	AllowedIPs []string `yaml:"allowedIPs"`
+	// DNSServers replace the DNS servers of the client config
+	// Defaults to the clientConfig.dnsServers
+	DNSServers []string `yaml:"dnsServers"`
+	// MTU replaces the MTU of the client config
+	// Defaults to 0 (the clientConfig.mtu applies)
+	MTU int `yaml:"mtu"`
+}
+
+// Policy is a named network access policy for the devices
+// of all users that have a certain claim
+type Policy struct {
+	// Name of the policy, shown in the device list
+	Name string `yaml:"name"`
+	// Claim selects the users this policy applies to.
+	// Either "<claim>=<value>" or "<claim>", which requires the claim to be "true".
+	// Example: "group=devops"
+	Claim string `yaml:"claim"`
+	// AllowedIPs are the destination networks devices may reach.
+	// If empty, the server wide vpn.allowedIPs apply.
+	AllowedIPs []string `yaml:"allowedIPs"`
+	// ClientIsolation blocks traffic from these devices to other client devices
+	// Defaults to false
+	ClientIsolation bool `yaml:"clientIsolation"`
+	// DeviceQuota limits the number of devices a user may add
+	// Defaults to 0 (the default device quota applies)
+	DeviceQuota int `yaml:"deviceQuota"`
+	// MaxLifetime is the maximum time from now a device may be valid
+	// Defaults to 0 (the default maximum lifetime applies)
+	MaxLifetime time.Duration `yaml:"maxLifetime"`
+	// TrafficQuota is the number of bytes a user may transfer per month
+	// Defaults to 0 (the default traffic quota applies)
+	TrafficQuota int64 `yaml:"trafficQuota"`
+	// Pool is the name of the IPAM pool devices get their addresses from
+	// Defaults to the default pool
+	Pool string `yaml:"pool"`
+}
@@ -1,9 +1,11 @@
 package devices
 
 import (
+	"context"
 	"fmt"
 	"net/netip"
 	"regexp"
+	"strings"
 	"sync"
 	"time"
 
@@ -11,16 +13,89 @@ import (
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 
+	"github.com/freifunkMUC/wg-access-server/internal/audit"
+	"github.com/freifunkMUC/wg-access-server/internal/config"
+	"github.com/freifunkMUC/wg-access-server/internal/ipam"
 	"github.com/freifunkMUC/wg-access-server/internal/network"
 	"github.com/freifunkMUC/wg-access-server/internal/storage"
 	"github.com/freifunkMUC/wg-access-server/pkg/authnz/authsession"
 )
 
 type DeviceManager struct {
-	wg      wgembed.WireGuardInterface
-	storage storage.Storage
-	cidr    string
-	cidrv6  string
+	wg       wgembed.WireGuardInterface
+	storage  storage.Storage
+	cidr     string
+	cidrv6   string
+	firewall *network.PeerFirewall
+	policies []config.Policy
+	profiles []config.Profile
+	quota    config.DeviceQuota
+	expiry   config.DeviceExpiry
+	usage    config.Usage
+	// trafficQuota is enforced by the metadata loop
+	trafficQuota config.TrafficQuota
+	// allowedIPs must not overlap the routes of gateway devices
+	allowedIPs []string
+	// shaper throttles devices over their traffic quota, nil unless throttling is configured
+	shaper *network.Shaper
+	// router installs the kernel routes of gateway devices, nil without a WireGuard interface
+	router *network.Router
+	// networksLock serializes the validation of the networks of devices with their save,
+	// so that concurrent changes can't claim overlapping networks
+	networksLock sync.Mutex
+	ipam         *ipam.IPAM
+	audit        *audit.Log
+	// callbacks of devices removed by the inactive device deletion
+	inactiveDelete []storage.Callback
+	// callbacks of devices that became stale
+	stale []StaleCallback
+	// callbacks of devices that expire soon
+	expiring []storage.Callback
+	// 0 if the inactive device deletion is disabled
+	inactiveGracePeriod      time.Duration
+	inactiveWarningThreshold time.Duration
+	// peers tracks the configuration of the WireGuard peers by public key,
+	// it is used to detect changes when the storage doesn't report the previous state of a device
+	peers     map[string]string
+	peersLock sync.Mutex
+	// events of WatchDevices
+	events *deviceEvents
+	// index is the in-memory copy of the devices, loaded by StartSync
+	index *deviceIndex
+	// callbacks of changes of the indexed devices
+	change []func()
+}
+
+// DeviceUpdate describes the changes of UpdateDevice, nil fields are left unchanged
+type DeviceUpdate struct {
+	Name      *string
+	PublicKey *string
+	// an empty pre-shared key removes it
+	PresharedKey *string
+	// empty addresses are left unchanged
+	ManualIPv4Address *string
+	ManualIPv6Address *string
+}
+
+// AddDeviceRequest describes a new device of AddDevice
+type AddDeviceRequest struct {
+	Name      string
+	PublicKey string
+	// the pre-shared key is optional
+	PresharedKey string
+	// the manual addresses are only assigned with ManualIPAssignment, one of them is required
+	ManualIPAssignment bool
+	ManualIPv4Address  string
+	ManualIPv6Address  string
+	// AccessPolicy is set by admins, nil if the policy of the user applies
+	AccessPolicy *storage.AccessPolicy
+	// Profile is the name of the client config profile, empty for the default client config
+	Profile string
+	// Routes are only allowed for gateway devices
+	Gateway bool
+	Routes  string
+	// ExpiresAt defaults to the maximum lifetime of the user
+	ExpiresAt *time.Time
 }
 
 type User struct {
@@ -31,41 +106,173 @@ type User struct {
 // https://lists.zx2c4.com/pipermail/wireguard/2020-December/006222.html
 var wgKeyRegex = regexp.MustCompile("^[A-Za-z0-9+/]{42}[A|E|I|M|Q|U|Y|c|g|k|o|s|w|4|8|0]=$")
 
-func New(wg wgembed.WireGuardInterface, s storage.Storage, cidr, cidrv6 string) *DeviceManager {
-	return &DeviceManager{wg, s, cidr, cidrv6}
+// Options configure the DeviceManager, the components may be nil if they are disabled
+type Options struct {
+	CIDR   string
+	CIDRv6 string
+	// AllowedIPs must not overlap the routes of gateway devices
+	AllowedIPs   []string
+	Firewall     *network.PeerFirewall
+	Policies     []config.Policy
+	Profiles     []config.Profile
+	DeviceQuota  config.DeviceQuota
+	DeviceExpiry config.DeviceExpiry
+	Usage        config.Usage
+	TrafficQuota config.TrafficQuota
+	Shaper       *network.Shaper
+	Router       *network.Router
+	IPAM         *ipam.IPAM
+	Audit        *audit.Log
 }
 
-func (d *DeviceManager) StartSync(enableMetadataCollection, enableInactiveDeviceDeletion bool, inactiveDeviceGracePeriod time.Duration) error {
+func New(wg wgembed.WireGuardInterface, s storage.Storage, opts Options) *DeviceManager {
+	return &DeviceManager{
+		wg:           wg,
+		storage:      s,
+		cidr:         opts.CIDR,
+		cidrv6:       opts.CIDRv6,
+		allowedIPs:   opts.AllowedIPs,
+		firewall:     opts.Firewall,
+		policies:     opts.Policies,
+		profiles:     opts.Profiles,
+		quota:        opts.DeviceQuota,
+		expiry:       opts.DeviceExpiry,
+		usage:        opts.Usage,
+		trafficQuota: opts.TrafficQuota,
+		shaper:       opts.Shaper,
+		router:       opts.Router,
+		ipam:         opts.IPAM,
+		audit:        opts.Audit,
+		peers:        make(map[string]string),
+		events:       newDeviceEvents(),
+		index:        newDeviceIndex(),
+	}
+}
+
+// OnInactiveDelete registers a callback for devices removed by the inactive device deletion,
+// it must be registered before StartSync
+func (d *DeviceManager) OnInactiveDelete(cb storage.Callback) {
+	d.inactiveDelete = append(d.inactiveDelete, cb)
+}
+
+// OnChange registers a callback for changes of the devices returned by Snapshot,
+// it must be registered before StartSync
+func (d *DeviceManager) OnChange(cb func()) {
+	d.change = append(d.change, cb)
+}
+
+func (d *DeviceManager) StartSync(enableMetadataCollection, enableInactiveDeviceDeletion bool, inactiveDeviceGracePeriod, inactiveDeviceWarningThreshold time.Duration) error {
 	// Start listening to the device add/remove events
 	d.storage.OnAdd(func(device *storage.Device) {
 		logrus.Infof("Storage event: add device '%s' (public key: '%s') for user: %s %s", device.Name, device.PublicKey, device.OwnerName, device.Owner)
-		if err := d.wg.AddPeer(device.PublicKey, device.PresharedKey, network.SplitAddresses(device.Address)); err != nil {
-			logrus.Error(errors.Wrap(err, "failed to add WireGuard peer"))
+		d.index.update(nil, device)
+		d.changed()
+		if err := d.applyPeer(device); err != nil {
+			logrus.Error(err)
+		}
+		d.events.publish(DeviceAdded, device, nil)
+	})
+
+	d.storage.OnUpdate(func(previous *storage.Device, device *storage.Device) {
+		if previous == nil {
+			// The storage doesn't know what changed, which includes every metadata update.
+			// These don't change the DNS zone or the WireGuard peer.
+			if indexed, ok := d.index.get(device.Owner, device.Name); ok && onlyMetadataChanged(indexed, device) {
+				d.index.update(nil, device)
+				d.events.publish(DeviceUpdated, device, nil)
+				return
+			}
+		}
+		if d.index.update(previous, device) {
+			d.changed()
+		} else if err := d.reloadIndex(); err != nil {
+			logrus.Error(err)
+		}
+		d.events.publish(DeviceUpdated, device, previous)
+		if previous == nil {
+			if !d.peerEnabled(device) {
+				if d.peerAdded(device.PublicKey) {
+					if err := d.removePeer(device.PublicKey); err != nil {
+						logrus.Error(err)
+					}
+				}
+				return
+			}
+			// Only sync if the WireGuard peer of the device isn't up to date
+			if d.peerConfigured(device) {
+				if err := d.firewall.AddPeer(d.peerRules(device)); err != nil {
+					logrus.Error(errors.Wrap(err, "failed to apply device access policy"))
+				}
+				return
+			}
+			logrus.Infof("Storage event: update device '%s' (public key: '%s') for user: %s %s", device.Name, device.PublicKey, device.OwnerName, device.Owner)
+			if err := d.sync(); err != nil {
+				logrus.Error(errors.Wrap(err, "device sync after storage update event failed"))
+			}
+			return
+		}
+
+		logrus.Infof("Storage event: update device '%s' (public key: '%s') to '%s' (public key: '%s') for user: %s %s", previous.Name, previous.PublicKey, device.Name, device.PublicKey, device.OwnerName, device.Owner)
+		// Add the new peer before removing the old one. WireGuard moves the allowed IPs
+		// to the new peer, so there is no moment where the address isn't routed.
+		if err := d.applyPeer(device); err != nil {
+			logrus.Error(err)
+		}
+		if previous.PublicKey != device.PublicKey {
+			if err := d.removePeer(previous.PublicKey); err != nil {
+				logrus.Error(err)
+			}
 		}
 	})
 
 	d.storage.OnDelete(func(device *storage.Device) {
 		logrus.Infof("Storage event: remove device '%s' (public key: '%s') for user: %s %s", device.Name, device.PublicKey, device.OwnerName, device.Owner)
-		if err := d.wg.RemovePeer(device.PublicKey); err != nil {
-			logrus.Error(errors.Wrap(err, "failed to remove WireGuard peer"))
+		d.index.delete(device)
+		d.changed()
+		if err := d.removePeer(device.PublicKey); err != nil {
+			logrus.Error(err)
 		}
+		if err := d.shaper.Unthrottle(device.PublicKey); err != nil {
+			logrus.Error(err)
+		}
+		d.events.publish(DeviceDeleted, device, nil)
 	})
 
 	d.storage.OnReconnect(func() {
+		// changes may have been missed while the connection was lost
+		if err := d.reloadIndex(); err != nil {
+			logrus.Error(err)
+		}
 		if err := d.sync(); err != nil {
 			logrus.Error(errors.Wrap(err, "device sync after storage backend reconnect event failed"))
 		}
 	})
 
+	if err := d.reloadIndex(); err != nil {
+		return errors.Wrap(err, "initial device index load failed")
+	}
+
+	// Allocate the addresses of devices added before the IPAM existed
+	if err := d.syncAllocations(); err != nil {
+		return errors.Wrap(err, "initial address allocation sync failed")
+	}
+
 	// Do an initial sync of existing devices
 	if err := d.sync(); err != nil {
 		return errors.Wrap(err, "initial device sync from storage failed")
 	}
+	d.events.seed(d.Snapshot())
+
+	// start the expired devices loop
+	go expiryLoop(d)
 
 	// start the metrics loop
 	if enableMetadataCollection {
 		logrus.Info("Start collecting device metadata")
 		go metadataLoop(d)
+		go usageLoop(d)
+	} else if d.TrafficQuotaConfigured() {
+		logrus.Infof("Ignoring the traffic quotas because the metadata collection is disabled and they are based on device metadata.")
 	}
 
 	// start inactive devices loop
@@ -74,51 +281,31 @@ func (d *DeviceManager) StartSync(enableMetadataCollection, enableInactiveDevice
 			logrus.Infof("Ignoring the automatic device deletion because the metadata collection is disabled and it is based on device metadata.")
 		} else {
 			logrus.Infof("Start looking for inactive devices. Inactive device grace period is set to %s", inactiveDeviceGracePeriod.String())
-			go inactiveLoop(d, inactiveDeviceGracePeriod)
+			d.inactiveGracePeriod = inactiveDeviceGracePeriod
+			d.inactiveWarningThreshold = inactiveDeviceWarningThreshold
+			go inactiveLoop(d)
 		}
 	}
 
 	return nil
 }
 
-func (d *DeviceManager) usedAddresses() (map[netip.Addr]bool, map[netip.Addr]bool, error) {
-	devices, err := d.ListDevices("")
-	if err != nil {
-		return nil, nil, errors.Wrap(err, "failed to list devices")
-	}
-
-	usedIPv4s := make(map[netip.Addr]bool, len(devices)+3)
-	usedIPv6s := make(map[netip.Addr]bool, len(devices)+3)
-
-	// Check what IP addresses are already occupied
-	for _, device := range devices {
-		addresses := network.SplitAddresses(device.Address)
-		for _, addr := range addresses {
-			addr := netip.MustParsePrefix(addr).Addr()
-			if addr.Is4() {
-				usedIPv4s[addr] = true
-			} else {
-				usedIPv6s[addr] = true
-			}
-		}
-	}
-
-	return usedIPv4s, usedIPv6s, nil
-}
-
-func (d *DeviceManager) AddDevice(identity *authsession.Identity, name string, publicKey string, presharedKey string, manualIPAssignment bool, manualIPv4Address string, manualIPv6Address string) (*storage.Device, error) {
-	if name == "" {
+func (d *DeviceManager) AddDevice(ctx context.Context, identity *authsession.Identity, req AddDeviceRequest) (*storage.Device, error) {
+	if req.Name == "" {
 		return nil, errors.New("Device name must not be empty.")
 	}
 
+	d.networksLock.Lock()
+	defer d.networksLock.Unlock()
+
 	nameTaken := false
-	devices, err := d.ListDevices(identity.Subject)
+	devices, err := d.ListDevices(ctx, identity.Subject)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to list devices")
 	}
 
 	for _, x := range devices {
-		if x.Name == name {
+		if x.Name == req.Name {
 			nameTaken = true
 			break
 		}
@@ -128,130 +315,244 @@ func (d *DeviceManager) AddDevice(identity *authsession.Identity, name string, p
 		return nil, errors.New("Device name already taken.")
 	}
 
-	if !wgKeyRegex.MatchString(publicKey) {
+	if quota := d.DeviceQuota(identity); quota > 0 && len(devices) >= quota {
+		return nil, &QuotaError{Quota: quota}
+	}
+
+	// Explicit access policies (set by admins) take precedence over the configured ones
+	accessPolicy := req.AccessPolicy
+	pool := ""
+	if policy := d.ResolvePolicy(identity); policy != nil {
+		if accessPolicy == nil {
+			accessPolicy = accessPolicyFromConfig(policy)
+		}
+		pool = policy.Pool
+	}
+
+	if !wgKeyRegex.MatchString(req.PublicKey) {
 		return nil, errors.New("Public key has invalid format.")
 	}
 
 	// preshared key is optional
-	if len(presharedKey) != 0 && !wgKeyRegex.MatchString(presharedKey) {
+	if len(req.PresharedKey) != 0 && !wgKeyRegex.MatchString(req.PresharedKey) {
 		return nil, errors.New("Pre-shared key has invalid format.")
 	}
 
-	clientAddr := ""
-	if manualIPAssignment {
-		if manualIPv4Address == "" && manualIPv6Address == "" {
-			return nil, errors.New("Manual IP assignment enabled but no IP address provided.")
-		}
+	if err := validateAccessPolicy(accessPolicy); err != nil {
+		return nil, err
+	}
+
+	if err := d.validateProfile(req.Profile); err != nil {
+		return nil, err
+	}
 
-		usedIPv4s, usedIPv6s, err := d.usedAddresses()
+	routes := req.Routes
+	if routes != "" {
+		if !req.Gateway {
+			return nil, errors.New("Only gateway devices can have routes.")
+		}
+		routes, err = d.validateRoutes(ctx, routes)
 		if err != nil {
-			return nil, errors.Wrap(err, "failed to get used addresses")
+			return nil, err
 		}
+	}
 
-		var ipv4Addr, ipv6Addr string
+	now := time.Now()
+	expiresAt, err := expiresAt(d.MaxLifetime(identity), req.ExpiresAt, now)
+	if err != nil {
+		return nil, err
+	}
 
-		if manualIPv4Address != "" {
-			if d.cidr == "" {
-				return nil, errors.New("Manual IPv4 assignment not possible, IPv4 subnet is not configured.")
-			}
+	clientAddr := ""
+	if req.ManualIPAssignment {
+		if req.ManualIPv4Address == "" && req.ManualIPv6Address == "" {
+			return nil, errors.New("Manual IP assignment enabled but no IP address provided.")
+		}
 
-			ipv4, err := netip.ParseAddr(manualIPv4Address)
+		var ipv4Addr, ipv6Addr string
+		if req.ManualIPv4Address != "" {
+			ipv4Addr, err = d.manualIPv4Address(req.ManualIPv4Address)
 			if err != nil {
-				return nil, errors.Wrap(err, "invalid manual IPv4 address")
-			}
-			if !ipv4.Is4() {
-				return nil, errors.New("manual IPv4 address is not a valid IPv4 address")
-			}
-
-			vpnsubnetv4 := netip.MustParsePrefix(d.cidr)
-			if !vpnsubnetv4.Contains(ipv4) {
-				return nil, fmt.Errorf("manual IPv4 address %s is not in the configured subnet %s", manualIPv4Address, d.cidr)
-			}
-
-			// also check for server and network address
-			startIPv4 := vpnsubnetv4.Masked().Addr()
-			if ipv4 == startIPv4 || ipv4 == startIPv4.Next() {
-				return nil, fmt.Errorf("manual IPv4 address %s is reserved", manualIPv4Address)
-			}
-
-			if usedIPv4s[ipv4] {
-				return nil, fmt.Errorf("manual IPv4 address %s is already in use", manualIPv4Address)
+				return nil, err
 			}
-
-			ipv4Addr = netip.PrefixFrom(ipv4, 32).String()
 		}
-
-		if manualIPv6Address != "" {
-			if d.cidrv6 == "" {
-				return nil, errors.New("Manual IPv6 assignment not possible, IPv6 subnet is not configured.")
-			}
-
-			ipv6, err := netip.ParseAddr(manualIPv6Address)
+		if req.ManualIPv6Address != "" {
+			ipv6Addr, err = d.manualIPv6Address(req.ManualIPv6Address)
 			if err != nil {
-				return nil, errors.Wrap(err, "invalid manual IPv6 address")
-			}
-			if !ipv6.Is6() {
-				return nil, errors.New("manual IPv6 address is not a valid IPv6 address")
-			}
-
-			vpnsubnetv6 := netip.MustParsePrefix(d.cidrv6)
-			if !vpnsubnetv6.Contains(ipv6) {
-				return nil, fmt.Errorf("manual IPv6 address %s is not in the configured subnet %s", manualIPv6Address, d.cidrv6)
-			}
-
-			// also check for server and network address
-			startIPv6 := vpnsubnetv6.Masked().Addr()
-			if ipv6 == startIPv6 || ipv6 == startIPv6.Next() {
-				return nil, fmt.Errorf("manual IPv6 address %s is reserved", manualIPv6Address)
+				return nil, err
 			}
-
-			if usedIPv6s[ipv6] {
-				return nil, fmt.Errorf("manual IPv6 address %s is already in use", manualIPv6Address)
-			}
-
-			ipv6Addr = netip.PrefixFrom(ipv6, 128).String()
 		}
-
-		if ipv4Addr != "" && ipv6Addr != "" {
-			clientAddr = fmt.Sprintf("%s, %s", ipv4Addr, ipv6Addr)
-		} else if ipv4Addr != "" {
-			clientAddr = ipv4Addr
-		} else {
-			clientAddr = ipv6Addr
+		if err := d.validateAddresses(ctx, ipv4Addr, ipv6Addr); err != nil {
+			return nil, err
 		}
-
+		if err := d.claimAddresses(ipv4Addr, ipv6Addr); err != nil {
+			return nil, err
+		}
+		clientAddr = joinAddresses(ipv4Addr, ipv6Addr)
 	} else {
-		clientAddr, err = d.nextClientAddress()
+		clientAddr, err = d.ipam.Allocate(pool)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to generate an ip address for device")
 		}
 	}
 
+	trafficQuota := d.TrafficQuota(identity)
 	device := &storage.Device{
 		Owner:         identity.Subject,
 		OwnerName:     identity.Name,
 		OwnerEmail:    identity.Email,
 		OwnerProvider: identity.Provider,
-		Name:          name,
-		PublicKey:     publicKey,
-		PresharedKey:  presharedKey,
+		Name:          req.Name,
+		PublicKey:     req.PublicKey,
+		PresharedKey:  req.PresharedKey,
 		Address:       clientAddr,
-		CreatedAt:     time.Now(),
+		CreatedAt:     now,
+		ExpiresAt:     expiresAt,
+		AccessPolicy:  accessPolicy,
+		Profile:       req.Profile,
+		Gateway:       req.Gateway,
+		Routes:        routes,
+		TrafficQuota:  &trafficQuota,
 	}
 
-	if err := d.SaveDevice(device); err != nil {
+	if err := d.SaveDevice(ctx, device); err != nil {
+		d.freeAddresses(clientAddr)
 		return nil, errors.Wrap(err, "failed to save the new device")
 	}
 
 	return device, nil
 }
 
-func (d *DeviceManager) SaveDevice(device *storage.Device) error {
-	return d.storage.Save(device)
+func (d *DeviceManager) manualIPv4Address(manualIPv4Address string) (string, error) {
+	if d.cidr == "" {
+		return "", errors.New("Manual IPv4 assignment not possible, IPv4 subnet is not configured.")
+	}
+
+	ipv4, err := netip.ParseAddr(manualIPv4Address)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid manual IPv4 address")
+	}
+	if !ipv4.Is4() {
+		return "", errors.New("manual IPv4 address is not a valid IPv4 address")
+	}
+
+	vpnsubnetv4 := netip.MustParsePrefix(d.cidr)
+	if !vpnsubnetv4.Contains(ipv4) {
+		return "", fmt.Errorf("manual IPv4 address %s is not in the configured subnet %s", manualIPv4Address, d.cidr)
+	}
+
+	// also check for server and network address
+	startIPv4 := vpnsubnetv4.Masked().Addr()
+	if ipv4 == startIPv4 || ipv4 == startIPv4.Next() {
+		return "", fmt.Errorf("manual IPv4 address %s is reserved", manualIPv4Address)
+	}
+
+	return netip.PrefixFrom(ipv4, 32).String(), nil
+}
+
+func (d *DeviceManager) manualIPv6Address(manualIPv6Address string) (string, error) {
+	if d.cidrv6 == "" {
+		return "", errors.New("Manual IPv6 assignment not possible, IPv6 subnet is not configured.")
+	}
+
+	ipv6, err := netip.ParseAddr(manualIPv6Address)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid manual IPv6 address")
+	}
+	if !ipv6.Is6() {
+		return "", errors.New("manual IPv6 address is not a valid IPv6 address")
+	}
+
+	vpnsubnetv6 := netip.MustParsePrefix(d.cidrv6)
+	if !vpnsubnetv6.Contains(ipv6) {
+		return "", fmt.Errorf("manual IPv6 address %s is not in the configured subnet %s", manualIPv6Address, d.cidrv6)
+	}
+
+	// also check for server and network address
+	startIPv6 := vpnsubnetv6.Masked().Addr()
+	if ipv6 == startIPv6 || ipv6 == startIPv6.Next() {
+		return "", fmt.Errorf("manual IPv6 address %s is reserved", manualIPv6Address)
+	}
+
+	return netip.PrefixFrom(ipv6, 128).String(), nil
+}
+
+// claimAddresses allocates manually assigned addresses, either all or none of them
+func (d *DeviceManager) claimAddresses(addresses ...string) error {
+	claimed := []string{}
+	for _, address := range addresses {
+		if address == "" {
+			continue
+		}
+		addr := netip.MustParsePrefix(address).Addr()
+		if err := d.ipam.Claim(addr); err != nil {
+			d.freeAddresses(strings.Join(claimed, ", "))
+			if err == storage.ErrAddressAllocated {
+				family := "IPv4"
+				if addr.Is6() {
+					family = "IPv6"
+				}
+				return fmt.Errorf("manual %s address %s is already in use", family, addr)
+			}
+			return errors.Wrap(err, "failed to allocate manual IP address")
+		}
+		claimed = append(claimed, address)
+	}
+	return nil
+}
+
+// freeAddresses returns allocated addresses that were never used by a device
+func (d *DeviceManager) freeAddresses(addresses string) {
+	if err := d.ipam.Free(addresses); err != nil {
+		logrus.Warn(errors.Wrapf(err, "failed to free addresses %s", addresses))
+	}
+}
+
+func joinAddresses(ipv4Addr string, ipv6Addr string) string {
+	if ipv4Addr != "" && ipv6Addr != "" {
+		return fmt.Sprintf("%s, %s", ipv4Addr, ipv6Addr)
+	} else if ipv4Addr != "" {
+		return ipv4Addr
+	}
+	return ipv6Addr
+}
+
+// SaveDevice stores the device, e.g. with new metadata, without changing its WireGuard peer
+func (d *DeviceManager) SaveDevice(ctx context.Context, device *storage.Device) error {
+	if err := d.storage.WithContext(ctx).Save(device); err != nil {
+		return err
+	}
+	// the sql storage doesn't report saves of existing devices
+	d.index.update(nil, device)
+	d.events.publish(DeviceUpdated, device, nil)
+	return nil
+}
+
+// reloadIndex replaces the indexed devices with the devices in the storage
+func (d *DeviceManager) reloadIndex() error {
+	devices, err := d.ListAllDevices(context.Background())
+	if err != nil {
+		return errors.Wrap(err, "failed to list devices")
+	}
+	d.index.load(devices)
+	d.changed()
+	return nil
+}
+
+func (d *DeviceManager) changed() {
+	for _, cb := range d.change {
+		cb()
+	}
+}
+
+// Snapshot returns copies of all devices from memory without querying the storage.
+// The devices are loaded by StartSync and kept current by the storage events and the metadata sync.
+func (d *DeviceManager) Snapshot() []*storage.Device {
+	return d.index.list()
 }
 
 func (d *DeviceManager) sync() error {
-	devices, err := d.ListAllDevices()
+	devices, err := d.ListAllDevices(context.Background())
 	if err != nil {
 		return errors.Wrap(err, "failed to list devices")
 	}
@@ -261,18 +562,26 @@ func (d *DeviceManager) sync() error {
 		return errors.Wrap(err, "failed to list peers")
 	}
 
-	// Remove any peers for devices that are no longer in storage
+	// Suspended and expired devices are kept in storage without a peer
+	enabled := make([]*storage.Device, 0, len(devices))
+	for _, device := range devices {
+		if d.peerEnabled(device) {
+			enabled = append(enabled, device)
+		}
+	}
+
+	// Remove any peers for devices that are no longer in storage or disabled
 	for _, peer := range peers {
-		if !deviceListContains(devices, peer.PublicKey.String()) {
-			if err := d.wg.RemovePeer(peer.PublicKey.String()); err != nil {
+		if !deviceListContains(enabled, peer.PublicKey.String()) {
+			if err := d.removePeer(peer.PublicKey.String()); err != nil {
 				logrus.Error(errors.Wrapf(err, "failed to remove peer during sync: %s", peer.PublicKey.String()))
 			}
 		}
 	}
 
-	// Add peers for all devices in storage
-	for _, device := range devices {
-		if err := d.wg.AddPeer(device.PublicKey, device.PresharedKey, network.SplitAddresses(device.Address)); err != nil {
+	// Add peers for all enabled devices in storage
+	for _, device := range enabled {
+		if err := d.addPeer(device); err != nil {
 			logrus.Warn(errors.Wrapf(err, "failed to add device during sync: %s", device.Name))
 		}
 	}
@@ -280,96 +589,265 @@ func (d *DeviceManager) sync() error {
 	return nil
 }
 
-func (d *DeviceManager) ListAllDevices() ([]*storage.Device, error) {
-	return d.storage.List("")
+func (d *DeviceManager) syncAllocations() error {
+	devices := d.Snapshot()
+	addresses := make([]string, 0, len(devices))
+	for _, device := range devices {
+		addresses = append(addresses, device.Address)
+	}
+	return d.ipam.Sync(addresses)
 }
 
-func (d *DeviceManager) ListDevices(user string) ([]*storage.Device, error) {
-	return d.storage.List(user)
+// addPeer installs the firewall rules and the WireGuard peer of a device
+func (d *DeviceManager) addPeer(device *storage.Device) error {
+	// Install the firewall rules before the peer can send any traffic
+	if err := d.firewall.AddPeer(d.peerRules(device)); err != nil {
+		return errors.Wrap(err, "failed to apply device access policy")
+	}
+	if err := d.wg.AddPeer(device.PublicKey, device.PresharedKey, peerAddresses(device)); err != nil {
+		return errors.Wrap(err, "failed to add WireGuard peer")
+	}
+	if err := d.router.SetRoutes(device.PublicKey, device.Routes); err != nil {
+		return errors.Wrap(err, "failed to route the networks of the gateway")
+	}
+	d.peersLock.Lock()
+	d.peers[device.PublicKey] = peerConfig(device)
+	d.peersLock.Unlock()
+	return nil
 }
 
-func (d *DeviceManager) DeleteDevice(user string, name string) error {
-	device, err := d.storage.Get(user, name)
-	if err != nil {
-		return errors.Wrap(err, "failed to retrieve device")
+// applyPeer adds the peer of an enabled device and removes the peer of a disabled one
+func (d *DeviceManager) applyPeer(device *storage.Device) error {
+	if d.peerEnabled(device) {
+		return d.addPeer(device)
 	}
-
-	if err := d.storage.Delete(device); err != nil {
-		return err
+	if d.peerAdded(device.PublicKey) {
+		return d.removePeer(device.PublicKey)
 	}
+	return nil
+}
+
+// peerEnabled reports whether the device should have a WireGuard peer
+func (d *DeviceManager) peerEnabled(device *storage.Device) bool {
+	return !d.suspended(device) && !expired(device, time.Now())
+}
 
+// removePeer removes the WireGuard peer and the firewall rules of a public key
+func (d *DeviceManager) removePeer(publicKey string) error {
+	d.peersLock.Lock()
+	delete(d.peers, publicKey)
+	d.peersLock.Unlock()
+	if err := d.wg.RemovePeer(publicKey); err != nil {
+		return errors.Wrap(err, "failed to remove WireGuard peer")
+	}
+	if err := d.router.RemoveRoutes(publicKey); err != nil {
+		return errors.Wrap(err, "failed to remove the routes of the gateway")
+	}
+	if err := d.firewall.RemovePeer(publicKey); err != nil {
+		return errors.Wrap(err, "failed to remove device access policy")
+	}
 	return nil
 }
 
-func (d *DeviceManager) GetByPublicKey(publicKey string) (*storage.Device, error) {
-	return d.storage.GetByPublicKey(publicKey)
+// peerAdded reports whether a WireGuard peer was added for the public key
+func (d *DeviceManager) peerAdded(publicKey string) bool {
+	d.peersLock.Lock()
+	defer d.peersLock.Unlock()
+	_, ok := d.peers[publicKey]
+	return ok
 }
 
-var nextIPLock = sync.Mutex{}
+// peerConfigured reports whether the WireGuard peer of the device was added with its current settings
+func (d *DeviceManager) peerConfigured(device *storage.Device) bool {
+	d.peersLock.Lock()
+	defer d.peersLock.Unlock()
+	config, ok := d.peers[device.PublicKey]
+	return ok && config == peerConfig(device)
+}
 
-func (d *DeviceManager) nextClientAddress() (string, error) {
-	nextIPLock.Lock()
-	defer nextIPLock.Unlock()
+func peerConfig(device *storage.Device) string {
+	return device.PresharedKey + " " + device.Address + " " + device.Routes
+}
 
-	// TODO: read up on better ways to allocate client's IP
-	// addresses from a configurable CIDR
+// UpdateDevice renames a device, replaces its keys or changes its manual IP addresses
+func (d *DeviceManager) UpdateDevice(ctx context.Context, owner string, name string, update DeviceUpdate) (*storage.Device, error) {
+	d.networksLock.Lock()
+	defer d.networksLock.Unlock()
 
-	usedIPv4s, usedIPv6s, err := d.usedAddresses()
+	previous, err := d.storage.WithContext(ctx).Get(owner, name)
 	if err != nil {
-		return "", errors.Wrap(err, "failed to get used addresses")
+		return nil, errors.Wrap(err, "failed to retrieve device")
 	}
+	device := *previous
 
-	var ipv4 string
-	var ipv6 string
+	if update.Name != nil && *update.Name != previous.Name {
+		if *update.Name == "" {
+			return nil, errors.New("Device name must not be empty.")
+		}
+		if _, err := d.storage.WithContext(ctx).Get(owner, *update.Name); err == nil {
+			return nil, errors.New("Device name already taken.")
+		}
+		device.Name = *update.Name
+	}
+
+	if update.PublicKey != nil && *update.PublicKey != previous.PublicKey {
+		if !wgKeyRegex.MatchString(*update.PublicKey) {
+			return nil, errors.New("Public key has invalid format.")
+		}
+		if _, err := d.storage.WithContext(ctx).GetByPublicKey(*update.PublicKey); err == nil {
+			return nil, errors.New("Public key is already in use.")
+		}
+		device.PublicKey = *update.PublicKey
+	}
 
-	if d.cidr != "" {
-		vpnsubnetv4 := netip.MustParsePrefix(d.cidr)
-		startIPv4 := vpnsubnetv4.Masked().Addr()
+	if update.PresharedKey != nil {
+		// preshared key is optional
+		if len(*update.PresharedKey) != 0 && !wgKeyRegex.MatchString(*update.PresharedKey) {
+			return nil, errors.New("Pre-shared key has invalid format.")
+		}
+		device.PresharedKey = *update.PresharedKey
+	}
 
-		// Add the network address and the VPN server address to the list of occupied addresses
-		usedIPv4s[startIPv4] = true        // x.x.x.0
-		usedIPv4s[startIPv4.Next()] = true // x.x.x.1
+	manualIPv4Address := ""
+	if update.ManualIPv4Address != nil {
+		manualIPv4Address = *update.ManualIPv4Address
+	}
+	manualIPv6Address := ""
+	if update.ManualIPv6Address != nil {
+		manualIPv6Address = *update.ManualIPv6Address
+	}
+	// addresses of the device that are replaced, released after the update
+	replaced := []string{}
+	claimed := []string{}
+	if manualIPv4Address != "" || manualIPv6Address != "" {
+		var ipv4Addr, ipv6Addr string
+		for _, addr := range network.SplitAddresses(previous.Address) {
+			if netip.MustParsePrefix(addr).Addr().Is4() {
+				ipv4Addr = addr
+			} else {
+				ipv6Addr = addr
+			}
+		}
 
-		for ip := startIPv4.Next().Next(); vpnsubnetv4.Contains(ip); ip = ip.Next() {
-			if !usedIPv4s[ip] {
-				ipv4 = netip.PrefixFrom(ip, 32).String()
-				break
+		if manualIPv4Address != "" {
+			addr, err := d.manualIPv4Address(manualIPv4Address)
+			if err != nil {
+				return nil, err
+			}
+			if addr != ipv4Addr {
+				claimed = append(claimed, addr)
+				if ipv4Addr != "" {
+					replaced = append(replaced, ipv4Addr)
+				}
+				ipv4Addr = addr
 			}
 		}
+		if manualIPv6Address != "" {
+			addr, err := d.manualIPv6Address(manualIPv6Address)
+			if err != nil {
+				return nil, err
+			}
+			if addr != ipv6Addr {
+				claimed = append(claimed, addr)
+				if ipv6Addr != "" {
+					replaced = append(replaced, ipv6Addr)
+				}
+				ipv6Addr = addr
+			}
+		}
+		if err := d.validateAddresses(ctx, claimed...); err != nil {
+			return nil, err
+		}
+		if err := d.claimAddresses(claimed...); err != nil {
+			return nil, err
+		}
+		device.Address = joinAddresses(ipv4Addr, ipv6Addr)
 	}
 
-	if d.cidrv6 != "" {
-		vpnsubnetv6 := netip.MustParsePrefix(d.cidrv6)
-		startIPv6 := vpnsubnetv6.Masked().Addr()
-
-		// Add the network address and the VPN server address to the list of occupied addresses
-		usedIPv6s[startIPv6] = true        // ::0
-		usedIPv6s[startIPv6.Next()] = true // ::1
+	if err := d.storage.WithContext(ctx).Update(previous, &device); err != nil {
+		d.freeAddresses(strings.Join(claimed, ", "))
+		return nil, errors.Wrap(err, "failed to update the device")
+	}
 
-		for ip := startIPv6.Next().Next(); vpnsubnetv6.Contains(ip); ip = ip.Next() {
-			if !usedIPv6s[ip] {
-				ipv6 = netip.PrefixFrom(ip, 128).String()
-				break
-			}
+	if len(replaced) > 0 {
+		if err := d.ipam.Release(strings.Join(replaced, ", ")); err != nil {
+			logrus.Error(errors.Wrap(err, "failed to release replaced addresses"))
 		}
 	}
 
-	if ipv4 != "" {
-		if ipv6 != "" {
-			return fmt.Sprintf("%s, %s", ipv4, ipv6), nil
-		} else if d.cidrv6 != "" {
-			return "", fmt.Errorf("there are no free IP addresses in the vpn subnet: '%s'", d.cidrv6)
-		} else {
-			return ipv4, nil
+	return &device, nil
+}
+
+func (d *DeviceManager) ListAllDevices(ctx context.Context) ([]*storage.Device, error) {
+	return d.storage.WithContext(ctx).List("")
+}
+
+func (d *DeviceManager) ListDevices(ctx context.Context, user string) ([]*storage.Device, error) {
+	return d.storage.WithContext(ctx).List(user)
+}
+
+func (d *DeviceManager) DeleteDevice(ctx context.Context, user string, name string) error {
+	device, err := d.storage.WithContext(ctx).Get(user, name)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve device")
+	}
+
+	if err := d.storage.WithContext(ctx).Delete(device); err != nil {
+		return err
+	}
+
+	if err := d.ipam.Release(device.Address); err != nil {
+		logrus.Error(errors.Wrap(err, "failed to release the addresses of the deleted device"))
+	}
+
+	return nil
+}
+
+func (d *DeviceManager) GetDevice(ctx context.Context, owner string, name string) (*storage.Device, error) {
+	return d.storage.WithContext(ctx).Get(owner, name)
+}
+
+func (d *DeviceManager) GetByPublicKey(publicKey string) (*storage.Device, error) {
+	return d.storage.GetByPublicKey(publicKey)
+}
+
+func validateAccessPolicy(policy *storage.AccessPolicy) error {
+	if policy == nil {
+		return nil
+	}
+	for _, rule := range policy.Rules {
+		if err := toNetworkRule(rule).Validate(); err != nil {
+			return errors.Wrap(err, "invalid access policy")
 		}
-	} else if ipv6 != "" {
-		if d.cidr != "" {
-			return "", fmt.Errorf("there are no free IP addresses in the vpn subnet: '%s'", d.cidr)
-		} else {
-			return ipv6, nil
+	}
+	return nil
+}
+
+// peerRules converts the access policy and the profile of a device into firewall rules
+func (d *DeviceManager) peerRules(device *storage.Device) network.PeerRules {
+	peer := network.PeerRules{
+		PublicKey: device.PublicKey,
+		// traffic from the networks behind a gateway is subject to its policy as well
+		Addresses: strings.Join(peerAddresses(device), ", "),
+	}
+	if device.AccessPolicy != nil {
+		peer.ClientIsolation = device.AccessPolicy.ClientIsolation
+		for _, rule := range device.AccessPolicy.Rules {
+			peer.Rules = append(peer.Rules, toNetworkRule(rule))
 		}
-	} else {
-		return "", fmt.Errorf("there are no free IP addresses in the vpn subnets: '%s', '%s'", d.cidr, d.cidrv6)
+	}
+	// Devices of a profile that was removed from the config fall back to the default client config
+	if profile := d.Profile(device.Profile); profile != nil {
+		peer.Destinations = profile.AllowedIPs
+	}
+	return peer
+}
+
+func toNetworkRule(rule storage.AccessRule) network.Rule {
+	return network.Rule{
+		CIDR:     rule.CIDR,
+		Protocol: rule.Protocol,
+		Ports:    rule.Ports,
 	}
 }
 
@@ -382,8 +860,8 @@ func deviceListContains(devices []*storage.Device, publicKey string) bool {
 	return false
 }
 
-func (d *DeviceManager) ListUsers() ([]*User, error) {
-	devices, err := d.storage.List("")
+func (d *DeviceManager) ListUsers(ctx context.Context) ([]*User, error) {
+	devices, err := d.storage.WithContext(ctx).List("")
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to retrieve devices")
 	}
@@ -400,15 +878,15 @@ func (d *DeviceManager) ListUsers() ([]*User, error) {
 	return users, nil
 }
 
-func (d *DeviceManager) DeleteDevicesForUser(user string) error {
-	devices, err := d.ListDevices(user)
+func (d *DeviceManager) DeleteDevicesForUser(ctx context.Context, user string) error {
+	devices, err := d.ListDevices(ctx, user)
 	if err != nil {
 		return errors.Wrap(err, "failed to retrieve devices")
 	}
 
 	for _, dev := range devices {
 		// TODO not transactional
-		if err := d.DeleteDevice(user, dev.Name); err != nil {
+		if err := d.DeleteDevice(ctx, user, dev.Name); err != nil {
 			return errors.Wrap(err, "failed to delete device")
 		}
 	}
@@ -0,0 +1,152 @@
+package devices
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/freifunkMUC/wg-access-server/internal/storage"
+)
+
+// EventType is the kind of change reported by WatchDevices
+type EventType int
+
+const (
+	DeviceAdded EventType = iota
+	// DeviceUpdated is sent for changes by users and admins as well as for new metadata
+	DeviceUpdated
+	DeviceDeleted
+)
+
+// watchBuffer is the number of events a watcher may fall behind before it is dropped
+const watchBuffer = 100
+
+type DeviceEvent struct {
+	Type   EventType
+	Device *storage.Device
+	// PreviousName is set if the device was renamed
+	PreviousName string
+}
+
+type watcher struct {
+	// empty to watch the devices of all users
+	owner  string
+	events chan DeviceEvent
+}
+
+// deviceEvents fans the changes of devices out to the watchers.
+// It is fed by the storage callbacks and SaveDevice, which may report
+// the same change twice, so it skips devices that didn't change.
+type deviceEvents struct {
+	lock     sync.Mutex
+	watchers map[*watcher]bool
+	// devices is the last published state of each device
+	devices map[string]string
+}
+
+func newDeviceEvents() *deviceEvents {
+	return &deviceEvents{
+		watchers: map[*watcher]bool{},
+		devices:  map[string]string{},
+	}
+}
+
+func eventKey(device *storage.Device) string {
+	return device.Owner + "/" + device.Name
+}
+
+func eventState(device *storage.Device) string {
+	data, err := json.Marshal(device)
+	if err != nil {
+		logrus.Error(errors.Wrap(err, "failed to encode device event"))
+		return ""
+	}
+	return string(data)
+}
+
+// seed records the state of devices that existed before the events were published
+func (e *deviceEvents) seed(devices []*storage.Device) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	for _, device := range devices {
+		if _, ok := e.devices[eventKey(device)]; !ok {
+			e.devices[eventKey(device)] = eventState(device)
+		}
+	}
+}
+
+// publish sends the change to the watchers of the device,
+// previous is the device before an update if the storage knows it
+func (e *deviceEvents) publish(t EventType, device *storage.Device, previous *storage.Device) {
+	// watchers read the device concurrently to the next change
+	copied := *device
+	event := DeviceEvent{Type: t, Device: &copied}
+	key := eventKey(device)
+	state := eventState(device)
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if previous != nil && eventKey(previous) != key {
+		delete(e.devices, eventKey(previous))
+		event.PreviousName = previous.Name
+	}
+	if t == DeviceDeleted {
+		delete(e.devices, key)
+	} else {
+		last, ok := e.devices[key]
+		if ok && last == state && event.PreviousName == "" {
+			return
+		}
+		if ok && t == DeviceAdded {
+			// in-memory storage reports every save as an add
+			event.Type = DeviceUpdated
+		}
+		e.devices[key] = state
+	}
+
+	for w := range e.watchers {
+		if w.owner != "" && w.owner != device.Owner {
+			continue
+		}
+		select {
+		case w.events <- event:
+		default:
+			logrus.Warnf("Dropping device watcher of '%s', it doesn't keep up with the events", w.owner)
+			delete(e.watchers, w)
+			close(w.events)
+		}
+	}
+}
+
+func (e *deviceEvents) watch(owner string) (*watcher, func()) {
+	w := &watcher{owner: owner, events: make(chan DeviceEvent, watchBuffer)}
+	e.lock.Lock()
+	e.watchers[w] = true
+	e.lock.Unlock()
+
+	return w, func() {
+		e.lock.Lock()
+		defer e.lock.Unlock()
+		if e.watchers[w] {
+			delete(e.watchers, w)
+			close(w.events)
+		}
+	}
+}
+
+// WatchDevices returns the devices of the owner (all devices if empty) and a channel of their changes.
+// A change may already be contained in the returned devices. The channel is closed if the
+// watcher falls too far behind, stop must be called once it isn't needed anymore.
+func (d *DeviceManager) WatchDevices(owner string) ([]*storage.Device, <-chan DeviceEvent, func(), error) {
+	// watch before listing, so no change gets lost in between
+	w, stop := d.events.watch(owner)
+	devices, err := d.storage.List(owner)
+	if err != nil {
+		stop()
+		return nil, nil, nil, errors.Wrap(err, "failed to list devices")
+	}
+	return devices, w.events, stop, nil
+}
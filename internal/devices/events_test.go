@@ -0,0 +1,73 @@
+package devices
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/freifunkMUC/wg-access-server/internal/storage"
+)
+
+func TestDeviceEvents(t *testing.T) {
+	require := require.New(t)
+
+	e := newDeviceEvents()
+	e.seed([]*storage.Device{{Owner: "alice", Name: "notebook"}})
+	alice, stopAlice := e.watch("alice")
+	defer stopAlice()
+	all, stopAll := e.watch("")
+	defer stopAll()
+
+	// the in-memory storage reports saves of existing devices as adds
+	notebook := &storage.Device{Owner: "alice", Name: "notebook"}
+	e.publish(DeviceAdded, notebook, nil)
+	require.Empty(alice.events)
+
+	notebook.ReceiveBytes = 42
+	e.publish(DeviceAdded, notebook, nil)
+	e.publish(DeviceUpdated, notebook, nil)
+	event := <-alice.events
+	require.Equal(DeviceUpdated, event.Type)
+	require.Equal(int64(42), event.Device.ReceiveBytes)
+	require.Empty(alice.events)
+	<-all.events
+
+	// published devices are copies
+	notebook.ReceiveBytes = 43
+	require.Equal(int64(42), event.Device.ReceiveBytes)
+
+	e.publish(DeviceAdded, &storage.Device{Owner: "bob", Name: "phone"}, nil)
+	require.Empty(alice.events)
+	event = <-all.events
+	require.Equal(DeviceAdded, event.Type)
+	require.Equal("bob", event.Device.Owner)
+
+	renamed := *notebook
+	renamed.Name = "laptop"
+	e.publish(DeviceUpdated, &renamed, notebook)
+	event = <-alice.events
+	require.Equal(DeviceUpdated, event.Type)
+	require.Equal("laptop", event.Device.Name)
+	require.Equal("notebook", event.PreviousName)
+	<-all.events
+
+	e.publish(DeviceDeleted, &renamed, nil)
+	event = <-alice.events
+	require.Equal(DeviceDeleted, event.Type)
+	<-all.events
+
+	// watchers that fall behind are closed
+	for i := 1; i <= watchBuffer+1; i++ {
+		e.publish(DeviceUpdated, &storage.Device{Owner: "bob", Name: "phone", ReceiveBytes: int64(i)}, nil)
+	}
+	for range all.events {
+	}
+	require.Empty(alice.events)
+	e.publish(DeviceUpdated, &storage.Device{Owner: "alice", Name: "tablet"}, nil)
+	require.Len(alice.events, 1)
+
+	// stopping twice or after the watcher was closed is fine
+	stopAll()
+	stopAlice()
+	stopAlice()
+}
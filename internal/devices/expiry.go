@@ -0,0 +1,188 @@
+package devices
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/freifunkMUC/wg-access-server/internal/audit"
+	"github.com/freifunkMUC/wg-access-server/internal/config"
+	"github.com/freifunkMUC/wg-access-server/internal/storage"
+	"github.com/freifunkMUC/wg-access-server/internal/traces"
+	"github.com/freifunkMUC/wg-access-server/pkg/authnz/authsession"
+)
+
+// LifetimeError is returned if the requested expiry date exceeds the maximum lifetime of the user,
+// or if it is missing when a device without maximum lifetime is renewed
+type LifetimeError struct {
+	MaxLifetime time.Duration
+}
+
+func (e *LifetimeError) Error() string {
+	if e.MaxLifetime == 0 {
+		return "An expiry date is required to renew devices without a maximum lifetime."
+	}
+	return fmt.Sprintf("Devices may be valid for at most %s.", e.MaxLifetime)
+}
+
+// MaxLifetime returns the maximum time from now a device of the user may be valid, 0 means unlimited.
+// The lifetime of the first matching policy takes precedence over the default.
+func (d *DeviceManager) MaxLifetime(identity *authsession.Identity) time.Duration {
+	if policy := d.ResolvePolicy(identity); policy != nil && policy.MaxLifetime > 0 {
+		return policy.MaxLifetime
+	}
+	return d.expiry.MaxLifetime
+}
+
+// deviceMaxLifetime returns the maximum lifetime of the owner of the device. The claims of the owner
+// are only known while they are signed in, so the policy the device was created with applies.
+func (d *DeviceManager) deviceMaxLifetime(device *storage.Device) time.Duration {
+	if device.AccessPolicy != nil {
+		for _, policy := range d.policies {
+			if policy.Name == device.AccessPolicy.Name && policy.MaxLifetime > 0 {
+				return policy.MaxLifetime
+			}
+		}
+	}
+	return d.expiry.MaxLifetime
+}
+
+// expiresAt validates the requested expiry date of a device,
+// it defaults to the maximum lifetime from now
+func expiresAt(maxLifetime time.Duration, requested *time.Time, now time.Time) (*time.Time, error) {
+	if requested == nil {
+		if maxLifetime == 0 {
+			return nil, nil
+		}
+		expiresAt := now.Add(maxLifetime)
+		return &expiresAt, nil
+	}
+	if !requested.After(now) {
+		return nil, errors.New("Expiry date must be in the future.")
+	}
+	if maxLifetime > 0 && requested.After(now.Add(maxLifetime)) {
+		return nil, &LifetimeError{MaxLifetime: maxLifetime}
+	}
+	return requested, nil
+}
+
+// RenewDevice sets a new expiry date within the maximum lifetime of the owner,
+// an expired device is enabled again. Without a maximum lifetime the expiry date is required.
+func (d *DeviceManager) RenewDevice(ctx context.Context, owner string, name string, requested *time.Time) (*storage.Device, error) {
+	stored, err := d.storage.WithContext(ctx).Get(owner, name)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve device")
+	}
+	maxLifetime := d.deviceMaxLifetime(stored)
+	if requested == nil && maxLifetime == 0 {
+		return nil, &LifetimeError{}
+	}
+	expiresAt, err := expiresAt(maxLifetime, requested, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	device, err := d.storage.WithContext(ctx).SetExpiresAt(owner, name, expiresAt)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to renew the device")
+	}
+	return device, nil
+}
+
+// expired reports whether the device is expired at the given time
+func expired(device *storage.Device, now time.Time) bool {
+	return device.ExpiresAt != nil && !now.Before(*device.ExpiresAt)
+}
+
+// OnExpiring registers a callback for devices expiring within expiry.warnBefore,
+// it is called once per expiry date
+func (d *DeviceManager) OnExpiring(cb storage.Callback) {
+	d.expiring = append(d.expiring, cb)
+}
+
+func expiryLoop(d *DeviceManager) {
+	for {
+		checkExpired(d, time.Now())
+		time.Sleep(30 * time.Second)
+	}
+}
+
+// checkExpired removes the WireGuard peers of expired devices
+// and deletes devices that have been expired for longer than expiry.deleteAfter
+func checkExpired(d *DeviceManager, now time.Time) {
+	logrus.Debug("Expired device check executing")
+
+	ctx, span := traces.Start(context.Background(), "devices.check_expired")
+	defer span.End()
+
+	devices, err := d.ListAllDevices(ctx)
+	if err != nil {
+		logrus.Warn(errors.Wrap(err, "failed to list devices - expired devices cannot be disabled"))
+		return
+	}
+
+	for _, dev := range devices {
+		if !expired(dev, now) {
+			if expiring(dev, now, d.expiry.WarnBefore) {
+				logrus.Infof("Device expires soon: %s/%s", dev.Owner, dev.Name)
+				if err := d.storage.WithContext(ctx).SetExpiryWarned(dev, &now); err != nil {
+					logrus.Error(errors.Wrap(err, fmt.Sprintf("failed to mark the expiry warning of device: %s/%s", dev.Owner, dev.Name)))
+					continue
+				}
+				dev.ExpiryWarnedAt = &now
+				for _, cb := range d.expiring {
+					cb(dev)
+				}
+			}
+			continue
+		}
+
+		if d.expiry.DeleteAfter > 0 && now.Sub(*dev.ExpiresAt) >= d.expiry.DeleteAfter {
+			logrus.Warnf("Deleting expired device: %s/%s", dev.Owner, dev.Name)
+			if err := d.DeleteDevice(ctx, dev.Owner, dev.Name); err != nil {
+				logrus.Error(errors.Wrap(err, fmt.Sprintf("failed to delete device: %s/%s", dev.Owner, dev.Name)))
+				continue
+			}
+			event := audit.Event(nil, audit.ExpiredDeviceDelete, audit.DeviceTarget(dev.Owner, dev.Name))
+			event.Details = fmt.Sprintf("expired at %s", dev.ExpiresAt.Format(time.RFC3339))
+			d.audit.Record(event)
+			continue
+		}
+
+		if d.peerAdded(dev.PublicKey) {
+			logrus.Infof("Disabling expired device: %s/%s", dev.Owner, dev.Name)
+			if err := d.removePeer(dev.PublicKey); err != nil {
+				logrus.Error(errors.Wrap(err, fmt.Sprintf("failed to disable device: %s/%s", dev.Owner, dev.Name)))
+			}
+		}
+	}
+}
+
+// expiring reports whether the owner of the device should be warned about its expiry,
+// a renewed device is warned again before its new expiry date
+func expiring(device *storage.Device, now time.Time, warnBefore time.Duration) bool {
+	if device.ExpiresAt == nil || warnBefore <= 0 {
+		return false
+	}
+	warnAt := device.ExpiresAt.Add(-warnBefore)
+	if now.Before(warnAt) {
+		return false
+	}
+	return device.ExpiryWarnedAt == nil || device.ExpiryWarnedAt.Before(warnAt)
+}
+
+// ValidateDeviceExpiry checks the configured device expiry for errors
+func ValidateDeviceExpiry(expiry config.DeviceExpiry) error {
+	if expiry.MaxLifetime < 0 {
+		return errors.New("maximum device lifetime must not be negative")
+	}
+	if expiry.DeleteAfter < 0 {
+		return errors.New("deletion delay of expired devices must not be negative")
+	}
+	if expiry.WarnBefore < 0 {
+		return errors.New("expiry warning must not be negative")
+	}
+	return nil
+}
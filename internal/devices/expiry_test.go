@@ -0,0 +1,45 @@
+package devices
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/freifunkMUC/wg-access-server/internal/config"
+	"github.com/freifunkMUC/wg-access-server/internal/storage"
+)
+
+func TestRenewDevice(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	s := storage.NewMemoryStorage()
+	d := &DeviceManager{
+		storage:  s,
+		policies: []config.Policy{{Name: "guests", Claim: "guest", MaxLifetime: time.Hour}},
+	}
+	expired := time.Now().Add(-time.Minute)
+	require.NoError(s.Save(&storage.Device{Owner: "alice", Name: "phone", ExpiresAt: &expired}))
+	require.NoError(s.Save(&storage.Device{Owner: "bob", Name: "phone", ExpiresAt: &expired, ExpiryWarnedAt: &expired, AccessPolicy: &storage.AccessPolicy{Name: "guests"}}))
+	_, err := s.SetSuspended("bob", "phone", true)
+	require.NoError(err)
+
+	// the lifetime of the owner's policy applies, regardless of who renews the device
+	device, err := d.RenewDevice(ctx, "bob", "phone", nil)
+	require.NoError(err)
+	require.WithinDuration(time.Now().Add(time.Hour), *device.ExpiresAt, time.Minute)
+	require.Nil(device.ExpiryWarnedAt)
+	require.True(device.Suspended)
+	tomorrow := time.Now().Add(24 * time.Hour)
+	_, err = d.RenewDevice(ctx, "bob", "phone", &tomorrow)
+	require.ErrorAs(err, new(*LifetimeError))
+
+	// without a maximum lifetime the expiry date isn't cleared
+	_, err = d.RenewDevice(ctx, "alice", "phone", nil)
+	require.ErrorAs(err, new(*LifetimeError))
+	device, err = d.RenewDevice(ctx, "alice", "phone", &tomorrow)
+	require.NoError(err)
+	require.Equal(tomorrow, *device.ExpiresAt)
+}
@@ -0,0 +1,122 @@
+package devices
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/freifunkMUC/wg-access-server/internal/network"
+	"github.com/freifunkMUC/wg-access-server/internal/storage"
+)
+
+// peerAddresses returns the allowed IPs of the WireGuard peer of a device,
+// its VPN addresses followed by the networks routed to a gateway
+func peerAddresses(device *storage.Device) []string {
+	return append(network.SplitAddresses(device.Address), network.SplitAddresses(device.Routes)...)
+}
+
+// usedNetwork is a network that the routes of a gateway must not overlap
+type usedNetwork struct {
+	prefix netip.Prefix
+	// owner describes the network in errors
+	owner string
+}
+
+// validateRoutes checks the networks routed to a new gateway device and returns them normalized.
+// They must not overlap the VPN networks, the addresses and routes of the other devices
+// or the AllowedIPs, except for the default routes of the latter.
+func (d *DeviceManager) validateRoutes(ctx context.Context, routes string) (string, error) {
+	prefixes := []netip.Prefix{}
+	for _, route := range network.SplitAddresses(routes) {
+		prefix, err := netip.ParsePrefix(route)
+		if err != nil {
+			return "", fmt.Errorf("Invalid route '%s'.", route)
+		}
+		if prefix.Addr().Is4In6() {
+			return "", fmt.Errorf("Route %s must be given as IPv4 network.", prefix)
+		}
+		if prefix.Bits() == 0 {
+			return "", fmt.Errorf("Default route %s can't be routed to a device.", prefix)
+		}
+		if prefix != prefix.Masked() {
+			return "", fmt.Errorf("Route %s has host bits set, use %s instead.", prefix, prefix.Masked())
+		}
+		prefixes = append(prefixes, prefix)
+	}
+
+	used, err := d.usedNetworks(ctx)
+	if err != nil {
+		return "", err
+	}
+	normalized := make([]string, 0, len(prefixes))
+	for i, prefix := range prefixes {
+		for _, other := range prefixes[:i] {
+			if prefix.Overlaps(other) {
+				return "", fmt.Errorf("Routes %s and %s overlap.", other, prefix)
+			}
+		}
+		for _, network := range used {
+			if prefix.Overlaps(network.prefix) {
+				return "", fmt.Errorf("Route %s overlaps %s %s.", prefix, network.owner, network.prefix)
+			}
+		}
+		normalized = append(normalized, prefix.String())
+	}
+	return strings.Join(normalized, ", "), nil
+}
+
+// validateAddresses checks that manually assigned addresses don't overlap the routes of gateway devices,
+// e.g. after the VPN network was changed to include a routed network
+func (d *DeviceManager) validateAddresses(ctx context.Context, addresses ...string) error {
+	devices, err := d.ListAllDevices(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to list devices")
+	}
+	for _, address := range addresses {
+		if address == "" {
+			continue
+		}
+		addr := netip.MustParsePrefix(address)
+		for _, device := range devices {
+			for _, route := range network.SplitAddresses(device.Routes) {
+				if prefix, err := netip.ParsePrefix(route); err == nil && prefix.Overlaps(addr) {
+					return fmt.Errorf("Address %s overlaps the route %s of the device '%s' of %s.", addr.Addr(), prefix, device.Name, device.Owner)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// usedNetworks returns the VPN networks, the AllowedIPs except for default routes
+// and the addresses and routes of all devices
+func (d *DeviceManager) usedNetworks(ctx context.Context) ([]usedNetwork, error) {
+	used := []usedNetwork{}
+	for _, cidr := range []string{d.cidr, d.cidrv6} {
+		if prefix, err := netip.ParsePrefix(cidr); err == nil {
+			used = append(used, usedNetwork{prefix.Masked(), "the VPN network"})
+		}
+	}
+	for _, allowed := range d.allowedIPs {
+		if prefix, err := netip.ParsePrefix(allowed); err == nil && prefix.Bits() > 0 {
+			used = append(used, usedNetwork{prefix.Masked(), "the AllowedIPs"})
+		}
+	}
+
+	devices, err := d.ListAllDevices(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list devices")
+	}
+	for _, device := range devices {
+		owner := fmt.Sprintf("the device '%s' of %s", device.Name, device.Owner)
+		for _, addr := range peerAddresses(device) {
+			if prefix, err := netip.ParsePrefix(addr); err == nil {
+				used = append(used, usedNetwork{prefix.Masked(), owner})
+			}
+		}
+	}
+	return used, nil
+}
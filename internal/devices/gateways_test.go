@@ -0,0 +1,59 @@
+package devices
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/freifunkMUC/wg-access-server/internal/storage"
+)
+
+func TestValidateRoutes(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	s := storage.NewMemoryStorage()
+	d := &DeviceManager{
+		storage:    s,
+		cidr:       "10.44.0.0/24",
+		cidrv6:     "fd48:4c4:7aa9::/64",
+		allowedIPs: []string{"0.0.0.0/0", "::/0", "172.16.0.0/16"},
+	}
+	require.NoError(s.Save(&storage.Device{Owner: "alice", Name: "branch", PublicKey: "a1", Address: "10.44.0.2/32", Gateway: true, Routes: "192.168.10.0/24"}))
+
+	routes, err := d.validateRoutes(ctx, "192.168.20.0/24,fd00:20::/64")
+	require.NoError(err)
+	require.Equal("192.168.20.0/24, fd00:20::/64", routes)
+
+	for _, invalid := range []string{
+		"192.168.20.0",
+		"192.168.20.1/24",
+		"0.0.0.0/0",
+		"::ffff:192.168.20.0/120",
+		"192.168.20.0/24, 192.168.20.128/25",
+		// the VPN networks
+		"10.44.0.0/16",
+		"fd48:4c4:7aa9::/48",
+		// the routes of another gateway
+		"192.168.10.128/25",
+		// the allowed ips
+		"172.16.1.0/24",
+	} {
+		_, err := d.validateRoutes(ctx, invalid)
+		require.Error(err, invalid)
+	}
+}
+
+func TestValidateAddresses(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	s := storage.NewMemoryStorage()
+	d := &DeviceManager{storage: s}
+	require.NoError(s.Save(&storage.Device{Owner: "alice", Name: "branch", PublicKey: "a1", Address: "10.44.0.2/32", Gateway: true, Routes: "10.44.1.0/24, fd00:10::/64"}))
+
+	require.NoError(d.validateAddresses(ctx, "10.44.0.3/32", "", "fd00:20::1/128"))
+	require.Error(d.validateAddresses(ctx, "10.44.1.3/32"))
+	require.Error(d.validateAddresses(ctx, "fd00:10::3/128"))
+}
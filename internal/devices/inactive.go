@@ -1,24 +1,42 @@
 package devices
 
 import (
+	"context"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+
+	"github.com/freifunkMUC/wg-access-server/internal/audit"
+	"github.com/freifunkMUC/wg-access-server/internal/storage"
+	"github.com/freifunkMUC/wg-access-server/internal/traces"
 )
 
-func inactiveLoop(d *DeviceManager, inactiveDeviceGracePeriod time.Duration) {
+// StaleCallback is called when a device became stale, with the time it will be deleted
+type StaleCallback func(device *storage.Device, deletesAt time.Time)
+
+// OnStale registers a callback for devices that have been inactive for longer than the warning threshold,
+// it is called once until the device connects again
+func (d *DeviceManager) OnStale(cb StaleCallback) {
+	d.stale = append(d.stale, cb)
+}
+
+func inactiveLoop(d *DeviceManager) {
 	for {
-		checkAndRemove(d, inactiveDeviceGracePeriod)
+		checkAndRemove(d, time.Now())
 		time.Sleep(30 * time.Second)
 	}
 }
 
-func checkAndRemove(d *DeviceManager, inactiveDeviceGracePeriod time.Duration) {
+func checkAndRemove(d *DeviceManager, now time.Time) {
 	logrus.Debug("Inactive check executing")
 
-	devices, err := d.ListAllDevices()
+	ctx, span := traces.Start(context.Background(), "devices.check_inactive")
+	defer span.End()
+
+	devices, err := d.ListAllDevices(ctx)
 	if err != nil {
 		logrus.Warn(errors.Wrap(err, "failed to list devices - inactive devices cannot be deleted"))
 		return
@@ -27,21 +45,115 @@ func checkAndRemove(d *DeviceManager, inactiveDeviceGracePeriod time.Duration) {
 	for _, dev := range devices {
 		logrus.Debugf("Checking inactive device: %s/%s", dev.Owner, dev.Name)
 
-		var elapsed time.Duration
-		if dev.LastHandshakeTime == nil {
-			// Never connected
-			elapsed = time.Since(dev.CreatedAt)
-		} else {
-			elapsed = time.Since(*dev.LastHandshakeTime)
+		// Suspended devices can't connect, they are kept until they are resumed or the traffic quota is reset
+		if d.suspended(dev) {
+			continue
 		}
 
-		if elapsed > inactiveDeviceGracePeriod {
+		elapsed := now.Sub(lastActivity(dev))
+
+		if elapsed > d.inactiveGracePeriod {
 			logrus.Warnf("Deleting inactive device: %s/%s", dev.Owner, dev.Name)
-			err := d.DeleteDevice(dev.Owner, dev.Name)
+			err := d.DeleteDevice(ctx, dev.Owner, dev.Name)
 			if err != nil {
 				logrus.Error(errors.Wrap(err, fmt.Sprintf("failed to delete device: %s/%s", dev.Owner, dev.Name)))
 				continue
 			}
+			event := audit.Event(nil, audit.InactiveDeviceDelete, audit.DeviceTarget(dev.Owner, dev.Name))
+			event.Details = fmt.Sprintf("inactive for %s", elapsed.Round(time.Second))
+			d.audit.Record(event)
+			for _, cb := range d.inactiveDelete {
+				cb(dev)
+			}
+			continue
+		}
+
+		stale := d.inactiveWarningThreshold > 0 && elapsed > d.inactiveWarningThreshold
+		if stale && dev.StaleSince == nil {
+			logrus.Infof("Inactive device is stale: %s/%s", dev.Owner, dev.Name)
+			if err := d.markStale(ctx, dev, &now); err != nil {
+				logrus.Error(errors.Wrap(err, fmt.Sprintf("failed to mark device as stale: %s/%s", dev.Owner, dev.Name)))
+				continue
+			}
+			deletesAt := lastActivity(dev).Add(d.inactiveGracePeriod)
+			for _, cb := range d.stale {
+				cb(dev, deletesAt)
+			}
+		} else if !stale && dev.StaleSince != nil {
+			// the device connected to another replica or the threshold was raised
+			if err := d.markStale(ctx, dev, nil); err != nil {
+				logrus.Error(errors.Wrap(err, fmt.Sprintf("failed to unmark stale device: %s/%s", dev.Owner, dev.Name)))
+			}
+		}
+	}
+}
+
+// suspended reports whether the device was suspended by an admin or for exceeding the traffic quota
+func (d *DeviceManager) suspended(device *storage.Device) bool {
+	return device.Suspended || (device.OverQuota && !d.throttleOverQuota())
+}
+
+// markStale writes the stale mark of the device alone, so that concurrent changes aren't overwritten.
+// The indexed device is updated as well, because the metadata loop saves it.
+func (d *DeviceManager) markStale(ctx context.Context, device *storage.Device, staleSince *time.Time) error {
+	if err := d.storage.WithContext(ctx).SetStaleSince(device, staleSince); err != nil {
+		return err
+	}
+	device.StaleSince = staleSince
+	if indexed, ok := d.index.getByPublicKey(device.PublicKey); ok {
+		indexed.StaleSince = staleSince
+		d.index.update(nil, indexed)
+		d.events.publish(DeviceUpdated, indexed, nil)
+	}
+	return nil
+}
+
+// lastActivity is the last handshake of the device or its creation if it never connected
+func lastActivity(device *storage.Device) time.Time {
+	if device.LastHandshakeTime == nil {
+		return device.CreatedAt
+	}
+	return *device.LastHandshakeTime
+}
+
+// DeletionTime returns the time the device will be deleted automatically,
+// because it stays inactive or expired. nil if it won't be deleted.
+func (d *DeviceManager) DeletionTime(device *storage.Device) *time.Time {
+	var deletesAt *time.Time
+	if d.inactiveGracePeriod > 0 && !d.suspended(device) {
+		t := lastActivity(device).Add(d.inactiveGracePeriod)
+		deletesAt = &t
+	}
+	if d.expiry.DeleteAfter > 0 && device.ExpiresAt != nil {
+		t := device.ExpiresAt.Add(d.expiry.DeleteAfter)
+		if deletesAt == nil || t.Before(*deletesAt) {
+			deletesAt = &t
+		}
+	}
+	return deletesAt
+}
+
+// PendingDeletions returns the devices that will be deleted automatically
+// within the given duration (0 for all), ordered by their deletion time
+func (d *DeviceManager) PendingDeletions(ctx context.Context, within time.Duration) ([]*storage.Device, error) {
+	devices, err := d.ListAllDevices(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list devices")
+	}
+
+	deadline := time.Now().Add(within)
+	pending := []*storage.Device{}
+	deletesAt := map[*storage.Device]time.Time{}
+	for _, dev := range devices {
+		t := d.DeletionTime(dev)
+		if t == nil || (within > 0 && t.After(deadline)) {
+			continue
 		}
+		pending = append(pending, dev)
+		deletesAt[dev] = *t
 	}
+	sort.SliceStable(pending, func(i, j int) bool {
+		return deletesAt[pending[i]].Before(deletesAt[pending[j]])
+	})
+	return pending, nil
 }
@@ -0,0 +1,57 @@
+package devices
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/freifunkMUC/wg-access-server/internal/config"
+	"github.com/freifunkMUC/wg-access-server/internal/ipam"
+	"github.com/freifunkMUC/wg-access-server/internal/storage"
+)
+
+func TestInactiveDevices(t *testing.T) {
+	require := require.New(t)
+
+	s := storage.NewMemoryStorage()
+	allocator, err := ipam.New(s, ipam.Options{CIDR: "10.44.0.0/24"})
+	require.NoError(err)
+	d := &DeviceManager{
+		storage:                  s,
+		ipam:                     allocator,
+		trafficQuota:             config.TrafficQuota{Action: config.TrafficQuotaSuspend},
+		inactiveGracePeriod:      30 * 24 * time.Hour,
+		inactiveWarningThreshold: 20 * 24 * time.Hour,
+		index:                    newDeviceIndex(),
+		events:                   newDeviceEvents(),
+	}
+	now := time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)
+	lastMonth := now.Add(-40 * 24 * time.Hour)
+	require.NoError(s.Save(&storage.Device{Owner: "alice", Name: "inactive", PublicKey: "a1", CreatedAt: lastMonth}))
+	require.NoError(s.Save(&storage.Device{Owner: "alice", Name: "suspended", PublicKey: "a2", CreatedAt: lastMonth, Suspended: true}))
+	// devices suspended for exceeding the traffic quota are kept until the quota is reset
+	require.NoError(s.Save(&storage.Device{Owner: "bob", Name: "over-quota", PublicKey: "b1", CreatedAt: lastMonth, OverQuota: true}))
+	stale := now.Add(-25 * 24 * time.Hour)
+	require.NoError(s.Save(&storage.Device{Owner: "bob", Name: "stale", PublicKey: "b2", CreatedAt: lastMonth, LastHandshakeTime: &stale}))
+
+	checkAndRemove(d, now)
+	devices, err := s.List("")
+	require.NoError(err)
+	names := []string{}
+	for _, device := range devices {
+		names = append(names, device.Name)
+	}
+	require.ElementsMatch([]string{"suspended", "over-quota", "stale"}, names)
+	require.Nil(d.DeletionTime(&storage.Device{CreatedAt: lastMonth, OverQuota: true}))
+
+	device, err := s.Get("bob", "stale")
+	require.NoError(err)
+	require.Equal(now, *device.StaleSince)
+
+	// throttled devices can connect, so they are deleted once they are inactive
+	d.trafficQuota = config.TrafficQuota{Action: config.TrafficQuotaThrottle, ThrottleRate: 1}
+	checkAndRemove(d, now)
+	_, err = s.Get("bob", "over-quota")
+	require.Error(err)
+}
@@ -0,0 +1,161 @@
+package devices
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/freifunkMUC/wg-access-server/internal/storage"
+)
+
+// deviceIndex is an in-memory copy of the devices in the storage. It is kept current by the
+// storage callbacks and serves the frequent reads of the metrics, the DNS zone and the metadata sync.
+type deviceIndex struct {
+	lock sync.RWMutex
+	// by owner and name
+	devices map[string]*storage.Device
+	// owner and name by public key
+	publicKeys map[string]string
+}
+
+func newDeviceIndex() *deviceIndex {
+	return &deviceIndex{
+		devices:    map[string]*storage.Device{},
+		publicKeys: map[string]string{},
+	}
+}
+
+// load replaces the indexed devices
+func (i *deviceIndex) load(devices []*storage.Device) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	i.devices = make(map[string]*storage.Device, len(devices))
+	i.publicKeys = make(map[string]string, len(devices))
+	for _, device := range devices {
+		i.put(device)
+	}
+}
+
+// update indexes the device, previous is the device before an update if it is known.
+// It reports false if the device may have been renamed without the previous state,
+// then the index must be reloaded.
+func (i *deviceIndex) update(previous *storage.Device, device *storage.Device) bool {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	if previous != nil {
+		i.remove(previous)
+	}
+	_, known := i.devices[eventKey(device)]
+	if key, ok := i.publicKeys[device.PublicKey]; ok && key != eventKey(device) {
+		// public keys are unique, the device was renamed
+		i.remove(i.devices[key])
+		known = true
+	}
+	i.put(device)
+	return previous != nil || known
+}
+
+// updateMetadata merges the metadata fields of the metadata sync into the indexed device and returns a copy of it.
+// The other fields are kept, they may have changed since the device was read for the sync.
+func (i *deviceIndex) updateMetadata(device *storage.Device) (*storage.Device, bool) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	indexed, ok := i.devices[eventKey(device)]
+	if !ok || indexed.PublicKey != device.PublicKey {
+		return nil, false
+	}
+	indexed.LastHandshakeTime = device.LastHandshakeTime
+	indexed.ReceiveBytes = device.ReceiveBytes
+	indexed.TransmitBytes = device.TransmitBytes
+	indexed.Endpoint = device.Endpoint
+	indexed.StaleSince = device.StaleSince
+	copied := *indexed
+	return &copied, true
+}
+
+// delete removes the device from the index
+func (i *deviceIndex) delete(device *storage.Device) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	i.remove(device)
+}
+
+func (i *deviceIndex) put(device *storage.Device) {
+	// the callers may change their device afterwards
+	copied := *device
+	i.devices[eventKey(device)] = &copied
+	i.publicKeys[device.PublicKey] = eventKey(device)
+}
+
+func (i *deviceIndex) remove(device *storage.Device) {
+	key := eventKey(device)
+	if indexed, ok := i.devices[key]; ok {
+		delete(i.publicKeys, indexed.PublicKey)
+		delete(i.devices, key)
+	}
+}
+
+// list returns copies of all indexed devices
+func (i *deviceIndex) list() []*storage.Device {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+	devices := make([]*storage.Device, 0, len(i.devices))
+	for _, device := range i.devices {
+		copied := *device
+		devices = append(devices, &copied)
+	}
+	return devices
+}
+
+// get returns a copy of the device with the owner and name
+func (i *deviceIndex) get(owner string, name string) (*storage.Device, bool) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+	device, ok := i.devices[owner+"/"+name]
+	if !ok {
+		return nil, false
+	}
+	copied := *device
+	return &copied, true
+}
+
+// getByPublicKey returns a copy of the device with the public key
+func (i *deviceIndex) getByPublicKey(publicKey string) (*storage.Device, bool) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+	key, ok := i.publicKeys[publicKey]
+	if !ok {
+		return nil, false
+	}
+	copied := *i.devices[key]
+	return &copied, true
+}
+
+// onlyMetadataChanged reports whether an update of a device changed nothing but the metadata fields,
+// the stale mark or the expiry warning, which don't change the WireGuard peer or the DNS zone
+func onlyMetadataChanged(previous *storage.Device, device *storage.Device) bool {
+	return previous.Owner == device.Owner &&
+		previous.OwnerName == device.OwnerName &&
+		previous.OwnerEmail == device.OwnerEmail &&
+		previous.OwnerProvider == device.OwnerProvider &&
+		previous.Name == device.Name &&
+		previous.PublicKey == device.PublicKey &&
+		previous.PresharedKey == device.PresharedKey &&
+		previous.Address == device.Address &&
+		equalTime(previous.ExpiresAt, device.ExpiresAt) &&
+		previous.Suspended == device.Suspended &&
+		previous.OverQuota == device.OverQuota &&
+		reflect.DeepEqual(previous.TrafficQuota, device.TrafficQuota) &&
+		reflect.DeepEqual(previous.AccessPolicy, device.AccessPolicy) &&
+		previous.Profile == device.Profile &&
+		previous.Gateway == device.Gateway &&
+		previous.Routes == device.Routes
+}
+
+// equalTime compares the instants of optional times, the storage may return them in another location
+func equalTime(a *time.Time, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
@@ -0,0 +1,79 @@
+package devices
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/freifunkMUC/wg-access-server/internal/storage"
+)
+
+func TestDeviceIndex(t *testing.T) {
+	require := require.New(t)
+
+	i := newDeviceIndex()
+	i.load([]*storage.Device{
+		{Owner: "alice", Name: "notebook", PublicKey: "a1"},
+		{Owner: "bob", Name: "phone", PublicKey: "b1"},
+	})
+
+	// indexed devices are copies
+	device, ok := i.getByPublicKey("a1")
+	require.True(ok)
+	device.ReceiveBytes = 42
+	device, _ = i.getByPublicKey("a1")
+	require.Zero(device.ReceiveBytes)
+
+	// a rename with the previous state
+	require.True(i.update(&storage.Device{Owner: "alice", Name: "notebook"}, &storage.Device{Owner: "alice", Name: "laptop", PublicKey: "a2"}))
+	_, ok = i.getByPublicKey("a1")
+	require.False(ok)
+	device, ok = i.getByPublicKey("a2")
+	require.True(ok)
+	require.Equal("laptop", device.Name)
+
+	// a rename without the previous state is detected by the public key
+	require.True(i.update(nil, &storage.Device{Owner: "bob", Name: "mobile", PublicKey: "b1"}))
+	require.Len(i.list(), 2)
+
+	// unknown devices require a reload
+	require.False(i.update(nil, &storage.Device{Owner: "bob", Name: "tablet", PublicKey: "b2"}))
+
+	i.delete(&storage.Device{Owner: "bob", Name: "mobile"})
+	_, ok = i.getByPublicKey("b1")
+	require.False(ok)
+	require.Len(i.list(), 2)
+}
+
+func TestDeviceIndexUpdateMetadata(t *testing.T) {
+	require := require.New(t)
+
+	i := newDeviceIndex()
+	i.load([]*storage.Device{{Owner: "alice", Name: "laptop", PublicKey: "a1"}})
+
+	// the sync read the device before it was suspended
+	synced, _ := i.getByPublicKey("a1")
+	suspended := *synced
+	suspended.Suspended = true
+	i.update(nil, &suspended)
+
+	synced.ReceiveBytes = 42
+	device, ok := i.updateMetadata(synced)
+	require.True(ok)
+	require.Equal(int64(42), device.ReceiveBytes)
+	require.True(device.Suspended)
+
+	// the device was re-keyed in the meantime
+	synced.PublicKey = "a0"
+	_, ok = i.updateMetadata(synced)
+	require.False(ok)
+
+	now := time.Now()
+	metadata := *device
+	metadata.LastHandshakeTime = &now
+	metadata.TransmitBytes = 7
+	require.True(onlyMetadataChanged(device, &metadata))
+	metadata.Address = "10.44.0.3/32"
+	require.False(onlyMetadataChanged(device, &metadata))
+}
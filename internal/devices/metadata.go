@@ -1,15 +1,20 @@
 package devices
 
 import (
+	"context"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+
+	"github.com/freifunkMUC/wg-access-server/internal/storage"
+	"github.com/freifunkMUC/wg-access-server/internal/traces"
 )
 
 func metadataLoop(d *DeviceManager) {
 	for {
 		syncMetrics(d)
+		checkTrafficQuotas(d, time.Now())
 		time.Sleep(30 * time.Second)
 	}
 }
@@ -17,32 +22,52 @@ func metadataLoop(d *DeviceManager) {
 func syncMetrics(d *DeviceManager) {
 	logrus.Debug("Metadata sync executing")
 
-	peers, err := d.wg.ListPeers()
+	ctx, span := traces.Start(context.Background(), "devices.sync_metadata")
+	defer span.End()
+
+	peers, err := traces.WireGuardWithContext(ctx, d.wg).ListPeers()
 	if err != nil {
 		logrus.Warn(errors.Wrap(err, "failed to list peers - metrics cannot be recorded"))
 		return
 	}
 
+	// the metadata is written in one batch instead of a query per device
+	updated := []*storage.Device{}
 	for _, peer := range peers {
 		// if the peer is connected we can update their metrics
 		// importantly, we'll ignore peers that we know about
 		// but aren't connected at the moment.
 		// they may actually be connected to another replica.
 		if peer.Endpoint != nil {
-			if device, err := d.GetByPublicKey(peer.PublicKey.String()); err == nil {
+			if device, ok := d.index.getByPublicKey(peer.PublicKey.String()); ok {
 				if !IsConnected(peer.LastHandshakeTime) && device.LastHandshakeTime != nil && !IsConnected(*device.LastHandshakeTime) {
 					// Not connected, and we haven't been the last time either, nothing to update
 					continue
 				}
+				d.recordUsage(ctx, device, peer.ReceiveBytes, peer.TransmitBytes, time.Now())
 				device.Endpoint = peer.Endpoint.IP.String()
 				device.ReceiveBytes = peer.ReceiveBytes
 				device.TransmitBytes = peer.TransmitBytes
-				device.LastHandshakeTime = &peer.LastHandshakeTime
-
-				if err := d.SaveDevice(device); err != nil {
-					logrus.Error(errors.Wrap(err, "failed to save device during metadata sync"))
+				lastHandshakeTime := peer.LastHandshakeTime
+				device.LastHandshakeTime = &lastHandshakeTime
+				if IsConnected(peer.LastHandshakeTime) {
+					device.StaleSince = nil
 				}
+				updated = append(updated, device)
 			}
 		}
 	}
+	if len(updated) == 0 {
+		return
+	}
+
+	if err := d.storage.WithContext(ctx).SaveMetadata(updated); err != nil {
+		logrus.Error(errors.Wrap(err, "failed to save devices during metadata sync"))
+		return
+	}
+	for _, device := range updated {
+		if indexed, ok := d.index.updateMetadata(device); ok {
+			d.events.publish(DeviceUpdated, indexed, nil)
+		}
+	}
 }
@@ -0,0 +1,74 @@
+package devices
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/freifunkMUC/wg-access-server/internal/config"
+	"github.com/freifunkMUC/wg-access-server/internal/network"
+	"github.com/freifunkMUC/wg-access-server/internal/storage"
+	"github.com/freifunkMUC/wg-access-server/pkg/authnz/authsession"
+)
+
+// ResolvePolicy returns the first configured policy that matches the claims of the user,
+// or nil if there is none
+func (d *DeviceManager) ResolvePolicy(identity *authsession.Identity) *config.Policy {
+	for i, policy := range d.policies {
+		if policyMatches(policy, identity.Claims) {
+			return &d.policies[i]
+		}
+	}
+	return nil
+}
+
+func policyMatches(policy config.Policy, claims authsession.Claims) bool {
+	name, value, found := strings.Cut(policy.Claim, "=")
+	if !found {
+		value = "true"
+	}
+	return claims.Has(strings.TrimSpace(name), strings.TrimSpace(value))
+}
+
+// accessPolicyFromConfig converts a configured policy into the access policy stored with a device
+func accessPolicyFromConfig(policy *config.Policy) *storage.AccessPolicy {
+	accessPolicy := &storage.AccessPolicy{
+		Name:            policy.Name,
+		ClientIsolation: policy.ClientIsolation,
+	}
+	for _, cidr := range policy.AllowedIPs {
+		accessPolicy.Rules = append(accessPolicy.Rules, storage.AccessRule{CIDR: cidr})
+	}
+	return accessPolicy
+}
+
+// ValidatePolicies checks the configured policies for errors
+func ValidatePolicies(policies []config.Policy, pools []config.Pool) error {
+	for _, policy := range policies {
+		if policy.Name == "" {
+			return errors.New("policy name must not be empty")
+		}
+		if policy.Claim == "" {
+			return errors.Errorf("policy '%s' has no claim", policy.Name)
+		}
+		if policy.DeviceQuota < 0 {
+			return errors.Errorf("device quota of policy '%s' must not be negative", policy.Name)
+		}
+		if policy.TrafficQuota < 0 {
+			return errors.Errorf("traffic quota of policy '%s' must not be negative", policy.Name)
+		}
+		if policy.MaxLifetime < 0 {
+			return errors.Errorf("max lifetime of policy '%s' must not be negative", policy.Name)
+		}
+		if policy.Pool != "" && !slices.ContainsFunc(pools, func(pool config.Pool) bool { return pool.Name == policy.Pool }) {
+			return errors.Errorf("policy '%s' references the unknown pool '%s'", policy.Name, policy.Pool)
+		}
+		for _, cidr := range policy.AllowedIPs {
+			if err := (network.Rule{CIDR: cidr}).Validate(); err != nil {
+				return errors.Wrapf(err, "invalid allowedIPs in policy '%s'", policy.Name)
+			}
+		}
+	}
+	return nil
+}
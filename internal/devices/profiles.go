@@ -0,0 +1,53 @@
+package devices
+
+import (
+	"slices"
+
+	"github.com/pkg/errors"
+
+	"github.com/freifunkMUC/wg-access-server/internal/config"
+	"github.com/freifunkMUC/wg-access-server/internal/network"
+)
+
+// Profile returns the configured client config profile with the given name,
+// or nil if there is none
+func (d *DeviceManager) Profile(name string) *config.Profile {
+	for i, profile := range d.profiles {
+		if profile.Name == name {
+			return &d.profiles[i]
+		}
+	}
+	return nil
+}
+
+// validateProfile checks that a device can be added with the profile, empty selects no profile
+func (d *DeviceManager) validateProfile(name string) error {
+	if name != "" && d.Profile(name) == nil {
+		return errors.Errorf("Unknown profile '%s'.", name)
+	}
+	return nil
+}
+
+// ValidateProfiles checks the configured client config profiles for errors
+func ValidateProfiles(profiles []config.Profile) error {
+	for i, profile := range profiles {
+		if profile.Name == "" {
+			return errors.New("profile name must not be empty")
+		}
+		if slices.ContainsFunc(profiles[:i], func(p config.Profile) bool { return p.Name == profile.Name }) {
+			return errors.Errorf("profile '%s' is configured more than once", profile.Name)
+		}
+		if len(profile.AllowedIPs) == 0 {
+			return errors.Errorf("profile '%s' has no allowedIPs", profile.Name)
+		}
+		for _, cidr := range profile.AllowedIPs {
+			if err := (network.Rule{CIDR: cidr}).Validate(); err != nil {
+				return errors.Wrapf(err, "invalid allowedIPs in profile '%s'", profile.Name)
+			}
+		}
+		if profile.MTU < 0 {
+			return errors.Errorf("mtu of profile '%s' must not be negative", profile.Name)
+		}
+	}
+	return nil
+}
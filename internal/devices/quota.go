@@ -0,0 +1,55 @@
+package devices
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/freifunkMUC/wg-access-server/internal/config"
+	"github.com/freifunkMUC/wg-access-server/pkg/authnz/authsession"
+)
+
+// QuotaError is returned by AddDevice if the user reached their device quota
+type QuotaError struct {
+	Quota int
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("Device quota of %d devices reached.", e.Quota)
+}
+
+// DeviceQuota returns the maximum number of devices of the user, 0 means unlimited.
+// A quota configured for the user takes precedence over the quota
+// of the first matching policy, which takes precedence over the default.
+func (d *DeviceManager) DeviceQuota(identity *authsession.Identity) int {
+	if quota, ok := d.quota.Users[identity.Subject]; ok {
+		return quota
+	}
+	if policy := d.ResolvePolicy(identity); policy != nil && policy.DeviceQuota > 0 {
+		return policy.DeviceQuota
+	}
+	return d.quota.Default
+}
+
+// DeviceUsage returns the number of devices of the user and their device quota
+func (d *DeviceManager) DeviceUsage(ctx context.Context, identity *authsession.Identity) (int, int, error) {
+	devices, err := d.ListDevices(ctx, identity.Subject)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "failed to list devices")
+	}
+	return len(devices), d.DeviceQuota(identity), nil
+}
+
+// ValidateDeviceQuota checks the configured device quotas for errors
+func ValidateDeviceQuota(quota config.DeviceQuota) error {
+	if quota.Default < 0 {
+		return errors.New("default device quota must not be negative")
+	}
+	for user, value := range quota.Users {
+		if value < 0 {
+			return errors.Errorf("device quota of user '%s' must not be negative", user)
+		}
+	}
+	return nil
+}
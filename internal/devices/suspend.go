@@ -0,0 +1,60 @@
+package devices
+
+import (
+	"context"
+	"github.com/pkg/errors"
+
+	"github.com/freifunkMUC/wg-access-server/internal/storage"
+)
+
+// SuspendDevice removes the WireGuard peer of a device,
+// the device keeps its name, addresses and metadata until it is resumed
+func (d *DeviceManager) SuspendDevice(ctx context.Context, owner string, name string) (*storage.Device, error) {
+	return d.setSuspended(ctx, owner, name, true)
+}
+
+// ResumeDevice adds the WireGuard peer of a suspended device again
+func (d *DeviceManager) ResumeDevice(ctx context.Context, owner string, name string) (*storage.Device, error) {
+	return d.setSuspended(ctx, owner, name, false)
+}
+
+func (d *DeviceManager) setSuspended(ctx context.Context, owner string, name string, suspended bool) (*storage.Device, error) {
+	previous, err := d.storage.WithContext(ctx).Get(owner, name)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve device")
+	}
+	if previous.Suspended == suspended {
+		return previous, nil
+	}
+	device, err := d.storage.WithContext(ctx).SetSuspended(owner, name, suspended)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to update the device")
+	}
+	return device, nil
+}
+
+// SuspendDevicesForUser suspends all devices of the user
+func (d *DeviceManager) SuspendDevicesForUser(ctx context.Context, user string) error {
+	return d.setSuspendedForUser(ctx, user, true)
+}
+
+// ResumeDevicesForUser resumes all suspended devices of the user
+func (d *DeviceManager) ResumeDevicesForUser(ctx context.Context, user string) error {
+	return d.setSuspendedForUser(ctx, user, false)
+}
+
+func (d *DeviceManager) setSuspendedForUser(ctx context.Context, user string, suspended bool) error {
+	devices, err := d.ListDevices(ctx, user)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve devices")
+	}
+
+	for _, dev := range devices {
+		// TODO not transactional
+		if _, err := d.setSuspended(ctx, user, dev.Name, suspended); err != nil {
+			return errors.Wrapf(err, "failed to update device %s", dev.Name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,241 @@
+package devices
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/freifunkMUC/wg-access-server/internal/audit"
+	"github.com/freifunkMUC/wg-access-server/internal/config"
+	"github.com/freifunkMUC/wg-access-server/internal/storage"
+	"github.com/freifunkMUC/wg-access-server/internal/traces"
+	"github.com/freifunkMUC/wg-access-server/pkg/authnz/authsession"
+)
+
+// UserTraffic is the traffic of a user in the current quota period
+type UserTraffic struct {
+	User string
+	// Used is the number of received and transmitted bytes
+	Used int64
+	// Quota is 0 if the traffic of the user is unlimited
+	Quota int64
+}
+
+// quotaPeriod returns the start of the traffic quota period containing the time,
+// periods are calendar months in UTC
+func quotaPeriod(now time.Time) time.Time {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// TrafficQuota returns the number of bytes the user may transfer per month, 0 means unlimited.
+// It takes precedence like the DeviceQuota and is recorded on the devices the user adds.
+func (d *DeviceManager) TrafficQuota(identity *authsession.Identity) int64 {
+	if quota, ok := d.trafficQuota.Users[identity.Subject]; ok {
+		return quota
+	}
+	if policy := d.ResolvePolicy(identity); policy != nil && policy.TrafficQuota > 0 {
+		return policy.TrafficQuota
+	}
+	return d.trafficQuota.Default
+}
+
+// deviceTrafficQuota returns the traffic quota recorded on the device. The claims of the owner
+// are only known while they are signed in, so devices added before the quota was recorded
+// fall back to the policy they were created with.
+func (d *DeviceManager) deviceTrafficQuota(device *storage.Device) int64 {
+	if quota, ok := d.trafficQuota.Users[device.Owner]; ok {
+		return quota
+	}
+	if device.TrafficQuota != nil {
+		return *device.TrafficQuota
+	}
+	if device.AccessPolicy != nil {
+		for _, policy := range d.policies {
+			if policy.Name == device.AccessPolicy.Name && policy.TrafficQuota > 0 {
+				return policy.TrafficQuota
+			}
+		}
+	}
+	return d.trafficQuota.Default
+}
+
+// ownerTrafficQuotas returns the traffic quotas of the owners of the devices by subject.
+// The newest device of a user decides, so a change of their claims applies to all of their devices
+// once they add a device.
+func (d *DeviceManager) ownerTrafficQuotas(devices []*storage.Device) map[string]int64 {
+	newest := map[string]*storage.Device{}
+	for _, device := range devices {
+		if current, ok := newest[device.Owner]; !ok || device.CreatedAt.After(current.CreatedAt) {
+			newest[device.Owner] = device
+		}
+	}
+	quotas := make(map[string]int64, len(newest))
+	for owner, device := range newest {
+		quotas[owner] = d.deviceTrafficQuota(device)
+	}
+	return quotas
+}
+
+// TrafficUsage returns the traffic of the user in the current period, their traffic quota
+// and the time the period ends. The quota is the one enforced for the devices of the user,
+// users without devices get the quota of the next device they add.
+func (d *DeviceManager) TrafficUsage(ctx context.Context, identity *authsession.Identity) (int64, int64, time.Time, error) {
+	now := time.Now()
+	used, err := d.quotaUsage(ctx, now)
+	if err != nil {
+		return 0, 0, time.Time{}, err
+	}
+	devices, err := d.ListDevices(ctx, identity.Subject)
+	if err != nil {
+		return 0, 0, time.Time{}, errors.Wrap(err, "failed to list devices")
+	}
+	quota, ok := d.ownerTrafficQuotas(devices)[identity.Subject]
+	if !ok {
+		quota = d.TrafficQuota(identity)
+	}
+	return used[identity.Subject], quota, quotaPeriod(now).AddDate(0, 1, 0), nil
+}
+
+// TrafficUsages returns the traffic of all users with devices in the current period
+func (d *DeviceManager) TrafficUsages() ([]UserTraffic, error) {
+	used, err := d.quotaUsage(context.Background(), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	devices := d.Snapshot()
+	quotas := d.ownerTrafficQuotas(devices)
+
+	usages := []UserTraffic{}
+	seen := map[string]bool{}
+	for _, device := range devices {
+		if seen[device.Owner] {
+			continue
+		}
+		seen[device.Owner] = true
+		usages = append(usages, UserTraffic{User: device.Owner, Used: used[device.Owner], Quota: quotas[device.Owner]})
+	}
+	return usages, nil
+}
+
+// quotaUsage returns the traffic of the users by subject in the period containing now
+func (d *DeviceManager) quotaUsage(ctx context.Context, now time.Time) (map[string]int64, error) {
+	usages, err := d.storage.WithContext(ctx).ListQuotaUsage(quotaPeriod(now))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read quota usage")
+	}
+	used := make(map[string]int64, len(usages))
+	for _, usage := range usages {
+		used[usage.Owner] = usage.Bytes
+	}
+	return used, nil
+}
+
+// recordQuotaUsage adds the traffic of a device to the quota period of its owner
+func (d *DeviceManager) recordQuotaUsage(ctx context.Context, device *storage.Device, bytes int64, now time.Time) {
+	usage := &storage.QuotaUsage{Owner: device.Owner, Period: quotaPeriod(now), Bytes: bytes}
+	if err := d.storage.WithContext(ctx).AddQuotaUsage(usage); err != nil {
+		logrus.Error(errors.Wrap(err, fmt.Sprintf("failed to record the quota usage of user: %s", device.Owner)))
+	}
+}
+
+// TrafficQuotaConfigured reports whether any traffic quota is configured
+func (d *DeviceManager) TrafficQuotaConfigured() bool {
+	if d.trafficQuota.Default > 0 || len(d.trafficQuota.Users) > 0 {
+		return true
+	}
+	for _, policy := range d.policies {
+		if policy.TrafficQuota > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// throttleOverQuota reports whether devices over their quota are throttled instead of suspended
+func (d *DeviceManager) throttleOverQuota() bool {
+	return d.trafficQuota.Action == config.TrafficQuotaThrottle
+}
+
+// checkTrafficQuotas marks the devices of users that exceeded their traffic quota,
+// which suspends or throttles them, and releases them once a new period started
+func checkTrafficQuotas(d *DeviceManager, now time.Time) {
+	ctx, span := traces.Start(context.Background(), "devices.check_traffic_quotas")
+	defer span.End()
+
+	used, err := d.quotaUsage(ctx, now)
+	if err != nil {
+		logrus.Error(err)
+		return
+	}
+	devices, err := d.ListAllDevices(ctx)
+	if err != nil {
+		logrus.Error(errors.Wrap(err, "failed to list devices"))
+		return
+	}
+
+	quotas := d.ownerTrafficQuotas(devices)
+
+	// the changes are audited once per user
+	audited := map[string]bool{}
+	for _, device := range devices {
+		quota := quotas[device.Owner]
+		over := quota > 0 && used[device.Owner] >= quota
+
+		if over != device.OverQuota {
+			if over {
+				logrus.Warnf("Traffic quota of user %s exceeded, restricting device: %s", device.Owner, device.Name)
+			} else {
+				logrus.Infof("Traffic quota of user %s released, enabling device: %s", device.Owner, device.Name)
+			}
+			if _, err := d.storage.WithContext(ctx).SetOverQuota(device.Owner, device.Name, over); err != nil {
+				logrus.Error(errors.Wrap(err, fmt.Sprintf("failed to update device: %s/%s", device.Owner, device.Name)))
+				continue
+			}
+			if !audited[device.Owner] {
+				audited[device.Owner] = true
+				action := audit.TrafficQuotaRelease
+				if over {
+					action = audit.TrafficQuotaExceed
+				}
+				event := audit.Event(nil, action, device.Owner)
+				event.Details = fmt.Sprintf("%d of %d bytes used", used[device.Owner], quota)
+				d.audit.Record(event)
+			}
+		}
+
+		if over && d.throttleOverQuota() {
+			if err := d.shaper.Throttle(device.PublicKey, strings.Join(peerAddresses(device), ", ")); err != nil {
+				logrus.Error(errors.Wrap(err, fmt.Sprintf("failed to throttle device: %s/%s", device.Owner, device.Name)))
+			}
+		} else if err := d.shaper.Unthrottle(device.PublicKey); err != nil {
+			logrus.Error(errors.Wrap(err, fmt.Sprintf("failed to unthrottle device: %s/%s", device.Owner, device.Name)))
+		}
+	}
+}
+
+// ValidateTrafficQuota checks the configured traffic quotas for errors
+func ValidateTrafficQuota(quota config.TrafficQuota) error {
+	if quota.Default < 0 {
+		return errors.New("default traffic quota must not be negative")
+	}
+	for user, value := range quota.Users {
+		if value < 0 {
+			return errors.Errorf("traffic quota of user '%s' must not be negative", user)
+		}
+	}
+	switch quota.Action {
+	case "", config.TrafficQuotaSuspend:
+	case config.TrafficQuotaThrottle:
+		if quota.ThrottleRate == 0 {
+			return errors.New("traffic quota throttle rate must not be 0")
+		}
+	default:
+		return errors.Errorf("unknown traffic quota action '%s'", quota.Action)
+	}
+	return nil
+}
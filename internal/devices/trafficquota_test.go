@@ -0,0 +1,78 @@
+package devices
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/freifunkMUC/wg-access-server/internal/config"
+	"github.com/freifunkMUC/wg-access-server/internal/storage"
+)
+
+func TestTrafficQuota(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	s := storage.NewMemoryStorage()
+	d := &DeviceManager{
+		storage:      s,
+		policies:     []config.Policy{{Name: "guests", Claim: "guest", TrafficQuota: 10}},
+		trafficQuota: config.TrafficQuota{Default: 100, Users: map[string]int64{"carol": 0}},
+	}
+	require.NoError(s.Save(&storage.Device{Owner: "alice", Name: "notebook", PublicKey: "a1"}))
+	require.NoError(s.Save(&storage.Device{Owner: "alice", Name: "phone", PublicKey: "a2"}))
+	require.NoError(s.Save(&storage.Device{Owner: "bob", Name: "phone", PublicKey: "b1", AccessPolicy: &storage.AccessPolicy{Name: "guests"}}))
+	require.NoError(s.Save(&storage.Device{Owner: "carol", Name: "phone", PublicKey: "c1"}))
+
+	now := time.Date(2024, 3, 31, 23, 0, 0, 0, time.UTC)
+	for _, user := range []string{"alice", "bob", "carol"} {
+		d.recordQuotaUsage(ctx, &storage.Device{Owner: user}, 60, now)
+	}
+	checkTrafficQuotas(d, now)
+	phone, err := s.Get("alice", "phone")
+	require.NoError(err)
+	require.False(phone.OverQuota)
+	phone, err = s.Get("bob", "phone")
+	require.NoError(err)
+	require.True(phone.OverQuota)
+	require.False(d.peerEnabled(phone))
+
+	d.recordQuotaUsage(ctx, &storage.Device{Owner: "alice"}, 40, now)
+	d.recordQuotaUsage(ctx, &storage.Device{Owner: "carol"}, 1000, now)
+	checkTrafficQuotas(d, now)
+	devices, err := s.List("")
+	require.NoError(err)
+	for _, device := range devices {
+		require.Equal(device.Owner != "carol", device.OverQuota, device.Owner)
+	}
+
+	// the quota is reset in the next month
+	checkTrafficQuotas(d, now.Add(time.Hour))
+	devices, err = s.List("")
+	require.NoError(err)
+	for _, device := range devices {
+		require.False(device.OverQuota)
+	}
+}
+
+func TestOwnerTrafficQuotas(t *testing.T) {
+	require := require.New(t)
+
+	quota := func(bytes int64) *int64 { return &bytes }
+	d := &DeviceManager{
+		policies:     []config.Policy{{Name: "guests", Claim: "guest", TrafficQuota: 10}},
+		trafficQuota: config.TrafficQuota{Default: 100, Users: map[string]int64{"carol": 0}},
+	}
+	created := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	quotas := d.ownerTrafficQuotas([]*storage.Device{
+		// the newest device of alice was added after her claims changed
+		{Owner: "alice", Name: "notebook", CreatedAt: created, TrafficQuota: quota(10)},
+		{Owner: "alice", Name: "phone", CreatedAt: created.Add(time.Hour), TrafficQuota: quota(0)},
+		// devices added before the quota was recorded fall back to their policy
+		{Owner: "bob", Name: "phone", CreatedAt: created, AccessPolicy: &storage.AccessPolicy{Name: "guests"}},
+		{Owner: "carol", Name: "phone", CreatedAt: created, TrafficQuota: quota(10)},
+	})
+	require.Equal(map[string]int64{"alice": 0, "bob": 10, "carol": 0}, quotas)
+}
@@ -0,0 +1,144 @@
+package devices
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/freifunkMUC/wg-access-server/internal/config"
+	"github.com/freifunkMUC/wg-access-server/internal/storage"
+	"github.com/freifunkMUC/wg-access-server/internal/traces"
+)
+
+const (
+	usageDay = 24 * time.Hour
+	// maxUsagePoints limits the length of a usage series
+	maxUsagePoints = 10000
+)
+
+// UsageRangeError is returned if a usage series can't be created for the requested range
+type UsageRangeError struct {
+	Reason string
+}
+
+func (e *UsageRangeError) Error() string {
+	return e.Reason
+}
+
+// UsagePoint is the traffic of a device within a step of a usage series
+type UsagePoint struct {
+	Time          time.Time
+	ReceiveBytes  int64
+	TransmitBytes int64
+}
+
+// usageDelta is the traffic since the last sync,
+// WireGuard resets the counters when the interface or the peer is recreated
+func usageDelta(previous int64, current int64) int64 {
+	if current < previous {
+		return current
+	}
+	return current - previous
+}
+
+// recordUsage adds the traffic since the counters of the device were last saved to its usage
+func (d *DeviceManager) recordUsage(ctx context.Context, device *storage.Device, receiveBytes int64, transmitBytes int64, now time.Time) {
+	usage := &storage.Usage{
+		Owner:         device.Owner,
+		Device:        device.Name,
+		Time:          now.UTC().Truncate(d.usage.Resolution),
+		Resolution:    d.usage.Resolution,
+		ReceiveBytes:  usageDelta(device.ReceiveBytes, receiveBytes),
+		TransmitBytes: usageDelta(device.TransmitBytes, transmitBytes),
+	}
+	if usage.ReceiveBytes == 0 && usage.TransmitBytes == 0 {
+		return
+	}
+	if err := d.storage.WithContext(ctx).AddUsage(usage); err != nil {
+		logrus.Error(errors.Wrap(err, fmt.Sprintf("failed to record the usage of device: %s/%s", device.Owner, device.Name)))
+	}
+	d.recordQuotaUsage(ctx, device, usage.ReceiveBytes+usage.TransmitBytes, now)
+}
+
+func usageLoop(d *DeviceManager) {
+	for {
+		compactUsage(d, time.Now())
+		time.Sleep(time.Hour)
+	}
+}
+
+// compactUsage downsamples the usage older than usage.retention to days
+// and deletes the days older than usage.dailyRetention as well as past traffic quota periods
+func compactUsage(d *DeviceManager, now time.Time) {
+	logrus.Debug("Usage compaction executing")
+
+	ctx, span := traces.Start(context.Background(), "devices.compact_usage")
+	defer span.End()
+
+	if err := d.storage.WithContext(ctx).DownsampleUsage(now.Add(-d.usage.Retention).Truncate(usageDay), usageDay); err != nil {
+		logrus.Error(errors.Wrap(err, "failed to downsample usage"))
+	}
+	if d.usage.DailyRetention > 0 {
+		if err := d.storage.WithContext(ctx).PurgeUsage(now.Add(-d.usage.DailyRetention).Truncate(usageDay)); err != nil {
+			logrus.Error(errors.Wrap(err, "failed to purge usage"))
+		}
+	}
+	if err := d.storage.WithContext(ctx).PurgeQuotaUsage(quotaPeriod(now)); err != nil {
+		logrus.Error(errors.Wrap(err, "failed to purge quota usage"))
+	}
+}
+
+// Usage returns the traffic of the device between from (inclusive) and to (exclusive) in steps,
+// 0 defaults to the recorded resolution. The traffic of a bucket is counted in the step it starts in,
+// so steps shorter than a day show the traffic of downsampled days at their start.
+func (d *DeviceManager) Usage(ctx context.Context, owner string, name string, from time.Time, to time.Time, step time.Duration) ([]UsagePoint, error) {
+	if step == 0 {
+		step = d.usage.Resolution
+	}
+	if step < 0 {
+		return nil, &UsageRangeError{Reason: "Step must not be negative."}
+	}
+	if !from.Before(to) {
+		return nil, &UsageRangeError{Reason: "The start of the range must be before its end."}
+	}
+	steps := (to.Sub(from) + step - 1) / step
+	if steps > maxUsagePoints {
+		return nil, &UsageRangeError{Reason: fmt.Sprintf("The range must contain at most %d steps.", maxUsagePoints)}
+	}
+
+	usages, err := d.storage.WithContext(ctx).ListUsage(owner, name, from, to)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read usage")
+	}
+
+	points := make([]UsagePoint, steps)
+	for i := range points {
+		points[i].Time = from.Add(time.Duration(i) * step).UTC()
+	}
+	for _, usage := range usages {
+		i := usage.Time.Sub(from) / step
+		points[i].ReceiveBytes += usage.ReceiveBytes
+		points[i].TransmitBytes += usage.TransmitBytes
+	}
+	return points, nil
+}
+
+// ValidateUsage checks the configured traffic history for errors
+func ValidateUsage(usage config.Usage) error {
+	if usage.Resolution <= 0 || usage.Resolution > usageDay || usageDay%usage.Resolution != 0 {
+		return errors.New("usage resolution must divide a day")
+	}
+	if usage.Retention < 0 {
+		return errors.New("usage retention must not be negative")
+	}
+	if usage.DailyRetention < 0 {
+		return errors.New("daily usage retention must not be negative")
+	}
+	if usage.DailyRetention > 0 && usage.DailyRetention < usage.Retention {
+		return errors.New("daily usage retention must not be shorter than the usage retention")
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package devices
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/freifunkMUC/wg-access-server/internal/config"
+	"github.com/freifunkMUC/wg-access-server/internal/storage"
+)
+
+func TestUsage(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	s := storage.NewMemoryStorage()
+	d := &DeviceManager{storage: s, usage: config.Usage{Resolution: 5 * time.Minute}}
+	device := &storage.Device{Owner: "alice", Name: "notebook", ReceiveBytes: 100, TransmitBytes: 50}
+
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	d.recordUsage(ctx, device, 150, 60, start.Add(time.Minute))
+	// the counters were reset
+	device.ReceiveBytes, device.TransmitBytes = 150, 60
+	d.recordUsage(ctx, device, 20, 5, start.Add(12*time.Minute))
+
+	points, err := d.Usage(ctx, "alice", "notebook", start, start.Add(15*time.Minute), 0)
+	require.NoError(err)
+	require.Len(points, 3)
+	require.Equal(int64(50), points[0].ReceiveBytes)
+	require.Equal(int64(10), points[0].TransmitBytes)
+	require.Zero(points[1].ReceiveBytes)
+	require.Equal(int64(20), points[2].ReceiveBytes)
+	require.True(start.Add(10 * time.Minute).Equal(points[2].Time))
+
+	points, err = d.Usage(ctx, "alice", "notebook", start, start.Add(15*time.Minute), time.Hour)
+	require.NoError(err)
+	require.Len(points, 1)
+	require.Equal(int64(70), points[0].ReceiveBytes)
+
+	_, err = d.Usage(ctx, "alice", "notebook", start, start.Add(time.Hour), time.Nanosecond)
+	require.IsType(&UsageRangeError{}, err)
+}
+
+func TestValidateUsage(t *testing.T) {
+	require := require.New(t)
+
+	require.NoError(ValidateUsage(config.Usage{Resolution: 5 * time.Minute, Retention: 7 * 24 * time.Hour}))
+	require.Error(ValidateUsage(config.Usage{Resolution: 7 * time.Minute}))
+	require.Error(ValidateUsage(config.Usage{Resolution: time.Hour, Retention: 48 * time.Hour, DailyRetention: 24 * time.Hour}))
+}
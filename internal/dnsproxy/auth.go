@@ -3,6 +3,7 @@ package dnsproxy
 import (
 	"fmt"
 	"net/netip"
+	"slices"
 	"strings"
 	"sync"
 
@@ -22,6 +23,7 @@ type DNSAuth struct {
 	// Lock zoneLock before accessing
 	zone     Zone
 	zoneLock *sync.RWMutex
+	metrics  *metrics
 }
 
 func (d *DNSAuth) PushZone(zone Zone) {
@@ -31,8 +33,49 @@ func (d *DNSAuth) PushZone(zone Zone) {
 	d.zoneLock.Unlock()
 }
 
+// Reverse answers reverse lookups of device addresses, all other queries are passed to the next handler.
+// Addresses within the networks routed to gateways are left to the resolvers of those networks.
+func (d *DNSAuth) Reverse(next dns.Handler) dns.Handler {
+	return dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+		if r.Opcode != dns.OpcodeQuery || len(r.Question) != 1 || r.Question[0].Qtype != dns.TypePTR || r.Question[0].Qclass != dns.ClassINET {
+			next.ServeDNS(w, r)
+			return
+		}
+		addr, ok := reverseAddr(r.Question[0].Name)
+		if !ok {
+			next.ServeDNS(w, r)
+			return
+		}
+		key, ok := d.getName(addr)
+		if !ok {
+			next.ServeDNS(w, r)
+			return
+		}
+		d.metrics.query("authoritative")
+
+		target := d.Domain
+		if key != (ZoneKey{}) {
+			target = fmt.Sprintf("%s.%s.%s", key.Name, key.Owner, d.Domain)
+		}
+		m := new(dns.Msg)
+		m.SetReply(r)
+		m.Authoritative = true
+		rr, err := newRR(r.Question[0].Name, dns.ClassINET, dns.TypePTR, target)
+		if err != nil {
+			logrus.Errorf("failed to create PTR record with error: %s\n%s", err.Error(), r)
+			HandleFailed(w, r)
+			return
+		}
+		m.Answer = append(m.Answer, rr)
+		if err := w.WriteMsg(m); err != nil {
+			logrus.Errorf("failed write response for client with error: %s\n%s", err.Error(), r)
+		}
+	})
+}
+
 func (d *DNSAuth) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 	logrus.Debugf("auth dns query: %s", prettyPrintMsg(r))
+	d.metrics.query("authoritative")
 
 	switch r.Opcode {
 	case dns.OpcodeQuery:
@@ -133,6 +176,43 @@ func (d *DNSAuth) getDevice(owner, device string) []netip.Addr {
 	return d.zone[ZoneKey{owner, device}]
 }
 
+// getName returns the device with the address
+func (d *DNSAuth) getName(addr netip.Addr) (ZoneKey, bool) {
+	d.zoneLock.RLock()
+	defer d.zoneLock.RUnlock()
+	for key, addresses := range d.zone {
+		if slices.Contains(addresses, addr) {
+			return key, true
+		}
+	}
+	return ZoneKey{}, false
+}
+
+// reverseAddr parses the address of a reverse lookup name,
+// e.g. "2.0.44.10.in-addr.arpa." or the 32 nibbles of an IPv6 address in "ip6.arpa."
+func reverseAddr(name string) (netip.Addr, bool) {
+	labels := dns.SplitDomainName(strings.ToLower(name))
+	switch {
+	case len(labels) == 6 && labels[4] == "in-addr" && labels[5] == "arpa":
+		addr, err := netip.ParseAddr(strings.Join([]string{labels[3], labels[2], labels[1], labels[0]}, "."))
+		return addr, err == nil && addr.Is4()
+	case len(labels) == 34 && labels[32] == "ip6" && labels[33] == "arpa":
+		b := &strings.Builder{}
+		for i := 31; i >= 0; i-- {
+			if len(labels[i]) != 1 {
+				return netip.Addr{}, false
+			}
+			b.WriteString(labels[i])
+			if i%4 == 0 && i > 0 {
+				b.WriteByte(':')
+			}
+		}
+		addr, err := netip.ParseAddr(b.String())
+		return addr, err == nil
+	}
+	return netip.Addr{}, false
+}
+
 // newRR creates a new resource record from the arguments
 func newRR(qname string, qclass uint16, qtype uint16, data string) (dns.RR, error) {
 	return dns.NewRR(fmt.Sprintf("%s %d %s %s %s", qname, authoritativeTTL, dns.ClassToString[qclass], dns.TypeToString[qtype], data))
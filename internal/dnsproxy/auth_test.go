@@ -0,0 +1,45 @@
+package dnsproxy
+
+import (
+	"net/netip"
+	"sync"
+	"testing"
+)
+
+func TestDNSAuth_GetName(t *testing.T) {
+	auth := &DNSAuth{Domain: "vpn.", zoneLock: new(sync.RWMutex)}
+	gateway := ZoneKey{Owner: "alice", Name: "branch"}
+	auth.PushZone(Zone{
+		ZoneKey{}: {netip.MustParseAddr("10.44.0.1")},
+		gateway:   {netip.MustParseAddr("10.44.0.2"), netip.MustParseAddr("fd48::2")},
+	})
+
+	tests := []struct {
+		name  string
+		key   ZoneKey
+		found bool
+	}{
+		{"1.0.44.10.in-addr.arpa.", ZoneKey{}, true},
+		{"2.0.44.10.in-addr.arpa.", gateway, true},
+		{"2.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.4.d.f.ip6.arpa.", gateway, true},
+		{"2.0.44.10.IN-ADDR.ARPA.", gateway, true},
+		// addresses behind gateways are resolved by the gateway's network
+		{"23.10.168.192.in-addr.arpa.", ZoneKey{}, false},
+	}
+	for _, test := range tests {
+		addr, ok := reverseAddr(test.name)
+		if !ok {
+			t.Fatalf("failed to parse %s", test.name)
+		}
+		key, found := auth.getName(addr)
+		if key != test.key || found != test.found {
+			t.Errorf("%s resolved to %v (%t), expected %v (%t)", test.name, key, found, test.key, test.found)
+		}
+	}
+
+	for _, name := range []string{"10.in-addr.arpa.", "2.0.44.10.example.com.", "20.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.4.d.f.ip6.arpa."} {
+		if _, ok := reverseAddr(name); ok {
+			t.Errorf("%s is not the name of an address", name)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package dnsproxy
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metrics of the DNS server, a nil *metrics records nothing
+type metrics struct {
+	queries          *prometheus.CounterVec
+	cacheHits        prometheus.Counter
+	cacheMisses      prometheus.Counter
+	upstreamErrors   *prometheus.CounterVec
+	upstreamDuration *prometheus.HistogramVec
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		queries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "wg_access_server",
+			Subsystem: "dns",
+			Name:      "queries_total",
+			Help:      "DNS queries by handler (proxy or authoritative).",
+		}, []string{"handler"}),
+		cacheHits: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "wg_access_server",
+			Subsystem: "dns",
+			Name:      "cache_hits_total",
+			Help:      "Proxied DNS queries answered from the cache.",
+		}),
+		cacheMisses: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "wg_access_server",
+			Subsystem: "dns",
+			Name:      "cache_misses_total",
+			Help:      "Proxied DNS queries sent to the upstream servers.",
+		}),
+		upstreamErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "wg_access_server",
+			Subsystem: "dns",
+			Name:      "upstream_errors_total",
+			Help:      "Failed exchanges with an upstream DNS server.",
+		}, []string{"upstream"}),
+		upstreamDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "wg_access_server",
+			Subsystem: "dns",
+			Name:      "upstream_duration_seconds",
+			Help:      "Duration of successful exchanges with an upstream DNS server.",
+			Buckets:   []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		}, []string{"upstream"}),
+	}
+}
+
+func (m *metrics) query(handler string) {
+	if m != nil {
+		m.queries.WithLabelValues(handler).Inc()
+	}
+}
+
+func (m *metrics) cacheHit(hit bool) {
+	if m == nil {
+		return
+	}
+	if hit {
+		m.cacheHits.Inc()
+	} else {
+		m.cacheMisses.Inc()
+	}
+}
+
+func (m *metrics) exchange(upstream string, duration time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	if err != nil {
+		m.upstreamErrors.WithLabelValues(upstream).Inc()
+	} else {
+		m.upstreamDuration.WithLabelValues(upstream).Observe(duration.Seconds())
+	}
+}
+
+// Collectors returns the Prometheus metrics of the DNS server
+func (d *DNSServer) Collectors() []prometheus.Collector {
+	m := d.proxy.metrics
+	return []prometheus.Collector{m.queries, m.cacheHits, m.cacheMisses, m.upstreamErrors, m.upstreamDuration}
+}
@@ -1,6 +1,7 @@
 package dnsproxy
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"runtime/debug"
@@ -9,6 +10,9 @@ import (
 	"github.com/miekg/dns"
 	"github.com/patrickmn/go-cache"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/freifunkMUC/wg-access-server/internal/traces"
 )
 
 type DNSProxy struct {
@@ -16,6 +20,7 @@ type DNSProxy struct {
 	tcpClient *dns.Client
 	cache     *cache.Cache
 	upstream  []string
+	metrics   *metrics
 }
 
 // ServeDNS is called by the mux from the listening servers.
@@ -28,6 +33,7 @@ func (d *DNSProxy) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 	}()
 
 	logrus.Debugf("dns query: %s", prettyPrintMsg(r))
+	d.metrics.query("proxy")
 
 	switch r.Opcode {
 	case dns.OpcodeQuery:
@@ -66,7 +72,9 @@ func (d *DNSProxy) Lookup(m *dns.Msg) (*dns.Msg, error) {
 	key := makekey(m)
 
 	// check the cache first
-	if item, found := d.cache.Get(key); found {
+	item, found := d.cache.Get(key)
+	d.metrics.cacheHit(found)
+	if found {
 		logrus.Debugf("dns cache hit %s", prettyPrintMsg(m))
 		return item.(*dns.Msg).Copy(), nil
 	}
@@ -76,15 +84,14 @@ func (d *DNSProxy) Lookup(m *dns.Msg) (*dns.Msg, error) {
 	var response *dns.Msg
 	var firstErr error
 	for _, upstream := range d.upstream {
-		target := net.JoinHostPort(upstream, "53")
-		resp, _, err := d.udpClient.Exchange(m, target)
+		resp, err := d.exchange(d.udpClient, m, upstream)
 		if err != nil && firstErr == nil {
 			logrus.Warnf("DNS lookup failed for upstream %s: %v", upstream, err)
 			firstErr = err
 		} else if err == nil {
 			// Retry truncated responses over TCP
 			if resp.Truncated {
-				resp, _, err = d.tcpClient.Exchange(m, target)
+				resp, err = d.exchange(d.tcpClient, m, upstream)
 				if err != nil && firstErr == nil {
 					logrus.Warnf("DNS lookup failed over TCP for upstream %s: %v", upstream, err)
 					firstErr = err
@@ -108,6 +115,26 @@ func (d *DNSProxy) Lookup(m *dns.Msg) (*dns.Msg, error) {
 	return response.Copy(), nil
 }
 
+// exchange sends the query to the upstream server, it is recorded in the metrics and traces
+func (d *DNSProxy) exchange(client *dns.Client, m *dns.Msg, upstream string) (*dns.Msg, error) {
+	transport := client.Net
+	if transport == "" {
+		transport = "udp"
+	}
+	_, span := traces.Start(context.Background(), "dns.exchange",
+		attribute.String("dns.upstream", upstream),
+		attribute.String("dns.question", makekey(m)),
+		attribute.String("network.transport", transport),
+	)
+	resp, rtt, err := client.Exchange(m, net.JoinHostPort(upstream, "53"))
+	if err == nil {
+		span.SetAttributes(attribute.String("dns.rcode", dns.RcodeToString[resp.Rcode]))
+	}
+	traces.End(span, err)
+	d.metrics.exchange(upstream, rtt, err)
+	return resp, err
+}
+
 func purgeECS(m *dns.Msg) {
 	if opt := m.IsEdns0(); opt != nil {
 		for i, option := range opt.Option {
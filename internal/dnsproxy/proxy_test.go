@@ -8,6 +8,7 @@ import (
 
 	"github.com/miekg/dns"
 	"github.com/patrickmn/go-cache"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 var ffmucUpstreams, _ = net.LookupHost("dns.ffmuc.net")
@@ -66,6 +67,7 @@ func TestDNSProxy_Lookup(t *testing.T) {
 		tcpClient: &dns.Client{Net: "tcp"},
 		cache:     cache.New(5*time.Minute, 10*time.Minute),
 		upstream:  ffmucUpstreams,
+		metrics:   newMetrics(),
 	}
 
 	t.Run("Cache hit", func(t *testing.T) {
@@ -80,5 +82,8 @@ func TestDNSProxy_Lookup(t *testing.T) {
 		if resp == nil {
 			t.Fatal("expected response, got nil")
 		}
+		if hits := testutil.ToFloat64(proxy.metrics.cacheHits); hits != 1 {
+			t.Errorf("expected 1 cache hit, got %v", hits)
+		}
 	})
 }
@@ -31,6 +31,7 @@ func New(opts DNSServerOpts) (*DNSServer, error) {
 		return nil, errors.New("At least 1 upstream dns server is required for the dns proxy server to function")
 	}
 
+	metrics := newMetrics()
 	dnsServer := &DNSServer{
 		servers: []*dns.Server{},
 		proxy: &DNSProxy{
@@ -45,10 +46,12 @@ func New(opts DNSServerOpts) (*DNSServer, error) {
 			},
 			cache:    cache.New(10*time.Minute, 10*time.Minute),
 			upstream: opts.Upstream,
+			metrics:  metrics,
 		},
 		auth: &DNSAuth{
 			Domain:   dns.Fqdn(opts.Domain),
 			zoneLock: new(sync.RWMutex),
+			metrics:  metrics,
 		},
 	}
 
@@ -56,6 +59,9 @@ func New(opts DNSServerOpts) (*DNSServer, error) {
 	serveMux := dns.NewServeMux()
 	if opts.Domain != "" {
 		serveMux.Handle(dnsServer.auth.Domain, dnsServer.auth)
+		// Reverse lookups of unknown addresses are proxied as well
+		serveMux.Handle("in-addr.arpa.", dnsServer.auth.Reverse(dnsServer.proxy))
+		serveMux.Handle("ip6.arpa.", dnsServer.auth.Reverse(dnsServer.proxy))
 	}
 	serveMux.Handle(".", dnsServer.proxy)
 
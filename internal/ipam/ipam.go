@@ -0,0 +1,458 @@
+package ipam
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/freifunkMUC/wg-access-server/internal/config"
+	"github.com/freifunkMUC/wg-access-server/internal/network"
+	"github.com/freifunkMUC/wg-access-server/internal/storage"
+)
+
+// with Options.Random, subnets with more host bits are allocated randomly instead of sequentially
+const maxSequentialHostBits = 16
+
+// attempts to allocate an address if it was allocated concurrently by another replica
+const maxAttempts = 10
+
+// allocations without a device are only released after this age,
+// the device of a new allocation may not be saved yet
+const orphanGracePeriod = time.Minute
+
+type Options struct {
+	CIDR          string
+	CIDRv6        string
+	ReuseCooldown time.Duration
+	Reserved      []string
+	Pools         []config.Pool
+	// Random picks the addresses of large subnets (e.g. an IPv6 /64) randomly
+	// instead of assigning the lowest free address
+	Random bool
+}
+
+// IPAM assigns the addresses of devices. Allocations are persisted in the storage,
+// which guarantees that an address is never handed out twice, even by multiple replicas.
+type IPAM struct {
+	storage  storage.AllocationStorage
+	cooldown time.Duration
+	random   bool
+	// by pool name, the default pool has the empty name
+	pools map[string]*pool
+}
+
+type pool struct {
+	v4 *subnet
+	v6 *subnet
+}
+
+type subnet struct {
+	prefix netip.Prefix
+	// never assigned automatically
+	excluded []addrRange
+	// name of the pool the subnet was configured for
+	pool string
+}
+
+type addrRange struct {
+	from netip.Addr
+	to   netip.Addr
+}
+
+func (r addrRange) contains(addr netip.Addr) bool {
+	return r.from.Compare(addr) <= 0 && addr.Compare(r.to) <= 0
+}
+
+func New(s storage.AllocationStorage, opts Options) (*IPAM, error) {
+	var vpnv4, vpnv6 netip.Prefix
+	reserved := []addrRange{}
+	for _, cidr := range []string{opts.CIDR, opts.CIDRv6} {
+		if cidr == "" {
+			continue
+		}
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid vpn subnet '%s'", cidr)
+		}
+		prefix = prefix.Masked()
+		if prefix.Addr().Is4() {
+			vpnv4 = prefix
+		} else {
+			vpnv6 = prefix
+		}
+		// the network address and the VPN server address
+		reserved = append(reserved, addrRange{prefix.Addr(), prefix.Addr().Next()})
+	}
+
+	for _, value := range opts.Reserved {
+		r, err := parseRange(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid reserved range '%s'", value)
+		}
+		reserved = append(reserved, r)
+	}
+
+	defaultPool := &pool{}
+	defaultExcluded := append([]addrRange{}, reserved...)
+	if vpnv4.IsValid() {
+		defaultPool.v4 = &subnet{prefix: vpnv4}
+	}
+	if vpnv6.IsValid() {
+		defaultPool.v6 = &subnet{prefix: vpnv6}
+	}
+
+	pools := map[string]*pool{"": defaultPool}
+	// subnets of the named pools by the name of their pool
+	named := map[string][]netip.Prefix{}
+	for _, p := range opts.Pools {
+		if p.Name == "" {
+			return nil, errors.New("pool name must not be empty")
+		}
+		if _, ok := pools[p.Name]; ok {
+			return nil, errors.Errorf("duplicate pool '%s'", p.Name)
+		}
+		namedPool := &pool{v4: defaultPool.v4, v6: defaultPool.v6}
+		for _, cidr := range []string{p.CIDR, p.CIDRv6} {
+			if cidr == "" {
+				continue
+			}
+			prefix, err := netip.ParsePrefix(cidr)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid subnet of pool '%s'", p.Name)
+			}
+			prefix = prefix.Masked()
+			vpn := vpnv4
+			if prefix.Addr().Is6() {
+				vpn = vpnv6
+			}
+			if !vpn.IsValid() || vpn.Bits() > prefix.Bits() || !vpn.Contains(prefix.Addr()) {
+				return nil, errors.Errorf("subnet %s of pool '%s' is not within the vpn subnet", prefix, p.Name)
+			}
+			for other, prefixes := range named {
+				for _, otherPrefix := range prefixes {
+					if prefix.Overlaps(otherPrefix) {
+						return nil, errors.Errorf("subnet %s of pool '%s' overlaps subnet %s of pool '%s'", prefix, p.Name, otherPrefix, other)
+					}
+				}
+			}
+			named[p.Name] = append(named[p.Name], prefix)
+			if prefix.Addr().Is4() {
+				namedPool.v4 = &subnet{prefix: prefix, excluded: reserved, pool: p.Name}
+			} else {
+				namedPool.v6 = &subnet{prefix: prefix, excluded: reserved, pool: p.Name}
+			}
+			// addresses of the default pool are assigned from outside of all pools
+			defaultExcluded = append(defaultExcluded, addrRange{prefix.Addr(), lastAddr(prefix)})
+		}
+		pools[p.Name] = namedPool
+	}
+	if defaultPool.v4 != nil {
+		defaultPool.v4.excluded = defaultExcluded
+	}
+	if defaultPool.v6 != nil {
+		defaultPool.v6.excluded = defaultExcluded
+	}
+
+	return &IPAM{
+		storage:  s,
+		cooldown: opts.ReuseCooldown,
+		random:   opts.Random,
+		pools:    pools,
+	}, nil
+}
+
+// HasPool reports whether a pool with the name is configured
+func (i *IPAM) HasPool(name string) bool {
+	_, ok := i.pools[name]
+	return ok
+}
+
+// Allocate assigns an address of every configured address family from the pool
+// and returns them in the format of storage.Device.Address
+func (i *IPAM) Allocate(poolName string) (string, error) {
+	p, ok := i.pools[poolName]
+	if !ok {
+		return "", errors.Errorf("unknown address pool '%s'", poolName)
+	}
+	if err := i.purge(); err != nil {
+		return "", err
+	}
+
+	addresses := []string{}
+	for _, s := range []*subnet{p.v4, p.v6} {
+		if s == nil {
+			continue
+		}
+		addr, err := i.allocate(poolName, s)
+		if err != nil {
+			if err := i.Free(strings.Join(addresses, ", ")); err != nil {
+				logrus.Warn(errors.Wrap(err, "failed to free addresses"))
+			}
+			return "", err
+		}
+		addresses = append(addresses, netip.PrefixFrom(addr, addr.BitLen()).String())
+	}
+	if len(addresses) == 0 {
+		return "", errors.New("no vpn subnet configured")
+	}
+	return strings.Join(addresses, ", "), nil
+}
+
+func (i *IPAM) allocate(poolName string, s *subnet) (netip.Addr, error) {
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		var addr netip.Addr
+		var err error
+		if i.random && s.prefix.Addr().BitLen()-s.prefix.Bits() > maxSequentialHostBits {
+			addr, err = s.random()
+		} else {
+			addr, err = i.nextFree(s)
+		}
+		if err != nil {
+			return netip.Addr{}, err
+		}
+
+		err = i.storage.CreateAllocation(&storage.Allocation{
+			Address:   addr.String(),
+			Pool:      poolName,
+			CreatedAt: time.Now(),
+		})
+		if err == nil {
+			return addr, nil
+		}
+		if err != storage.ErrAddressAllocated {
+			return netip.Addr{}, err
+		}
+		logrus.Debugf("address %s was allocated concurrently, retrying", addr)
+	}
+	return netip.Addr{}, fmt.Errorf("failed to allocate an IP address in the vpn subnet '%s'", s.prefix)
+}
+
+// nextFree returns the lowest address of the subnet that isn't allocated or excluded
+func (i *IPAM) nextFree(s *subnet) (netip.Addr, error) {
+	allocations, err := i.storage.ListAllocationsBetween(s.prefix.Addr(), lastAddr(s.prefix))
+	if err != nil {
+		return netip.Addr{}, errors.Wrap(err, "failed to list allocations")
+	}
+	used := make(map[netip.Addr]bool, len(allocations))
+	for _, allocation := range allocations {
+		if addr, err := netip.ParseAddr(allocation.Address); err == nil {
+			used[addr] = true
+		}
+	}
+
+	for ip := s.prefix.Addr(); s.prefix.Contains(ip); ip = ip.Next() {
+		if r, ok := s.excludedRange(ip); ok {
+			// skip the whole range
+			ip = r.to
+			continue
+		}
+		if !used[ip] {
+			return ip, nil
+		}
+	}
+	return netip.Addr{}, fmt.Errorf("there are no free IP addresses in the vpn subnet: '%s'", s.prefix)
+}
+
+// random returns a random address of the subnet that isn't excluded,
+// the chance of picking an allocated address is negligible in large subnets
+func (s *subnet) random() (netip.Addr, error) {
+	for attempt := 0; attempt < 100; attempt++ {
+		addr, err := randomAddr(s.prefix)
+		if err != nil {
+			return netip.Addr{}, errors.Wrap(err, "failed to generate a random address")
+		}
+		if _, ok := s.excludedRange(addr); !ok {
+			return addr, nil
+		}
+	}
+	return netip.Addr{}, fmt.Errorf("there are no free IP addresses in the vpn subnet: '%s'", s.prefix)
+}
+
+func (s *subnet) excludedRange(addr netip.Addr) (addrRange, bool) {
+	for _, r := range s.excluded {
+		if r.contains(addr) {
+			return r, true
+		}
+	}
+	return addrRange{}, false
+}
+
+// Claim allocates a manually assigned address.
+// It fails with storage.ErrAddressAllocated if the address is in use or in the reuse cooldown.
+func (i *IPAM) Claim(addr netip.Addr) error {
+	if err := i.purge(); err != nil {
+		return err
+	}
+	return i.claim(addr)
+}
+
+func (i *IPAM) claim(addr netip.Addr) error {
+	return i.storage.CreateAllocation(&storage.Allocation{
+		Address:   addr.String(),
+		Pool:      i.poolOf(addr),
+		CreatedAt: time.Now(),
+	})
+}
+
+// Release returns the addresses of a deleted device,
+// they are only reused after the reuse cooldown
+func (i *IPAM) Release(addresses string) error {
+	if i.cooldown == 0 {
+		return i.Free(addresses)
+	}
+	now := time.Now()
+	for _, addr := range splitAddrs(addresses) {
+		if err := i.storage.ReleaseAllocation(addr.String(), now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Free returns addresses immediately, e.g. if they were never used by a device
+func (i *IPAM) Free(addresses string) error {
+	for _, addr := range splitAddrs(addresses) {
+		if err := i.storage.DeleteAllocation(addr.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sync creates the missing allocations for the addresses of existing devices,
+// e.g. after an upgrade, and releases allocations that don't belong to a device anymore
+func (i *IPAM) Sync(deviceAddresses []string) error {
+	allocations, err := i.storage.ListAllocations()
+	if err != nil {
+		return errors.Wrap(err, "failed to list allocations")
+	}
+	allocated := make(map[string]*storage.Allocation, len(allocations))
+	for _, allocation := range allocations {
+		allocated[allocation.Address] = allocation
+	}
+
+	inUse := map[string]bool{}
+	for _, addresses := range deviceAddresses {
+		for _, addr := range splitAddrs(addresses) {
+			inUse[addr.String()] = true
+			allocation, ok := allocated[addr.String()]
+			if ok && allocation.ReleasedAt == nil {
+				continue
+			}
+			if ok {
+				// the device is still there, end the cooldown
+				if err := i.storage.DeleteAllocation(addr.String()); err != nil {
+					return err
+				}
+			}
+			if err := i.claim(addr); err != nil && err != storage.ErrAddressAllocated {
+				return errors.Wrapf(err, "failed to allocate address %s", addr)
+			}
+		}
+	}
+
+	for address, allocation := range allocated {
+		if !inUse[address] && allocation.ReleasedAt == nil && allocation.CreatedAt.Before(time.Now().Add(-orphanGracePeriod)) {
+			logrus.Infof("releasing address %s without a device", address)
+			if err := i.Release(address); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// purge deletes the allocations whose reuse cooldown is over
+func (i *IPAM) purge() error {
+	if err := i.storage.PurgeAllocations(time.Now().Add(-i.cooldown)); err != nil {
+		return errors.Wrap(err, "failed to purge released allocations")
+	}
+	return nil
+}
+
+// poolOf returns the name of the pool with a subnet containing the address
+func (i *IPAM) poolOf(addr netip.Addr) string {
+	for _, p := range i.pools {
+		for _, s := range []*subnet{p.v4, p.v6} {
+			if s != nil && s.pool != "" && s.prefix.Contains(addr) {
+				return s.pool
+			}
+		}
+	}
+	return ""
+}
+
+// parseRange parses a CIDR or "<first address>-<last address>"
+func parseRange(value string) (addrRange, error) {
+	if from, to, found := strings.Cut(value, "-"); found {
+		first, err := netip.ParseAddr(strings.TrimSpace(from))
+		if err != nil {
+			return addrRange{}, err
+		}
+		last, err := netip.ParseAddr(strings.TrimSpace(to))
+		if err != nil {
+			return addrRange{}, err
+		}
+		if first.BitLen() != last.BitLen() || last.Less(first) {
+			return addrRange{}, errors.New("the last address must be after the first address")
+		}
+		return addrRange{first, last}, nil
+	}
+	prefix, err := netip.ParsePrefix(strings.TrimSpace(value))
+	if err != nil {
+		return addrRange{}, err
+	}
+	prefix = prefix.Masked()
+	return addrRange{prefix.Addr(), lastAddr(prefix)}, nil
+}
+
+func lastAddr(prefix netip.Prefix) netip.Addr {
+	b := prefix.Masked().Addr().AsSlice()
+	for i := range b {
+		b[i] |= hostMask(prefix.Bits(), i)
+	}
+	addr, _ := netip.AddrFromSlice(b)
+	return addr
+}
+
+func randomAddr(prefix netip.Prefix) (netip.Addr, error) {
+	b := prefix.Masked().Addr().AsSlice()
+	random := make([]byte, len(b))
+	if _, err := rand.Read(random); err != nil {
+		return netip.Addr{}, err
+	}
+	for i := range b {
+		b[i] |= random[i] & hostMask(prefix.Bits(), i)
+	}
+	addr, _ := netip.AddrFromSlice(b)
+	return addr, nil
+}
+
+// hostMask returns the host bits of the i-th byte of an address with the given prefix length
+func hostMask(bits int, i int) byte {
+	switch {
+	case bits >= (i+1)*8:
+		return 0
+	case bits <= i*8:
+		return 0xff
+	default:
+		return 0xff >> (bits - i*8)
+	}
+}
+
+func splitAddrs(addresses string) []netip.Addr {
+	addrs := []netip.Addr{}
+	for _, value := range network.SplitAddresses(addresses) {
+		if prefix, err := netip.ParsePrefix(value); err == nil {
+			addrs = append(addrs, prefix.Addr())
+		} else if addr, err := netip.ParseAddr(value); err == nil {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
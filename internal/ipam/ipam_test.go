@@ -0,0 +1,158 @@
+package ipam
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/freifunkMUC/wg-access-server/internal/config"
+	"github.com/freifunkMUC/wg-access-server/internal/storage"
+)
+
+func TestAllocateSequential(t *testing.T) {
+	require := require.New(t)
+
+	allocator, err := New(storage.NewMemoryStorage(), Options{
+		CIDR:     "10.44.0.0/24",
+		Reserved: []string{"10.44.0.2-10.44.0.9"},
+	})
+	require.NoError(err)
+
+	address, err := allocator.Allocate("")
+	require.NoError(err)
+	require.Equal("10.44.0.10/32", address)
+
+	address, err = allocator.Allocate("")
+	require.NoError(err)
+	require.Equal("10.44.0.11/32", address)
+
+	require.NoError(allocator.Release("10.44.0.10/32"))
+	address, err = allocator.Allocate("")
+	require.NoError(err)
+	require.Equal("10.44.0.10/32", address)
+}
+
+func TestAllocateExhausted(t *testing.T) {
+	require := require.New(t)
+
+	allocator, err := New(storage.NewMemoryStorage(), Options{CIDR: "10.44.0.0/30"})
+	require.NoError(err)
+
+	address, err := allocator.Allocate("")
+	require.NoError(err)
+	require.Equal("10.44.0.2/32", address)
+	address, err = allocator.Allocate("")
+	require.NoError(err)
+	require.Equal("10.44.0.3/32", address)
+
+	_, err = allocator.Allocate("")
+	require.Error(err)
+}
+
+func TestAllocateRandom(t *testing.T) {
+	require := require.New(t)
+
+	// large subnets are filled from the lowest address by default
+	allocator, err := New(storage.NewMemoryStorage(), Options{CIDR: "10.44.0.0/24", CIDRv6: "fd48:4c4:7aa9::/64"})
+	require.NoError(err)
+	address, err := allocator.Allocate("")
+	require.NoError(err)
+	require.Equal("10.44.0.2/32, fd48:4c4:7aa9::2/128", address)
+	address, err = allocator.Allocate("")
+	require.NoError(err)
+	require.Equal("10.44.0.3/32, fd48:4c4:7aa9::3/128", address)
+
+	allocator, err = New(storage.NewMemoryStorage(), Options{CIDR: "10.44.0.0/24", CIDRv6: "fd48:4c4:7aa9::/64", Random: true})
+	require.NoError(err)
+
+	seen := map[string]bool{}
+	for i := 0; i < 50; i++ {
+		address, err := allocator.Allocate("")
+		require.NoError(err)
+		require.False(seen[address])
+		seen[address] = true
+
+		addrs := splitAddrs(address)
+		require.Len(addrs, 2)
+		require.True(netip.MustParsePrefix("fd48:4c4:7aa9::/64").Contains(addrs[1]))
+	}
+}
+
+func TestPools(t *testing.T) {
+	require := require.New(t)
+
+	allocator, err := New(storage.NewMemoryStorage(), Options{
+		CIDR: "10.44.0.0/24",
+		Pools: []config.Pool{
+			{Name: "devops", CIDR: "10.44.0.0/28"},
+		},
+	})
+	require.NoError(err)
+
+	address, err := allocator.Allocate("devops")
+	require.NoError(err)
+	require.Equal("10.44.0.2/32", address)
+
+	// the default pool skips the ranges of all pools
+	address, err = allocator.Allocate("")
+	require.NoError(err)
+	require.Equal("10.44.0.16/32", address)
+
+	_, err = allocator.Allocate("unknown")
+	require.Error(err)
+
+	_, err = New(storage.NewMemoryStorage(), Options{
+		CIDR:  "10.44.0.0/24",
+		Pools: []config.Pool{{Name: "outside", CIDR: "10.45.0.0/28"}},
+	})
+	require.Error(err)
+
+	_, err = New(storage.NewMemoryStorage(), Options{
+		CIDR: "10.44.0.0/24",
+		Pools: []config.Pool{
+			{Name: "devops", CIDR: "10.44.0.0/28"},
+			{Name: "contractors", CIDR: "10.44.0.8/29"},
+		},
+	})
+	require.ErrorContains(err, "overlaps")
+}
+
+func TestReuseCooldown(t *testing.T) {
+	require := require.New(t)
+
+	s := storage.NewMemoryStorage()
+	allocator, err := New(s, Options{CIDR: "10.44.0.0/24", ReuseCooldown: time.Hour})
+	require.NoError(err)
+
+	address, err := allocator.Allocate("")
+	require.NoError(err)
+	require.NoError(allocator.Release(address))
+
+	// the released address is skipped
+	next, err := allocator.Allocate("")
+	require.NoError(err)
+	require.Equal("10.44.0.3/32", next)
+	require.Equal(storage.ErrAddressAllocated, allocator.Claim(netip.MustParseAddr("10.44.0.2")))
+
+	// and reused once the cooldown is over
+	require.NoError(s.PurgeAllocations(time.Now().Add(time.Minute)))
+	require.NoError(allocator.Claim(netip.MustParseAddr("10.44.0.2")))
+}
+
+func TestSync(t *testing.T) {
+	require := require.New(t)
+
+	s := storage.NewMemoryStorage()
+	allocator, err := New(s, Options{CIDR: "10.44.0.0/24"})
+	require.NoError(err)
+
+	require.NoError(s.CreateAllocation(&storage.Allocation{Address: "10.44.0.9", CreatedAt: time.Now().Add(-time.Hour)}))
+	require.NoError(allocator.Sync([]string{"10.44.0.2/32"}))
+
+	allocations, err := s.ListAllocations()
+	require.NoError(err)
+	require.Len(allocations, 1)
+	require.Equal("10.44.0.2", allocations[0].Address)
+}
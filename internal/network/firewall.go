@@ -0,0 +1,147 @@
+package network
+
+import (
+	"net/netip"
+	"os"
+	"strings"
+
+	"github.com/google/nftables"
+	"github.com/pkg/errors"
+)
+
+// Backends that install the firewall rules
+const (
+	FirewallAuto     = "auto"
+	FirewallIPTables = "iptables"
+	FirewallNFTables = "nftables"
+)
+
+// DetectFirewallBackend resolves the auto backend. nftables is used if the kernel supports it,
+// unless the legacy iptables tables are in use. Their rules would be evaluated independently
+// of ours, so the iptables backend is used to add the rules to the same chains.
+func DetectFirewallBackend(backend string) string {
+	if backend != "" && backend != FirewallAuto {
+		return backend
+	}
+	for _, names := range []string{"/proc/net/ip_tables_names", "/proc/net/ip6_tables_names"} {
+		if content, err := os.ReadFile(names); err == nil && strings.TrimSpace(string(content)) != "" {
+			return FirewallIPTables
+		}
+	}
+	conn, err := nftables.New()
+	if err != nil {
+		return FirewallIPTables
+	}
+	if _, err := conn.ListTables(); err != nil {
+		return FirewallIPTables
+	}
+	return FirewallNFTables
+}
+
+// firewallBackend installs the firewall rules with iptables or nftables
+type firewallBackend interface {
+	// configure replaces the global forwarding rules and the per-peer rules
+	configure(options ForwardingOptions, peers map[string][]filterRule) error
+	// replacePeer replaces the applied rules of a peer with the new rules.
+	// inUse reports whether a rule is applied for another peer as well.
+	replacePeer(publicKey string, applied []filterRule, rules []filterRule, inUse func(filterRule) bool) error
+	// drifted reports whether the installed rules differ from the rules configure would install
+	drifted(options ForwardingOptions, peers map[string][]filterRule) (bool, error)
+	// teardown removes all rules and chains of the backend
+	teardown(options ForwardingOptions) error
+}
+
+func newFirewallBackend(options ForwardingOptions) (firewallBackend, error) {
+	switch backend := DetectFirewallBackend(options.Backend); backend {
+	case FirewallIPTables:
+		return newIPTablesFirewall(options)
+	case FirewallNFTables:
+		return newNFTablesFirewall()
+	default:
+		return nil, errors.Errorf("unknown firewall backend '%s'", backend)
+	}
+}
+
+// vpnFamily is a configured VPN network of one address family
+type vpnFamily struct {
+	cidr       netip.Prefix
+	allowedIPs []netip.Prefix
+	nat        bool
+}
+
+// vpnFamilies returns the configured VPN networks of prepared options
+func vpnFamilies(options ForwardingOptions) []vpnFamily {
+	families := []vpnFamily{}
+	if options.cidr.IsValid() {
+		families = append(families, vpnFamily{options.cidr, options.allowedIPv4s, options.NAT44})
+	}
+	if options.cidrv6.IsValid() {
+		families = append(families, vpnFamily{options.cidrv6, options.allowedIPv6s, options.NAT66})
+	}
+	return families
+}
+
+// filterRule is a forwarding rule that is rendered by the firewall backends
+type filterRule struct {
+	// source and destination networks are matched if valid
+	source      netip.Prefix
+	destination netip.Prefix
+	// protocol is matched if set, e.g. "tcp"
+	protocol string
+	// ports is a comma separated list of ports or port ranges, e.g. "22,8000-8080".
+	// They are matched against the destination ports, or the source ports for return traffic.
+	ports       string
+	sourcePorts bool
+	// accept the traffic, it is rejected otherwise
+	accept bool
+}
+
+// reverse returns the rule for the return traffic, the ports are matched against the source ports
+func (r filterRule) reverse() filterRule {
+	r.source, r.destination = r.destination, r.source
+	r.sourcePorts = !r.sourcePorts
+	return r
+}
+
+// is6 reports whether the rule matches IPv6 traffic
+func (r filterRule) is6() bool {
+	if r.source.IsValid() {
+		return r.source.Addr().Is6()
+	}
+	return r.destination.Addr().Is6()
+}
+
+// forwardRules returns the global forwarding rules of the VPN network of one address family,
+// they are evaluated after the per-device rules
+func forwardRules(family vpnFamily, options ForwardingOptions) []filterRule {
+	cidr := family.cidr
+	filters := []filterRule{}
+	if options.ClientIsolation {
+		// Reject inter-device traffic
+		filters = append(filters, filterRule{source: cidr, destination: cidr})
+	}
+	// The structured rules in their configured order
+	accepted := []filterRule{}
+	for _, rule := range options.Rules {
+		if filter, ok := rule.filter(cidr); ok {
+			filters = append(filters, filter)
+			if filter.accept {
+				accepted = append(accepted, filter)
+			}
+		}
+	}
+	// Accept client traffic for given allowed ips
+	for _, allowed := range family.allowedIPs {
+		filter := filterRule{source: cidr, destination: allowed, accept: true}
+		filters = append(filters, filter)
+		accepted = append(accepted, filter)
+	}
+	// Accept return traffic when NAT is disabled
+	if !family.nat {
+		for _, filter := range accepted {
+			filters = append(filters, filter.reverse())
+		}
+	}
+	// And reject everything else
+	return append(filters, filterRule{source: cidr})
+}
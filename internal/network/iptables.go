@@ -0,0 +1,298 @@
+package network
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/coreos/go-iptables/iptables"
+	"github.com/pkg/errors"
+)
+
+// devicesChain holds the per-device rules. It is jumped to from
+// WG_ACCESS_SERVER_FORWARD before any of the global rules are evaluated.
+const devicesChain = "WG_ACCESS_SERVER_DEVICES"
+
+// iptablesFirewall installs the rules into chains of iptables and ip6tables
+type iptablesFirewall struct {
+	ipt4 *iptables.IPTables
+	ipt6 *iptables.IPTables
+}
+
+func newIPTablesFirewall(options ForwardingOptions) (*iptablesFirewall, error) {
+	fw := &iptablesFirewall{}
+	if options.CIDR != "" {
+		ipt, err := iptables.NewWithProtocol(iptables.ProtocolIPv4)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to init iptables")
+		}
+		fw.ipt4 = ipt
+	}
+	if options.CIDRv6 != "" {
+		ipt, err := iptables.NewWithProtocol(iptables.ProtocolIPv6)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to init ip6tables")
+		}
+		fw.ipt6 = ipt
+	}
+	return fw, nil
+}
+
+func (fw *iptablesFirewall) table(ipv6 bool) *iptables.IPTables {
+	if ipv6 {
+		return fw.ipt6
+	}
+	return fw.ipt4
+}
+
+func (fw *iptablesFirewall) configure(options ForwardingOptions, peers map[string][]filterRule) error {
+	for _, family := range vpnFamilies(options) {
+		ipt := fw.table(family.cidr.Addr().Is6())
+		if err := configureIPTablesFamily(ipt, family, options); err != nil {
+			return err
+		}
+		for publicKey, rules := range peers {
+			for _, rule := range rules {
+				if rule.is6() != family.cidr.Addr().Is6() {
+					continue
+				}
+				if err := ipt.AppendUnique("filter", devicesChain, iptablesSpec(rule)...); err != nil {
+					return errors.Wrapf(err, "failed to set ip tables rule for peer %s", publicKey)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func configureIPTablesFamily(ipt *iptables.IPTables, family vpnFamily, options ForwardingOptions) error {
+	// Cleanup our chains first so that we don't leak
+	// iptable rules when the network configuration changes.
+	err := clearOrCreateChain(ipt, "filter", "WG_ACCESS_SERVER_FORWARD")
+	if err != nil {
+		return err
+	}
+
+	err = clearOrCreateChain(ipt, "filter", devicesChain)
+	if err != nil {
+		return err
+	}
+
+	err = clearOrCreateChain(ipt, "nat", "WG_ACCESS_SERVER_POSTROUTING")
+	if err != nil {
+		return err
+	}
+
+	err = ipt.AppendUnique("filter", "FORWARD", "-j", "WG_ACCESS_SERVER_FORWARD")
+	if err != nil {
+		return errors.Wrap(err, "failed to append FORWARD rule to filter chain")
+	}
+
+	err = ipt.AppendUnique("nat", "POSTROUTING", "-j", "WG_ACCESS_SERVER_POSTROUTING")
+	if err != nil {
+		return errors.Wrap(err, "failed to append POSTROUTING rule to nat chain")
+	}
+
+	// Per-device rules take precedence over the rules below
+	if err := ipt.AppendUnique("filter", "WG_ACCESS_SERVER_FORWARD", "-j", devicesChain); err != nil {
+		return errors.Wrap(err, "failed to set ip tables rule")
+	}
+
+	for _, rule := range forwardRules(family, options) {
+		if err := ipt.AppendUnique("filter", "WG_ACCESS_SERVER_FORWARD", iptablesSpec(rule)...); err != nil {
+			return errors.Wrap(err, "failed to set ip tables rule")
+		}
+	}
+
+	if masquerade := iptablesMasquerade(family, options); masquerade != nil {
+		if err := ipt.AppendUnique("nat", "WG_ACCESS_SERVER_POSTROUTING", masquerade...); err != nil {
+			return errors.Wrap(err, "failed to set ip tables rule")
+		}
+	}
+	return nil
+}
+
+// iptablesMasquerade returns the NAT rule spec of the VPN network, nil if NAT is disabled
+func iptablesMasquerade(family vpnFamily, options ForwardingOptions) []string {
+	if options.GatewayIface == "" || !family.nat {
+		return nil
+	}
+	return []string{"-s", family.cidr.String(), "-o", options.GatewayIface, "-j", "MASQUERADE"}
+}
+
+func clearOrCreateChain(ipt *iptables.IPTables, table, chain string) error {
+	exists, err := ipt.ChainExists(table, chain)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read table %s", table)
+	}
+	if exists {
+		err = ipt.ClearChain(table, chain)
+		if err != nil {
+			return errors.Wrapf(err, "failed to clear chain %s in table %s", chain, table)
+		}
+	} else {
+		// Create our own chain for forwarding rules
+		err = ipt.NewChain(table, chain)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create chain %s in table %s", chain, table)
+		}
+	}
+	return nil
+}
+
+func (fw *iptablesFirewall) replacePeer(publicKey string, applied []filterRule, rules []filterRule, inUse func(filterRule) bool) error {
+	for _, rule := range applied {
+		// the same address is briefly assigned to two keys while a device is re-keyed
+		if inUse(rule) {
+			continue
+		}
+		if err := fw.table(rule.is6()).DeleteIfExists("filter", devicesChain, iptablesSpec(rule)...); err != nil {
+			return errors.Wrapf(err, "failed to remove ip tables rule for peer %s", publicKey)
+		}
+	}
+	for _, rule := range rules {
+		if err := fw.table(rule.is6()).AppendUnique("filter", devicesChain, iptablesSpec(rule)...); err != nil {
+			return errors.Wrapf(err, "failed to set ip tables rule for peer %s", publicKey)
+		}
+	}
+	return nil
+}
+
+func (fw *iptablesFirewall) drifted(options ForwardingOptions, peers map[string][]filterRule) (bool, error) {
+	for _, family := range vpnFamilies(options) {
+		ipt := fw.table(family.cidr.Addr().Is6())
+
+		for _, jump := range [][]string{{"filter", "FORWARD", "WG_ACCESS_SERVER_FORWARD"}, {"nat", "POSTROUTING", "WG_ACCESS_SERVER_POSTROUTING"}} {
+			exists, err := ipt.ChainExists(jump[0], jump[2])
+			if err != nil {
+				return false, errors.Wrapf(err, "failed to read table %s", jump[0])
+			}
+			if !exists {
+				return true, nil
+			}
+			jumped, err := ipt.Exists(jump[0], jump[1], "-j", jump[2])
+			if err != nil {
+				return false, errors.Wrapf(err, "failed to read chain %s", jump[1])
+			}
+			if !jumped {
+				return true, nil
+			}
+		}
+
+		forward := [][]string{{"-j", devicesChain}}
+		for _, rule := range forwardRules(family, options) {
+			forward = append(forward, iptablesSpec(rule))
+		}
+		postrouting := [][]string{}
+		if masquerade := iptablesMasquerade(family, options); masquerade != nil {
+			postrouting = append(postrouting, masquerade)
+		}
+		devices := [][]string{}
+		for _, rules := range peers {
+			for _, rule := range rules {
+				if rule.is6() != family.cidr.Addr().Is6() {
+					continue
+				}
+				// the same rule of multiple peers is only appended once
+				spec := iptablesSpec(rule)
+				if !slices.ContainsFunc(devices, func(s []string) bool { return slices.Equal(s, spec) }) {
+					devices = append(devices, spec)
+				}
+			}
+		}
+
+		for _, chain := range []struct {
+			table, name string
+			specs       [][]string
+		}{
+			{"filter", "WG_ACCESS_SERVER_FORWARD", forward},
+			{"filter", devicesChain, devices},
+			{"nat", "WG_ACCESS_SERVER_POSTROUTING", postrouting},
+		} {
+			drifted, err := iptablesChainDrifted(ipt, chain.table, chain.name, chain.specs)
+			if err != nil || drifted {
+				return drifted, err
+			}
+		}
+	}
+	return false, nil
+}
+
+// iptablesChainDrifted reports whether the rules of a chain differ from the rule specs
+func iptablesChainDrifted(ipt *iptables.IPTables, table, chain string, specs [][]string) (bool, error) {
+	exists, err := ipt.ChainExists(table, chain)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to read table %s", table)
+	}
+	if !exists {
+		return true, nil
+	}
+	rules, err := ipt.List(table, chain)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to list chain %s", chain)
+	}
+	// the first line creates the chain
+	if len(rules)-1 != len(specs) {
+		return true, nil
+	}
+	for _, spec := range specs {
+		exists, err := ipt.Exists(table, chain, spec...)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to read chain %s", chain)
+		}
+		if !exists {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (fw *iptablesFirewall) teardown(options ForwardingOptions) error {
+	for _, family := range vpnFamilies(options) {
+		ipt := fw.table(family.cidr.Addr().Is6())
+		if err := ipt.DeleteIfExists("filter", "FORWARD", "-j", "WG_ACCESS_SERVER_FORWARD"); err != nil {
+			return errors.Wrap(err, "failed to remove FORWARD rule from filter chain")
+		}
+		if err := ipt.DeleteIfExists("nat", "POSTROUTING", "-j", "WG_ACCESS_SERVER_POSTROUTING"); err != nil {
+			return errors.Wrap(err, "failed to remove POSTROUTING rule from nat chain")
+		}
+		// the forward chain jumps to the devices chain, it is deleted first
+		for _, chain := range [][]string{{"filter", "WG_ACCESS_SERVER_FORWARD"}, {"filter", devicesChain}, {"nat", "WG_ACCESS_SERVER_POSTROUTING"}} {
+			exists, err := ipt.ChainExists(chain[0], chain[1])
+			if err != nil {
+				return errors.Wrapf(err, "failed to read table %s", chain[0])
+			}
+			if !exists {
+				continue
+			}
+			if err := ipt.ClearAndDeleteChain(chain[0], chain[1]); err != nil {
+				return errors.Wrapf(err, "failed to delete chain %s in table %s", chain[1], chain[0])
+			}
+		}
+	}
+	return nil
+}
+
+// iptablesSpec renders the rule spec of a filter rule
+func iptablesSpec(rule filterRule) []string {
+	spec := []string{}
+	if rule.source.IsValid() {
+		spec = append(spec, "-s", rule.source.String())
+	}
+	if rule.destination.IsValid() {
+		spec = append(spec, "-d", rule.destination.String())
+	}
+	if rule.protocol != "" {
+		spec = append(spec, "-p", rule.protocol)
+		if rule.ports != "" {
+			portsFlag := "--dports"
+			if rule.sourcePorts {
+				portsFlag = "--sports"
+			}
+			spec = append(spec, "-m", "multiport", portsFlag, strings.ReplaceAll(rule.ports, "-", ":"))
+		}
+	}
+	if rule.accept {
+		return append(spec, "-j", "ACCEPT")
+	}
+	return append(spec, "-j", "REJECT")
+}
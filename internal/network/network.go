@@ -1,11 +1,9 @@
 package network
 
 import (
-	"net"
 	"net/netip"
 	"strings"
 
-	"github.com/coreos/go-iptables/iptables"
 	"github.com/pkg/errors"
 )
 
@@ -68,202 +66,83 @@ func SplitAddresses(addresses string) []string {
 
 // ForwardingOptions contains all options used for configuring the firewall rules
 type ForwardingOptions struct {
+	// Backend is one of FirewallIPTables or FirewallNFTables, FirewallAuto detects it
+	Backend         string
 	GatewayIface    string
 	CIDR, CIDRv6    string
 	NAT44, NAT66    bool
 	ClientIsolation bool
 	AllowedIPs      []string
-	allowedIPv4s    []string
-	allowedIPv6s    []string
+	cidr, cidrv6    netip.Prefix
+	allowedIPv4s    []netip.Prefix
+	allowedIPv6s    []netip.Prefix
+	// Rules are evaluated in order before the AllowedIPs
+	Rules []Rule
+	// DisableIPTables disables the firewall configuration of either backend
 	DisableIPTables bool
 }
 
+// ConfigureForwarding replaces the global forwarding and NAT rules of the VPN networks
+// and clears the per-device rules
 func ConfigureForwarding(options ForwardingOptions) error {
 	// If iptables is disabled, return early
 	if options.DisableIPTables {
 		return nil
 	}
-
-	// Networking configuration (iptables) configuration
-	// to ensure that traffic from clients of the WireGuard interface
-	// is sent to the provided network interface
-	allowedIPv4s := make([]string, 0, len(options.AllowedIPs)/2)
-	allowedIPv6s := make([]string, 0, len(options.AllowedIPs)/2)
-
-	for _, allowedCIDR := range options.AllowedIPs {
-		parsedAddress, parsedNetwork, err := net.ParseCIDR(allowedCIDR)
-		if err != nil {
-			return errors.Wrap(err, "invalid cidr in AllowedIPs")
-		}
-		if as4 := parsedAddress.To4(); as4 != nil {
-			// Handle IPv4-mapped IPv6 addresses, if they go into ip6tables they don't get hit
-			// and go-iptables can't convert them (whereas commandline iptables can).
-			parsedNetwork.IP = as4
-			allowedIPv4s = append(allowedIPv4s, parsedNetwork.String())
-		} else {
-			allowedIPv6s = append(allowedIPv6s, parsedNetwork.String())
-		}
-	}
-	options.allowedIPv4s = allowedIPv4s
-	options.allowedIPv6s = allowedIPv6s
-
-	if options.CIDR != "" {
-		if err := configureForwardingv4(options); err != nil {
-			return err
-		}
-	}
-	if options.CIDRv6 != "" {
-		if err := configureForwardingv6(options); err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
-func configureForwardingv4(options ForwardingOptions) error {
-	ipt, err := iptables.NewWithProtocol(iptables.ProtocolIPv4)
-	if err != nil {
-		return errors.Wrap(err, "failed to init iptables")
-	}
-
-	// Cleanup our chains first so that we don't leak
-	// iptable rules when the network configuration changes.
-	err = clearOrCreateChain(ipt, "filter", "WG_ACCESS_SERVER_FORWARD")
+	options, err := prepareForwarding(options)
 	if err != nil {
 		return err
 	}
-
-	err = clearOrCreateChain(ipt, "nat", "WG_ACCESS_SERVER_POSTROUTING")
+	backend, err := newFirewallBackend(options)
 	if err != nil {
 		return err
 	}
-
-	err = ipt.AppendUnique("filter", "FORWARD", "-j", "WG_ACCESS_SERVER_FORWARD")
-	if err != nil {
-		return errors.Wrap(err, "failed to append FORWARD rule to filter chain")
-	}
-
-	err = ipt.AppendUnique("nat", "POSTROUTING", "-j", "WG_ACCESS_SERVER_POSTROUTING")
-	if err != nil {
-		return errors.Wrap(err, "failed to append POSTROUTING rule to nat chain")
-	}
-
-	if options.ClientIsolation {
-		// Reject inter-device traffic
-		if err := ipt.AppendUnique("filter", "WG_ACCESS_SERVER_FORWARD", "-s", options.CIDR, "-d", options.CIDR, "-j", "REJECT"); err != nil {
-			return errors.Wrap(err, "failed to set ip tables rule")
-		}
-	}
-	// Accept client traffic for given allowed ips
-	for _, allowedCIDR := range options.allowedIPv4s {
-		if err := ipt.AppendUnique("filter", "WG_ACCESS_SERVER_FORWARD", "-s", options.CIDR, "-d", allowedCIDR, "-j", "ACCEPT"); err != nil {
-			return errors.Wrap(err, "failed to set ip tables rule")
-		}
-	}
-
-	// Accept return traffic when NAT is disabled
-	if !options.NAT44 {
-		for _, allowedCIDR := range options.allowedIPv4s {
-			if err := ipt.AppendUnique("filter", "WG_ACCESS_SERVER_FORWARD", "-s", allowedCIDR, "-d", options.CIDR, "-j", "ACCEPT"); err != nil {
-				return errors.Wrap(err, "failed to set ip tables rule for return traffic")
-			}
-		}
-	}
-
-	// And reject everything else
-	if err := ipt.AppendUnique("filter", "WG_ACCESS_SERVER_FORWARD", "-s", options.CIDR, "-j", "REJECT"); err != nil {
-		return errors.Wrap(err, "failed to set ip tables rule")
-	}
-
-	if options.GatewayIface != "" {
-		if options.NAT44 {
-			if err := ipt.AppendUnique("nat", "WG_ACCESS_SERVER_POSTROUTING", "-s", options.CIDR, "-o", options.GatewayIface, "-j", "MASQUERADE"); err != nil {
-				return errors.Wrap(err, "failed to set ip tables rule")
-			}
-		}
-	}
-	return nil
+	return backend.configure(options, nil)
 }
 
-func configureForwardingv6(options ForwardingOptions) error {
-	ipt, err := iptables.NewWithProtocol(iptables.ProtocolIPv6)
-	if err != nil {
-		return errors.Wrap(err, "failed to init ip6tables")
-	}
-
-	err = clearOrCreateChain(ipt, "filter", "WG_ACCESS_SERVER_FORWARD")
-	if err != nil {
-		return err
-	}
-
-	err = clearOrCreateChain(ipt, "nat", "WG_ACCESS_SERVER_POSTROUTING")
-	if err != nil {
-		return err
-	}
-
-	err = ipt.AppendUnique("filter", "FORWARD", "-j", "WG_ACCESS_SERVER_FORWARD")
-	if err != nil {
-		return errors.Wrap(err, "failed to append FORWARD rule to filter chain")
-	}
-
-	err = ipt.AppendUnique("nat", "POSTROUTING", "-j", "WG_ACCESS_SERVER_POSTROUTING")
-	if err != nil {
-		return errors.Wrap(err, "failed to append POSTROUTING rule to nat chain")
-	}
-
-	if options.ClientIsolation {
-		// Reject inter-device traffic
-		if err := ipt.AppendUnique("filter", "WG_ACCESS_SERVER_FORWARD", "-s", options.CIDRv6, "-d", options.CIDRv6, "-j", "REJECT"); err != nil {
-			return errors.Wrap(err, "failed to set ip tables rule")
-		}
-	}
-	// Accept client traffic for given allowed ips
-	for _, allowedCIDR := range options.allowedIPv6s {
-		if err := ipt.AppendUnique("filter", "WG_ACCESS_SERVER_FORWARD", "-s", options.CIDRv6, "-d", allowedCIDR, "-j", "ACCEPT"); err != nil {
-			return errors.Wrap(err, "failed to set ip tables rule")
+// prepareForwarding parses the networks of the options
+func prepareForwarding(options ForwardingOptions) (ForwardingOptions, error) {
+	if options.CIDR != "" {
+		cidr, err := netip.ParsePrefix(options.CIDR)
+		if err != nil {
+			return options, errors.Wrap(err, "invalid vpn cidr")
 		}
+		options.cidr = cidr
 	}
-
-	// Accept return traffic when NAT is disabled
-	if !options.NAT66 {
-		for _, allowedCIDR := range options.allowedIPv6s {
-			if err := ipt.AppendUnique("filter", "WG_ACCESS_SERVER_FORWARD", "-s", allowedCIDR, "-d", options.CIDRv6, "-j", "ACCEPT"); err != nil {
-				return errors.Wrap(err, "failed to set ip tables rule for return traffic")
-			}
+	if options.CIDRv6 != "" {
+		cidr, err := netip.ParsePrefix(options.CIDRv6)
+		if err != nil {
+			return options, errors.Wrap(err, "invalid vpn cidrv6")
 		}
+		options.cidrv6 = cidr
 	}
 
-	// And reject everything else
-	if err := ipt.AppendUnique("filter", "WG_ACCESS_SERVER_FORWARD", "-s", options.CIDRv6, "-j", "REJECT"); err != nil {
-		return errors.Wrap(err, "failed to set ip tables rule")
-	}
+	// Firewall configuration to ensure that traffic from clients
+	// of the WireGuard interface is sent to the provided network interface
+	allowedIPv4s := make([]netip.Prefix, 0, len(options.AllowedIPs)/2)
+	allowedIPv6s := make([]netip.Prefix, 0, len(options.AllowedIPs)/2)
 
-	if options.GatewayIface != "" {
-		if options.NAT66 {
-			if err := ipt.AppendUnique("nat", "WG_ACCESS_SERVER_POSTROUTING", "-s", options.CIDRv6, "-o", options.GatewayIface, "-j", "MASQUERADE"); err != nil {
-				return errors.Wrap(err, "failed to set ip tables rule")
-			}
+	for _, allowedCIDR := range options.AllowedIPs {
+		prefix, err := netip.ParsePrefix(allowedCIDR)
+		if err != nil {
+			return options, errors.Wrap(err, "invalid cidr in AllowedIPs")
+		}
+		// Handle IPv4-mapped IPv6 addresses, if they go into ip6tables they don't get hit
+		// and go-iptables can't convert them (whereas commandline iptables can).
+		prefix = unmapPrefix(prefix)
+		if prefix.Addr().Is4() {
+			allowedIPv4s = append(allowedIPv4s, prefix)
+		} else {
+			allowedIPv6s = append(allowedIPv6s, prefix)
 		}
 	}
-	return nil
-}
+	options.allowedIPv4s = allowedIPv4s
+	options.allowedIPv6s = allowedIPv6s
 
-func clearOrCreateChain(ipt *iptables.IPTables, table, chain string) error {
-	exists, err := ipt.ChainExists(table, chain)
-	if err != nil {
-		return errors.Wrapf(err, "failed to read table %s", table)
-	}
-	if exists {
-		err = ipt.ClearChain(table, chain)
-		if err != nil {
-			return errors.Wrapf(err, "failed to clear chain %s in table %s", chain, table)
-		}
-	} else {
-		// Create our own chain for forwarding rules
-		err = ipt.NewChain(table, chain)
-		if err != nil {
-			return errors.Wrapf(err, "failed to create chain %s in table %s", chain, table)
+	for _, rule := range options.Rules {
+		if err := rule.Validate(); err != nil {
+			return options, errors.Wrap(err, "invalid forwarding rule")
 		}
 	}
-	return nil
+	return options, nil
 }
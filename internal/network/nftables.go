@@ -0,0 +1,316 @@
+package network
+
+import (
+	"encoding/binary"
+	"maps"
+	"net"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/nftables"
+	"github.com/google/nftables/expr"
+	"github.com/google/nftables/userdata"
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+// The nftables backend manages the rules of both address families in a single inet table.
+// The table is replaced in one transaction, so the traffic is never half-filtered.
+var (
+	nftTable       = &nftables.Table{Name: "wg_access_server", Family: nftables.TableFamilyINet}
+	nftForward     = &nftables.Chain{Name: "forward", Table: nftTable}
+	nftDevices     = &nftables.Chain{Name: "devices", Table: nftTable}
+	nftPostrouting = &nftables.Chain{Name: "postrouting", Table: nftTable}
+)
+
+// iptablesChains are the chains of the iptables backend, they are flushed if
+// they were left behind in the tables of the iptables-nft shim
+var iptablesChains = []string{"WG_ACCESS_SERVER_FORWARD", devicesChain, "WG_ACCESS_SERVER_POSTROUTING"}
+
+// nftablesFirewall installs the rules into the inet table of nftables.
+// The per-peer rules are tagged with the public key of their peer.
+type nftablesFirewall struct {
+	conn *nftables.Conn
+}
+
+func newNFTablesFirewall() (*nftablesFirewall, error) {
+	conn, err := nftables.New()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to init nftables")
+	}
+	return &nftablesFirewall{conn: conn}, nil
+}
+
+func (fw *nftablesFirewall) configure(options ForwardingOptions, peers map[string][]filterRule) error {
+	chains, err := fw.conn.ListChains()
+	if err != nil {
+		return errors.Wrap(err, "failed to list nftables chains")
+	}
+	for _, chain := range chains {
+		if chain.Table.Family != nftables.TableFamilyINet && slices.Contains(iptablesChains, chain.Name) {
+			fw.conn.FlushChain(chain)
+		}
+	}
+
+	// Adding the table before deleting it doesn't fail if it doesn't exist yet
+	fw.conn.AddTable(nftTable)
+	fw.conn.DelTable(nftTable)
+	fw.conn.AddTable(nftTable)
+	fw.conn.AddChain(&nftables.Chain{
+		Name:     nftForward.Name,
+		Table:    nftTable,
+		Type:     nftables.ChainTypeFilter,
+		Hooknum:  nftables.ChainHookForward,
+		Priority: nftables.ChainPriorityFilter,
+	})
+	fw.conn.AddChain(nftDevices)
+	fw.conn.AddChain(&nftables.Chain{
+		Name:     nftPostrouting.Name,
+		Table:    nftTable,
+		Type:     nftables.ChainTypeNAT,
+		Hooknum:  nftables.ChainHookPostrouting,
+		Priority: nftables.ChainPriorityNATSource,
+	})
+	for _, rule := range nftRules(options, peers) {
+		fw.conn.AddRule(rule)
+	}
+
+	if err := fw.conn.Flush(); err != nil {
+		return errors.Wrap(err, "failed to set nftables rules")
+	}
+	return nil
+}
+
+// nftRules returns the rules of all chains of the table
+func nftRules(options ForwardingOptions, peers map[string][]filterRule) []*nftables.Rule {
+	// Per-device rules take precedence over the rules below
+	rules := []*nftables.Rule{{
+		Table: nftTable,
+		Chain: nftForward,
+		Exprs: []expr.Any{&expr.Verdict{Kind: expr.VerdictJump, Chain: nftDevices.Name}},
+	}}
+	for _, family := range vpnFamilies(options) {
+		for _, rule := range forwardRules(family, options) {
+			for _, exprs := range nftRuleExprs(rule) {
+				rules = append(rules, &nftables.Rule{Table: nftTable, Chain: nftForward, Exprs: exprs})
+			}
+		}
+		if options.GatewayIface != "" && family.nat {
+			exprs := nftFamilyMatch(family.cidr.Addr().Is6())
+			exprs = append(exprs, nftPrefixMatch(family.cidr, true)...)
+			exprs = append(exprs,
+				&expr.Meta{Key: expr.MetaKeyOIFNAME, Register: 1},
+				&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: nftIfname(options.GatewayIface)},
+				&expr.Masq{},
+			)
+			rules = append(rules, &nftables.Rule{Table: nftTable, Chain: nftPostrouting, Exprs: exprs})
+		}
+	}
+	for publicKey, filters := range peers {
+		rules = append(rules, nftPeerRules(publicKey, filters)...)
+	}
+	return rules
+}
+
+func nftPeerRules(publicKey string, filters []filterRule) []*nftables.Rule {
+	rules := []*nftables.Rule{}
+	for _, filter := range filters {
+		for _, exprs := range nftRuleExprs(filter) {
+			rules = append(rules, &nftables.Rule{
+				Table:    nftTable,
+				Chain:    nftDevices,
+				Exprs:    exprs,
+				UserData: userdata.AppendString(nil, userdata.TypeComment, publicKey),
+			})
+		}
+	}
+	return rules
+}
+
+func (fw *nftablesFirewall) replacePeer(publicKey string, applied []filterRule, rules []filterRule, inUse func(filterRule) bool) error {
+	if len(applied) > 0 {
+		existing, err := fw.conn.GetRules(nftTable, nftDevices)
+		if err != nil {
+			return errors.Wrapf(err, "failed to list nftables rules for peer %s", publicKey)
+		}
+		for _, rule := range existing {
+			if comment, ok := userdata.GetString(rule.UserData, userdata.TypeComment); ok && comment == publicKey {
+				if err := fw.conn.DelRule(rule); err != nil {
+					return errors.Wrapf(err, "failed to remove nftables rule for peer %s", publicKey)
+				}
+			}
+		}
+	}
+	for _, rule := range nftPeerRules(publicKey, rules) {
+		fw.conn.AddRule(rule)
+	}
+	// the rules of the peer are replaced in one transaction
+	if err := fw.conn.Flush(); err != nil {
+		return errors.Wrapf(err, "failed to set nftables rules for peer %s", publicKey)
+	}
+	return nil
+}
+
+func (fw *nftablesFirewall) drifted(options ForwardingOptions, peers map[string][]filterRule) (bool, error) {
+	chains, err := fw.conn.ListChainsOfTableFamily(nftables.TableFamilyINet)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to list nftables chains")
+	}
+	for _, name := range []string{nftForward.Name, nftDevices.Name, nftPostrouting.Name} {
+		if !slices.ContainsFunc(chains, func(chain *nftables.Chain) bool {
+			return chain.Table.Name == nftTable.Name && chain.Name == name
+		}) {
+			return true, nil
+		}
+	}
+
+	desired := map[string][]*nftables.Rule{}
+	for _, rule := range nftRules(options, peers) {
+		desired[rule.Chain.Name] = append(desired[rule.Chain.Name], rule)
+	}
+	for _, chain := range []*nftables.Chain{nftForward, nftDevices, nftPostrouting} {
+		installed, err := fw.conn.GetRules(nftTable, chain)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to list nftables chain %s", chain.Name)
+		}
+		if !maps.EqualFunc(nftRuleKeys(installed), nftRuleKeys(desired[chain.Name]), slices.Equal) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// nftRuleKeys returns the marshalled expressions of the rules in order by their comment.
+// The rules of different peers may be in any order.
+func nftRuleKeys(rules []*nftables.Rule) map[string][]string {
+	keys := map[string][]string{}
+	for _, rule := range rules {
+		comment, _ := userdata.GetString(rule.UserData, userdata.TypeComment)
+		key := []byte{}
+		for _, e := range rule.Exprs {
+			// expressions that can't be marshalled don't match any desired rule
+			data, err := expr.Marshal(byte(nftTable.Family), e)
+			if err != nil {
+				data = []byte(err.Error())
+			}
+			key = append(key, data...)
+		}
+		keys[comment] = append(keys[comment], string(key))
+	}
+	return keys
+}
+
+func (fw *nftablesFirewall) teardown(options ForwardingOptions) error {
+	fw.conn.AddTable(nftTable)
+	fw.conn.DelTable(nftTable)
+	if err := fw.conn.Flush(); err != nil {
+		return errors.Wrap(err, "failed to delete nftables table")
+	}
+	return nil
+}
+
+// nftRuleExprs renders the expressions of a filter rule. A rule with multiple
+// ports or port ranges is rendered into one nftables rule per range.
+func nftRuleExprs(rule filterRule) [][]expr.Any {
+	match := nftFamilyMatch(rule.is6())
+	if rule.source.IsValid() {
+		match = append(match, nftPrefixMatch(rule.source, true)...)
+	}
+	if rule.destination.IsValid() {
+		match = append(match, nftPrefixMatch(rule.destination, false)...)
+	}
+	if rule.protocol != "" {
+		match = append(match,
+			&expr.Meta{Key: expr.MetaKeyL4PROTO, Register: 1},
+			&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: []byte{nftProtocols[rule.protocol]}},
+		)
+	}
+
+	var verdict expr.Any = &expr.Reject{Type: unix.NFT_REJECT_ICMPX_UNREACH, Code: unix.NFT_REJECT_ICMPX_PORT_UNREACH}
+	if rule.accept {
+		verdict = &expr.Verdict{Kind: expr.VerdictAccept}
+	}
+	if rule.ports == "" {
+		return [][]expr.Any{append(match, verdict)}
+	}
+
+	// the source port is the first field of the tcp and udp headers, the destination port the second
+	offset := uint32(2)
+	if rule.sourcePorts {
+		offset = 0
+	}
+	rules := [][]expr.Any{}
+	for _, portRange := range strings.Split(rule.ports, ",") {
+		ports := strings.SplitN(portRange, "-", 2)
+		exprs := append(slices.Clone(match), &expr.Payload{DestRegister: 1, Base: expr.PayloadBaseTransportHeader, Offset: offset, Len: 2})
+		from := nftPort(ports[0])
+		if len(ports) == 1 {
+			exprs = append(exprs, &expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: from})
+		} else {
+			exprs = append(exprs, &expr.Range{Op: expr.CmpOpEq, Register: 1, FromData: from, ToData: nftPort(ports[1])})
+		}
+		rules = append(rules, append(exprs, verdict))
+	}
+	return rules
+}
+
+var nftProtocols = map[string]byte{
+	"tcp":    unix.IPPROTO_TCP,
+	"udp":    unix.IPPROTO_UDP,
+	"icmp":   unix.IPPROTO_ICMP,
+	"icmpv6": unix.IPPROTO_ICMPV6,
+}
+
+// nftFamilyMatch matches the address family, the network header differs between them
+func nftFamilyMatch(ipv6 bool) []expr.Any {
+	family := byte(unix.NFPROTO_IPV4)
+	if ipv6 {
+		family = unix.NFPROTO_IPV6
+	}
+	return []expr.Any{
+		&expr.Meta{Key: expr.MetaKeyNFPROTO, Register: 1},
+		&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: []byte{family}},
+	}
+}
+
+// nftPrefixMatch matches the source or destination address of the network header against the prefix
+func nftPrefixMatch(prefix netip.Prefix, source bool) []expr.Any {
+	prefix = prefix.Masked()
+	if prefix.Bits() == 0 {
+		return nil
+	}
+	// offsets of the addresses in the IPv4 and IPv6 headers
+	size, offset := uint32(4), uint32(16)
+	if prefix.Addr().Is6() {
+		size, offset = 16, 24
+	}
+	if source {
+		offset -= size
+	}
+	exprs := []expr.Any{&expr.Payload{DestRegister: 1, Base: expr.PayloadBaseNetworkHeader, Offset: offset, Len: size}}
+	if bits := prefix.Addr().BitLen(); prefix.Bits() < bits {
+		exprs = append(exprs, &expr.Bitwise{
+			SourceRegister: 1,
+			DestRegister:   1,
+			Len:            size,
+			Mask:           net.CIDRMask(prefix.Bits(), bits),
+			Xor:            make([]byte, size),
+		})
+	}
+	return append(exprs, &expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: prefix.Addr().AsSlice()})
+}
+
+func nftPort(port string) []byte {
+	// the ports are validated by Rule.Validate
+	p, _ := strconv.ParseUint(port, 10, 16)
+	return binary.BigEndian.AppendUint16(nil, uint16(p))
+}
+
+// nftIfname pads the interface name like the kernel compares it
+func nftIfname(name string) []byte {
+	b := make([]byte, unix.IFNAMSIZ)
+	copy(b, name)
+	return b
+}
@@ -0,0 +1,85 @@
+package network
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/google/nftables/expr"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func TestForwardRules(t *testing.T) {
+	require := require.New(t)
+
+	cidr := netip.MustParsePrefix("10.44.0.0/24")
+	allowed := []netip.Prefix{netip.MustParsePrefix("192.168.1.0/24")}
+
+	require.Equal([][]string{
+		{"-s", "10.44.0.0/24", "-d", "10.44.0.0/24", "-j", "REJECT"},
+		{"-s", "10.44.0.0/24", "-d", "192.168.1.0/24", "-j", "ACCEPT"},
+		{"-s", "192.168.1.0/24", "-d", "10.44.0.0/24", "-j", "ACCEPT"},
+		{"-s", "10.44.0.0/24", "-j", "REJECT"},
+	}, iptablesSpecs(forwardRules(vpnFamily{cidr, allowed, false}, ForwardingOptions{ClientIsolation: true})))
+
+	require.Equal([][]string{
+		{"-s", "10.44.0.0/24", "-d", "192.168.1.0/24", "-j", "ACCEPT"},
+		{"-s", "10.44.0.0/24", "-j", "REJECT"},
+	}, iptablesSpecs(forwardRules(vpnFamily{cidr, allowed, true}, ForwardingOptions{})))
+
+	options := ForwardingOptions{Rules: []Rule{
+		{CIDR: "10.0.0.5/32", Protocol: "tcp", Ports: "22", Action: RuleReject},
+		{CIDR: "10.0.0.0/24", Protocol: "tcp", Ports: "22, 443"},
+		{CIDR: "fd00::/64"},
+	}}
+	require.Equal([][]string{
+		{"-s", "10.44.0.0/24", "-d", "10.0.0.5/32", "-p", "tcp", "-m", "multiport", "--dports", "22", "-j", "REJECT"},
+		{"-s", "10.44.0.0/24", "-d", "10.0.0.0/24", "-p", "tcp", "-m", "multiport", "--dports", "22,443", "-j", "ACCEPT"},
+		{"-s", "10.0.0.0/24", "-d", "10.44.0.0/24", "-p", "tcp", "-m", "multiport", "--sports", "22,443", "-j", "ACCEPT"},
+		{"-s", "10.44.0.0/24", "-j", "REJECT"},
+	}, iptablesSpecs(forwardRules(vpnFamily{cidr: cidr}, options)))
+}
+
+func TestNFTRuleExprs(t *testing.T) {
+	require := require.New(t)
+
+	rules := nftRuleExprs(filterRule{
+		source:      netip.MustParsePrefix("10.0.0.0/24"),
+		destination: netip.MustParsePrefix("10.44.0.2/32"),
+		protocol:    "tcp",
+		ports:       "22,8000-8080",
+		sourcePorts: true,
+		accept:      true,
+	})
+	match := []expr.Any{
+		&expr.Meta{Key: expr.MetaKeyNFPROTO, Register: 1},
+		&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: []byte{unix.NFPROTO_IPV4}},
+		&expr.Payload{DestRegister: 1, Base: expr.PayloadBaseNetworkHeader, Offset: 12, Len: 4},
+		&expr.Bitwise{SourceRegister: 1, DestRegister: 1, Len: 4, Mask: []byte{255, 255, 255, 0}, Xor: []byte{0, 0, 0, 0}},
+		&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: []byte{10, 0, 0, 0}},
+		&expr.Payload{DestRegister: 1, Base: expr.PayloadBaseNetworkHeader, Offset: 16, Len: 4},
+		&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: []byte{10, 44, 0, 2}},
+		&expr.Meta{Key: expr.MetaKeyL4PROTO, Register: 1},
+		&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: []byte{unix.IPPROTO_TCP}},
+		&expr.Payload{DestRegister: 1, Base: expr.PayloadBaseTransportHeader, Offset: 0, Len: 2},
+	}
+	require.Equal([][]expr.Any{
+		append(match[:len(match):len(match)],
+			&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: []byte{0, 22}},
+			&expr.Verdict{Kind: expr.VerdictAccept},
+		),
+		append(match[:len(match):len(match)],
+			&expr.Range{Op: expr.CmpOpEq, Register: 1, FromData: []byte{0x1f, 0x40}, ToData: []byte{0x1f, 0x90}},
+			&expr.Verdict{Kind: expr.VerdictAccept},
+		),
+	}, rules)
+
+	rules = nftRuleExprs(filterRule{source: netip.MustParsePrefix("fd48::2/128")})
+	require.Equal([][]expr.Any{{
+		&expr.Meta{Key: expr.MetaKeyNFPROTO, Register: 1},
+		&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: []byte{unix.NFPROTO_IPV6}},
+		&expr.Payload{DestRegister: 1, Base: expr.PayloadBaseNetworkHeader, Offset: 8, Len: 16},
+		&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: netip.MustParseAddr("fd48::2").AsSlice()},
+		&expr.Reject{Type: unix.NFT_REJECT_ICMPX_UNREACH, Code: unix.NFT_REJECT_ICMPX_PORT_UNREACH},
+	}}, rules)
+}
@@ -0,0 +1,341 @@
+package network
+
+import (
+	"fmt"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Actions of a rule
+const (
+	RuleAccept = "accept"
+	RuleReject = "reject"
+)
+
+// Rule allows (or rejects) forwarding traffic to a destination network,
+// optionally narrowed down to a single protocol and a set of destination ports
+type Rule struct {
+	// CIDR is the destination network
+	CIDR string
+	// Protocol is matched if set, e.g. "tcp", "udp" or "icmp"
+	Protocol string
+	// Ports is a comma separated list of ports or port ranges,
+	// e.g. "53" or "443,8000-8080". Requires Protocol to be tcp or udp.
+	Ports string
+	// Action is RuleAccept or RuleReject, empty accepts the traffic
+	Action string
+}
+
+// Validate checks that the rule can be rendered into firewall rules
+func (r Rule) Validate() error {
+	prefix, err := netip.ParsePrefix(r.CIDR)
+	if err != nil {
+		return errors.Wrapf(err, "invalid cidr '%s' in rule", r.CIDR)
+	}
+	switch r.Protocol {
+	case "", "tcp", "udp":
+	case "icmp":
+		if !prefix.Addr().Unmap().Is4() {
+			return fmt.Errorf("protocol icmp requires an IPv4 cidr, use icmpv6 for '%s'", r.CIDR)
+		}
+	case "icmpv6":
+		if prefix.Addr().Unmap().Is4() {
+			return fmt.Errorf("protocol icmpv6 requires an IPv6 cidr, use icmp for '%s'", r.CIDR)
+		}
+	default:
+		return fmt.Errorf("unsupported protocol '%s' in rule", r.Protocol)
+	}
+	switch r.Action {
+	case "", RuleAccept, RuleReject:
+	default:
+		return fmt.Errorf("unsupported action '%s' in rule", r.Action)
+	}
+	if r.Ports == "" {
+		return nil
+	}
+	if r.Protocol != "tcp" && r.Protocol != "udp" {
+		return fmt.Errorf("ports can only be used together with the tcp or udp protocol")
+	}
+	ranges := SplitAddresses(r.Ports)
+	if len(ranges) > 15 {
+		return fmt.Errorf("at most 15 ports or port ranges are allowed per rule")
+	}
+	for _, portRange := range ranges {
+		for _, port := range strings.SplitN(portRange, "-", 2) {
+			if p, err := strconv.ParseUint(port, 10, 16); err != nil || p == 0 {
+				return fmt.Errorf("invalid port '%s' in rule", port)
+			}
+		}
+	}
+	return nil
+}
+
+// PeerRules contains the rules that apply to the traffic of a single peer
+type PeerRules struct {
+	// PublicKey identifies the peer
+	PublicKey string
+	// Addresses are the peer's VPN addresses (e.g. "10.44.0.2/32, fd48:4c4:7aa9::2/128")
+	Addresses string
+	// Rules are the destinations the peer may reach.
+	// Everything else from the peer is rejected.
+	Rules []Rule
+	// ClientIsolation rejects traffic from the peer to other clients
+	ClientIsolation bool
+	// Destinations narrow the traffic of the peer down to these networks,
+	// e.g. the AllowedIPs of its client config profile. The Rules, or the
+	// global forwarding rules if there are none, only apply within them.
+	Destinations []string
+}
+
+// PeerFirewall maintains per-peer firewall rules which take precedence
+// over the global forwarding rules set up by ConfigureForwarding
+type PeerFirewall struct {
+	options ForwardingOptions
+	backend firewallBackend
+	// applied rules per peer public key, needed to remove them again
+	applied map[string][]filterRule
+	// closed after the rules were removed on shutdown
+	closed  bool
+	metrics *firewallMetrics
+	lock    sync.Mutex
+}
+
+// NewPeerFirewall creates a PeerFirewall for the chains set up by ConfigureForwarding.
+// A nil *PeerFirewall is valid and ignores all peers.
+func NewPeerFirewall(options ForwardingOptions) (*PeerFirewall, error) {
+	if options.DisableIPTables {
+		return nil, nil
+	}
+	options, err := prepareForwarding(options)
+	if err != nil {
+		return nil, err
+	}
+	backend, err := newFirewallBackend(options)
+	if err != nil {
+		return nil, err
+	}
+	return &PeerFirewall{
+		options: options,
+		backend: backend,
+		applied: make(map[string][]filterRule),
+		metrics: newFirewallMetrics(),
+	}, nil
+}
+
+// AddPeer installs the rules of a peer, replacing any rules previously installed for it.
+// Peers without rules, destinations or client isolation are only subject to the global forwarding rules.
+func (fw *PeerFirewall) AddPeer(peer PeerRules) error {
+	if fw == nil {
+		return nil
+	}
+	fw.lock.Lock()
+	defer fw.lock.Unlock()
+	if fw.closed {
+		return nil
+	}
+
+	peerRules, isolation := peer.Rules, peer.ClientIsolation
+	restricted := len(peerRules) > 0
+	if len(peer.Destinations) > 0 {
+		if !restricted {
+			// The rules of the peer are evaluated before the global ones,
+			// so the global rules have to be repeated within the destinations
+			peerRules = fw.globalRules()
+		}
+		narrowed, err := narrowRules(peerRules, peer.Destinations)
+		if err != nil {
+			return errors.Wrapf(err, "invalid destinations of peer %s", peer.PublicKey)
+		}
+		peerRules, restricted = narrowed, true
+	}
+	if restricted {
+		// The global client isolation must not be bypassed by the rules of the peer
+		isolation = isolation || fw.options.ClientIsolation
+	}
+
+	rules := []filterRule{}
+	if restricted || isolation {
+		for _, addr := range SplitAddresses(peer.Addresses) {
+			prefix, err := netip.ParsePrefix(addr)
+			if err != nil {
+				return errors.Wrapf(err, "invalid peer address '%s'", addr)
+			}
+			cidr, nat := fw.options.cidr, fw.options.NAT44
+			if prefix.Addr().Is6() {
+				cidr, nat = fw.options.cidrv6, fw.options.NAT66
+			}
+			if !cidr.IsValid() {
+				continue
+			}
+			isolate := netip.Prefix{}
+			if isolation {
+				isolate = cidr
+			}
+			rules = append(rules, peerFilterRules(prefix, peerRules, restricted, nat, isolate)...)
+		}
+	}
+
+	// Devices are saved (and thus added) again on every metadata update,
+	// don't touch the rules if nothing changed.
+	applied, ok := fw.applied[peer.PublicKey]
+	if ok && slices.Equal(applied, rules) {
+		return nil
+	}
+	if !ok && len(rules) == 0 {
+		return nil
+	}
+	delete(fw.applied, peer.PublicKey)
+	if len(rules) > 0 {
+		fw.applied[peer.PublicKey] = rules
+	}
+	// on errors the rules stay recorded, so they are removed with the peer
+	return fw.backend.replacePeer(peer.PublicKey, applied, rules, fw.ruleInUse(peer.PublicKey))
+}
+
+// RemovePeer deletes all rules that were installed for a peer
+func (fw *PeerFirewall) RemovePeer(publicKey string) error {
+	if fw == nil {
+		return nil
+	}
+	fw.lock.Lock()
+	defer fw.lock.Unlock()
+
+	applied, ok := fw.applied[publicKey]
+	if !ok || fw.closed {
+		return nil
+	}
+	delete(fw.applied, publicKey)
+	return fw.backend.replacePeer(publicKey, applied, nil, fw.ruleInUse(publicKey))
+}
+
+// Close removes the forwarding rules, the per-peer rules and the chains of the firewall.
+// Peers added afterwards are ignored.
+func (fw *PeerFirewall) Close() error {
+	if fw == nil {
+		return nil
+	}
+	fw.lock.Lock()
+	defer fw.lock.Unlock()
+	if fw.closed {
+		return nil
+	}
+	fw.closed = true
+	return fw.backend.teardown(fw.options)
+}
+
+// ruleInUse returns a function that reports whether a rule is applied for a peer other than publicKey
+func (fw *PeerFirewall) ruleInUse(publicKey string) func(filterRule) bool {
+	return func(rule filterRule) bool {
+		for key, rules := range fw.applied {
+			if key != publicKey && slices.Contains(rules, rule) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// globalRules returns the global forwarding rules followed by the AllowedIPs, in the order they are evaluated
+func (fw *PeerFirewall) globalRules() []Rule {
+	rules := slices.Clone(fw.options.Rules)
+	for _, allowed := range fw.options.AllowedIPs {
+		rules = append(rules, Rule{CIDR: allowed})
+	}
+	return rules
+}
+
+// narrowRules restricts the destinations of the rules to the given networks.
+// Rules outside of all networks are dropped, the order of the rules is kept.
+func narrowRules(rules []Rule, networks []string) ([]Rule, error) {
+	prefixes := make([]netip.Prefix, 0, len(networks))
+	for _, network := range networks {
+		prefix, err := netip.ParsePrefix(network)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid destination '%s'", network)
+		}
+		prefixes = append(prefixes, unmapPrefix(prefix))
+	}
+	narrowed := []Rule{}
+	for _, rule := range rules {
+		dest, err := netip.ParsePrefix(rule.CIDR)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid cidr '%s' in rule", rule.CIDR)
+		}
+		dest = unmapPrefix(dest)
+		for _, prefix := range prefixes {
+			if !dest.Overlaps(prefix) {
+				continue
+			}
+			// Overlapping prefixes contain each other, the longer one is the intersection
+			r := rule
+			if prefix.Bits() > dest.Bits() {
+				r.CIDR = prefix.String()
+			}
+			narrowed = append(narrowed, r)
+		}
+	}
+	return narrowed, nil
+}
+
+// peerFilterRules returns the filter rules for a single peer address.
+// Rules of the other address family are skipped.
+// If isolate is valid, traffic to that network (the VPN subnet) is rejected first.
+// Unless restricted, the rules are ignored and the global rules decide about the traffic.
+func peerFilterRules(peer netip.Prefix, rules []Rule, restricted bool, nat bool, isolate netip.Prefix) []filterRule {
+	filters := []filterRule{}
+	if isolate.IsValid() {
+		filters = append(filters, filterRule{source: peer, destination: isolate})
+	}
+	if !restricted {
+		// Only isolation, the global rules decide about everything else
+		return filters
+	}
+	for _, rule := range rules {
+		filter, ok := rule.filter(peer)
+		if !ok {
+			continue
+		}
+		filters = append(filters, filter)
+
+		// Accept return traffic when NAT is disabled
+		if !nat && filter.accept {
+			filters = append(filters, filter.reverse())
+		}
+	}
+	// And reject everything else from this peer
+	return append(filters, filterRule{source: peer})
+}
+
+// filter returns the filter rule for the traffic from source to the destination of the rule.
+// It reports false if the destination is of another address family.
+func (r Rule) filter(source netip.Prefix) (filterRule, bool) {
+	dest, err := netip.ParsePrefix(r.CIDR)
+	if err != nil || dest.Addr().Unmap().Is4() != source.Addr().Is4() {
+		return filterRule{}, false
+	}
+	dest = unmapPrefix(dest)
+	ports := ""
+	if r.Ports != "" {
+		ports = strings.Join(SplitAddresses(r.Ports), ",")
+	}
+	return filterRule{source: source, destination: dest, protocol: r.Protocol, ports: ports, accept: r.Action != RuleReject}, true
+}
+
+// bitsOffset returns the number of prefix bits that belong to the
+// IPv4-mapped IPv6 prefix (::ffff:0:0/96) when unmapping an address
+func bitsOffset(prefix netip.Prefix) int {
+	if prefix.Addr().Is4In6() {
+		return 96
+	}
+	return 0
+}
+
+// unmapPrefix converts IPv4-mapped IPv6 networks into IPv4 networks
+func unmapPrefix(prefix netip.Prefix) netip.Prefix {
+	return netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-bitsOffset(prefix)).Masked()
+}
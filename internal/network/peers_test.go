@@ -0,0 +1,92 @@
+package network
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuleValidate(t *testing.T) {
+	require := require.New(t)
+
+	require.NoError(Rule{CIDR: "10.0.0.0/24"}.Validate())
+	require.NoError(Rule{CIDR: "10.0.0.0/24", Protocol: "tcp", Ports: "22, 8000-8080"}.Validate())
+	require.NoError(Rule{CIDR: "fd00::/64", Protocol: "icmpv6"}.Validate())
+	require.NoError(Rule{CIDR: "10.0.0.5/32", Protocol: "udp", Ports: "53", Action: RuleReject}.Validate())
+
+	require.Error(Rule{CIDR: "10.0.0.0"}.Validate())
+	require.Error(Rule{CIDR: "10.0.0.0/24", Protocol: "sctp"}.Validate())
+	require.Error(Rule{CIDR: "10.0.0.0/24", Protocol: "icmp", Ports: "22"}.Validate())
+	require.Error(Rule{CIDR: "10.0.0.0/24", Protocol: "tcp", Ports: "0"}.Validate())
+	require.Error(Rule{CIDR: "10.0.0.0/24", Protocol: "udp", Ports: "53-70000"}.Validate())
+	require.Error(Rule{CIDR: "fd00::/64", Protocol: "icmp"}.Validate())
+	require.Error(Rule{CIDR: "10.0.0.0/24", Action: "drop"}.Validate())
+}
+
+func TestPeerRuleSpecs(t *testing.T) {
+	require := require.New(t)
+
+	rules := []Rule{
+		{CIDR: "10.0.0.0/24", Protocol: "tcp", Ports: "22, 8000-8080"},
+		{CIDR: "::ffff:192.168.1.0/120"},
+		{CIDR: "fd00::/64"},
+	}
+
+	specs := iptablesSpecs(peerFilterRules(netip.MustParsePrefix("10.44.0.2/32"), rules, true, true, netip.Prefix{}))
+	require.Equal([][]string{
+		{"-s", "10.44.0.2/32", "-d", "10.0.0.0/24", "-p", "tcp", "-m", "multiport", "--dports", "22,8000:8080", "-j", "ACCEPT"},
+		{"-s", "10.44.0.2/32", "-d", "192.168.1.0/24", "-j", "ACCEPT"},
+		{"-s", "10.44.0.2/32", "-j", "REJECT"},
+	}, specs)
+
+	specs = iptablesSpecs(peerFilterRules(netip.MustParsePrefix("fd48::2/128"), rules, true, false, netip.Prefix{}))
+	require.Equal([][]string{
+		{"-s", "fd48::2/128", "-d", "fd00::/64", "-j", "ACCEPT"},
+		{"-s", "fd00::/64", "-d", "fd48::2/128", "-j", "ACCEPT"},
+		{"-s", "fd48::2/128", "-j", "REJECT"},
+	}, specs)
+
+	specs = iptablesSpecs(peerFilterRules(netip.MustParsePrefix("10.44.0.2/32"), nil, false, true, netip.MustParsePrefix("10.44.0.0/24")))
+	require.Equal([][]string{
+		{"-s", "10.44.0.2/32", "-d", "10.44.0.0/24", "-j", "REJECT"},
+	}, specs)
+}
+
+func TestNarrowRules(t *testing.T) {
+	require := require.New(t)
+
+	rules := []Rule{
+		{CIDR: "10.0.0.5/32", Protocol: "tcp", Ports: "22", Action: RuleReject},
+		{CIDR: "0.0.0.0/0"},
+		{CIDR: "::/0"},
+	}
+	narrowed, err := narrowRules(rules, []string{"10.0.0.0/24", "192.168.1.0/24"})
+	require.NoError(err)
+	require.Equal([]Rule{
+		{CIDR: "10.0.0.5/32", Protocol: "tcp", Ports: "22", Action: RuleReject},
+		{CIDR: "10.0.0.0/24"},
+		{CIDR: "192.168.1.0/24"},
+	}, narrowed)
+
+	narrowed, err = narrowRules([]Rule{{CIDR: "10.0.0.0/24"}}, []string{"fd00::/64"})
+	require.NoError(err)
+	require.Empty(narrowed)
+
+	// Without any rule within the destinations all traffic of the peer is rejected
+	specs := iptablesSpecs(peerFilterRules(netip.MustParsePrefix("10.44.0.2/32"), narrowed, true, true, netip.Prefix{}))
+	require.Equal([][]string{
+		{"-s", "10.44.0.2/32", "-j", "REJECT"},
+	}, specs)
+
+	_, err = narrowRules(rules, []string{"10.0.0.0"})
+	require.Error(err)
+}
+
+func iptablesSpecs(rules []filterRule) [][]string {
+	specs := [][]string{}
+	for _, rule := range rules {
+		specs = append(specs, iptablesSpec(rule))
+	}
+	return specs
+}
@@ -0,0 +1,89 @@
+package network
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+type firewallMetrics struct {
+	drift  prometheus.Counter
+	errors prometheus.Counter
+}
+
+func newFirewallMetrics() *firewallMetrics {
+	return &firewallMetrics{
+		drift: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "wg_access_server",
+			Subsystem: "firewall",
+			Name:      "drift_total",
+			Help:      "Reconciliations that found the firewall rules drifted from the configuration and re-applied them.",
+		}),
+		errors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "wg_access_server",
+			Subsystem: "firewall",
+			Name:      "reconcile_errors_total",
+			Help:      "Reconciliations that failed to read or re-apply the firewall rules.",
+		}),
+	}
+}
+
+// Collectors returns the Prometheus metrics of the firewall reconciliation
+func (fw *PeerFirewall) Collectors() []prometheus.Collector {
+	if fw == nil {
+		return nil
+	}
+	return []prometheus.Collector{fw.metrics.drift, fw.metrics.errors}
+}
+
+// StartReconcile periodically restores the firewall rules if they drifted,
+// e.g. because someone flushed the chains. An interval of 0 disables it.
+func (fw *PeerFirewall) StartReconcile(interval time.Duration) {
+	if fw == nil || interval <= 0 {
+		return
+	}
+	go reconcileLoop(fw, interval)
+}
+
+func reconcileLoop(fw *PeerFirewall, interval time.Duration) {
+	for {
+		time.Sleep(interval)
+		drifted, err := fw.Reconcile()
+		if drifted {
+			logrus.Warn("Firewall rules drifted from the configuration, re-applying them")
+		}
+		if err != nil {
+			logrus.Error(err)
+		}
+	}
+}
+
+// Reconcile compares the installed forwarding and per-peer rules with the configuration
+// and re-applies all of them if they differ. It reports whether the rules drifted.
+func (fw *PeerFirewall) Reconcile() (bool, error) {
+	if fw == nil {
+		return false, nil
+	}
+	fw.lock.Lock()
+	defer fw.lock.Unlock()
+	if fw.closed {
+		return false, nil
+	}
+
+	drifted, err := fw.backend.drifted(fw.options, fw.applied)
+	if err != nil {
+		fw.metrics.errors.Inc()
+		return false, errors.Wrap(err, "failed to read the firewall rules")
+	}
+	if !drifted {
+		return false, nil
+	}
+	fw.metrics.drift.Inc()
+	if err := fw.backend.configure(fw.options, fw.applied); err != nil {
+		fw.metrics.errors.Inc()
+		return true, errors.Wrap(err, "failed to re-apply the firewall rules")
+	}
+	return true, nil
+}
@@ -0,0 +1,87 @@
+package network
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeFirewall records the rules like an installed ruleset
+type fakeFirewall struct {
+	installed  map[string][]filterRule
+	configured int
+}
+
+func (f *fakeFirewall) configure(options ForwardingOptions, peers map[string][]filterRule) error {
+	f.configured++
+	f.installed = map[string][]filterRule{}
+	for publicKey, rules := range peers {
+		f.installed[publicKey] = rules
+	}
+	return nil
+}
+
+func (f *fakeFirewall) replacePeer(publicKey string, applied []filterRule, rules []filterRule, inUse func(filterRule) bool) error {
+	if len(rules) == 0 {
+		delete(f.installed, publicKey)
+	} else {
+		f.installed[publicKey] = rules
+	}
+	return nil
+}
+
+func (f *fakeFirewall) drifted(options ForwardingOptions, peers map[string][]filterRule) (bool, error) {
+	return len(f.installed) != len(peers), nil
+}
+
+func (f *fakeFirewall) teardown(options ForwardingOptions) error {
+	f.installed = nil
+	return nil
+}
+
+func TestReconcile(t *testing.T) {
+	require := require.New(t)
+
+	backend := &fakeFirewall{installed: map[string][]filterRule{}}
+	options, err := prepareForwarding(ForwardingOptions{CIDR: "10.44.0.0/24"})
+	require.NoError(err)
+	fw := &PeerFirewall{options: options, backend: backend, applied: map[string][]filterRule{}, metrics: newFirewallMetrics()}
+
+	require.NoError(fw.AddPeer(PeerRules{PublicKey: "a", Addresses: "10.44.0.2/32", ClientIsolation: true}))
+	drifted, err := fw.Reconcile()
+	require.NoError(err)
+	require.False(drifted)
+
+	// someone flushed the rules
+	backend.installed = map[string][]filterRule{}
+	drifted, err = fw.Reconcile()
+	require.NoError(err)
+	require.True(drifted)
+	require.Equal(1, backend.configured)
+	require.Equal([]filterRule{{source: netip.MustParsePrefix("10.44.0.2/32"), destination: netip.MustParsePrefix("10.44.0.0/24")}}, backend.installed["a"])
+	require.Equal(1.0, testutil.ToFloat64(fw.metrics.drift))
+
+	require.NoError(fw.Close())
+	require.Nil(backend.installed)
+	drifted, err = fw.Reconcile()
+	require.NoError(err)
+	require.False(drifted)
+	require.NoError(fw.AddPeer(PeerRules{PublicKey: "b", Addresses: "10.44.0.3/32", ClientIsolation: true}))
+	require.Nil(backend.installed)
+}
+
+func TestGlobalClientIsolationFirst(t *testing.T) {
+	require := require.New(t)
+
+	backend := &fakeFirewall{installed: map[string][]filterRule{}}
+	options, err := prepareForwarding(ForwardingOptions{CIDR: "10.44.0.0/24", NAT44: true, ClientIsolation: true})
+	require.NoError(err)
+	fw := &PeerFirewall{options: options, backend: backend, applied: map[string][]filterRule{}, metrics: newFirewallMetrics()}
+
+	// the policy of the peer allows everything, including the other clients
+	require.NoError(fw.AddPeer(PeerRules{PublicKey: "a", Addresses: "10.44.0.2/32", Rules: []Rule{{CIDR: "0.0.0.0/0"}}}))
+	peer, vpn := netip.MustParsePrefix("10.44.0.2/32"), netip.MustParsePrefix("10.44.0.0/24")
+	require.Equal(filterRule{source: peer, destination: vpn}, backend.installed["a"][0])
+}
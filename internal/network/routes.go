@@ -0,0 +1,116 @@
+package network
+
+import (
+	"net"
+	"net/netip"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// routeProtocol marks the kernel routes of the Router,
+// so that the routes of a previous run can be told apart from other routes of the interface
+const routeProtocol netlink.RouteProtocol = 0x77
+
+// Router installs kernel routes to the WireGuard interface for the networks routed to peers,
+// e.g. the LAN of a gateway device. WireGuard picks the peer of a network by its allowed IPs.
+type Router struct {
+	link netlink.Link
+	// owners of the routed networks by public key, a network moves to the new key when a peer is re-keyed
+	routes map[netip.Prefix]string
+	lock   sync.Mutex
+}
+
+// NewRouter removes the routes of a previous run from the interface.
+// A nil *Router is valid and routes nothing.
+func NewRouter(iface string) (*Router, error) {
+	link, err := netlink.LinkByName(iface)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find interface %s", iface)
+	}
+	filter := &netlink.Route{LinkIndex: link.Attrs().Index, Protocol: routeProtocol}
+	for _, family := range []int{netlink.FAMILY_V4, netlink.FAMILY_V6} {
+		routes, err := netlink.RouteListFiltered(family, filter, netlink.RT_FILTER_OIF|netlink.RT_FILTER_PROTOCOL)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list routes")
+		}
+		for _, route := range routes {
+			if err := netlink.RouteDel(&route); err != nil {
+				return nil, errors.Wrapf(err, "failed to remove route %s", route.Dst)
+			}
+		}
+	}
+	return &Router{
+		link:   link,
+		routes: make(map[netip.Prefix]string),
+	}, nil
+}
+
+// SetRoutes routes the networks (e.g. "192.168.10.0/24, fd00:10::/64") to the interface for a peer,
+// replacing the networks previously routed for it
+func (r *Router) SetRoutes(publicKey string, networks string) error {
+	if r == nil {
+		return nil
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	prefixes := map[netip.Prefix]bool{}
+	for _, network := range SplitAddresses(networks) {
+		prefix, err := netip.ParsePrefix(network)
+		if err != nil {
+			return errors.Wrapf(err, "invalid route '%s'", network)
+		}
+		prefix = unmapPrefix(prefix)
+		if err := netlink.RouteReplace(r.route(prefix)); err != nil {
+			return errors.Wrapf(err, "failed to add route %s for peer %s", prefix, publicKey)
+		}
+		r.routes[prefix] = publicKey
+		prefixes[prefix] = true
+	}
+	for prefix, owner := range r.routes {
+		if owner == publicKey && !prefixes[prefix] {
+			if err := r.remove(prefix); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RemoveRoutes removes the routes of a peer
+func (r *Router) RemoveRoutes(publicKey string) error {
+	if r == nil {
+		return nil
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for prefix, owner := range r.routes {
+		if owner == publicKey {
+			if err := r.remove(prefix); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *Router) remove(prefix netip.Prefix) error {
+	delete(r.routes, prefix)
+	if err := netlink.RouteDel(r.route(prefix)); err != nil && !errors.Is(err, unix.ESRCH) {
+		return errors.Wrapf(err, "failed to remove route %s", prefix)
+	}
+	return nil
+}
+
+func (r *Router) route(prefix netip.Prefix) *netlink.Route {
+	return &netlink.Route{
+		LinkIndex: r.link.Attrs().Index,
+		Dst:       &net.IPNet{IP: prefix.Addr().AsSlice(), Mask: net.CIDRMask(prefix.Bits(), prefix.Addr().BitLen())},
+		Scope:     netlink.SCOPE_LINK,
+		Protocol:  routeProtocol,
+	}
+}
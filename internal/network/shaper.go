@@ -0,0 +1,209 @@
+package network
+
+import (
+	"encoding/binary"
+	"net/netip"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+var (
+	shaperRoot    = netlink.MakeHandle(1, 0)
+	shaperIngress = netlink.MakeHandle(0xffff, 0)
+)
+
+// Shaper limits the bandwidth of single peers with tc on the WireGuard interface.
+// Traffic to a peer is shaped by a HTB class, traffic from a peer is policed on ingress.
+type Shaper struct {
+	link netlink.Link
+	// rate in bits per second
+	rate uint64
+	// classes of the throttled peers by public key
+	peers map[string]shapedPeer
+	lock  sync.Mutex
+}
+
+type shapedPeer struct {
+	class     uint32
+	addresses string
+}
+
+// NewShaper replaces the qdiscs of the interface, which removes the shaping of a previous run.
+// A nil *Shaper is valid and throttles nothing.
+func NewShaper(iface string, rate uint64) (*Shaper, error) {
+	link, err := netlink.LinkByName(iface)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find interface %s", iface)
+	}
+	attrs := netlink.QdiscAttrs{LinkIndex: link.Attrs().Index, Handle: shaperRoot, Parent: netlink.HANDLE_ROOT}
+	// unclassified traffic isn't shaped
+	if err := netlink.QdiscReplace(netlink.NewHtb(attrs)); err != nil {
+		return nil, errors.Wrap(err, "failed to set up the htb qdisc")
+	}
+	ingress := &netlink.Ingress{QdiscAttrs: netlink.QdiscAttrs{LinkIndex: link.Attrs().Index, Handle: shaperIngress, Parent: netlink.HANDLE_INGRESS}}
+	// the ingress qdisc can't be replaced, deleting it removes its filters
+	_ = netlink.QdiscDel(ingress)
+	if err := netlink.QdiscAdd(ingress); err != nil {
+		return nil, errors.Wrap(err, "failed to set up the ingress qdisc")
+	}
+	return &Shaper{
+		link:  link,
+		rate:  rate,
+		peers: make(map[string]shapedPeer),
+	}, nil
+}
+
+// Throttle limits the bandwidth of the peer's addresses (e.g. "10.44.0.2/32, fd48:4c4:7aa9::2/128"),
+// a peer is only throttled once
+func (s *Shaper) Throttle(publicKey string, addresses string) error {
+	if s == nil {
+		return nil
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if peer, ok := s.peers[publicKey]; ok {
+		if peer.addresses == addresses {
+			return nil
+		}
+		if err := s.unthrottle(publicKey); err != nil {
+			return err
+		}
+	}
+
+	class := s.freeClass()
+	if class == 0 {
+		return errors.New("no traffic class left to throttle the peer")
+	}
+	attrs := netlink.ClassAttrs{LinkIndex: s.link.Attrs().Index, Parent: shaperRoot, Handle: class}
+	if err := netlink.ClassAdd(netlink.NewHtbClass(attrs, netlink.HtbClassAttrs{Rate: s.rate})); err != nil {
+		return errors.Wrapf(err, "failed to add the traffic class of peer %s", publicKey)
+	}
+	// remember the class before adding the filters, so a partial setup is removed again
+	s.peers[publicKey] = shapedPeer{class: class, addresses: addresses}
+
+	for _, addr := range SplitAddresses(addresses) {
+		prefix, err := netip.ParsePrefix(addr)
+		if err != nil {
+			return errors.Wrapf(err, "invalid peer address '%s'", addr)
+		}
+		if err := netlink.FilterAdd(s.filter(shaperRoot, class, prefix, false)); err != nil {
+			return errors.Wrapf(err, "failed to shape the traffic to peer %s", publicKey)
+		}
+		if err := netlink.FilterAdd(s.filter(shaperIngress, class, prefix, true)); err != nil {
+			return errors.Wrapf(err, "failed to police the traffic from peer %s", publicKey)
+		}
+	}
+	return nil
+}
+
+// Unthrottle removes the bandwidth limit of a peer
+func (s *Shaper) Unthrottle(publicKey string) error {
+	if s == nil {
+		return nil
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.unthrottle(publicKey)
+}
+
+func (s *Shaper) unthrottle(publicKey string) error {
+	peer, ok := s.peers[publicKey]
+	if !ok {
+		return nil
+	}
+	delete(s.peers, publicKey)
+	for _, parent := range []uint32{shaperRoot, shaperIngress} {
+		filters, err := netlink.FilterList(s.link, parent)
+		if err != nil {
+			return errors.Wrap(err, "failed to list tc filters")
+		}
+		for _, filter := range filters {
+			if u32, ok := filter.(*netlink.U32); ok && u32.ClassId == peer.class {
+				if err := netlink.FilterDel(filter); err != nil {
+					return errors.Wrapf(err, "failed to remove the tc filter of peer %s", publicKey)
+				}
+			}
+		}
+	}
+	attrs := netlink.ClassAttrs{LinkIndex: s.link.Attrs().Index, Parent: shaperRoot, Handle: peer.class}
+	if err := netlink.ClassDel(netlink.NewHtbClass(attrs, netlink.HtbClassAttrs{})); err != nil {
+		return errors.Wrapf(err, "failed to remove the traffic class of peer %s", publicKey)
+	}
+	return nil
+}
+
+// freeClass returns an unused class handle below the root qdisc, 0 if there is none left
+func (s *Shaper) freeClass() uint32 {
+	used := make(map[uint32]bool, len(s.peers))
+	for _, peer := range s.peers {
+		used[peer.class] = true
+	}
+	for minor := uint16(1); minor < 0xffff; minor++ {
+		if class := netlink.MakeHandle(1, minor); !used[class] {
+			return class
+		}
+	}
+	return 0
+}
+
+// filter matches the destination (or the source on ingress) of the traffic against the prefix.
+// Egress traffic is sent to the class, ingress traffic is policed to the rate.
+func (s *Shaper) filter(parent uint32, class uint32, prefix netip.Prefix, ingress bool) *netlink.U32 {
+	// offsets of the addresses in the IPv4 and IPv6 headers,
+	// WireGuard interfaces have no link layer header
+	protocol, priority, offset := uint16(unix.ETH_P_IP), uint16(1), int32(16)
+	if prefix.Addr().Is6() {
+		protocol, priority, offset = unix.ETH_P_IPV6, 2, 24
+	}
+	if ingress {
+		offset = 12
+		if prefix.Addr().Is6() {
+			offset = 8
+		}
+	}
+
+	filter := &netlink.U32{
+		FilterAttrs: netlink.FilterAttrs{
+			LinkIndex: s.link.Attrs().Index,
+			Parent:    parent,
+			Priority:  priority,
+			Protocol:  protocol,
+		},
+		ClassId: class,
+		Sel:     &netlink.TcU32Sel{Flags: netlink.TC_U32_TERMINAL, Keys: prefixKeys(prefix, offset)},
+	}
+	filter.Sel.Nkeys = uint8(len(filter.Sel.Keys))
+	if ingress {
+		police := netlink.NewPoliceAction()
+		police.Rate = uint32(min(s.rate/8, 1<<32-1))
+		// allow bursts of 100ms of traffic
+		police.Burst = max(police.Rate/10, 16*1024)
+		police.ExceedAction = netlink.TC_POLICE_SHOT
+		police.NotExceedAction = netlink.TC_POLICE_UNSPEC
+		filter.Actions = []netlink.Action{police}
+	}
+	return filter
+}
+
+// prefixKeys matches the address at the offset against the prefix, 32 bits per key
+func prefixKeys(prefix netip.Prefix, offset int32) []netlink.TcU32Key {
+	prefix = prefix.Masked()
+	addr := prefix.Addr().AsSlice()
+	keys := []netlink.TcU32Key{}
+	for i := 0; i < len(addr); i += 4 {
+		bits := min(max(prefix.Bits()-i*8, 0), 32)
+		if bits == 0 {
+			break
+		}
+		keys = append(keys, netlink.TcU32Key{
+			Mask: ^uint32(0) << (32 - bits),
+			Val:  binary.BigEndian.Uint32(addr[i : i+4]),
+			Off:  offset + int32(i),
+		})
+	}
+	return keys
+}
@@ -0,0 +1,232 @@
+package notify
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/freifunkMUC/wg-access-server/internal/config"
+	"github.com/freifunkMUC/wg-access-server/internal/storage"
+	"github.com/freifunkMUC/wg-access-server/pkg/authnz/authsession"
+)
+
+// Notification events, they are the names of the templates
+const (
+	DeviceAdded      = "device_added"
+	DeviceRemoved    = "device_removed"
+	DeviceExpiring   = "device_expiring"
+	DeviceStale      = "device_stale"
+	NewProviderLogin = "new_provider_login"
+)
+
+var Events = []string{DeviceAdded, DeviceRemoved, DeviceExpiring, DeviceStale, NewProviderLogin}
+
+//go:embed templates/*.go.txt
+var templates embed.FS
+
+var funcs = template.FuncMap{
+	"date": date,
+}
+
+// Notifier sends messages to users, e.g. by mail
+type Notifier interface {
+	Notify(to string, subject string, body string) error
+}
+
+// Data is passed to the templates
+type Data struct {
+	// Name is the name of the recipient
+	Name string
+	// Device is set for device events
+	Device *storage.Device
+	// Actor is the name of the admin removing a device
+	Actor string
+	// Provider is the authentication provider of a login
+	Provider string
+	// SourceIP is the address of a login
+	SourceIP string
+	// Time is the time of the event
+	Time time.Time
+	// DeletesAt is the time a stale device will be deleted
+	DeletesAt time.Time
+	// ExternalHost is the address of the web ui
+	ExternalHost string
+}
+
+// Mailer renders the notification templates and sends them in the background.
+// A nil *Mailer is valid and sends nothing.
+type Mailer struct {
+	notifier     Notifier
+	externalHost string
+	// templates of the enabled events
+	templates map[string]*template.Template
+	pending   sync.WaitGroup
+}
+
+func NewMailer(n Notifier, externalHost string, conf config.Notifications) (*Mailer, error) {
+	for event := range conf.Events {
+		if !known(event) {
+			return nil, fmt.Errorf("unknown notification event %q", event)
+		}
+	}
+
+	base, err := template.New("mail").Funcs(funcs).ParseFS(templates, "templates/footer.go.txt")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the mail footer")
+	}
+
+	m := &Mailer{
+		notifier:     n,
+		externalHost: externalHost,
+		templates:    map[string]*template.Template{},
+	}
+	for _, event := range Events {
+		c := conf.Events[event]
+		if c.Enabled != nil && !*c.Enabled {
+			continue
+		}
+		t := template.Must(base.Clone())
+		if c.Template != "" {
+			data, err := os.ReadFile(c.Template)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to read the %s template", event)
+			}
+			t, err = t.New(event).Parse(string(data))
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to parse the %s template", event)
+			}
+		} else {
+			t = template.Must(t.ParseFS(templates, "templates/"+event+".go.txt"))
+		}
+		if t.Lookup("subject") == nil || t.Lookup("body") == nil {
+			return nil, fmt.Errorf("the %s template must define a subject and a body", event)
+		}
+		m.templates[event] = t
+	}
+	return m, nil
+}
+
+func known(event string) bool {
+	for _, e := range Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Enabled reports whether notifications of the event are sent
+func (m *Mailer) Enabled(event string) bool {
+	if m == nil {
+		return false
+	}
+	_, ok := m.templates[event]
+	return ok
+}
+
+// DeviceAdded tells the owner about a new device in their account
+func (m *Mailer) DeviceAdded(device *storage.Device) {
+	m.send(DeviceAdded, device.OwnerEmail, &Data{Name: ownerName(device), Device: device})
+}
+
+// DeviceRemoved tells the owner that an admin removed their device
+func (m *Mailer) DeviceRemoved(device *storage.Device, actor *authsession.Identity) {
+	data := &Data{Name: ownerName(device), Device: device, Actor: actor.Subject}
+	if actor.Name != "" {
+		data.Actor = actor.Name
+	}
+	m.send(DeviceRemoved, device.OwnerEmail, data)
+}
+
+// DeviceExpiring warns the owner that their device expires soon
+func (m *Mailer) DeviceExpiring(device *storage.Device) {
+	m.send(DeviceExpiring, device.OwnerEmail, &Data{Name: ownerName(device), Device: device})
+}
+
+// DeviceStale warns the owner of a stale device about its upcoming deletion
+func (m *Mailer) DeviceStale(device *storage.Device, deletesAt time.Time) {
+	m.send(DeviceStale, device.OwnerEmail, &Data{Name: ownerName(device), Device: device, DeletesAt: deletesAt})
+}
+
+// NewProviderLogin tells the user about the first login with an authentication provider
+func (m *Mailer) NewProviderLogin(user *authsession.Identity, sourceIP string) {
+	name := user.Name
+	if name == "" {
+		name = user.Subject
+	}
+	m.send(NewProviderLogin, user.Email, &Data{Name: name, Provider: user.Provider, SourceIP: sourceIP})
+}
+
+// send renders the event and delivers it in the background,
+// users without an email address are skipped
+func (m *Mailer) send(event string, to string, data *Data) {
+	if !m.Enabled(event) || to == "" {
+		return
+	}
+	if data.Time.IsZero() {
+		data.Time = time.Now()
+	}
+	data.ExternalHost = m.externalHost
+
+	subject, body, err := m.render(event, data)
+	if err != nil {
+		logrus.Error(err)
+		return
+	}
+	m.pending.Add(1)
+	go func() {
+		defer m.pending.Done()
+		if err := m.notifier.Notify(to, subject, body); err != nil {
+			logrus.Error(errors.Wrapf(err, "failed to send the %s notification to %s", event, to))
+		}
+	}()
+}
+
+func (m *Mailer) render(event string, data *Data) (string, string, error) {
+	t := m.templates[event]
+	subject := &bytes.Buffer{}
+	if err := t.ExecuteTemplate(subject, "subject", data); err != nil {
+		return "", "", errors.Wrapf(err, "failed to render the %s subject", event)
+	}
+	body := &bytes.Buffer{}
+	if err := t.ExecuteTemplate(body, "body", data); err != nil {
+		return "", "", errors.Wrapf(err, "failed to render the %s body", event)
+	}
+	// a subject must not span multiple header lines
+	return strings.Join(strings.Fields(subject.String()), " "), strings.TrimLeft(body.String(), "\n"), nil
+}
+
+// Close waits for the pending notifications
+func (m *Mailer) Close() {
+	if m == nil {
+		return
+	}
+	m.pending.Wait()
+}
+
+func date(t interface{}) string {
+	switch t := t.(type) {
+	case time.Time:
+		return t.Format(time.RFC1123)
+	case *time.Time:
+		if t != nil {
+			return t.Format(time.RFC1123)
+		}
+	}
+	return ""
+}
+
+func ownerName(device *storage.Device) string {
+	if device.OwnerName != "" {
+		return device.OwnerName
+	}
+	return device.Owner
+}
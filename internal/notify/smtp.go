@@ -0,0 +1,124 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/freifunkMUC/wg-access-server/internal/config"
+)
+
+const (
+	defaultSMTPPort = 587
+	dialTimeout     = 10 * time.Second
+	sendTimeout     = 30 * time.Second
+)
+
+// SMTPNotifier sends notifications as plain text mails
+type SMTPNotifier struct {
+	host        string
+	addr        string
+	from        string
+	username    string
+	password    string
+	implicitTLS bool
+	// tlsConfig verifies the certificate of the mail server
+	tlsConfig *tls.Config
+}
+
+func NewSMTPNotifier(conf config.SMTP) (*SMTPNotifier, error) {
+	if conf.Host == "" {
+		return nil, errors.New("smtp host must not be empty")
+	}
+	if conf.From == "" {
+		return nil, errors.New("smtp sender address must not be empty")
+	}
+	port := conf.Port
+	if port == 0 {
+		port = defaultSMTPPort
+	}
+	return &SMTPNotifier{
+		host:        conf.Host,
+		addr:        net.JoinHostPort(conf.Host, strconv.Itoa(port)),
+		from:        conf.From,
+		username:    conf.Username,
+		password:    conf.Password,
+		implicitTLS: conf.ImplicitTLS,
+		tlsConfig:   &tls.Config{ServerName: conf.Host},
+	}, nil
+}
+
+func (s *SMTPNotifier) Notify(to string, subject string, body string) error {
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	var conn net.Conn
+	var err error
+	if s.implicitTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", s.addr, s.tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", s.addr)
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to connect to the smtp server")
+	}
+	_ = conn.SetDeadline(time.Now().Add(sendTimeout))
+
+	c, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return errors.Wrap(err, "failed to connect to the smtp server")
+	}
+	defer c.Close()
+
+	if !s.implicitTLS {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(s.tlsConfig); err != nil {
+				return errors.Wrap(err, "failed to start tls")
+			}
+		}
+	}
+	if s.username != "" {
+		// smtp.PlainAuth refuses to send the password over unencrypted connections
+		if err := c.Auth(smtp.PlainAuth("", s.username, s.password, s.host)); err != nil {
+			return errors.Wrap(err, "smtp authentication failed")
+		}
+	}
+
+	if err := c.Mail(s.from); err != nil {
+		return errors.Wrap(err, "smtp server rejected the sender")
+	}
+	if err := c.Rcpt(to); err != nil {
+		return errors.Wrapf(err, "smtp server rejected the recipient %s", to)
+	}
+	w, err := c.Data()
+	if err != nil {
+		return errors.Wrap(err, "failed to send the mail")
+	}
+	if _, err := w.Write(s.message(to, subject, body)); err != nil {
+		return errors.Wrap(err, "failed to send the mail")
+	}
+	if err := w.Close(); err != nil {
+		return errors.Wrap(err, "failed to send the mail")
+	}
+	return c.Quit()
+}
+
+func (s *SMTPNotifier) message(to string, subject string, body string) []byte {
+	msg := &bytes.Buffer{}
+	fmt.Fprintf(msg, "From: %s\r\n", s.from)
+	fmt.Fprintf(msg, "To: %s\r\n", to)
+	fmt.Fprintf(msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+	return msg.Bytes()
+}
@@ -0,0 +1,223 @@
+package notify
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/freifunkMUC/wg-access-server/internal/config"
+	"github.com/freifunkMUC/wg-access-server/internal/storage"
+	"github.com/freifunkMUC/wg-access-server/pkg/authnz/authsession"
+)
+
+type mail struct {
+	from string
+	to   []string
+	data string
+}
+
+// smtpServer is a minimal SMTP stand-in accepting every mail
+func smtpServer(t *testing.T) (config.SMTP, <-chan mail) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	mails := make(chan mail, 10)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go serveSMTP(conn, mails)
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(l.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return config.SMTP{Host: host, Port: p, From: "vpn@example.com"}, mails
+}
+
+func serveSMTP(conn net.Conn, mails chan<- mail) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+	reply("220 localhost ESMTP")
+	m := mail{}
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		command := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			m.from = strings.Trim(line[len("MAIL FROM:"):], "<>")
+			reply("250 OK")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			m.to = append(m.to, strings.Trim(line[len("RCPT TO:"):], "<>"))
+			reply("250 OK")
+		case command == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			data := &strings.Builder{}
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+			m.data = data.String()
+			mails <- m
+			m = mail{}
+			reply("250 OK")
+		case command == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func receive(t *testing.T, mails <-chan mail) mail {
+	select {
+	case m := <-mails:
+		return m
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "no mail received")
+		return mail{}
+	}
+}
+
+func TestStaleDeviceMail(t *testing.T) {
+	require := require.New(t)
+
+	conf, mails := smtpServer(t)
+	notifier, err := NewSMTPNotifier(conf)
+	require.NoError(err)
+	mailer, err := NewMailer(notifier, "https://vpn.example.com", config.Notifications{})
+	require.NoError(err)
+
+	lastSeen := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	device := &storage.Device{Owner: "alice", OwnerName: "Alice", OwnerEmail: "alice@example.com", Name: "notebook", LastHandshakeTime: &lastSeen}
+	mailer.DeviceStale(device, lastSeen.Add(30*24*time.Hour))
+
+	m := receive(t, mails)
+	require.Equal("vpn@example.com", m.from)
+	require.Equal([]string{"alice@example.com"}, m.to)
+	require.Contains(m.data, "Subject: Your VPN device notebook will be deleted\r\n")
+	require.Contains(m.data, "Hello Alice,\r\n")
+	require.Contains(m.data, "last seen: Tue, 02 Jan 2024 03:04:05 UTC")
+	require.Contains(m.data, "Thu, 01 Feb 2024 03:04:05 UTC")
+	require.Contains(m.data, "Manage your devices at https://vpn.example.com")
+
+	// devices of owners without an email address are skipped
+	device.OwnerEmail = ""
+	mailer.DeviceStale(device, time.Now())
+	mailer.Close()
+	require.Empty(mails)
+}
+
+func TestDeviceMails(t *testing.T) {
+	require := require.New(t)
+
+	conf, mails := smtpServer(t)
+	notifier, err := NewSMTPNotifier(conf)
+	require.NoError(err)
+	mailer, err := NewMailer(notifier, "", config.Notifications{})
+	require.NoError(err)
+
+	expiresAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	device := &storage.Device{Owner: "alice", OwnerEmail: "alice@example.com", Name: "phone", ExpiresAt: &expiresAt}
+
+	mailer.DeviceAdded(device)
+	m := receive(t, mails)
+	require.Contains(m.data, "Subject: A VPN device was added to your account\r\n")
+	require.Contains(m.data, "Hello alice,\r\n")
+	require.NotContains(m.data, "Manage your devices")
+
+	mailer.DeviceRemoved(device, &authsession.Identity{Subject: "admin", Name: "Bob"})
+	m = receive(t, mails)
+	require.Contains(m.data, "Subject: Your VPN device phone was removed\r\n")
+	require.Contains(m.data, "removed by the administrator Bob")
+
+	mailer.DeviceExpiring(device)
+	m = receive(t, mails)
+	require.Contains(m.data, "Subject: Your VPN device phone expires soon\r\n")
+	require.Contains(m.data, "expires on Fri, 01 Mar 2024 12:00:00 UTC")
+
+	mailer.NewProviderLogin(&authsession.Identity{Subject: "alice", Email: "alice@example.com", Provider: "gitlab"}, "192.0.2.1")
+	m = receive(t, mails)
+	require.Contains(m.data, "Subject: New sign-in to your VPN account\r\n")
+	require.Contains(m.data, "with gitlab for the first time")
+	require.Contains(m.data, "from 192.0.2.1.")
+}
+
+func TestNotificationConfig(t *testing.T) {
+	require := require.New(t)
+
+	conf, mails := smtpServer(t)
+	notifier, err := NewSMTPNotifier(conf)
+	require.NoError(err)
+
+	custom := filepath.Join(t.TempDir(), "added.tmpl")
+	require.NoError(os.WriteFile(custom, []byte(`{{define "subject"}}New device {{.Device.Name}}{{end}}{{define "body"}}{{.Device.Name}} for {{.Name}}{{template "footer" .}}{{end}}`), 0600))
+	disabled := false
+	mailer, err := NewMailer(notifier, "https://vpn.example.com", config.Notifications{Events: map[string]config.NotificationEvent{
+		DeviceAdded:   {Template: custom},
+		DeviceRemoved: {Enabled: &disabled},
+	}})
+	require.NoError(err)
+	require.True(mailer.Enabled(DeviceAdded))
+	require.False(mailer.Enabled(DeviceRemoved))
+	require.True(mailer.Enabled(DeviceExpiring))
+
+	device := &storage.Device{Owner: "alice", OwnerEmail: "alice@example.com", Name: "phone"}
+	mailer.DeviceRemoved(device, &authsession.Identity{Subject: "admin"})
+	mailer.DeviceAdded(device)
+	m := receive(t, mails)
+	require.Contains(m.data, "Subject: New device phone\r\n")
+	require.Contains(m.data, "phone for alice\r\nManage your devices at https://vpn.example.com")
+	mailer.Close()
+	require.Empty(mails)
+
+	_, err = NewMailer(notifier, "", config.Notifications{Events: map[string]config.NotificationEvent{"device_renamed": {}}})
+	require.Error(err)
+
+	require.NoError(os.WriteFile(custom, []byte(`{{define "subject"}}subject only{{end}}`), 0600))
+	_, err = NewMailer(notifier, "", config.Notifications{Events: map[string]config.NotificationEvent{DeviceAdded: {Template: custom}}})
+	require.Error(err)
+
+	var nilMailer *Mailer
+	require.False(nilMailer.Enabled(DeviceAdded))
+	nilMailer.DeviceAdded(device)
+	nilMailer.Close()
+}
+
+func TestSMTPConnectionError(t *testing.T) {
+	require := require.New(t)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(err)
+	host, port, _ := net.SplitHostPort(l.Addr().String())
+	l.Close()
+	p, _ := strconv.Atoi(port)
+
+	notifier, err := NewSMTPNotifier(config.SMTP{Host: host, Port: p, From: "vpn@example.com"})
+	require.NoError(err)
+	require.Error(notifier.Notify("alice@example.com", "subject", "body"))
+}
@@ -7,16 +7,23 @@ import (
 	"net/http"
 
 	"github.com/freifunkMUC/wg-embed/pkg/wgembed"
+	"github.com/gorilla/mux"
 	grpcMiddleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	grpcLogrus "github.com/grpc-ecosystem/go-grpc-middleware/logging/logrus"
 	grpcRecovery "github.com/grpc-ecosystem/go-grpc-middleware/recovery"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"github.com/improbable-eng/grpc-web/go/grpcweb"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
+	"github.com/freifunkMUC/wg-access-server/internal/audit"
 	"github.com/freifunkMUC/wg-access-server/internal/config"
 	"github.com/freifunkMUC/wg-access-server/internal/devices"
+	"github.com/freifunkMUC/wg-access-server/internal/notify"
+	"github.com/freifunkMUC/wg-access-server/internal/tokens"
 	"github.com/freifunkMUC/wg-access-server/internal/traces"
 	"github.com/freifunkMUC/wg-access-server/proto/proto"
 	"github.com/sirupsen/logrus"
@@ -26,44 +33,123 @@ type ApiServices struct {
 	Config        *config.AppConfig
 	DeviceManager *devices.DeviceManager
 	Wg            wgembed.WireGuardInterface
+	TokenManager  *tokens.TokenManager
+	Audit         *audit.Log
+	Mailer        *notify.Mailer
+	// Metrics is optional
+	Metrics *GRPCMetrics
 }
 
-func ApiRouter(deps *ApiServices) http.Handler {
+func ApiRouter(deps *ApiServices) (http.Handler, error) {
+	// the REST gateway calls the services through the same interceptors
+	unaryInterceptor := grpcMiddleware.ChainUnaryServer(
+		deps.Metrics.unaryInterceptor,
+		func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+			// wrapped in anonymous func to get ctx
+			return grpcLogrus.UnaryServerInterceptor(grpcLoggerWith(ctx))(ctx, req, info, handler)
+		},
+		grpcRecovery.UnaryServerInterceptor(
+			grpcRecovery.WithRecoveryHandlerContext(func(ctx context.Context, p interface{}) (err error) {
+				// add trace id to error message so it's visible for the client
+				return status.Errorf(codes.Internal, "%v; trace = %s", p, traces.TraceID(ctx))
+			}),
+		),
+		readOnlyInterceptor,
+	)
+
 	// Native GRPC server
 	server := grpc.NewServer([]grpc.ServerOption{
 		grpc.MaxRecvMsgSize(int(1 * math.Pow(2, 20))), // 1MB
-		grpc.UnaryInterceptor(grpcMiddleware.ChainUnaryServer(
-			func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
-				// wrapped in anonymous func to get ctx
-				return grpcLogrus.UnaryServerInterceptor(grpcLoggerWith(ctx))(ctx, req, info, handler)
+		// a span per call, the REST gateway calls are traced by the http middleware
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.UnaryInterceptor(unaryInterceptor),
+		grpc.StreamInterceptor(grpcMiddleware.ChainStreamServer(
+			deps.Metrics.streamInterceptor,
+			func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+				return grpcLogrus.StreamServerInterceptor(grpcLoggerWith(ss.Context()))(srv, ss, info, handler)
 			},
-			grpcRecovery.UnaryServerInterceptor(
+			grpcRecovery.StreamServerInterceptor(
 				grpcRecovery.WithRecoveryHandlerContext(func(ctx context.Context, p interface{}) (err error) {
-					// add trace id to error message so it's visible for the client
 					return status.Errorf(codes.Internal, "%v; trace = %s", p, traces.TraceID(ctx))
 				}),
 			),
+			readOnlyStreamInterceptor,
 		)),
 	}...)
+	conn := newInProcessConn(unaryInterceptor)
 
 	// Register GRPC services
-	proto.RegisterServerServer(server, &ServerService{
-		Config: deps.Config,
-		Wg:     deps.Wg,
-	})
-	proto.RegisterUsersServer(server, &UserService{
+	serverService := &ServerService{
+		Config:        deps.Config,
+		Wg:            deps.Wg,
 		DeviceManager: deps.DeviceManager,
-	})
-	proto.RegisterDevicesServer(server, &DeviceService{
+	}
+	userService := &UserService{
 		DeviceManager: deps.DeviceManager,
-	})
+		TokenManager:  deps.TokenManager,
+		Audit:         deps.Audit,
+		Mailer:        deps.Mailer,
+	}
+	clientConfigs := NewClientConfigStash()
+	deviceService := &DeviceService{
+		DeviceManager: deps.DeviceManager,
+		Config:        deps.Config,
+		Wg:            deps.Wg,
+		ClientConfigs: clientConfigs,
+		Audit:         deps.Audit,
+		Mailer:        deps.Mailer,
+	}
+	tokenService := &TokenService{
+		TokenManager: deps.TokenManager,
+		Audit:        deps.Audit,
+	}
+	auditService := &AuditService{
+		Audit: deps.Audit,
+	}
+	for _, registrar := range []grpc.ServiceRegistrar{server, conn} {
+		proto.RegisterServerServer(registrar, serverService)
+		proto.RegisterUsersServer(registrar, userService)
+		proto.RegisterDevicesServer(registrar, deviceService)
+		proto.RegisterTokensServer(registrar, tokenService)
+		proto.RegisterAuditServer(registrar, auditService)
+	}
 
 	// Grpc Web in process proxy (wrapper)
 	grpcServer := grpcweb.WrapServer(server,
 		grpcweb.WithAllowNonRootResource(true),
 	)
 
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	// REST/JSON gateway, calls the services in-process
+	gateway := runtime.NewServeMux()
+	ctx := context.Background()
+	if err := proto.RegisterServerHandlerClient(ctx, gateway, proto.NewServerClient(conn)); err != nil {
+		return nil, errors.Wrap(err, "failed to register server service gateway")
+	}
+	if err := proto.RegisterUsersHandlerClient(ctx, gateway, proto.NewUsersClient(conn)); err != nil {
+		return nil, errors.Wrap(err, "failed to register users service gateway")
+	}
+	if err := proto.RegisterDevicesHandlerClient(ctx, gateway, proto.NewDevicesClient(conn)); err != nil {
+		return nil, errors.Wrap(err, "failed to register devices service gateway")
+	}
+	if err := proto.RegisterTokensHandlerClient(ctx, gateway, proto.NewTokensClient(conn)); err != nil {
+		return nil, errors.Wrap(err, "failed to register tokens service gateway")
+	}
+	if err := proto.RegisterAuditHandlerClient(ctx, gateway, proto.NewAuditClient(conn)); err != nil {
+		return nil, errors.Wrap(err, "failed to register audit service gateway")
+	}
+
+	router := mux.NewRouter()
+
+	router.Path("/api/v1/openapi.json").Methods(http.MethodGet).HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(proto.OpenAPI)
+	})
+	router.PathPrefix("/api/v1/").Handler(requireJSON(gateway))
+
+	// One-time download of configs generated by Devices.CreateDeviceWithConfig
+	router.Path("/api/devices/{name}/config").Methods(http.MethodGet).Handler(ClientConfigHandler(deps.Config, clientConfigs))
+
+	router.PathPrefix("/").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if grpcServer.IsGrpcWebRequest(r) {
 			grpcServer.ServeHTTP(w, r)
 			return
@@ -72,6 +158,8 @@ func ApiRouter(deps *ApiServices) http.Handler {
 		w.WriteHeader(400)
 		_, _ = fmt.Fprintln(w, "expected grpc request")
 	})
+
+	return router, nil
 }
 
 // GRPC events have the nature of DEBUG logs but are logged with INFO level. To clean up the log stream starting from INFO log level we only log WARN events.
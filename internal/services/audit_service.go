@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/logrus/ctxlogrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/freifunkMUC/wg-access-server/internal/audit"
+	"github.com/freifunkMUC/wg-access-server/internal/storage"
+	"github.com/freifunkMUC/wg-access-server/pkg/authnz/authsession"
+	"github.com/freifunkMUC/wg-access-server/proto/proto"
+)
+
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 500
+)
+
+type AuditService struct {
+	proto.UnimplementedAuditServer
+	Audit *audit.Log
+}
+
+func (a *AuditService) List(ctx context.Context, req *proto.ListAuditEventsReq) (*proto.ListAuditEventsRes, error) {
+	user, err := authsession.CurrentUser(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "not authenticated")
+	}
+
+	if !user.Claims.IsAdmin() {
+		return nil, status.Errorf(codes.PermissionDenied, "must be an admin")
+	}
+
+	pageSize := int(req.GetPageSize())
+	if pageSize <= 0 {
+		pageSize = defaultAuditPageSize
+	} else if pageSize > maxAuditPageSize {
+		pageSize = maxAuditPageSize
+	}
+
+	filter := storage.AuditFilter{
+		Actor:      req.GetActor(),
+		ActorEmail: req.GetActorEmail(),
+		Action:     req.GetAction(),
+		Target:     req.GetTarget(),
+		Limit:      pageSize,
+	}
+	if req.Since != nil {
+		since := TimestampToTime(req.Since)
+		filter.Since = &since
+	}
+	if req.Until != nil {
+		until := TimestampToTime(req.Until)
+		filter.Until = &until
+	}
+	if req.GetPageToken() != "" {
+		filter.BeforeID, err = strconv.ParseUint(req.GetPageToken(), 10, 64)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid page token")
+		}
+	}
+
+	events, err := a.Audit.List(filter)
+	if err != nil {
+		ctxlogrus.Extract(ctx).Error(err)
+		return nil, status.Errorf(codes.Internal, "failed to retrieve audit events")
+	}
+
+	res := &proto.ListAuditEventsRes{
+		Items: []*proto.AuditEvent{},
+	}
+	for _, event := range events {
+		res.Items = append(res.Items, mapAuditEvent(event))
+	}
+	if len(events) == pageSize {
+		res.NextPageToken = strconv.FormatUint(events[len(events)-1].ID, 10)
+	}
+	return res, nil
+}
+
+func mapAuditEvent(event *storage.AuditEvent) *proto.AuditEvent {
+	return &proto.AuditEvent{
+		Id:            event.ID,
+		Time:          TimeToTimestamp(&event.Time),
+		Actor:         event.Actor,
+		ActorName:     event.ActorName,
+		ActorProvider: event.ActorProvider,
+		ActorEmail:    event.ActorEmail,
+		Action:        event.Action,
+		Target:        event.Target,
+		SourceIp:      event.SourceIP,
+		Details:       event.Details,
+	}
+}
+
+// auditEvent creates an audit event of the current user of the request
+func auditEvent(ctx context.Context, user *authsession.Identity, action string, target string) *storage.AuditEvent {
+	event := audit.Event(user, action, target)
+	event.SourceIP = sourceIP(ctx)
+	return event
+}
+
+// sourceIP returns the client address of a grpc(-web) request,
+// or the address the gateway added to X-Forwarded-For for REST requests
+func sourceIP(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return requestHostname(p.Addr.String())
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("x-forwarded-for"); len(values) > 0 {
+			addresses := strings.Split(values[len(values)-1], ",")
+			return requestHostname(strings.TrimSpace(addresses[len(addresses)-1]))
+		}
+	}
+	return ""
+}
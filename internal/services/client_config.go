@@ -0,0 +1,218 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/patrickmn/go-cache"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/skip2/go-qrcode"
+
+	"github.com/freifunkMUC/wg-access-server/internal/config"
+	"github.com/freifunkMUC/wg-access-server/internal/network"
+	"github.com/freifunkMUC/wg-access-server/internal/storage"
+	"github.com/freifunkMUC/wg-access-server/pkg/authnz/authsession"
+)
+
+// ClientConfigOptions are the device specific values of a client config file
+type ClientConfigOptions struct {
+	PrivateKey          string
+	PresharedKey        string
+	Address             string
+	PersistentKeepalive int
+	// Profile replaces the AllowedIPs, DNS servers and MTU of the config if set
+	Profile *config.Profile
+}
+
+// RenderClientConfig renders a wg-quick config file for a device,
+// matching the one generated by the web UI
+func RenderClientConfig(conf *config.AppConfig, serverPublicKey string, host string, opts ClientConfigOptions) (string, error) {
+	vpnip, vpnipv6, err := network.ServerVPNIPs(conf.VPN.CIDR, conf.VPN.CIDRv6)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get server IPs")
+	}
+
+	dnsInfo := []string{}
+	if opts.Profile != nil && len(opts.Profile.DNSServers) > 0 {
+		dnsInfo = append(dnsInfo, strings.Join(opts.Profile.DNSServers, ", "))
+	} else if len(conf.ClientConfig.DNSServers) > 0 {
+		// If custom DNS entries are specified via client config, prefer them over the calculated ones.
+		dnsInfo = append(dnsInfo, clientConfigDnsServers(conf))
+	} else if conf.DNS.Enabled {
+		// Otherwise, and if DNS is enabled, use the ones from the server.
+		dnsInfo = append(dnsInfo, network.StringJoinIPs(vpnip, vpnipv6))
+	}
+	if conf.ClientConfig.DNSSearchDomain != "" {
+		dnsInfo = append(dnsInfo, conf.ClientConfig.DNSSearchDomain)
+	}
+
+	port := conf.WireGuard.Port
+	if port == 0 {
+		port = 51820
+	}
+
+	b := &strings.Builder{}
+	fmt.Fprintln(b, "[Interface]")
+	fmt.Fprintf(b, "PrivateKey = %s\n", opts.PrivateKey)
+	fmt.Fprintf(b, "Address = %s\n", opts.Address)
+	if len(dnsInfo) > 0 {
+		fmt.Fprintf(b, "DNS = %s\n", strings.Join(dnsInfo, ", "))
+	}
+	mtu, routes := conf.ClientConfig.MTU, allowedIPs(conf)
+	if opts.Profile != nil {
+		if opts.Profile.MTU != 0 {
+			mtu = opts.Profile.MTU
+		}
+		routes = strings.Join(opts.Profile.AllowedIPs, ", ")
+	}
+	if mtu != 0 {
+		fmt.Fprintf(b, "MTU = %d\n", mtu)
+	}
+	fmt.Fprintln(b)
+	fmt.Fprintln(b, "[Peer]")
+	fmt.Fprintf(b, "PublicKey = %s\n", serverPublicKey)
+	fmt.Fprintf(b, "AllowedIPs = %s\n", routes)
+	fmt.Fprintf(b, "Endpoint = %s:%d\n", endpointHost(host), port)
+	if opts.PresharedKey != "" {
+		fmt.Fprintf(b, "PresharedKey = %s\n", opts.PresharedKey)
+	}
+	if opts.PersistentKeepalive > 0 {
+		fmt.Fprintf(b, "PersistentKeepalive = %d\n", opts.PersistentKeepalive)
+	}
+	return b.String(), nil
+}
+
+// endpointHost puts IPv6 addresses into brackets so that a port can be appended
+func endpointHost(host string) string {
+	if strings.Contains(host, ":") {
+		if !strings.HasPrefix(host, "[") {
+			host = "[" + host
+		}
+		if !strings.HasSuffix(host, "]") {
+			host = host + "]"
+		}
+	}
+	return host
+}
+
+// requestHostname returns the hostname without the port of a request's host header
+func requestHostname(hostport string) string {
+	if host, _, err := net.SplitHostPort(hostport); err == nil {
+		return host
+	}
+	return hostport
+}
+
+// ClientConfigStash keeps generated client configs for a short time,
+// so that they can be downloaded exactly once after the device was created
+type ClientConfigStash struct {
+	cache *cache.Cache
+}
+
+func NewClientConfigStash() *ClientConfigStash {
+	return &ClientConfigStash{
+		cache: cache.New(10*time.Minute, 10*time.Minute),
+	}
+}
+
+func (s *ClientConfigStash) Put(device *storage.Device, clientConfig string) {
+	s.cache.SetDefault(stashKey(device.Owner, device.Name), clientConfig)
+}
+
+// Take returns the config of a device and removes it from the stash
+func (s *ClientConfigStash) Take(owner string, name string) (string, bool) {
+	key := stashKey(owner, name)
+	value, ok := s.cache.Get(key)
+	if !ok {
+		return "", false
+	}
+	s.cache.Delete(key)
+	return value.(string), true
+}
+
+func stashKey(owner string, name string) string {
+	return owner + "\x00" + name
+}
+
+// ClientConfigHandler serves a config generated by Devices.CreateDeviceWithConfig once.
+// The format query parameter selects between "conf" (default), "qr" (PNG) and "zip".
+func ClientConfigHandler(conf *config.AppConfig, stash *ClientConfigStash) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, err := authsession.CurrentUser(r.Context())
+		if err != nil {
+			http.Error(w, "not authenticated", http.StatusUnauthorized)
+			return
+		}
+
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = "conf"
+		}
+		if format != "conf" && format != "qr" && format != "zip" {
+			http.Error(w, fmt.Sprintf("unknown format '%s'", format), http.StatusBadRequest)
+			return
+		}
+
+		name := mux.Vars(r)["name"]
+		clientConfig, ok := stash.Take(user.Subject, name)
+		if !ok {
+			http.Error(w, "config not found, it can only be downloaded once shortly after the device was created", http.StatusNotFound)
+			return
+		}
+
+		filename := conf.Filename
+		if filename == "" {
+			filename = "WireGuard"
+		}
+
+		var body []byte
+		switch format {
+		case "conf":
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".conf"))
+			body = []byte(clientConfig)
+		case "qr":
+			body, err = qrcode.Encode(clientConfig, qrcode.Medium, 512)
+			if err != nil {
+				logrus.Error(errors.Wrap(err, "failed to encode client config as qr code"))
+				http.Error(w, "failed to encode qr code", http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "image/png")
+		case "zip":
+			body, err = zipClientConfig(filename, clientConfig)
+			if err != nil {
+				logrus.Error(errors.Wrap(err, "failed to zip client config"))
+				http.Error(w, "failed to create zip file", http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/zip")
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".zip"))
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		_, _ = w.Write(body)
+	})
+}
+
+func zipClientConfig(filename string, clientConfig string) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	archive := zip.NewWriter(buf)
+	f, err := archive.Create(filename + ".conf")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Write([]byte(clientConfig)); err != nil {
+		return nil, err
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -13,6 +13,15 @@ func TimestampToTime(value *timestamppb.Timestamp) time.Time {
 	return time.Unix(value.Seconds, int64(value.Nanos))
 }
 
+// timestampPtr converts an optional timestamp, nil if it is unset
+func timestampPtr(value *timestamppb.Timestamp) *time.Time {
+	if value == nil {
+		return nil
+	}
+	t := TimestampToTime(value)
+	return &t
+}
+
 func TimeToTimestamp(value *time.Time) *timestamppb.Timestamp {
 	if value == nil {
 		return nil
@@ -45,3 +54,10 @@ func stringValue(value *string) *wrapperspb.StringValue {
 	}
 	return nil
 }
+
+func stringPtr(value *wrapperspb.StringValue) *string {
+	if value != nil {
+		return &value.Value
+	}
+	return nil
+}
@@ -2,13 +2,25 @@ package services
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/freifunkMUC/wg-embed/pkg/wgembed"
 	"github.com/grpc-ecosystem/go-grpc-middleware/logging/logrus/ctxlogrus"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 
+	"github.com/freifunkMUC/wg-access-server/internal/audit"
+	"github.com/freifunkMUC/wg-access-server/internal/config"
 	"github.com/freifunkMUC/wg-access-server/internal/devices"
+	"github.com/freifunkMUC/wg-access-server/internal/notify"
 	"github.com/freifunkMUC/wg-access-server/internal/storage"
 	"github.com/freifunkMUC/wg-access-server/pkg/authnz/authsession"
 	"github.com/freifunkMUC/wg-access-server/proto/proto"
@@ -17,6 +29,11 @@ import (
 type DeviceService struct {
 	proto.UnimplementedDevicesServer
 	DeviceManager *devices.DeviceManager
+	Config        *config.AppConfig
+	Wg            wgembed.WireGuardInterface
+	ClientConfigs *ClientConfigStash
+	Audit         *audit.Log
+	Mailer        *notify.Mailer
 }
 
 func (d *DeviceService) AddDevice(ctx context.Context, req *proto.AddDeviceReq) (*proto.Device, error) {
@@ -25,13 +42,147 @@ func (d *DeviceService) AddDevice(ctx context.Context, req *proto.AddDeviceReq)
 		return nil, status.Errorf(codes.PermissionDenied, "Not authenticated")
 	}
 
-	device, err := d.DeviceManager.AddDevice(user, req.GetName(), req.GetPublicKey(), req.GetPresharedKey(), req.GetManualIpAssignment(), req.GetManualIpv4Address(), req.GetManualIpv6Address())
+	if req.AccessPolicy != nil && !user.Claims.IsAdmin() {
+		return nil, status.Errorf(codes.PermissionDenied, "Must be an admin to set an access policy")
+	}
+
+	if (req.Gateway || req.Routes != "") && !user.Claims.IsAdmin() {
+		return nil, status.Errorf(codes.PermissionDenied, "Must be an admin to add a gateway device")
+	}
+
+	device, err := d.DeviceManager.AddDevice(ctx, user, devices.AddDeviceRequest{
+		Name:               req.GetName(),
+		PublicKey:          req.GetPublicKey(),
+		PresharedKey:       req.GetPresharedKey(),
+		ManualIPAssignment: req.GetManualIpAssignment(),
+		ManualIPv4Address:  req.GetManualIpv4Address(),
+		ManualIPv6Address:  req.GetManualIpv6Address(),
+		AccessPolicy:       mapAccessPolicyReq(req.AccessPolicy),
+		Profile:            req.GetProfile(),
+		Gateway:            req.GetGateway(),
+		Routes:             req.GetRoutes(),
+		ExpiresAt:          timestampPtr(req.ExpiresAt),
+	})
 	if err != nil {
 		ctxlogrus.Extract(ctx).Error(err)
-		return nil, status.Errorf(codes.Internal, "%v", err)
+		return nil, addDeviceStatus(err)
+	}
+
+	d.Audit.Record(auditEvent(ctx, user, audit.DeviceCreate, audit.DeviceTarget(device.Owner, device.Name)))
+	d.Mailer.DeviceAdded(device)
+
+	return d.mapDevice(device), nil
+}
+
+func (d *DeviceService) CreateDeviceWithConfig(ctx context.Context, req *proto.CreateDeviceWithConfigReq) (*proto.CreateDeviceWithConfigRes, error) {
+	user, err := authsession.CurrentUser(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "Not authenticated")
+	}
+
+	if req.AccessPolicy != nil && !user.Claims.IsAdmin() {
+		return nil, status.Errorf(codes.PermissionDenied, "Must be an admin to set an access policy")
+	}
+
+	if (req.Gateway || req.Routes != "") && !user.Claims.IsAdmin() {
+		return nil, status.Errorf(codes.PermissionDenied, "Must be an admin to add a gateway device")
+	}
+
+	privateKey, err := wgtypes.GeneratePrivateKey()
+	if err != nil {
+		ctxlogrus.Extract(ctx).Error(err)
+		return nil, status.Errorf(codes.Internal, "failed to generate a private key")
+	}
+
+	presharedKey := ""
+	if req.GetUsePresharedKey() {
+		key, err := wgtypes.GenerateKey()
+		if err != nil {
+			ctxlogrus.Extract(ctx).Error(err)
+			return nil, status.Errorf(codes.Internal, "failed to generate a pre-shared key")
+		}
+		presharedKey = key.String()
+	}
+
+	serverPublicKey, err := d.Wg.PublicKey()
+	if err != nil {
+		ctxlogrus.Extract(ctx).Error(err)
+		return nil, status.Errorf(codes.Internal, "failed to get public key")
+	}
+
+	device, err := d.DeviceManager.AddDevice(ctx, user, devices.AddDeviceRequest{
+		Name:               req.GetName(),
+		PublicKey:          privateKey.PublicKey().String(),
+		PresharedKey:       presharedKey,
+		ManualIPAssignment: req.GetManualIpAssignment(),
+		ManualIPv4Address:  req.GetManualIpv4Address(),
+		ManualIPv6Address:  req.GetManualIpv6Address(),
+		AccessPolicy:       mapAccessPolicyReq(req.AccessPolicy),
+		Profile:            req.GetProfile(),
+		Gateway:            req.GetGateway(),
+		Routes:             req.GetRoutes(),
+		ExpiresAt:          timestampPtr(req.ExpiresAt),
+	})
+	if err != nil {
+		ctxlogrus.Extract(ctx).Error(err)
+		return nil, addDeviceStatus(err)
+	}
+
+	keepalive := d.Config.ClientConfig.PersistentKeepalive
+	if req.PersistentKeepalive != nil {
+		keepalive = int(req.PersistentKeepalive.Value)
 	}
 
-	return mapDevice(device), nil
+	clientConfig, err := RenderClientConfig(d.Config, serverPublicKey, d.endpoint(ctx), ClientConfigOptions{
+		PrivateKey:          privateKey.String(),
+		PresharedKey:        presharedKey,
+		Address:             device.Address,
+		PersistentKeepalive: keepalive,
+		Profile:             d.DeviceManager.Profile(device.Profile),
+	})
+	if err != nil {
+		ctxlogrus.Extract(ctx).Error(err)
+		return nil, status.Errorf(codes.Internal, "failed to render the client config")
+	}
+	d.ClientConfigs.Put(device, clientConfig)
+
+	d.Audit.Record(auditEvent(ctx, user, audit.DeviceCreate, audit.DeviceTarget(device.Owner, device.Name)))
+	d.Mailer.DeviceAdded(device)
+
+	return &proto.CreateDeviceWithConfigRes{
+		Device: d.mapDevice(device),
+		Config: clientConfig,
+	}, nil
+}
+
+// addDeviceStatus maps errors of DeviceManager.AddDevice to a grpc status
+func addDeviceStatus(err error) error {
+	var quotaErr *devices.QuotaError
+	if errors.As(err, &quotaErr) {
+		return status.Errorf(codes.ResourceExhausted, "%v", err)
+	}
+	var lifetimeErr *devices.LifetimeError
+	if errors.As(err, &lifetimeErr) {
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	return status.Errorf(codes.Internal, "%v", err)
+}
+
+// endpoint returns the configured external host or
+// the host the client used to reach the api otherwise
+func (d *DeviceService) endpoint(ctx context.Context) string {
+	if d.Config.ExternalHost != "" {
+		return d.Config.ExternalHost
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		// the REST gateway passes the host as x-forwarded-host
+		for _, key := range []string{"x-forwarded-host", ":authority"} {
+			if host := md.Get(key); len(host) > 0 {
+				return requestHostname(host[0])
+			}
+		}
+	}
+	return ""
 }
 
 func (d *DeviceService) ListDevices(ctx context.Context, req *proto.ListDevicesReq) (*proto.ListDevicesRes, error) {
@@ -40,16 +191,66 @@ func (d *DeviceService) ListDevices(ctx context.Context, req *proto.ListDevicesR
 		return nil, status.Errorf(codes.PermissionDenied, "Not authenticated")
 	}
 
-	devices, err := d.DeviceManager.ListDevices(user.Subject)
+	devices, err := d.DeviceManager.ListDevices(ctx, user.Subject)
 	if err != nil {
 		ctxlogrus.Extract(ctx).Error(err)
 		return nil, status.Errorf(codes.Internal, "Failed to retrieve devices")
 	}
 	return &proto.ListDevicesRes{
-		Items: mapDevices(devices),
+		Items: d.mapDevices(devices),
 	}, nil
 }
 
+func (d *DeviceService) WatchDevices(req *proto.WatchDevicesReq, stream grpc.ServerStreamingServer[proto.DeviceEvent]) error {
+	ctx := stream.Context()
+	user, err := authsession.CurrentUser(ctx)
+	if err != nil {
+		return status.Errorf(codes.PermissionDenied, "Not authenticated")
+	}
+
+	owner := user.Subject
+	if req.All {
+		if !user.Claims.IsAdmin() {
+			return status.Errorf(codes.PermissionDenied, "Must be an admin")
+		}
+		owner = ""
+	}
+
+	devices, events, stop, err := d.DeviceManager.WatchDevices(owner)
+	if err != nil {
+		ctxlogrus.Extract(ctx).Error(err)
+		return status.Errorf(codes.Internal, "Failed to retrieve devices")
+	}
+	defer stop()
+
+	for _, device := range devices {
+		if err := stream.Send(&proto.DeviceEvent{Type: proto.DeviceEvent_EXISTING, Device: d.mapDevice(device)}); err != nil {
+			return err
+		}
+	}
+	if err := stream.Send(&proto.DeviceEvent{Type: proto.DeviceEvent_SYNCED}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return status.Errorf(codes.Unavailable, "Too many device events, watch again")
+			}
+			if err := stream.Send(&proto.DeviceEvent{
+				Type:         mapEventType(event.Type),
+				Device:       d.mapDevice(event.Device),
+				PreviousName: event.PreviousName,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
 func (d *DeviceService) DeleteDevice(ctx context.Context, req *proto.DeleteDeviceReq) (*emptypb.Empty, error) {
 	user, err := authsession.CurrentUser(ctx)
 	if err != nil {
@@ -66,14 +267,215 @@ func (d *DeviceService) DeleteDevice(ctx context.Context, req *proto.DeleteDevic
 		}
 	}
 
-	if err := d.DeviceManager.DeleteDevice(deviceOwner, req.GetName()); err != nil {
+	// the owner is notified if an admin removes their device
+	var removed *storage.Device
+	if deviceOwner != user.Subject && d.Mailer.Enabled(notify.DeviceRemoved) {
+		if removed, err = d.DeviceManager.GetDevice(ctx, deviceOwner, req.GetName()); err != nil {
+			ctxlogrus.Extract(ctx).Error(err)
+			return nil, status.Errorf(codes.NotFound, "device not found")
+		}
+	}
+
+	if err := d.DeviceManager.DeleteDevice(ctx, deviceOwner, req.GetName()); err != nil {
 		ctxlogrus.Extract(ctx).Error(err)
 		return nil, status.Errorf(codes.Internal, "failed to delete device: %v", err)
 	}
 
+	d.Audit.Record(auditEvent(ctx, user, audit.DeviceDelete, audit.DeviceTarget(deviceOwner, req.GetName())))
+	if removed != nil {
+		d.Mailer.DeviceRemoved(removed, user)
+	}
+
 	return &emptypb.Empty{}, nil
 }
 
+func (d *DeviceService) UpdateDevice(ctx context.Context, req *proto.UpdateDeviceReq) (*proto.Device, error) {
+	user, err := authsession.CurrentUser(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "Not authenticated")
+	}
+
+	deviceOwner := user.Subject
+
+	if req.Owner != nil {
+		if user.Claims.IsAdmin() {
+			deviceOwner = req.Owner.Value
+		} else {
+			return nil, status.Errorf(codes.PermissionDenied, "must be an admin")
+		}
+	}
+
+	device, err := d.DeviceManager.UpdateDevice(ctx, deviceOwner, req.GetName(), devices.DeviceUpdate{
+		Name:              stringPtr(req.NewName),
+		PublicKey:         stringPtr(req.PublicKey),
+		PresharedKey:      stringPtr(req.PresharedKey),
+		ManualIPv4Address: stringPtr(req.ManualIpv4Address),
+		ManualIPv6Address: stringPtr(req.ManualIpv6Address),
+	})
+	if errors.Is(err, storage.ErrDeviceNotFound) {
+		return nil, status.Errorf(codes.NotFound, "device not found")
+	}
+	if err != nil {
+		ctxlogrus.Extract(ctx).Error(err)
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+
+	event := auditEvent(ctx, user, audit.DeviceUpdate, audit.DeviceTarget(deviceOwner, req.GetName()))
+	event.Details = updateDetails(req)
+	d.Audit.Record(event)
+
+	return d.mapDevice(device), nil
+}
+
+func (d *DeviceService) RenewDevice(ctx context.Context, req *proto.RenewDeviceReq) (*proto.Device, error) {
+	user, err := authsession.CurrentUser(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "Not authenticated")
+	}
+
+	deviceOwner := user.Subject
+
+	if req.Owner != nil {
+		if user.Claims.IsAdmin() {
+			deviceOwner = req.Owner.Value
+		} else {
+			return nil, status.Errorf(codes.PermissionDenied, "must be an admin")
+		}
+	}
+
+	device, err := d.DeviceManager.RenewDevice(ctx, deviceOwner, req.GetName(), timestampPtr(req.ExpiresAt))
+	if err != nil {
+		ctxlogrus.Extract(ctx).Error(err)
+		return nil, addDeviceStatus(err)
+	}
+
+	event := auditEvent(ctx, user, audit.DeviceRenew, audit.DeviceTarget(deviceOwner, req.GetName()))
+	event.Details = fmt.Sprintf("expires at %s", device.ExpiresAt.Format(time.RFC3339))
+	d.Audit.Record(event)
+
+	return d.mapDevice(device), nil
+}
+
+func (d *DeviceService) GetUsage(ctx context.Context, req *proto.GetUsageReq) (*proto.GetUsageRes, error) {
+	user, err := authsession.CurrentUser(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "Not authenticated")
+	}
+
+	deviceOwner := user.Subject
+
+	if req.Owner != nil {
+		if user.Claims.IsAdmin() {
+			deviceOwner = req.Owner.Value
+		} else {
+			return nil, status.Errorf(codes.PermissionDenied, "must be an admin")
+		}
+	}
+
+	if _, err := d.DeviceManager.GetDevice(ctx, deviceOwner, req.GetName()); err != nil {
+		return nil, status.Errorf(codes.NotFound, "device not found")
+	}
+
+	// defaults to the last day
+	to := time.Now()
+	if req.To != nil {
+		to = TimestampToTime(req.To)
+	}
+	from := to.Add(-24 * time.Hour)
+	if req.From != nil {
+		from = TimestampToTime(req.From)
+	}
+	step := time.Duration(0)
+	if req.Step != nil {
+		if err := req.Step.CheckValid(); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid step")
+		}
+		step = req.Step.AsDuration()
+	}
+
+	points, err := d.DeviceManager.Usage(ctx, deviceOwner, req.GetName(), from, to, step)
+	if err != nil {
+		var rangeErr *devices.UsageRangeError
+		if errors.As(err, &rangeErr) {
+			return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		ctxlogrus.Extract(ctx).Error(err)
+		return nil, status.Errorf(codes.Internal, "failed to retrieve usage: %v", err)
+	}
+
+	items := make([]*proto.UsagePoint, len(points))
+	for i, point := range points {
+		items[i] = &proto.UsagePoint{
+			Time:          TimeToTimestamp(&point.Time),
+			ReceiveBytes:  point.ReceiveBytes,
+			TransmitBytes: point.TransmitBytes,
+		}
+	}
+	return &proto.GetUsageRes{Items: items}, nil
+}
+
+func (d *DeviceService) SuspendDevice(ctx context.Context, req *proto.SuspendDeviceReq) (*proto.Device, error) {
+	return d.setSuspended(ctx, req.GetName(), req.Owner, true)
+}
+
+func (d *DeviceService) ResumeDevice(ctx context.Context, req *proto.ResumeDeviceReq) (*proto.Device, error) {
+	return d.setSuspended(ctx, req.GetName(), req.Owner, false)
+}
+
+func (d *DeviceService) setSuspended(ctx context.Context, name string, owner *wrapperspb.StringValue, suspended bool) (*proto.Device, error) {
+	user, err := authsession.CurrentUser(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "Not authenticated")
+	}
+
+	if !user.Claims.IsAdmin() {
+		return nil, status.Errorf(codes.PermissionDenied, "must be an admin")
+	}
+
+	deviceOwner := user.Subject
+	if owner != nil {
+		deviceOwner = owner.Value
+	}
+
+	var device *storage.Device
+	action := audit.DeviceResume
+	if suspended {
+		action = audit.DeviceSuspend
+		device, err = d.DeviceManager.SuspendDevice(ctx, deviceOwner, name)
+	} else {
+		device, err = d.DeviceManager.ResumeDevice(ctx, deviceOwner, name)
+	}
+	if err != nil {
+		ctxlogrus.Extract(ctx).Error(err)
+		return nil, status.Errorf(codes.Internal, "failed to update device")
+	}
+
+	d.Audit.Record(auditEvent(ctx, user, action, audit.DeviceTarget(deviceOwner, name)))
+
+	return d.mapDevice(device), nil
+}
+
+// updateDetails lists the changes of an update for the audit log, without any keys
+func updateDetails(req *proto.UpdateDeviceReq) string {
+	changes := []string{}
+	if req.NewName != nil && req.NewName.Value != req.GetName() {
+		changes = append(changes, fmt.Sprintf("renamed to '%s'", req.NewName.Value))
+	}
+	if req.PublicKey != nil {
+		changes = append(changes, "public key replaced")
+	}
+	if req.PresharedKey != nil {
+		changes = append(changes, "pre-shared key replaced")
+	}
+	if req.GetManualIpv4Address().GetValue() != "" {
+		changes = append(changes, fmt.Sprintf("IPv4 address set to %s", req.ManualIpv4Address.Value))
+	}
+	if req.GetManualIpv6Address().GetValue() != "" {
+		changes = append(changes, fmt.Sprintf("IPv6 address set to %s", req.ManualIpv6Address.Value))
+	}
+	return strings.Join(changes, ", ")
+}
+
 func (d *DeviceService) ListAllDevices(ctx context.Context, req *proto.ListAllDevicesReq) (*proto.ListAllDevicesRes, error) {
 	user, err := authsession.CurrentUser(ctx)
 	if err != nil {
@@ -84,32 +486,70 @@ func (d *DeviceService) ListAllDevices(ctx context.Context, req *proto.ListAllDe
 		return nil, status.Errorf(codes.PermissionDenied, "Must be an admin")
 	}
 
-	devices, err := d.DeviceManager.ListAllDevices()
+	devices, err := d.DeviceManager.ListAllDevices(ctx)
 	if err != nil {
 		ctxlogrus.Extract(ctx).Error(err)
 		return nil, status.Errorf(codes.Internal, "failed to retrieve devices: %v", err)
 	}
 
 	return &proto.ListAllDevicesRes{
-		Items: mapDevices(devices),
+		Items: d.mapDevices(devices),
 	}, nil
 }
 
-func mapDevice(d *storage.Device) *proto.Device {
+func (d *DeviceService) ListPendingDeletions(ctx context.Context, req *proto.ListPendingDeletionsReq) (*proto.ListPendingDeletionsRes, error) {
+	user, err := authsession.CurrentUser(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "Not authenticated")
+	}
+
+	if !user.Claims.IsAdmin() {
+		return nil, status.Errorf(codes.PermissionDenied, "Must be an admin")
+	}
+
+	within := time.Duration(0)
+	if req.Within != nil {
+		if err := req.Within.CheckValid(); err != nil || req.Within.AsDuration() < 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid duration")
+		}
+		within = req.Within.AsDuration()
+	}
+
+	devices, err := d.DeviceManager.PendingDeletions(ctx, within)
+	if err != nil {
+		ctxlogrus.Extract(ctx).Error(err)
+		return nil, status.Errorf(codes.Internal, "failed to retrieve devices: %v", err)
+	}
+
+	return &proto.ListPendingDeletionsRes{
+		Items: d.mapDevices(devices),
+	}, nil
+}
+
+func (d *DeviceService) mapDevice(device *storage.Device) *proto.Device {
 	return &proto.Device{
-		Name:              d.Name,
-		Owner:             d.Owner,
-		OwnerName:         d.OwnerName,
-		OwnerEmail:        d.OwnerEmail,
-		OwnerProvider:     d.OwnerProvider,
-		PublicKey:         d.PublicKey,
-		PresharedKey:      d.PresharedKey,
-		Address:           d.Address,
-		CreatedAt:         TimeToTimestamp(&d.CreatedAt),
-		LastHandshakeTime: TimeToTimestamp(d.LastHandshakeTime),
-		ReceiveBytes:      d.ReceiveBytes,
-		TransmitBytes:     d.TransmitBytes,
-		Endpoint:          d.Endpoint,
+		Name:              device.Name,
+		Owner:             device.Owner,
+		OwnerName:         device.OwnerName,
+		OwnerEmail:        device.OwnerEmail,
+		OwnerProvider:     device.OwnerProvider,
+		PublicKey:         device.PublicKey,
+		PresharedKey:      device.PresharedKey,
+		Address:           device.Address,
+		CreatedAt:         TimeToTimestamp(&device.CreatedAt),
+		LastHandshakeTime: TimeToTimestamp(device.LastHandshakeTime),
+		ReceiveBytes:      device.ReceiveBytes,
+		TransmitBytes:     device.TransmitBytes,
+		Endpoint:          device.Endpoint,
+		AccessPolicy:      mapAccessPolicy(device.AccessPolicy),
+		ExpiresAt:         TimeToTimestamp(device.ExpiresAt),
+		Suspended:         device.Suspended,
+		StaleSince:        TimeToTimestamp(device.StaleSince),
+		DeletionTime:      TimeToTimestamp(d.DeviceManager.DeletionTime(device)),
+		OverQuota:         device.OverQuota,
+		Profile:           device.Profile,
+		Gateway:           device.Gateway,
+		Routes:            device.Routes,
 		/**
 		 * WireGuard is a connectionless UDP protocol - data is only
 		 * sent over the wire when the client is sending real traffic.
@@ -117,14 +557,60 @@ func mapDevice(d *storage.Device) *proto.Device {
 		 * silent as possible.
 		 *
 		 */
-		Connected: d.LastHandshakeTime != nil && devices.IsConnected(*d.LastHandshakeTime),
+		Connected: device.LastHandshakeTime != nil && devices.IsConnected(*device.LastHandshakeTime),
 	}
 }
 
-func mapDevices(devices []*storage.Device) []*proto.Device {
+func (d *DeviceService) mapDevices(devices []*storage.Device) []*proto.Device {
 	items := []*proto.Device{}
-	for _, d := range devices {
-		items = append(items, mapDevice(d))
+	for _, device := range devices {
+		items = append(items, d.mapDevice(device))
 	}
 	return items
 }
+
+func mapEventType(t devices.EventType) proto.DeviceEvent_Type {
+	switch t {
+	case devices.DeviceAdded:
+		return proto.DeviceEvent_ADDED
+	case devices.DeviceDeleted:
+		return proto.DeviceEvent_DELETED
+	default:
+		return proto.DeviceEvent_UPDATED
+	}
+}
+
+func mapAccessPolicy(p *storage.AccessPolicy) *proto.AccessPolicy {
+	if p == nil {
+		return nil
+	}
+	policy := &proto.AccessPolicy{
+		Name:            p.Name,
+		ClientIsolation: p.ClientIsolation,
+	}
+	for _, rule := range p.Rules {
+		policy.Rules = append(policy.Rules, &proto.AccessRule{
+			Cidr:     rule.CIDR,
+			Protocol: rule.Protocol,
+			Ports:    rule.Ports,
+		})
+	}
+	return policy
+}
+
+func mapAccessPolicyReq(p *proto.AccessPolicy) *storage.AccessPolicy {
+	if p == nil {
+		return nil
+	}
+	policy := &storage.AccessPolicy{
+		ClientIsolation: p.GetClientIsolation(),
+	}
+	for _, rule := range p.GetRules() {
+		policy.Rules = append(policy.Rules, storage.AccessRule{
+			CIDR:     rule.GetCidr(),
+			Protocol: rule.GetProtocol(),
+			Ports:    rule.GetPorts(),
+		})
+	}
+	return policy
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCMetrics counts the gRPC, grpc-web and REST requests and their durations,
+// REST requests are served through the gRPC server and counted by their gRPC method.
+// A nil *GRPCMetrics records nothing.
+type GRPCMetrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+func NewGRPCMetrics() *GRPCMetrics {
+	return &GRPCMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "wg_access_server",
+			Subsystem: "grpc",
+			Name:      "requests_total",
+			Help:      "Handled gRPC requests by method and status code.",
+		}, []string{"method", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "wg_access_server",
+			Subsystem: "grpc",
+			Name:      "request_duration_seconds",
+			Help:      "Duration of gRPC requests by method, streams are measured until they end.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+	}
+}
+
+func (m *GRPCMetrics) observe(method string, start time.Time, err error) {
+	if m == nil {
+		return
+	}
+	m.requests.WithLabelValues(method, status.Code(err).String()).Inc()
+	m.duration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
+
+func (m *GRPCMetrics) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	m.observe(info.FullMethod, start, err)
+	return resp, err
+}
+
+func (m *GRPCMetrics) streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	m.observe(info.FullMethod, start, err)
+	return err
+}
+
+// Collectors returns the Prometheus metrics of the gRPC requests
+func (m *GRPCMetrics) Collectors() []prometheus.Collector {
+	if m == nil {
+		return nil
+	}
+	return []prometheus.Collector{m.requests, m.duration}
+}
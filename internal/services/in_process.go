@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+// inProcessConn is a client connection to the services registered on it, which calls them
+// in the same process through the interceptor of the native grpc server.
+// The REST gateway uses it, so that its calls are logged, recovered and restricted like grpc calls.
+type inProcessConn struct {
+	interceptor grpc.UnaryServerInterceptor
+	// by full method name, e.g. "/proto.Devices/AddDevice"
+	methods map[string]inProcessMethod
+}
+
+type inProcessMethod struct {
+	impl    interface{}
+	handler grpc.MethodHandler
+}
+
+func newInProcessConn(interceptor grpc.UnaryServerInterceptor) *inProcessConn {
+	return &inProcessConn{
+		interceptor: interceptor,
+		methods:     make(map[string]inProcessMethod),
+	}
+}
+
+// RegisterService implements grpc.ServiceRegistrar, only unary methods are registered
+func (c *inProcessConn) RegisterService(desc *grpc.ServiceDesc, impl interface{}) {
+	for _, method := range desc.Methods {
+		c.methods["/"+desc.ServiceName+"/"+method.MethodName] = inProcessMethod{impl, method.Handler}
+	}
+}
+
+// Invoke implements grpc.ClientConnInterface
+func (c *inProcessConn) Invoke(ctx context.Context, method string, args interface{}, reply interface{}, opts ...grpc.CallOption) error {
+	m, ok := c.methods[method]
+	if !ok {
+		return status.Errorf(codes.Unimplemented, "unknown method %s", strings.TrimPrefix(method, "/"))
+	}
+	// the gateway passes the request headers as outgoing metadata, the services read them as incoming metadata
+	if md, ok := metadata.FromOutgoingContext(ctx); ok {
+		ctx = metadata.NewIncomingContext(ctx, md)
+	}
+	dec := func(in interface{}) error {
+		protobuf.Merge(in.(protobuf.Message), args.(protobuf.Message))
+		return nil
+	}
+	res, err := m.handler(m.impl, ctx, dec, c.interceptor)
+	if err != nil {
+		return err
+	}
+	protobuf.Merge(reply.(protobuf.Message), res.(protobuf.Message))
+	return nil
+}
+
+// NewStream implements grpc.ClientConnInterface,
+// streaming methods are only available through grpc and grpc-web
+func (c *inProcessConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return nil, status.Errorf(codes.Unimplemented, "streaming method %s is not available through the REST gateway", strings.TrimPrefix(method, "/"))
+}
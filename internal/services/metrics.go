@@ -2,19 +2,29 @@ package services
 
 import (
 	"net/http"
+	"sort"
 
+	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
 
 	"github.com/freifunkMUC/wg-access-server/buildinfo"
 	"github.com/freifunkMUC/wg-access-server/internal/config"
 	"github.com/freifunkMUC/wg-access-server/internal/devices"
+	"github.com/freifunkMUC/wg-access-server/internal/dnsproxy"
+	"github.com/freifunkMUC/wg-access-server/internal/network"
 )
 
 type MetricsDeps struct {
 	Config        *config.AppConfig
 	DeviceManager *devices.DeviceManager
+	// DNS is nil if the dns proxy is disabled
+	DNS  *dnsproxy.DNSServer
+	GRPC *GRPCMetrics
+	// Firewall is nil if the firewall configuration is disabled
+	Firewall *network.PeerFirewall
 }
 
 // MetricsHandler returns an http.Handler that exposes Prometheus metrics.
@@ -56,6 +66,16 @@ func MetricsHandler(deps *MetricsDeps) http.Handler {
 	})
 	reg.MustRegister(up)
 
+	if deps.DNS != nil {
+		reg.MustRegister(deps.DNS.Collectors()...)
+	}
+	if deps.GRPC != nil {
+		reg.MustRegister(deps.GRPC.Collectors()...)
+	}
+	if deps.Firewall != nil {
+		reg.MustRegister(deps.Firewall.Collectors()...)
+	}
+
 	// Device-related metrics (included when metadata + device metrics enabled)
 	if deps.DeviceManager != nil && deps.Config.EnableMetadata && deps.Config.EnableDeviceMetrics {
 		// Total devices stored
@@ -64,10 +84,7 @@ func MetricsHandler(deps *MetricsDeps) http.Handler {
 			Name:      "devices_total",
 			Help:      "Total number of devices registered in storage.",
 		}, func() float64 {
-			devs, err := deps.DeviceManager.ListAllDevices()
-			if err != nil {
-				return 0
-			}
+			devs := deps.DeviceManager.Snapshot()
 			return float64(len(devs))
 		})
 		reg.MustRegister(devicesTotal)
@@ -78,10 +95,7 @@ func MetricsHandler(deps *MetricsDeps) http.Handler {
 			Name:      "devices_connected",
 			Help:      "Number of devices considered connected (recent handshake).",
 		}, func() float64 {
-			devs, err := deps.DeviceManager.ListAllDevices()
-			if err != nil {
-				return 0
-			}
+			devs := deps.DeviceManager.Snapshot()
 			var c int
 			for _, d := range devs {
 				if d.LastHandshakeTime != nil && devices.IsConnected(*d.LastHandshakeTime) {
@@ -98,10 +112,7 @@ func MetricsHandler(deps *MetricsDeps) http.Handler {
 			Name:      "devices_bytes_received_total",
 			Help:      "Sum of received bytes across all devices (as tracked).",
 		}, func() float64 {
-			devs, err := deps.DeviceManager.ListAllDevices()
-			if err != nil {
-				return 0
-			}
+			devs := deps.DeviceManager.Snapshot()
 			var sum int64
 			for _, d := range devs {
 				sum += d.ReceiveBytes
@@ -115,10 +126,7 @@ func MetricsHandler(deps *MetricsDeps) http.Handler {
 			Name:      "devices_bytes_transmitted_total",
 			Help:      "Sum of transmitted bytes across all devices (as tracked).",
 		}, func() float64 {
-			devs, err := deps.DeviceManager.ListAllDevices()
-			if err != nil {
-				return 0
-			}
+			devs := deps.DeviceManager.Snapshot()
 			var sum int64
 			for _, d := range devs {
 				sum += d.TransmitBytes
@@ -126,11 +134,123 @@ func MetricsHandler(deps *MetricsDeps) http.Handler {
 			return float64(sum)
 		})
 		reg.MustRegister(txBytesTotal)
+
+		// Series per device, limited to bound the cardinality
+		reg.MustRegister(newDeviceCollector(deps.DeviceManager, deps.Config.Metrics.DeviceLimit))
+	}
+
+	// Traffic of the users in the current quota period
+	if deps.DeviceManager != nil && deps.DeviceManager.TrafficQuotaConfigured() {
+		reg.MustRegister(newTrafficQuotaCollector(deps.DeviceManager))
 	}
 
 	return promhttp.HandlerFor(reg, promhttp.HandlerOpts{EnableOpenMetrics: true})
 }
 
+// deviceCollector exports the metadata of every device labelled by owner, name and provider.
+// Only the first limit devices ordered by owner and name are exported, 0 means unlimited.
+type deviceCollector struct {
+	deviceManager *devices.DeviceManager
+	limit         int
+	received      *prometheus.Desc
+	transmitted   *prometheus.Desc
+	lastHandshake *prometheus.Desc
+	connected     *prometheus.Desc
+	omitted       *prometheus.Desc
+}
+
+func newDeviceCollector(deviceManager *devices.DeviceManager, limit int) *deviceCollector {
+	labels := []string{"owner", "name", "provider"}
+	return &deviceCollector{
+		deviceManager: deviceManager,
+		limit:         limit,
+		received: prometheus.NewDesc("wg_access_server_device_received_bytes_total",
+			"Bytes received from the device, reset when the WireGuard peer is recreated.", labels, nil),
+		transmitted: prometheus.NewDesc("wg_access_server_device_transmitted_bytes_total",
+			"Bytes transmitted to the device, reset when the WireGuard peer is recreated.", labels, nil),
+		lastHandshake: prometheus.NewDesc("wg_access_server_device_last_handshake_timestamp_seconds",
+			"Time of the last handshake of the device, not exported if there was none.", labels, nil),
+		connected: prometheus.NewDesc("wg_access_server_device_connected",
+			"1 if the device is considered connected (recent handshake).", labels, nil),
+		omitted: prometheus.NewDesc("wg_access_server_device_metrics_omitted",
+			"Number of devices without series because of the device limit.", nil, nil),
+	}
+}
+
+func (c *deviceCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.received
+	ch <- c.transmitted
+	ch <- c.lastHandshake
+	ch <- c.connected
+	ch <- c.omitted
+}
+
+func (c *deviceCollector) Collect(ch chan<- prometheus.Metric) {
+	devs := c.deviceManager.Snapshot()
+	sort.Slice(devs, func(i, j int) bool {
+		if devs[i].Owner != devs[j].Owner {
+			return devs[i].Owner < devs[j].Owner
+		}
+		return devs[i].Name < devs[j].Name
+	})
+	omitted := 0
+	if c.limit > 0 && len(devs) > c.limit {
+		omitted = len(devs) - c.limit
+		devs = devs[:c.limit]
+	}
+	ch <- prometheus.MustNewConstMetric(c.omitted, prometheus.GaugeValue, float64(omitted))
+
+	for _, d := range devs {
+		labels := []string{d.Owner, d.Name, d.OwnerProvider}
+		ch <- prometheus.MustNewConstMetric(c.received, prometheus.CounterValue, float64(d.ReceiveBytes), labels...)
+		ch <- prometheus.MustNewConstMetric(c.transmitted, prometheus.CounterValue, float64(d.TransmitBytes), labels...)
+		connected := 0.0
+		if d.LastHandshakeTime != nil && !d.LastHandshakeTime.IsZero() {
+			ch <- prometheus.MustNewConstMetric(c.lastHandshake, prometheus.GaugeValue, float64(d.LastHandshakeTime.Unix()), labels...)
+			if devices.IsConnected(*d.LastHandshakeTime) {
+				connected = 1
+			}
+		}
+		ch <- prometheus.MustNewConstMetric(c.connected, prometheus.GaugeValue, connected, labels...)
+	}
+}
+
+// trafficQuotaCollector exports the traffic of every user with devices in the current quota period
+type trafficQuotaCollector struct {
+	deviceManager *devices.DeviceManager
+	used          *prometheus.Desc
+	quota         *prometheus.Desc
+}
+
+func newTrafficQuotaCollector(deviceManager *devices.DeviceManager) *trafficQuotaCollector {
+	return &trafficQuotaCollector{
+		deviceManager: deviceManager,
+		used: prometheus.NewDesc("wg_access_server_user_traffic_bytes",
+			"Received and transmitted bytes of the user in the current month.", []string{"user"}, nil),
+		quota: prometheus.NewDesc("wg_access_server_user_traffic_quota_bytes",
+			"Monthly traffic quota of the user, only exported for users with a quota.", []string{"user"}, nil),
+	}
+}
+
+func (c *trafficQuotaCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.used
+	ch <- c.quota
+}
+
+func (c *trafficQuotaCollector) Collect(ch chan<- prometheus.Metric) {
+	usages, err := c.deviceManager.TrafficUsages()
+	if err != nil {
+		logrus.Error(errors.Wrap(err, "failed to collect the traffic quota metrics"))
+		return
+	}
+	for _, usage := range usages {
+		ch <- prometheus.MustNewConstMetric(c.used, prometheus.GaugeValue, float64(usage.Used), usage.User)
+		if usage.Quota > 0 {
+			ch <- prometheus.MustNewConstMetric(c.quota, prometheus.GaugeValue, float64(usage.Quota), usage.User)
+		}
+	}
+}
+
 // MetricsEndpoint wraps MetricsHandler with optional basic auth protection.
 func MetricsEndpoint(deps *MetricsDeps) http.Handler {
 	h := MetricsHandler(deps)
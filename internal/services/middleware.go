@@ -2,16 +2,39 @@ package services
 
 import (
 	"fmt"
+	"mime"
 	"net/http"
 	"runtime/debug"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 
 	"github.com/freifunkMUC/wg-access-server/internal/traces"
 )
 
+// TracesMiddleware records a span per request, except for the health checks and metric scrapes,
+// and adds the trace id to the context for the logs
 func TracesMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		next.ServeHTTP(w, r.WithContext(traces.WithTraceID(r.Context())))
 	})
+	return otelhttp.NewHandler(handler, "http.request",
+		otelhttp.WithSpanNameFormatter(httpSpanName),
+		otelhttp.WithFilter(func(r *http.Request) bool {
+			return !strings.HasPrefix(r.URL.Path, "/health") && r.URL.Path != "/metrics"
+		}),
+	)
+}
+
+// httpSpanName names the span after the matched route to keep the number of span names low
+func httpSpanName(_ string, r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			return r.Method + " " + template
+		}
+	}
+	return r.Method
 }
 
 func RecoveryMiddleware(next http.Handler) http.Handler {
@@ -28,3 +51,20 @@ func RecoveryMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// requireJSON rejects requests that modify data without a JSON body, unless they are authenticated by a bearer token.
+// Browsers send cross-site form posts and fetches with e.g. text/plain bodies without a preflight,
+// together with the session cookie, but never set the JSON content type or the Authorization header.
+func requireJSON(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Header.Get("Authorization") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+			http.Error(w, "expected a request with content type application/json", http.StatusUnsupportedMediaType)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
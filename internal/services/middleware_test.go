@@ -0,0 +1,50 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/freifunkMUC/wg-access-server/internal/config"
+)
+
+func TestRequireJSON(t *testing.T) {
+	require := require.New(t)
+
+	router, err := ApiRouter(&ApiServices{Config: &config.AppConfig{}})
+	require.NoError(err)
+
+	// a cross-site form post of a signed in user
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/tokens", strings.NewReader(`{"name": "csrf"}`))
+	req.Header.Set("Content-Type", "text/plain")
+	req.AddCookie(&http.Cookie{Name: "auth-session", Value: "session"})
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(http.StatusUnsupportedMediaType, rec.Code)
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/v1/tokens/1", nil)
+	req.AddCookie(&http.Cookie{Name: "auth-session", Value: "session"})
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(http.StatusUnsupportedMediaType, rec.Code)
+
+	passed := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { passed = true })
+	for _, contentType := range []string{"application/json", "application/json; charset=utf-8"} {
+		passed = false
+		req = httptest.NewRequest(http.MethodPost, "/api/v1/tokens", strings.NewReader(`{"name": "api"}`))
+		req.Header.Set("Content-Type", contentType)
+		requireJSON(next).ServeHTTP(httptest.NewRecorder(), req)
+		require.True(passed, contentType)
+	}
+
+	// browsers don't send the Authorization header cross-site
+	passed = false
+	req = httptest.NewRequest(http.MethodDelete, "/api/v1/tokens/1", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	requireJSON(next).ServeHTTP(httptest.NewRecorder(), req)
+	require.True(passed)
+}
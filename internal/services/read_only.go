@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/freifunkMUC/wg-access-server/pkg/authnz/authsession"
+)
+
+// readOnlyMethods may be called by identities with the read-only claim (read-only API tokens)
+var readOnlyMethods = map[string]bool{
+	"/proto.Server/Info":                  true,
+	"/proto.Devices/ListDevices":          true,
+	"/proto.Devices/WatchDevices":         true,
+	"/proto.Devices/GetUsage":             true,
+	"/proto.Devices/ListAllDevices":       true,
+	"/proto.Devices/ListPendingDeletions": true,
+	"/proto.Users/ListUsers":              true,
+	"/proto.Tokens/ListTokens":            true,
+	"/proto.Audit/List":                   true,
+}
+
+var errReadOnly = status.Errorf(codes.PermissionDenied, "Not allowed with a read-only token")
+
+func isReadOnly(ctx context.Context) bool {
+	user, err := authsession.CurrentUser(ctx)
+	return err == nil && user.Claims.IsReadOnly()
+}
+
+// readOnlyInterceptor rejects all methods that modify data for read-only identities
+func readOnlyInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if !readOnlyMethods[info.FullMethod] && isReadOnly(ctx) {
+		return nil, errReadOnly
+	}
+	return handler(ctx, req)
+}
+
+// readOnlyStreamInterceptor is the readOnlyInterceptor of streaming methods
+func readOnlyStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if !readOnlyMethods[info.FullMethod] && isReadOnly(ss.Context()) {
+		return errReadOnly
+	}
+	return handler(srv, ss)
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"slices"
 	"strings"
 
 	"github.com/freifunkMUC/wg-embed/pkg/wgembed"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/freifunkMUC/wg-access-server/buildinfo"
 	"github.com/freifunkMUC/wg-access-server/internal/config"
+	"github.com/freifunkMUC/wg-access-server/internal/devices"
 	"github.com/freifunkMUC/wg-access-server/internal/network"
 	"github.com/freifunkMUC/wg-access-server/pkg/authnz/authsession"
 	"github.com/freifunkMUC/wg-access-server/proto/proto"
@@ -18,8 +20,9 @@ import (
 
 type ServerService struct {
 	proto.UnimplementedServerServer
-	Config *config.AppConfig
-	Wg     wgembed.WireGuardInterface
+	Config        *config.AppConfig
+	Wg            wgembed.WireGuardInterface
+	DeviceManager *devices.DeviceManager
 }
 
 func (s *ServerService) Info(ctx context.Context, req *proto.InfoReq) (*proto.InfoRes, error) {
@@ -28,15 +31,7 @@ func (s *ServerService) Info(ctx context.Context, req *proto.InfoReq) (*proto.In
 		return nil, status.Errorf(codes.PermissionDenied, "not authenticated")
 	}
 
-	host := s.Config.ExternalHost
-	if strings.Contains(host, ":") {
-		if !strings.HasPrefix(host, "[") {
-			host = "[" + host
-		}
-		if !strings.HasSuffix(host, "]") {
-			host = host + "]"
-		}
-	}
+	host := endpointHost(s.Config.ExternalHost)
 
 	publicKey, err := s.Wg.PublicKey()
 	if err != nil {
@@ -50,6 +45,18 @@ func (s *ServerService) Info(ctx context.Context, req *proto.InfoReq) (*proto.In
 	}
 	dnsAddress := network.StringJoinIPs(vpnip, vpnipv6)
 
+	deviceCount, deviceQuota, err := s.DeviceManager.DeviceUsage(ctx, user)
+	if err != nil {
+		ctxlogrus.Extract(ctx).Error(err)
+		return nil, status.Errorf(codes.Internal, "failed to get device usage")
+	}
+
+	trafficUsed, trafficQuota, trafficReset, err := s.DeviceManager.TrafficUsage(ctx, user)
+	if err != nil {
+		ctxlogrus.Extract(ctx).Error(err)
+		return nil, status.Errorf(codes.Internal, "failed to get traffic usage")
+	}
+
 	var hostVPNIP string
 	if vpnip.IsValid() {
 		hostVPNIP = vpnip.Addr().String()
@@ -77,11 +84,38 @@ func (s *ServerService) Info(ctx context.Context, req *proto.InfoReq) (*proto.In
 		ClientConfigPersistentKeepalive: int32(s.Config.ClientConfig.PersistentKeepalive),
 		BuildInfo:                       &proto.BuildInfo{Version: buildinfo.Version(), Commit: buildinfo.ShortCommitHash()},
 		Mtu:                             int32(s.Config.WireGuard.MTU),
+		DeviceQuota:                     int32(deviceQuota),
+		DeviceCount:                     int32(deviceCount),
+		TrafficQuota:                    trafficQuota,
+		TrafficUsed:                     trafficUsed,
+		TrafficQuotaReset:               TimeToTimestamp(&trafficReset),
+		Profiles:                        clientProfiles(s.Config),
 	}, nil
 }
 
+func clientProfiles(config *config.AppConfig) []*proto.ClientProfile {
+	profiles := make([]*proto.ClientProfile, 0, len(config.ClientConfig.Profiles))
+	for _, profile := range config.ClientConfig.Profiles {
+		profiles = append(profiles, &proto.ClientProfile{
+			Name:       profile.Name,
+			AllowedIps: strings.Join(profile.AllowedIPs, ", "),
+			DnsServers: strings.Join(profile.DNSServers, ", "),
+			Mtu:        int32(profile.MTU),
+		})
+	}
+	return profiles
+}
+
+// allowedIPs returns the networks clients route through the VPN,
+// the allowed IPs and the networks of the accepting rules
 func allowedIPs(config *config.AppConfig) string {
-	return strings.Join(config.VPN.AllowedIPs, ", ")
+	networks := slices.Clone(config.VPN.AllowedIPs)
+	for _, rule := range config.VPN.Rules {
+		if rule.Action != network.RuleReject && !slices.Contains(networks, rule.CIDR) {
+			networks = append(networks, rule.CIDR)
+		}
+	}
+	return strings.Join(networks, ", ")
 }
 
 func clientConfigDnsServers(config *config.AppConfig) string {
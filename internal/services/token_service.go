@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/logrus/ctxlogrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/freifunkMUC/wg-access-server/internal/audit"
+	"github.com/freifunkMUC/wg-access-server/internal/storage"
+	"github.com/freifunkMUC/wg-access-server/internal/tokens"
+	"github.com/freifunkMUC/wg-access-server/pkg/authnz/authsession"
+	"github.com/freifunkMUC/wg-access-server/proto/proto"
+)
+
+type TokenService struct {
+	proto.UnimplementedTokensServer
+	TokenManager *tokens.TokenManager
+	Audit        *audit.Log
+}
+
+func (t *TokenService) CreateToken(ctx context.Context, req *proto.CreateTokenReq) (*proto.CreateTokenRes, error) {
+	user, err := authsession.CurrentUser(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "Not authenticated")
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresAt != nil {
+		value := TimestampToTime(req.ExpiresAt)
+		expiresAt = &value
+	}
+
+	token, secret, err := t.TokenManager.Create(ctx, user, req.GetName(), req.GetReadOnly(), expiresAt)
+	if err != nil {
+		var validationErr *tokens.ValidationError
+		if errors.As(err, &validationErr) {
+			return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		if errors.Is(err, tokens.ErrReadOnly) {
+			return nil, status.Errorf(codes.PermissionDenied, "%v", err)
+		}
+		ctxlogrus.Extract(ctx).Error(err)
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+
+	event := auditEvent(ctx, user, audit.TokenCreate, token.ID)
+	event.Details = fmt.Sprintf("name '%s', read-only: %t", token.Name, token.ReadOnly)
+	t.Audit.Record(event)
+
+	return &proto.CreateTokenRes{
+		Token:  mapToken(token),
+		Secret: secret,
+	}, nil
+}
+
+func (t *TokenService) ListTokens(ctx context.Context, req *proto.ListTokensReq) (*proto.ListTokensRes, error) {
+	user, err := authsession.CurrentUser(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "Not authenticated")
+	}
+
+	tokens, err := t.TokenManager.List(ctx, user.Subject)
+	if err != nil {
+		ctxlogrus.Extract(ctx).Error(err)
+		return nil, status.Errorf(codes.Internal, "Failed to retrieve tokens")
+	}
+
+	items := []*proto.Token{}
+	for _, token := range tokens {
+		items = append(items, mapToken(token))
+	}
+	return &proto.ListTokensRes{
+		Items: items,
+	}, nil
+}
+
+func (t *TokenService) RevokeToken(ctx context.Context, req *proto.RevokeTokenReq) (*emptypb.Empty, error) {
+	user, err := authsession.CurrentUser(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "Not authenticated")
+	}
+
+	if err := t.TokenManager.Revoke(ctx, user.Subject, req.GetId()); err != nil {
+		ctxlogrus.Extract(ctx).Error(err)
+		return nil, status.Errorf(codes.NotFound, "failed to revoke token: %v", err)
+	}
+
+	t.Audit.Record(auditEvent(ctx, user, audit.TokenRevoke, req.GetId()))
+
+	return &emptypb.Empty{}, nil
+}
+
+func mapToken(t *storage.Token) *proto.Token {
+	return &proto.Token{
+		Id:        t.ID,
+		Name:      t.Name,
+		Owner:     t.Owner,
+		ReadOnly:  t.ReadOnly,
+		CreatedAt: TimeToTimestamp(&t.CreatedAt),
+		ExpiresAt: TimeToTimestamp(t.ExpiresAt),
+	}
+}
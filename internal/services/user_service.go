@@ -8,7 +8,10 @@ import (
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 
+	"github.com/freifunkMUC/wg-access-server/internal/audit"
 	"github.com/freifunkMUC/wg-access-server/internal/devices"
+	"github.com/freifunkMUC/wg-access-server/internal/notify"
+	"github.com/freifunkMUC/wg-access-server/internal/tokens"
 	"github.com/freifunkMUC/wg-access-server/pkg/authnz/authsession"
 	"github.com/freifunkMUC/wg-access-server/proto/proto"
 )
@@ -16,6 +19,9 @@ import (
 type UserService struct {
 	proto.UnimplementedUsersServer
 	DeviceManager *devices.DeviceManager
+	TokenManager  *tokens.TokenManager
+	Audit         *audit.Log
+	Mailer        *notify.Mailer
 }
 
 func (d *UserService) ListUsers(ctx context.Context, req *proto.ListUsersReq) (*proto.ListUsersRes, error) {
@@ -28,7 +34,7 @@ func (d *UserService) ListUsers(ctx context.Context, req *proto.ListUsersReq) (*
 		return nil, status.Errorf(codes.PermissionDenied, "must be an admin")
 	}
 
-	users, err := d.DeviceManager.ListUsers()
+	users, err := d.DeviceManager.ListUsers(ctx)
 	if err != nil {
 		ctxlogrus.Extract(ctx).Error(err)
 		return nil, status.Errorf(codes.Internal, "failed to retrieve users")
@@ -49,11 +55,73 @@ func (d *UserService) DeleteUser(ctx context.Context, req *proto.DeleteUserReq)
 		return nil, status.Errorf(codes.PermissionDenied, "must be an admin")
 	}
 
-	if err := d.DeviceManager.DeleteDevicesForUser(req.Name); err != nil {
+	userDevices, err := d.DeviceManager.ListDevices(ctx, req.Name)
+	if err != nil {
+		ctxlogrus.Extract(ctx).Error(err)
+		return nil, status.Errorf(codes.Internal, "failed to delete user")
+	}
+
+	if err := d.DeviceManager.DeleteDevicesForUser(ctx, req.Name); err != nil {
+		ctxlogrus.Extract(ctx).Error(err)
+		return nil, status.Errorf(codes.Internal, "failed to delete user")
+	}
+
+	revoked, err := d.TokenManager.RevokeAll(ctx, req.Name)
+	for _, token := range revoked {
+		d.Audit.Record(auditEvent(ctx, user, audit.TokenRevoke, token.ID))
+	}
+	if err != nil {
 		ctxlogrus.Extract(ctx).Error(err)
 		return nil, status.Errorf(codes.Internal, "failed to delete user")
 	}
 
+	d.Audit.Record(auditEvent(ctx, user, audit.UserDelete, req.Name))
+	if req.Name != user.Subject {
+		for _, device := range userDevices {
+			d.Mailer.DeviceRemoved(device, user)
+		}
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (d *UserService) SuspendUser(ctx context.Context, req *proto.SuspendUserReq) (*emptypb.Empty, error) {
+	user, err := authsession.CurrentUser(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "not authenticated")
+	}
+
+	if !user.Claims.Has("admin", "true") {
+		return nil, status.Errorf(codes.PermissionDenied, "must be an admin")
+	}
+
+	if err := d.DeviceManager.SuspendDevicesForUser(ctx, req.Name); err != nil {
+		ctxlogrus.Extract(ctx).Error(err)
+		return nil, status.Errorf(codes.Internal, "failed to suspend user")
+	}
+
+	d.Audit.Record(auditEvent(ctx, user, audit.UserSuspend, req.Name))
+
+	return &emptypb.Empty{}, nil
+}
+
+func (d *UserService) ResumeUser(ctx context.Context, req *proto.ResumeUserReq) (*emptypb.Empty, error) {
+	user, err := authsession.CurrentUser(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "not authenticated")
+	}
+
+	if !user.Claims.Has("admin", "true") {
+		return nil, status.Errorf(codes.PermissionDenied, "must be an admin")
+	}
+
+	if err := d.DeviceManager.ResumeDevicesForUser(ctx, req.Name); err != nil {
+		ctxlogrus.Extract(ctx).Error(err)
+		return nil, status.Errorf(codes.Internal, "failed to resume user")
+	}
+
+	d.Audit.Record(auditEvent(ctx, user, audit.UserResume, req.Name))
+
 	return &emptypb.Empty{}, nil
 }
 
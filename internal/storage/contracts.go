@@ -1,7 +1,10 @@
 package storage
 
 import (
+	"context"
+	"encoding/hex"
 	"fmt"
+	"net/netip"
 	"net/url"
 	"time"
 
@@ -12,21 +15,118 @@ import (
 type Storage interface {
 	Watcher
 	Pingable
+	TokenStorage
+	AllocationStorage
+	AuditStorage
+	UsageStorage
+	// WithContext returns the storage with its queries traced as part of the span in ctx
+	WithContext(ctx context.Context) Storage
 	Save(device *Device) error
+	// Update changes the name, keys or address of an existing device, the usage of a renamed device is kept.
+	// previous identifies the stored device and is passed on to OnUpdate callbacks.
+	// It fails with ErrDeviceNotFound if the device doesn't exist (anymore).
+	Update(previous *Device, device *Device) error
+	// SaveMetadata writes the metadata fields of existing devices in one batch, other fields are left unchanged.
+	// The saves aren't reported to the OnAdd and OnUpdate callbacks of the storage itself.
+	SaveMetadata(devices []*Device) error
+	// SetExpiryWarned writes the time the owner was warned about the expiry of an existing device,
+	// other fields are left unchanged. The write isn't reported to the OnUpdate callbacks.
+	SetExpiryWarned(device *Device, warnedAt *time.Time) error
+	// SetStaleSince writes the time an existing device became stale, other fields are left unchanged.
+	// The write isn't reported to the OnUpdate callbacks.
+	SetStaleSince(device *Device, staleSince *time.Time) error
+	// SetOverQuota writes whether an existing device is over the traffic quota of its owner, other fields are left unchanged.
+	// The device as stored afterwards is returned and reported to the OnUpdate callbacks.
+	SetOverQuota(owner string, name string, overQuota bool) (*Device, error)
+	// SetSuspended writes whether an existing device is suspended, other fields are left unchanged.
+	// The device as stored afterwards is returned and reported to the OnUpdate callbacks.
+	SetSuspended(owner string, name string, suspended bool) (*Device, error)
+	// SetExpiresAt writes the expiry date of an existing device and clears its expiry warning, other fields are left unchanged.
+	// The device as stored afterwards is returned and reported to the OnUpdate callbacks.
+	SetExpiresAt(owner string, name string, expiresAt *time.Time) (*Device, error)
 	List(owner string) ([]*Device, error)
 	Get(owner string, name string) (*Device, error)
 	GetByPublicKey(publicKey string) (*Device, error)
+	// Delete removes the device and its usage
 	Delete(device *Device) error
 	Close() error
 	Open() error
 }
 
+type TokenStorage interface {
+	SaveToken(token *Token) error
+	ListTokens(owner string) ([]*Token, error)
+	GetToken(id string) (*Token, error)
+	GetTokenByHash(hash string) (*Token, error)
+	DeleteToken(token *Token) error
+}
+
+// ErrDeviceNotFound is returned by writes to a device that doesn't exist, e.g. because it was deleted concurrently
+var ErrDeviceNotFound = errors.New("device doesn't exist")
+
+// ErrAddressAllocated is returned by CreateAllocation if the address is already allocated
+var ErrAddressAllocated = errors.New("address is already allocated")
+
+type AllocationStorage interface {
+	// CreateAllocation fails with ErrAddressAllocated if the address is already allocated,
+	// also if it was allocated concurrently by another replica
+	CreateAllocation(allocation *Allocation) error
+	ListAllocations() ([]*Allocation, error)
+	// ListAllocationsBetween lists the allocations of the addresses from first to last, inclusive
+	ListAllocationsBetween(first, last netip.Addr) ([]*Allocation, error)
+	// ReleaseAllocation marks an allocation as released, it is kept until purged
+	ReleaseAllocation(address string, releasedAt time.Time) error
+	DeleteAllocation(address string) error
+	// PurgeAllocations deletes all allocations released before the given time
+	PurgeAllocations(releasedBefore time.Time) error
+}
+
+type AuditStorage interface {
+	SaveAuditEvent(event *AuditEvent) error
+	// ListAuditEvents returns the matching events, newest first
+	ListAuditEvents(filter AuditFilter) ([]*AuditEvent, error)
+}
+
+// AuditFilter selects audit events, empty fields match all events
+type AuditFilter struct {
+	Actor         string
+	ActorEmail    string
+	ActorProvider string
+	Action        string
+	Target        string
+	Since         *time.Time
+	Until         *time.Time
+	// only events with a lower ID, used for pagination
+	BeforeID uint64
+	// 0 means no limit
+	Limit int
+}
+
+type UsageStorage interface {
+	// AddUsage adds the traffic to the bucket of the usage, the bucket is created if it doesn't exist
+	AddUsage(usage *Usage) error
+	// ListUsage returns the buckets of a device starting within [since, until), ordered by time
+	ListUsage(owner string, device string, since time.Time, until time.Time) ([]*Usage, error)
+	// DownsampleUsage merges the buckets starting before the given time into buckets of the resolution
+	DownsampleUsage(before time.Time, resolution time.Duration) error
+	// PurgeUsage deletes all buckets starting before the given time
+	PurgeUsage(before time.Time) error
+	// AddQuotaUsage adds the traffic to the quota period of the user, the period is created if it doesn't exist
+	AddQuotaUsage(usage *QuotaUsage) error
+	// ListQuotaUsage returns the traffic of all users within the quota period
+	ListQuotaUsage(period time.Time) ([]*QuotaUsage, error)
+	// PurgeQuotaUsage deletes all quota periods starting before the given time
+	PurgeQuotaUsage(before time.Time) error
+}
+
 type Watcher interface {
 	OnAdd(cb Callback)
 	OnDelete(cb Callback)
+	OnUpdate(cb UpdateCallback)
 	OnReconnect(func())
 	EmitAdd(device *Device)
 	EmitDelete(device *Device)
+	EmitUpdate(previous *Device, device *Device)
 }
 
 type Pingable interface {
@@ -35,6 +135,10 @@ type Pingable interface {
 
 type Callback func(device *Device)
 
+// UpdateCallback receives the device before and after an update.
+// previous is nil if the watcher doesn't know the previous state.
+type UpdateCallback func(previous *Device, device *Device)
+
 type Device struct {
 	Owner         string    `json:"owner" gorm:"type:varchar(100);unique_index:key;primary_key"`
 	OwnerName     string    `json:"owner_name"`
@@ -45,6 +149,31 @@ type Device struct {
 	PresharedKey  string    `json:"preshared_key" gorm:"type:varchar(100)"`
 	Address       string    `json:"address"`
 	CreatedAt     time.Time `json:"created_at" gorm:"column:created_at"`
+	// ExpiresAt disables the device at this time, nil if it never expires
+	ExpiresAt *time.Time `json:"expires_at" gorm:"column:expires_at"`
+	// Suspended devices have no WireGuard peer but keep their addresses
+	Suspended bool `json:"suspended"`
+	// StaleSince is set when the device has been inactive for longer than
+	// the warning threshold of the inactive device deletion
+	StaleSince *time.Time `json:"stale_since" gorm:"column:stale_since"`
+	// ExpiryWarnedAt is the time the owner was warned about the expiry of the device
+	ExpiryWarnedAt *time.Time `json:"expiry_warned_at" gorm:"column:expiry_warned_at"`
+	// OverQuota is set while the owner exceeded their monthly traffic quota
+	OverQuota bool `json:"over_quota"`
+	// TrafficQuota is the monthly traffic quota of the owner resolved when the device was added,
+	// 0 means unlimited. nil for devices added before the quota was recorded.
+	TrafficQuota *int64 `json:"traffic_quota" gorm:"column:traffic_quota"`
+
+	// AccessPolicy restricts the destinations this device can reach.
+	// nil means the server wide AllowedIPs apply.
+	AccessPolicy *AccessPolicy `json:"access_policy" gorm:"type:text"`
+	// Profile is the name of the client config profile of the device,
+	// empty if the default client config applies
+	Profile string `json:"profile"`
+	// Gateway devices route the networks behind them, e.g. the LAN of a branch office
+	Gateway bool `json:"gateway"`
+	// Routes are the networks routed to a gateway device (e.g. "192.168.10.0/24, fd00:10::/64")
+	Routes string `json:"routes"`
 
 	/**
 	 * Metadata fields below.
@@ -59,6 +188,99 @@ type Device struct {
 	Endpoint          string     `json:"endpoint"`
 }
 
+// Token is a personal API token.
+// Only the hash of the secret is stored.
+type Token struct {
+	ID            string `json:"id" gorm:"type:varchar(36);primary_key"`
+	Owner         string `json:"owner" gorm:"type:varchar(100);index"`
+	OwnerName     string `json:"owner_name"`
+	OwnerEmail    string `json:"owner_email"`
+	OwnerProvider string `json:"owner_provider"`
+	// JSON encoded claims of the owner when the token was created
+	OwnerClaims string     `json:"owner_claims" gorm:"type:text"`
+	Name        string     `json:"name"`
+	Hash        string     `json:"-" gorm:"type:varchar(64);unique_index"`
+	ReadOnly    bool       `json:"read_only"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"column:created_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+// AuditEvent records an administrative or device action.
+// Actions of the server itself (e.g. the inactive device cleanup) have no actor.
+type AuditEvent struct {
+	ID            uint64    `json:"id" gorm:"primary_key"`
+	Time          time.Time `json:"time" gorm:"index"`
+	Actor         string    `json:"actor" gorm:"type:varchar(100);index"`
+	ActorName     string    `json:"actor_name"`
+	ActorProvider string    `json:"actor_provider"`
+	ActorEmail    string    `json:"actor_email,omitempty" gorm:"type:varchar(255);index"`
+	Action        string    `json:"action" gorm:"type:varchar(50);index"`
+	// "<owner>/<device name>" for devices, the user id for users and the id for tokens
+	Target   string `json:"target" gorm:"index"`
+	SourceIP string `json:"source_ip"`
+	Details  string `json:"details,omitempty" gorm:"type:text"`
+}
+
+// Usage is the traffic of a device within a time bucket
+type Usage struct {
+	ID     uint64 `json:"-" gorm:"primary_key"`
+	Owner  string `json:"owner" gorm:"type:varchar(100);unique_index:usage_bucket"`
+	Device string `json:"device" gorm:"type:varchar(100);unique_index:usage_bucket"`
+	// Time is the start of the bucket in UTC, a multiple of the resolution
+	Time time.Time `json:"time" gorm:"unique_index:usage_bucket"`
+	// Resolution is the length of the bucket
+	Resolution    time.Duration `json:"resolution" gorm:"unique_index:usage_bucket"`
+	ReceiveBytes  int64         `json:"receive_bytes"`
+	TransmitBytes int64         `json:"transmit_bytes"`
+}
+
+// QuotaUsage is the traffic of a user within a traffic quota period.
+// It is kept separately from the usage of the devices, so deleting a device doesn't reset the quota.
+type QuotaUsage struct {
+	Owner string `json:"owner" gorm:"type:varchar(100);primary_key"`
+	// Period is the start of the quota period in UTC
+	Period time.Time `json:"period" gorm:"primary_key"`
+	// Bytes is the received and transmitted traffic
+	Bytes int64 `json:"bytes"`
+}
+
+// downsample merges the buckets into buckets of the resolution
+func downsample(usages []*Usage, resolution time.Duration) []*Usage {
+	merged := map[string]*Usage{}
+	result := []*Usage{}
+	for _, u := range usages {
+		start := u.Time.Truncate(resolution).UTC()
+		k := keyStr(u.Owner, u.Device) + "@" + start.Format(time.RFC3339)
+		m, ok := merged[k]
+		if !ok {
+			m = &Usage{Owner: u.Owner, Device: u.Device, Time: start, Resolution: resolution}
+			merged[k] = m
+			result = append(result, m)
+		}
+		m.ReceiveBytes += u.ReceiveBytes
+		m.TransmitBytes += u.TransmitBytes
+	}
+	return result
+}
+
+// Allocation is a single IP address (without prefix length) handed out by the IPAM.
+// The primary key guarantees that an address is only allocated once.
+type Allocation struct {
+	Address   string    `json:"address" gorm:"type:varchar(50);primary_key"`
+	Pool      string    `json:"pool"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at"`
+	// the address as hex, it sorts like the addresses for range queries, set by the storage
+	SortKey string `json:"-" gorm:"type:varchar(32);column:sort_key;index"`
+	// set once the device was deleted, the address is kept for the reuse cooldown
+	ReleasedAt *time.Time `json:"released_at" gorm:"index"`
+}
+
+// allocationSortKey returns the 16 bytes of the address as hex, IPv4 addresses are mapped to IPv6
+func allocationSortKey(addr netip.Addr) string {
+	b := addr.As16()
+	return hex.EncodeToString(b[:])
+}
+
 func NewStorage(uri string) (Storage, error) {
 	u, err := url.Parse(uri)
 	if err != nil {
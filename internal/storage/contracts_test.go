@@ -1,7 +1,9 @@
 package storage
 
 import (
+	"net/netip"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -59,3 +61,284 @@ func TestUnknownStorage(t *testing.T) {
 	require.Error(err)
 	require.Equal(err.Error(), "unknown storage backend foo:")
 }
+
+func TestSqliteStorageUpdate(t *testing.T) {
+	require := require.New(t)
+
+	s, err := NewStorage("sqlite3://" + t.TempDir() + "/sqlite.db")
+	require.NoError(err)
+	require.NoError(s.Open())
+	defer s.Close()
+
+	updates := 0
+	s.OnUpdate(func(previous *Device, device *Device) {
+		require.Equal("laptop", previous.Name)
+		require.Equal("notebook", device.Name)
+		updates++
+	})
+
+	previous := &Device{Owner: "alice", Name: "laptop", PublicKey: "old", Address: "10.44.0.2/32"}
+	require.NoError(s.Save(previous))
+
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	device := *previous
+	device.Name = "notebook"
+	device.PublicKey = "new"
+	device.ExpiresAt = &expiresAt
+	device.Suspended = true
+	require.NoError(s.Update(previous, &device))
+	require.Equal(1, updates)
+
+	_, err = s.Get("alice", "laptop")
+	require.Error(err)
+	stored, err := s.Get("alice", "notebook")
+	require.NoError(err)
+	require.Equal("new", stored.PublicKey)
+	require.Equal("10.44.0.2/32", stored.Address)
+	require.NotNil(stored.ExpiresAt)
+	require.True(expiresAt.Equal(*stored.ExpiresAt))
+	require.True(stored.Suspended)
+
+	// updates of deleted devices fail and aren't reported
+	require.NoError(s.Delete(stored))
+	require.ErrorIs(s.Update(stored, stored), ErrDeviceNotFound)
+	require.Equal(1, updates)
+}
+
+func TestSqliteStorageSetOverQuota(t *testing.T) {
+	require := require.New(t)
+
+	s, err := NewStorage("sqlite3://" + t.TempDir() + "/sqlite.db")
+	require.NoError(err)
+	require.NoError(s.Open())
+	defer s.Close()
+
+	updates := 0
+	s.OnUpdate(func(previous *Device, device *Device) {
+		updates++
+	})
+
+	listed := &Device{Owner: "alice", Name: "laptop", PublicKey: "a1", Address: "10.44.0.2/32"}
+	require.NoError(s.Save(listed))
+
+	// a suspension after the device was listed is kept
+	suspended := *listed
+	suspended.Suspended = true
+	require.NoError(s.Update(listed, &suspended))
+
+	stored, err := s.SetOverQuota("alice", "laptop", true)
+	require.NoError(err)
+	require.True(stored.OverQuota)
+	require.True(stored.Suspended)
+	require.Equal(2, updates)
+
+	// the monthly reset clears the flag again
+	_, err = s.SetOverQuota("alice", "laptop", false)
+	require.NoError(err)
+	stored, err = s.Get("alice", "laptop")
+	require.NoError(err)
+	require.False(stored.OverQuota)
+	require.True(stored.Suspended)
+
+	_, err = s.SetOverQuota("alice", "phone", true)
+	require.ErrorIs(err, ErrDeviceNotFound)
+}
+
+func TestSqliteStorageSetSuspended(t *testing.T) {
+	require := require.New(t)
+
+	s, err := NewStorage("sqlite3://" + t.TempDir() + "/sqlite.db")
+	require.NoError(err)
+	require.NoError(s.Open())
+	defer s.Close()
+
+	require.NoError(s.Save(&Device{Owner: "alice", Name: "laptop", PublicKey: "a1", Address: "10.44.0.2/32"}))
+	_, err = s.SetOverQuota("alice", "laptop", true)
+	require.NoError(err)
+
+	// the quota check and the suspension don't overwrite each other
+	stored, err := s.SetSuspended("alice", "laptop", true)
+	require.NoError(err)
+	require.True(stored.Suspended)
+	require.True(stored.OverQuota)
+}
+
+func TestSqliteStorageSetExpiresAt(t *testing.T) {
+	require := require.New(t)
+
+	s, err := NewStorage("sqlite3://" + t.TempDir() + "/sqlite.db")
+	require.NoError(err)
+	require.NoError(s.Open())
+	defer s.Close()
+
+	warned := time.Now().Add(-time.Hour)
+	require.NoError(s.Save(&Device{Owner: "alice", Name: "laptop", PublicKey: "a1", ExpiresAt: &warned, ExpiryWarnedAt: &warned, Suspended: true}))
+
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	stored, err := s.SetExpiresAt("alice", "laptop", &expiresAt)
+	require.NoError(err)
+	require.True(expiresAt.Equal(*stored.ExpiresAt))
+	require.Nil(stored.ExpiryWarnedAt)
+	require.True(stored.Suspended)
+}
+
+func TestSqliteStorageSaveMetadata(t *testing.T) {
+	require := require.New(t)
+
+	s, err := NewStorage("sqlite3://" + t.TempDir() + "/sqlite.db")
+	require.NoError(err)
+	require.NoError(s.Open())
+	defer s.Close()
+
+	require.NoError(s.Save(&Device{Owner: "alice", Name: "laptop", PublicKey: "a1", Address: "10.44.0.2/32"}))
+
+	handshake := time.Now().Truncate(time.Second)
+	// the address of a stale copy isn't written back, unknown devices are skipped
+	require.NoError(s.SaveMetadata([]*Device{
+		{Owner: "alice", Name: "laptop", PublicKey: "a1", Address: "10.44.0.3/32", ReceiveBytes: 1, TransmitBytes: 2, Endpoint: "192.0.2.1", LastHandshakeTime: &handshake},
+		{Owner: "bob", Name: "phone", PublicKey: "b1"},
+	}))
+
+	stored, err := s.Get("alice", "laptop")
+	require.NoError(err)
+	require.Equal("10.44.0.2/32", stored.Address)
+	require.Equal(int64(1), stored.ReceiveBytes)
+	require.Equal(int64(2), stored.TransmitBytes)
+	require.Equal("192.0.2.1", stored.Endpoint)
+	require.True(handshake.Equal(*stored.LastHandshakeTime))
+	_, err = s.Get("bob", "phone")
+	require.Error(err)
+}
+
+func TestSqliteStorageAllocations(t *testing.T) {
+	require := require.New(t)
+
+	s, err := NewStorage("sqlite3://" + t.TempDir() + "/sqlite.db")
+	require.NoError(err)
+	require.NoError(s.Open())
+	defer s.Close()
+
+	require.NoError(s.CreateAllocation(&Allocation{Address: "10.44.0.2", CreatedAt: time.Now()}))
+	require.Equal(ErrAddressAllocated, s.CreateAllocation(&Allocation{Address: "10.44.0.2", CreatedAt: time.Now()}))
+
+	for _, address := range []string{"10.44.1.2", "10.45.0.1", "fd48:4c4:7aa9::2"} {
+		require.NoError(s.CreateAllocation(&Allocation{Address: address, CreatedAt: time.Now()}))
+	}
+	allocations, err := s.ListAllocationsBetween(netip.MustParseAddr("10.44.0.0"), netip.MustParseAddr("10.44.255.255"))
+	require.NoError(err)
+	require.Len(allocations, 2)
+	for _, address := range []string{"10.44.1.2", "10.45.0.1", "fd48:4c4:7aa9::2"} {
+		require.NoError(s.DeleteAllocation(address))
+	}
+
+	require.NoError(s.ReleaseAllocation("10.44.0.2", time.Now().Add(-time.Hour)))
+	require.NoError(s.PurgeAllocations(time.Now()))
+	allocations, err = s.ListAllocations()
+	require.NoError(err)
+	require.Empty(allocations)
+}
+
+func TestSqliteStorageAuditEvents(t *testing.T) {
+	require := require.New(t)
+
+	s, err := NewStorage("sqlite3://" + t.TempDir() + "/sqlite.db")
+	require.NoError(err)
+	require.NoError(s.Open())
+	defer s.Close()
+
+	start := time.Now().Add(-time.Hour)
+	for i, action := range []string{"device.create", "device.delete", "device.create"} {
+		require.NoError(s.SaveAuditEvent(&AuditEvent{Time: start.Add(time.Duration(i) * time.Minute), Actor: "alice", Action: action, Target: "alice/notebook"}))
+	}
+
+	events, err := s.ListAuditEvents(AuditFilter{Action: "device.create"})
+	require.NoError(err)
+	require.Len(events, 2)
+	require.Greater(events[0].ID, events[1].ID)
+
+	events, err = s.ListAuditEvents(AuditFilter{Limit: 1, BeforeID: events[0].ID})
+	require.NoError(err)
+	require.Len(events, 1)
+	require.Equal("device.delete", events[0].Action)
+
+	since := start.Add(30 * time.Second)
+	events, err = s.ListAuditEvents(AuditFilter{Actor: "alice", Since: &since})
+	require.NoError(err)
+	require.Len(events, 2)
+}
+
+func TestSqliteStorageUsage(t *testing.T) {
+	s, err := NewStorage("sqlite3://" + t.TempDir() + "/sqlite.db")
+	require.NoError(t, err)
+	testUsage(t, s)
+}
+
+func TestMemoryStorageUsage(t *testing.T) {
+	s, err := NewStorage("memory://")
+	require.NoError(t, err)
+	testUsage(t, s)
+}
+
+func testUsage(t *testing.T, s Storage) {
+	require := require.New(t)
+
+	require.NoError(s.Open())
+	defer s.Close()
+
+	device := &Device{Owner: "alice", Name: "notebook", PublicKey: "key"}
+	require.NoError(s.Save(device))
+
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		bucket := day.Add(time.Duration(i) * 6 * time.Hour)
+		require.NoError(s.AddUsage(&Usage{Owner: "alice", Device: "notebook", Time: bucket, Resolution: time.Hour, ReceiveBytes: 10, TransmitBytes: 1}))
+	}
+	require.NoError(s.AddUsage(&Usage{Owner: "alice", Device: "notebook", Time: day, Resolution: time.Hour, ReceiveBytes: 5}))
+	require.NoError(s.AddUsage(&Usage{Owner: "alice", Device: "notebook", Time: day.Add(24 * time.Hour), Resolution: time.Hour, ReceiveBytes: 7}))
+
+	usages, err := s.ListUsage("alice", "notebook", day, day.Add(48*time.Hour))
+	require.NoError(err)
+	require.Len(usages, 5)
+	require.Equal(int64(15), usages[0].ReceiveBytes)
+	require.True(day.Equal(usages[0].Time))
+
+	require.NoError(s.DownsampleUsage(day.Add(24*time.Hour), 24*time.Hour))
+	usages, err = s.ListUsage("alice", "notebook", day, day.Add(48*time.Hour))
+	require.NoError(err)
+	require.Len(usages, 2)
+	require.Equal(int64(45), usages[0].ReceiveBytes)
+	require.Equal(int64(4), usages[0].TransmitBytes)
+	require.Equal(24*time.Hour, usages[0].Resolution)
+	require.Equal(time.Hour, usages[1].Resolution)
+
+	renamed := *device
+	renamed.Name = "laptop"
+	require.NoError(s.Update(device, &renamed))
+	usages, err = s.ListUsage("alice", "laptop", day, day.Add(48*time.Hour))
+	require.NoError(err)
+	require.Len(usages, 2)
+
+	require.NoError(s.PurgeUsage(day.Add(24 * time.Hour)))
+	usages, err = s.ListUsage("alice", "laptop", day, day.Add(48*time.Hour))
+	require.NoError(err)
+	require.Len(usages, 1)
+
+	require.NoError(s.Delete(&renamed))
+	usages, err = s.ListUsage("alice", "laptop", day, day.Add(48*time.Hour))
+	require.NoError(err)
+	require.Empty(usages)
+
+	// the quota usage is kept after the device was deleted
+	for i := 0; i < 2; i++ {
+		require.NoError(s.AddQuotaUsage(&QuotaUsage{Owner: "alice", Period: day, Bytes: 21}))
+	}
+	quotaUsages, err := s.ListQuotaUsage(day)
+	require.NoError(err)
+	require.Len(quotaUsages, 1)
+	require.Equal(int64(42), quotaUsages[0].Bytes)
+
+	require.NoError(s.PurgeQuotaUsage(day.AddDate(0, 1, 0)))
+	quotaUsages, err = s.ListQuotaUsage(day)
+	require.NoError(err)
+	require.Empty(quotaUsages)
+}
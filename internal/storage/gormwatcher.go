@@ -11,6 +11,9 @@ import (
 type GormWatcher struct {
 	*gorm.Callback
 	table string
+	// updates are emitted by SQLStorage.Update because the
+	// gorm update callbacks don't know the previous state
+	update []UpdateCallback
 }
 
 func NewGormWatcher(db *gorm.DB, table string) *GormWatcher {
@@ -38,6 +41,10 @@ func (w *GormWatcher) OnDelete(cb Callback) {
 	})
 }
 
+func (w *GormWatcher) OnUpdate(cb UpdateCallback) {
+	w.update = append(w.update, cb)
+}
+
 func (w *GormWatcher) OnReconnect(cb func()) {
 	// noop because the watcher can't reconnect
 }
@@ -55,3 +62,9 @@ func (w *GormWatcher) EmitAdd(device *Device) {
 func (w *GormWatcher) EmitDelete(device *Device) {
 	// noop because we rely on gorm callback
 }
+
+func (w *GormWatcher) EmitUpdate(previous *Device, device *Device) {
+	for _, cb := range w.update {
+		cb(previous, device)
+	}
+}
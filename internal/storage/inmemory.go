@@ -1,14 +1,32 @@
 package storage
 
 import (
+	"context"
 	"errors"
+	"net/netip"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 )
 
 // implements Storage interface
 type InMemoryStorage struct {
 	*InProcessWatcher
-	db map[string]*Device
+	db         map[string]*Device
+	tokens     map[string]*Token
+	tokensLock sync.RWMutex
+	// allocations by address
+	allocations     map[string]*Allocation
+	allocationsLock sync.Mutex
+	// audit events in the order they were recorded
+	auditEvents     []*AuditEvent
+	auditEventsLock sync.RWMutex
+	usages          []*Usage
+	usagesLock      sync.RWMutex
+	// traffic of the users by quota period and owner
+	quotaUsages     map[time.Time]map[string]int64
+	quotaUsagesLock sync.RWMutex
 }
 
 func NewMemoryStorage() *InMemoryStorage {
@@ -16,6 +34,9 @@ func NewMemoryStorage() *InMemoryStorage {
 	return &InMemoryStorage{
 		InProcessWatcher: NewInProcessWatcher(),
 		db:               db,
+		tokens:           make(map[string]*Token),
+		allocations:      make(map[string]*Allocation),
+		quotaUsages:      make(map[time.Time]map[string]int64),
 	}
 }
 
@@ -27,12 +48,106 @@ func (s *InMemoryStorage) Close() error {
 	return nil
 }
 
+// WithContext returns the storage itself, memory operations aren't traced
+func (s *InMemoryStorage) WithContext(ctx context.Context) Storage {
+	return s
+}
+
 func (s *InMemoryStorage) Save(device *Device) error {
 	s.db[key(device)] = device
 	s.EmitAdd(device)
 	return nil
 }
 
+func (s *InMemoryStorage) Update(previous *Device, device *Device) error {
+	if _, ok := s.db[key(previous)]; !ok {
+		return ErrDeviceNotFound
+	}
+	delete(s.db, key(previous))
+	s.db[key(device)] = device
+	if previous.Name != device.Name {
+		s.usagesLock.Lock()
+		for _, u := range s.usages {
+			if u.Owner == previous.Owner && u.Device == previous.Name {
+				u.Device = device.Name
+			}
+		}
+		s.usagesLock.Unlock()
+	}
+	s.EmitUpdate(previous, device)
+	return nil
+}
+
+func (s *InMemoryStorage) SaveMetadata(devices []*Device) error {
+	for _, device := range devices {
+		stored, ok := s.db[key(device)]
+		if !ok {
+			continue
+		}
+		updated := *stored
+		updated.LastHandshakeTime = device.LastHandshakeTime
+		updated.ReceiveBytes = device.ReceiveBytes
+		updated.TransmitBytes = device.TransmitBytes
+		updated.Endpoint = device.Endpoint
+		updated.StaleSince = device.StaleSince
+		s.db[key(device)] = &updated
+	}
+	return nil
+}
+
+func (s *InMemoryStorage) SetExpiryWarned(device *Device, warnedAt *time.Time) error {
+	stored, ok := s.db[key(device)]
+	if !ok {
+		return ErrDeviceNotFound
+	}
+	updated := *stored
+	updated.ExpiryWarnedAt = warnedAt
+	s.db[key(device)] = &updated
+	return nil
+}
+
+func (s *InMemoryStorage) SetStaleSince(device *Device, staleSince *time.Time) error {
+	stored, ok := s.db[key(device)]
+	if !ok {
+		return ErrDeviceNotFound
+	}
+	updated := *stored
+	updated.StaleSince = staleSince
+	s.db[key(device)] = &updated
+	return nil
+}
+
+func (s *InMemoryStorage) SetOverQuota(owner string, name string, overQuota bool) (*Device, error) {
+	return s.updateDevice(owner, name, func(device *Device) {
+		device.OverQuota = overQuota
+	})
+}
+
+func (s *InMemoryStorage) SetSuspended(owner string, name string, suspended bool) (*Device, error) {
+	return s.updateDevice(owner, name, func(device *Device) {
+		device.Suspended = suspended
+	})
+}
+
+func (s *InMemoryStorage) SetExpiresAt(owner string, name string, expiresAt *time.Time) (*Device, error) {
+	return s.updateDevice(owner, name, func(device *Device) {
+		device.ExpiresAt = expiresAt
+		device.ExpiryWarnedAt = nil
+	})
+}
+
+func (s *InMemoryStorage) updateDevice(owner string, name string, update func(device *Device)) (*Device, error) {
+	stored, ok := s.db[keyStr(owner, name)]
+	if !ok {
+		return nil, ErrDeviceNotFound
+	}
+	updated := *stored
+	update(&updated)
+	s.db[keyStr(owner, name)] = &updated
+	s.EmitUpdate(stored, &updated)
+	return &updated, nil
+}
+
 func (s *InMemoryStorage) List(username string) ([]*Device, error) {
 	devices := []*Device{}
 	prefix := func() string {
@@ -72,10 +187,258 @@ func (s *InMemoryStorage) GetByPublicKey(publicKey string) (*Device, error) {
 
 func (s *InMemoryStorage) Delete(device *Device) error {
 	delete(s.db, key(device))
+	s.usagesLock.Lock()
+	s.usages = filterUsages(s.usages, func(u *Usage) bool {
+		return u.Owner != device.Owner || u.Device != device.Name
+	})
+	s.usagesLock.Unlock()
 	s.EmitDelete(device)
 	return nil
 }
 
+func (s *InMemoryStorage) SaveToken(token *Token) error {
+	s.tokensLock.Lock()
+	defer s.tokensLock.Unlock()
+	s.tokens[token.ID] = token
+	return nil
+}
+
+func (s *InMemoryStorage) ListTokens(owner string) ([]*Token, error) {
+	s.tokensLock.RLock()
+	defer s.tokensLock.RUnlock()
+	tokens := []*Token{}
+	for _, token := range s.tokens {
+		if token.Owner == owner {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens, nil
+}
+
+func (s *InMemoryStorage) GetToken(id string) (*Token, error) {
+	s.tokensLock.RLock()
+	defer s.tokensLock.RUnlock()
+	token, ok := s.tokens[id]
+	if !ok {
+		return nil, errors.New("token doesn't exist")
+	}
+	return token, nil
+}
+
+func (s *InMemoryStorage) GetTokenByHash(hash string) (*Token, error) {
+	s.tokensLock.RLock()
+	defer s.tokensLock.RUnlock()
+	for _, token := range s.tokens {
+		if token.Hash == hash {
+			return token, nil
+		}
+	}
+	return nil, errors.New("token doesn't exist")
+}
+
+func (s *InMemoryStorage) DeleteToken(token *Token) error {
+	s.tokensLock.Lock()
+	defer s.tokensLock.Unlock()
+	delete(s.tokens, token.ID)
+	return nil
+}
+
+func (s *InMemoryStorage) CreateAllocation(allocation *Allocation) error {
+	s.allocationsLock.Lock()
+	defer s.allocationsLock.Unlock()
+	if _, ok := s.allocations[allocation.Address]; ok {
+		return ErrAddressAllocated
+	}
+	s.allocations[allocation.Address] = allocation
+	return nil
+}
+
+func (s *InMemoryStorage) ListAllocations() ([]*Allocation, error) {
+	s.allocationsLock.Lock()
+	defer s.allocationsLock.Unlock()
+	allocations := make([]*Allocation, 0, len(s.allocations))
+	for _, allocation := range s.allocations {
+		copied := *allocation
+		allocations = append(allocations, &copied)
+	}
+	return allocations, nil
+}
+
+func (s *InMemoryStorage) ListAllocationsBetween(first, last netip.Addr) ([]*Allocation, error) {
+	s.allocationsLock.Lock()
+	defer s.allocationsLock.Unlock()
+	allocations := []*Allocation{}
+	for _, allocation := range s.allocations {
+		addr, err := netip.ParseAddr(allocation.Address)
+		if err != nil {
+			continue
+		}
+		if addr = addr.Unmap(); first.Unmap().Compare(addr) <= 0 && addr.Compare(last.Unmap()) <= 0 {
+			copied := *allocation
+			allocations = append(allocations, &copied)
+		}
+	}
+	return allocations, nil
+}
+
+func (s *InMemoryStorage) ReleaseAllocation(address string, releasedAt time.Time) error {
+	s.allocationsLock.Lock()
+	defer s.allocationsLock.Unlock()
+	if allocation, ok := s.allocations[address]; ok {
+		allocation.ReleasedAt = &releasedAt
+	}
+	return nil
+}
+
+func (s *InMemoryStorage) DeleteAllocation(address string) error {
+	s.allocationsLock.Lock()
+	defer s.allocationsLock.Unlock()
+	delete(s.allocations, address)
+	return nil
+}
+
+func (s *InMemoryStorage) PurgeAllocations(releasedBefore time.Time) error {
+	s.allocationsLock.Lock()
+	defer s.allocationsLock.Unlock()
+	for address, allocation := range s.allocations {
+		if allocation.ReleasedAt != nil && allocation.ReleasedAt.Before(releasedBefore) {
+			delete(s.allocations, address)
+		}
+	}
+	return nil
+}
+
+func (s *InMemoryStorage) SaveAuditEvent(event *AuditEvent) error {
+	s.auditEventsLock.Lock()
+	defer s.auditEventsLock.Unlock()
+	event.ID = uint64(len(s.auditEvents) + 1)
+	s.auditEvents = append(s.auditEvents, event)
+	return nil
+}
+
+func (s *InMemoryStorage) ListAuditEvents(filter AuditFilter) ([]*AuditEvent, error) {
+	s.auditEventsLock.RLock()
+	defer s.auditEventsLock.RUnlock()
+	events := []*AuditEvent{}
+	for i := len(s.auditEvents) - 1; i >= 0; i-- {
+		event := s.auditEvents[i]
+		if (filter.Actor != "" && event.Actor != filter.Actor) ||
+			(filter.ActorEmail != "" && event.ActorEmail != filter.ActorEmail) ||
+			(filter.ActorProvider != "" && event.ActorProvider != filter.ActorProvider) ||
+			(filter.Action != "" && event.Action != filter.Action) ||
+			(filter.Target != "" && event.Target != filter.Target) ||
+			(filter.Since != nil && event.Time.Before(*filter.Since)) ||
+			(filter.Until != nil && !event.Time.Before(*filter.Until)) ||
+			(filter.BeforeID != 0 && event.ID >= filter.BeforeID) {
+			continue
+		}
+		events = append(events, event)
+		if filter.Limit > 0 && len(events) == filter.Limit {
+			break
+		}
+	}
+	return events, nil
+}
+
+func (s *InMemoryStorage) AddUsage(usage *Usage) error {
+	s.usagesLock.Lock()
+	defer s.usagesLock.Unlock()
+	s.addUsage(usage)
+	return nil
+}
+
+// addUsage requires the usagesLock
+func (s *InMemoryStorage) addUsage(usage *Usage) {
+	for _, u := range s.usages {
+		if u.Owner == usage.Owner && u.Device == usage.Device && u.Time.Equal(usage.Time) && u.Resolution == usage.Resolution {
+			u.ReceiveBytes += usage.ReceiveBytes
+			u.TransmitBytes += usage.TransmitBytes
+			return
+		}
+	}
+	added := *usage
+	s.usages = append(s.usages, &added)
+}
+
+func (s *InMemoryStorage) ListUsage(owner string, device string, since time.Time, until time.Time) ([]*Usage, error) {
+	s.usagesLock.RLock()
+	defer s.usagesLock.RUnlock()
+	usages := []*Usage{}
+	for _, u := range s.usages {
+		if u.Owner == owner && u.Device == device && !u.Time.Before(since) && u.Time.Before(until) {
+			copied := *u
+			usages = append(usages, &copied)
+		}
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		return usages[i].Time.Before(usages[j].Time)
+	})
+	return usages, nil
+}
+
+func (s *InMemoryStorage) DownsampleUsage(before time.Time, resolution time.Duration) error {
+	s.usagesLock.Lock()
+	defer s.usagesLock.Unlock()
+	old := func(u *Usage) bool {
+		return u.Time.Before(before) && u.Resolution < resolution
+	}
+	merged := downsample(filterUsages(s.usages, old), resolution)
+	s.usages = filterUsages(s.usages, func(u *Usage) bool { return !old(u) })
+	for _, m := range merged {
+		s.addUsage(m)
+	}
+	return nil
+}
+
+func (s *InMemoryStorage) PurgeUsage(before time.Time) error {
+	s.usagesLock.Lock()
+	defer s.usagesLock.Unlock()
+	s.usages = filterUsages(s.usages, func(u *Usage) bool { return !u.Time.Before(before) })
+	return nil
+}
+
+func (s *InMemoryStorage) AddQuotaUsage(usage *QuotaUsage) error {
+	s.quotaUsagesLock.Lock()
+	defer s.quotaUsagesLock.Unlock()
+	period := usage.Period.UTC()
+	if s.quotaUsages[period] == nil {
+		s.quotaUsages[period] = make(map[string]int64)
+	}
+	s.quotaUsages[period][usage.Owner] += usage.Bytes
+	return nil
+}
+
+func (s *InMemoryStorage) ListQuotaUsage(period time.Time) ([]*QuotaUsage, error) {
+	s.quotaUsagesLock.RLock()
+	defer s.quotaUsagesLock.RUnlock()
+	usages := []*QuotaUsage{}
+	for owner, bytes := range s.quotaUsages[period.UTC()] {
+		usages = append(usages, &QuotaUsage{Owner: owner, Period: period.UTC(), Bytes: bytes})
+	}
+	return usages, nil
+}
+
+func (s *InMemoryStorage) PurgeQuotaUsage(before time.Time) error {
+	s.quotaUsagesLock.Lock()
+	defer s.quotaUsagesLock.Unlock()
+	for period := range s.quotaUsages {
+		if period.Before(before) {
+			delete(s.quotaUsages, period)
+		}
+	}
+	return nil
+}
+
+func filterUsages(usages []*Usage, keep func(u *Usage) bool) []*Usage {
+	kept := []*Usage{}
+	for _, u := range usages {
+		if keep(u) {
+			kept = append(kept, u)
+		}
+	}
+	return kept
+}
+
 func (s *InMemoryStorage) Ping() error {
 	return nil
 }
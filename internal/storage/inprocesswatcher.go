@@ -5,6 +5,7 @@ import "github.com/sirupsen/logrus"
 type InProcessWatcher struct {
 	add    []Callback
 	delete []Callback
+	update []UpdateCallback
 }
 
 func NewInProcessWatcher() *InProcessWatcher {
@@ -12,6 +13,7 @@ func NewInProcessWatcher() *InProcessWatcher {
 	return &InProcessWatcher{
 		add:    []Callback{},
 		delete: []Callback{},
+		update: []UpdateCallback{},
 	}
 }
 
@@ -23,6 +25,10 @@ func (w *InProcessWatcher) OnDelete(cb Callback) {
 	w.delete = append(w.delete, cb)
 }
 
+func (w *InProcessWatcher) OnUpdate(cb UpdateCallback) {
+	w.update = append(w.update, cb)
+}
+
 func (w *InProcessWatcher) OnReconnect(cb func()) {
 	// noop because the inprocess watcher can't disconnect
 }
@@ -40,3 +46,9 @@ func (w *InProcessWatcher) EmitDelete(device *Device) {
 		cb(device)
 	}
 }
+
+func (w *InProcessWatcher) EmitUpdate(previous *Device, device *Device) {
+	for _, cb := range w.update {
+		cb(previous, device)
+	}
+}
@@ -30,10 +30,8 @@ func NewPgWatcher(connectionString string, table string) (*PgWatcher, error) {
 
 func (w *PgWatcher) OnAdd(cb Callback) {
 	w.OnEvent(func(event *pgevents.TableEvent) {
-		// we only emit the "add" event on an insert because wg-access-server
-		// doesn't allow anyone to modify their public key or allowed IPs.
-		// a future change to wg-access-server may require listening to "updates"
-		// if either of those properties become mutable.
+		// we only emit the "add" event on an insert,
+		// changes of existing devices are emitted as updates
 		if event.Action == "INSERT" {
 			w.emit(cb, event)
 		}
@@ -48,6 +46,18 @@ func (w *PgWatcher) OnDelete(cb Callback) {
 	})
 }
 
+func (w *PgWatcher) OnUpdate(cb UpdateCallback) {
+	w.OnEvent(func(event *pgevents.TableEvent) {
+		// the event only contains the new row, this includes
+		// every metadata update of a device
+		if event.Action == "UPDATE" {
+			w.emit(func(device *Device) {
+				cb(nil, device)
+			}, event)
+		}
+	})
+}
+
 func (w *PgWatcher) OnReconnect(cb func()) {
 	w.Listener.OnReconnect(cb)
 }
@@ -68,3 +78,7 @@ func (w *PgWatcher) EmitAdd(device *Device) {
 func (w *PgWatcher) EmitDelete(device *Device) {
 	// noop because we rely on postgres channels
 }
+
+func (w *PgWatcher) EmitUpdate(previous *Device, device *Device) {
+	// noop because we rely on postgres channels
+}
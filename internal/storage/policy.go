@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// AccessPolicy restricts which destinations a device can reach through the VPN.
+// A device without a policy is only subject to the server wide AllowedIPs.
+type AccessPolicy struct {
+	// Name of the configured policy this was derived from, if any
+	Name  string       `json:"name,omitempty"`
+	Rules []AccessRule `json:"rules"`
+	// ClientIsolation blocks traffic to other client devices
+	ClientIsolation bool `json:"client_isolation,omitempty"`
+}
+
+// AccessRule allows traffic to a destination network
+type AccessRule struct {
+	CIDR     string `json:"cidr"`
+	Protocol string `json:"protocol,omitempty"`
+	Ports    string `json:"ports,omitempty"`
+}
+
+// Value stores the policy as JSON text in SQL backends
+func (p AccessPolicy) Value() (driver.Value, error) {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan reads a policy stored as JSON text
+func (p *AccessPolicy) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*p = AccessPolicy{}
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), p)
+	case []byte:
+		return json.Unmarshal(v, p)
+	}
+	return fmt.Errorf("unsupported type %T for access policy", value)
+}
+
+// UnmarshalJSON additionally accepts the policy as a JSON encoded string,
+// which is how the text column arrives in postgres events
+func (p *AccessPolicy) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+		data = []byte(text)
+	}
+	type plain AccessPolicy
+	return json.Unmarshal(data, (*plain)(p))
+}
@@ -2,9 +2,11 @@ package storage
 
 import (
 	"fmt"
+	"net/netip"
 	"net/url"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/jinzhu/gorm"
 	_ "github.com/jinzhu/gorm/dialects/mysql"
@@ -116,7 +118,12 @@ func (s *SQLStorage) Open() error {
 	db.LogMode(true)
 
 	// Migrate the schema
-	s.db.AutoMigrate(&Device{})
+	s.db.AutoMigrate(&Device{}, &Token{}, &Allocation{}, &AuditEvent{}, &Usage{}, &QuotaUsage{})
+	if err := s.migrateAllocations(); err != nil {
+		return err
+	}
+
+	registerTracing(db, s.sqlType)
 
 	switch s.sqlType {
 	case "postgres":
@@ -152,6 +159,124 @@ func (s *SQLStorage) Save(device *Device) error {
 	return nil
 }
 
+func (s *SQLStorage) Update(previous *Device, device *Device) error {
+	logrus.Debugf("updating device %s to %s", key(previous), key(device))
+	result := s.db.Model(&Device{}).Where("owner = ? AND name = ?", previous.Owner, previous.Name).Updates(map[string]interface{}{
+		"name":          device.Name,
+		"public_key":    device.PublicKey,
+		"preshared_key": device.PresharedKey,
+		"address":       device.Address,
+		"expires_at":    device.ExpiresAt,
+		"suspended":     device.Suspended,
+		"over_quota":    device.OverQuota,
+	})
+	if err := s.checkUpdated(result, previous.Owner, previous.Name); err != nil {
+		return errors.Wrap(err, "failed to update device")
+	}
+	if previous.Name != device.Name {
+		err := s.db.Model(&Usage{}).Where("owner = ? AND device = ?", previous.Owner, previous.Name).Update("device", device.Name).Error
+		if err != nil {
+			return errors.Wrap(err, "failed to rename the usage of the device")
+		}
+	}
+	s.EmitUpdate(previous, device)
+	return nil
+}
+
+// checkUpdated returns ErrDeviceNotFound if the device of an update doesn't exist.
+// MySQL doesn't count rows that matched but were unchanged, so the device is looked up if no row was affected.
+func (s *SQLStorage) checkUpdated(result *gorm.DB, owner string, name string) error {
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 && s.db.Where("owner = ? AND name = ?", owner, name).First(&Device{}).RecordNotFound() {
+		return ErrDeviceNotFound
+	}
+	return nil
+}
+
+func (s *SQLStorage) SaveMetadata(devices []*Device) error {
+	logrus.Debugf("saving the metadata of %d device(s)", len(devices))
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return errors.Wrap(tx.Error, "failed to begin transaction")
+	}
+	for _, device := range devices {
+		err := tx.Model(&Device{}).Where("owner = ? AND name = ?", device.Owner, device.Name).Updates(map[string]interface{}{
+			"last_handshake_time": device.LastHandshakeTime,
+			"receive_bytes":       device.ReceiveBytes,
+			"transmit_bytes":      device.TransmitBytes,
+			"endpoint":            device.Endpoint,
+			"stale_since":         device.StaleSince,
+		}).Error
+		if err != nil {
+			tx.Rollback()
+			return errors.Wrapf(err, "failed to write the metadata of device %s", key(device))
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		return errors.Wrap(err, "failed to commit device metadata")
+	}
+	return nil
+}
+
+func (s *SQLStorage) SetExpiryWarned(device *Device, warnedAt *time.Time) error {
+	result := s.db.Model(&Device{}).Where("owner = ? AND name = ?", device.Owner, device.Name).Update("expiry_warned_at", warnedAt)
+	if err := s.checkUpdated(result, device.Owner, device.Name); err != nil {
+		return errors.Wrapf(err, "failed to write the expiry warning of device %s", key(device))
+	}
+	return nil
+}
+
+func (s *SQLStorage) SetStaleSince(device *Device, staleSince *time.Time) error {
+	result := s.db.Model(&Device{}).Where("owner = ? AND name = ?", device.Owner, device.Name).Update("stale_since", staleSince)
+	if err := s.checkUpdated(result, device.Owner, device.Name); err != nil {
+		return errors.Wrapf(err, "failed to write the stale mark of device %s", key(device))
+	}
+	return nil
+}
+
+func (s *SQLStorage) SetOverQuota(owner string, name string, overQuota bool) (*Device, error) {
+	device, err := s.updateColumns(owner, name, map[string]interface{}{"over_quota": overQuota})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to write the over quota mark of device %s", keyStr(owner, name))
+	}
+	return device, nil
+}
+
+func (s *SQLStorage) SetSuspended(owner string, name string, suspended bool) (*Device, error) {
+	device, err := s.updateColumns(owner, name, map[string]interface{}{"suspended": suspended})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to write the suspension of device %s", keyStr(owner, name))
+	}
+	return device, nil
+}
+
+func (s *SQLStorage) SetExpiresAt(owner string, name string, expiresAt *time.Time) (*Device, error) {
+	device, err := s.updateColumns(owner, name, map[string]interface{}{
+		"expires_at":       expiresAt,
+		"expiry_warned_at": nil,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to write the expiry date of device %s", keyStr(owner, name))
+	}
+	return device, nil
+}
+
+// updateColumns writes columns of an existing device, the device is read again for the OnUpdate callbacks
+func (s *SQLStorage) updateColumns(owner string, name string, columns map[string]interface{}) (*Device, error) {
+	result := s.db.Model(&Device{}).Where("owner = ? AND name = ?", owner, name).Updates(columns)
+	if err := s.checkUpdated(result, owner, name); err != nil {
+		return nil, err
+	}
+	device, err := s.Get(owner, name)
+	if err != nil {
+		return nil, err
+	}
+	s.EmitUpdate(device, device)
+	return device, nil
+}
+
 func (s *SQLStorage) List(username string) ([]*Device, error) {
 	var err error
 	devices := []*Device{}
@@ -188,10 +313,168 @@ func (s *SQLStorage) Delete(device *Device) error {
 	if err := s.db.Delete(&device).Error; err != nil {
 		return errors.Wrap(err, "failed to delete device file")
 	}
+	if err := s.db.Where("owner = ? AND device = ?", device.Owner, device.Name).Delete(&Usage{}).Error; err != nil {
+		logrus.Error(errors.Wrap(err, "failed to delete the usage of the device"))
+	}
 	s.EmitDelete(device)
 	return nil
 }
 
+func (s *SQLStorage) SaveToken(token *Token) error {
+	if err := s.db.Save(&token).Error; err != nil {
+		return errors.Wrap(err, "failed to write token")
+	}
+	return nil
+}
+
+func (s *SQLStorage) ListTokens(owner string) ([]*Token, error) {
+	tokens := []*Token{}
+	if err := s.db.Where("owner = ?", owner).Find(&tokens).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to read tokens from sql")
+	}
+	return tokens, nil
+}
+
+func (s *SQLStorage) GetToken(id string) (*Token, error) {
+	token := &Token{}
+	if err := s.db.Where("id = ?", id).First(&token).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to read token")
+	}
+	return token, nil
+}
+
+func (s *SQLStorage) GetTokenByHash(hash string) (*Token, error) {
+	token := &Token{}
+	if err := s.db.Where("hash = ?", hash).First(&token).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to read token")
+	}
+	return token, nil
+}
+
+func (s *SQLStorage) DeleteToken(token *Token) error {
+	if err := s.db.Delete(&token).Error; err != nil {
+		return errors.Wrap(err, "failed to delete token")
+	}
+	return nil
+}
+
+func (s *SQLStorage) CreateAllocation(allocation *Allocation) error {
+	addr, err := netip.ParseAddr(allocation.Address)
+	if err != nil {
+		return errors.Wrapf(err, "invalid allocation address '%s'", allocation.Address)
+	}
+	allocation.SortKey = allocationSortKey(addr)
+	if err := s.db.Create(allocation).Error; err != nil {
+		// the insert violated the primary key if the address exists now
+		if !s.db.Where("address = ?", allocation.Address).First(&Allocation{}).RecordNotFound() {
+			return ErrAddressAllocated
+		}
+		return errors.Wrap(err, "failed to write allocation")
+	}
+	return nil
+}
+
+func (s *SQLStorage) ListAllocations() ([]*Allocation, error) {
+	allocations := []*Allocation{}
+	if err := s.db.Find(&allocations).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to read allocations from sql")
+	}
+	return allocations, nil
+}
+
+func (s *SQLStorage) ListAllocationsBetween(first, last netip.Addr) ([]*Allocation, error) {
+	allocations := []*Allocation{}
+	err := s.db.Where("sort_key BETWEEN ? AND ?", allocationSortKey(first), allocationSortKey(last)).Find(&allocations).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read allocations from sql")
+	}
+	return allocations, nil
+}
+
+// migrateAllocations sets the sort key of the allocations written before it existed
+func (s *SQLStorage) migrateAllocations() error {
+	allocations := []*Allocation{}
+	if err := s.db.Where("sort_key IS NULL OR sort_key = ''").Find(&allocations).Error; err != nil {
+		return errors.Wrap(err, "failed to read allocations from sql")
+	}
+	for _, allocation := range allocations {
+		addr, err := netip.ParseAddr(allocation.Address)
+		if err != nil {
+			logrus.Warnf("skipping allocation with invalid address '%s'", allocation.Address)
+			continue
+		}
+		err = s.db.Model(&Allocation{}).Where("address = ?", allocation.Address).UpdateColumn("sort_key", allocationSortKey(addr)).Error
+		if err != nil {
+			return errors.Wrap(err, "failed to migrate allocation")
+		}
+	}
+	return nil
+}
+
+func (s *SQLStorage) ReleaseAllocation(address string, releasedAt time.Time) error {
+	if err := s.db.Model(&Allocation{}).Where("address = ?", address).Update("released_at", releasedAt).Error; err != nil {
+		return errors.Wrap(err, "failed to release allocation")
+	}
+	return nil
+}
+
+func (s *SQLStorage) DeleteAllocation(address string) error {
+	if err := s.db.Where("address = ?", address).Delete(&Allocation{}).Error; err != nil {
+		return errors.Wrap(err, "failed to delete allocation")
+	}
+	return nil
+}
+
+func (s *SQLStorage) PurgeAllocations(releasedBefore time.Time) error {
+	if err := s.db.Where("released_at < ?", releasedBefore).Delete(&Allocation{}).Error; err != nil {
+		return errors.Wrap(err, "failed to purge released allocations")
+	}
+	return nil
+}
+
+func (s *SQLStorage) SaveAuditEvent(event *AuditEvent) error {
+	if err := s.db.Create(event).Error; err != nil {
+		return errors.Wrap(err, "failed to write audit event")
+	}
+	return nil
+}
+
+func (s *SQLStorage) ListAuditEvents(filter AuditFilter) ([]*AuditEvent, error) {
+	query := s.db.Order("id desc")
+	if filter.Actor != "" {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+	if filter.ActorEmail != "" {
+		query = query.Where("actor_email = ?", filter.ActorEmail)
+	}
+	if filter.ActorProvider != "" {
+		query = query.Where("actor_provider = ?", filter.ActorProvider)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.Target != "" {
+		query = query.Where("target = ?", filter.Target)
+	}
+	if filter.Since != nil {
+		query = query.Where("time >= ?", *filter.Since)
+	}
+	if filter.Until != nil {
+		query = query.Where("time < ?", *filter.Until)
+	}
+	if filter.BeforeID != 0 {
+		query = query.Where("id < ?", filter.BeforeID)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	events := []*AuditEvent{}
+	if err := query.Find(&events).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to read audit events from sql")
+	}
+	return events, nil
+}
+
 func (s *SQLStorage) Ping() error {
 	db := s.db.DB()
 	if db == nil {
@@ -203,3 +486,128 @@ func (s *SQLStorage) Ping() error {
 	}
 	return nil
 }
+
+func (s *SQLStorage) AddUsage(usage *Usage) error {
+	return addUsage(s.db, usage)
+}
+
+func addUsage(db *gorm.DB, usage *Usage) error {
+	increment := func() (bool, error) {
+		result := db.Model(&Usage{}).
+			Where("owner = ? AND device = ? AND time = ? AND resolution = ?", usage.Owner, usage.Device, usage.Time, usage.Resolution).
+			Updates(map[string]interface{}{
+				"receive_bytes":  gorm.Expr("receive_bytes + ?", usage.ReceiveBytes),
+				"transmit_bytes": gorm.Expr("transmit_bytes + ?", usage.TransmitBytes),
+			})
+		return result.RowsAffected > 0, result.Error
+	}
+
+	updated, err := increment()
+	if err != nil {
+		return errors.Wrap(err, "failed to update usage")
+	}
+	if updated {
+		return nil
+	}
+	created := *usage
+	created.ID = 0
+	if createErr := db.Create(&created).Error; createErr != nil {
+		// the bucket was created concurrently
+		updated, err := increment()
+		if err != nil {
+			return errors.Wrap(err, "failed to update usage")
+		}
+		if !updated {
+			return errors.Wrap(createErr, "failed to write usage")
+		}
+	}
+	return nil
+}
+
+func (s *SQLStorage) ListUsage(owner string, device string, since time.Time, until time.Time) ([]*Usage, error) {
+	usages := []*Usage{}
+	err := s.db.Where("owner = ? AND device = ? AND time >= ? AND time < ?", owner, device, since.UTC(), until.UTC()).
+		Order("time").
+		Find(&usages).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read usage")
+	}
+	return usages, nil
+}
+
+func (s *SQLStorage) DownsampleUsage(before time.Time, resolution time.Duration) error {
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return errors.Wrap(tx.Error, "failed to start transaction")
+	}
+	defer tx.RollbackUnlessCommitted()
+
+	usages := []*Usage{}
+	if err := tx.Where("time < ? AND resolution < ?", before.UTC(), resolution).Find(&usages).Error; err != nil {
+		return errors.Wrap(err, "failed to read usage")
+	}
+	if len(usages) == 0 {
+		return nil
+	}
+	if err := tx.Where("time < ? AND resolution < ?", before.UTC(), resolution).Delete(&Usage{}).Error; err != nil {
+		return errors.Wrap(err, "failed to delete downsampled usage")
+	}
+	for _, usage := range downsample(usages, resolution) {
+		if err := addUsage(tx, usage); err != nil {
+			return err
+		}
+	}
+	return errors.Wrap(tx.Commit().Error, "failed to downsample usage")
+}
+
+func (s *SQLStorage) PurgeUsage(before time.Time) error {
+	if err := s.db.Where("time < ?", before.UTC()).Delete(&Usage{}).Error; err != nil {
+		return errors.Wrap(err, "failed to purge usage")
+	}
+	return nil
+}
+
+func (s *SQLStorage) AddQuotaUsage(usage *QuotaUsage) error {
+	increment := func() (bool, error) {
+		result := s.db.Model(&QuotaUsage{}).
+			Where("owner = ? AND period = ?", usage.Owner, usage.Period.UTC()).
+			UpdateColumn("bytes", gorm.Expr("bytes + ?", usage.Bytes))
+		return result.RowsAffected > 0, result.Error
+	}
+
+	updated, err := increment()
+	if err != nil {
+		return errors.Wrap(err, "failed to update quota usage")
+	}
+	if updated {
+		return nil
+	}
+	created := *usage
+	created.Period = usage.Period.UTC()
+	if createErr := s.db.Create(&created).Error; createErr != nil {
+		// the period was created concurrently
+		updated, err := increment()
+		if err != nil {
+			return errors.Wrap(err, "failed to update quota usage")
+		}
+		if !updated {
+			return errors.Wrap(createErr, "failed to write quota usage")
+		}
+	}
+	return nil
+}
+
+func (s *SQLStorage) ListQuotaUsage(period time.Time) ([]*QuotaUsage, error) {
+	usages := []*QuotaUsage{}
+	if err := s.db.Where("period = ?", period.UTC()).Find(&usages).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to read quota usage")
+	}
+	return usages, nil
+}
+
+func (s *SQLStorage) PurgeQuotaUsage(before time.Time) error {
+	if err := s.db.Where("period < ?", before.UTC()).Delete(&QuotaUsage{}).Error; err != nil {
+		return errors.Wrap(err, "failed to purge quota usage")
+	}
+	return nil
+}
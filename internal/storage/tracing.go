@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/jinzhu/gorm"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/freifunkMUC/wg-access-server/internal/traces"
+)
+
+const (
+	spanKey    = "traces:span"
+	contextKey = "traces:context"
+)
+
+// registerTracing records a span for every query. gorm v1 doesn't pass a context to the callbacks,
+// the queries of a storage returned by WithContext are part of the trace of its context,
+// the other queries start a new trace.
+func registerTracing(db *gorm.DB, system string) {
+	callbacks := db.Callback()
+	callbacks.Create().Before("gorm:begin_transaction").Register("traces:before_create", startSpan("gorm.create", system))
+	callbacks.Create().After("gorm:commit_or_rollback_transaction").Register("traces:after_create", endSpan)
+	callbacks.Query().Before("gorm:query").Register("traces:before_query", startSpan("gorm.query", system))
+	callbacks.Query().After("gorm:after_query").Register("traces:after_query", endSpan)
+	callbacks.Update().Before("gorm:begin_transaction").Register("traces:before_update", startSpan("gorm.update", system))
+	callbacks.Update().After("gorm:commit_or_rollback_transaction").Register("traces:after_update", endSpan)
+	callbacks.Delete().Before("gorm:begin_transaction").Register("traces:before_delete", startSpan("gorm.delete", system))
+	callbacks.Delete().After("gorm:commit_or_rollback_transaction").Register("traces:after_delete", endSpan)
+	callbacks.RowQuery().Before("gorm:row_query").Register("traces:before_row_query", startSpan("gorm.row_query", system))
+	callbacks.RowQuery().After("gorm:row_query").Register("traces:after_row_query", endSpan)
+}
+
+func startSpan(name string, system string) func(scope *gorm.Scope) {
+	return func(scope *gorm.Scope) {
+		ctx := context.Background()
+		if value, ok := scope.Get(contextKey); ok {
+			ctx = value.(context.Context)
+		}
+		_, span := traces.Start(ctx, name,
+			attribute.String("db.system", system),
+			attribute.String("db.sql.table", scope.TableName()),
+		)
+		scope.InstanceSet(spanKey, span)
+	}
+}
+
+func endSpan(scope *gorm.Scope) {
+	value, ok := scope.InstanceGet(spanKey)
+	if !ok {
+		return
+	}
+	span := value.(trace.Span)
+	span.SetAttributes(
+		attribute.String("db.statement", scope.SQL),
+		attribute.Int64("db.rows_affected", scope.DB().RowsAffected),
+	)
+	var err error
+	if scope.HasError() && !gorm.IsRecordNotFoundError(scope.DB().Error) {
+		err = scope.DB().Error
+	}
+	traces.End(span, err)
+}
+
+// WithContext returns a copy of the storage whose queries are traced as part of the span in ctx
+func (s *SQLStorage) WithContext(ctx context.Context) Storage {
+	copied := *s
+	copied.db = s.db.Set(contextKey, ctx)
+	return &copied
+}
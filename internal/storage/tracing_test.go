@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/freifunkMUC/wg-access-server/internal/traces"
+)
+
+func TestSqliteStorageTracing(t *testing.T) {
+	require := require.New(t)
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	s, err := NewStorage("sqlite3://" + t.TempDir() + "/sqlite.db")
+	require.NoError(err)
+	require.NoError(s.Open())
+	defer s.Close()
+
+	ctx, span := traces.Start(context.Background(), "test.request")
+	_, err = s.WithContext(ctx).List("alice")
+	require.NoError(err)
+	span.End()
+	_, err = s.List("bob")
+	require.NoError(err)
+
+	queries := []sdktrace.ReadOnlySpan{}
+	for _, ended := range recorder.Ended() {
+		if ended.Name() == "gorm.query" {
+			queries = append(queries, ended)
+		}
+	}
+	require.Len(queries, 2)
+	// the query of the request is part of its trace, the other one starts a new trace
+	require.Equal(span.SpanContext().SpanID(), queries[0].Parent().SpanID())
+	require.Equal(span.SpanContext().TraceID(), queries[0].SpanContext().TraceID())
+	require.False(queries[1].Parent().IsValid())
+}
@@ -0,0 +1,173 @@
+package tokens
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/freifunkMUC/wg-access-server/internal/storage"
+	"github.com/freifunkMUC/wg-access-server/pkg/authnz/authsession"
+)
+
+// prefix makes tokens recognizable, e.g. for secret scanners
+const prefix = "wgas_"
+
+type TokenManager struct {
+	storage storage.Storage
+}
+
+// ValidationError is returned if a token can't be created with the requested name or expiry
+type ValidationError struct {
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Reason
+}
+
+// ErrReadOnly is returned if a read-only identity tries to create a token
+var ErrReadOnly = errors.New("Read-only tokens can't create tokens.")
+
+func New(s storage.Storage) *TokenManager {
+	return &TokenManager{s}
+}
+
+// Create issues a new token for the identity.
+// The returned secret is not stored and can't be retrieved again.
+func (t *TokenManager) Create(ctx context.Context, identity *authsession.Identity, name string, readOnly bool, expiresAt *time.Time) (*storage.Token, string, error) {
+	if name == "" {
+		return nil, "", &ValidationError{"Token name must not be empty."}
+	}
+	if expiresAt != nil && expiresAt.Before(time.Now()) {
+		return nil, "", &ValidationError{"Token expiry must be in the future."}
+	}
+	if identity.Claims.IsReadOnly() {
+		return nil, "", ErrReadOnly
+	}
+
+	claims, err := json.Marshal(identity.Claims)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to encode claims")
+	}
+
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return nil, "", errors.Wrap(err, "failed to generate token")
+	}
+	secret := prefix + base64.RawURLEncoding.EncodeToString(random)
+
+	token := &storage.Token{
+		ID:            uuid.New().String(),
+		Owner:         identity.Subject,
+		OwnerName:     identity.Name,
+		OwnerEmail:    identity.Email,
+		OwnerProvider: identity.Provider,
+		OwnerClaims:   string(claims),
+		Name:          name,
+		Hash:          hash(secret),
+		ReadOnly:      readOnly,
+		CreatedAt:     time.Now(),
+		ExpiresAt:     expiresAt,
+	}
+	if err := t.storage.WithContext(ctx).SaveToken(token); err != nil {
+		return nil, "", errors.Wrap(err, "failed to save the new token")
+	}
+	return token, secret, nil
+}
+
+func (t *TokenManager) List(ctx context.Context, owner string) ([]*storage.Token, error) {
+	return t.storage.WithContext(ctx).ListTokens(owner)
+}
+
+// Revoke deletes a token of the owner
+func (t *TokenManager) Revoke(ctx context.Context, owner string, id string) error {
+	token, err := t.storage.WithContext(ctx).GetToken(id)
+	if err != nil || token.Owner != owner {
+		return errors.New("token doesn't exist")
+	}
+	return t.storage.WithContext(ctx).DeleteToken(token)
+}
+
+// RevokeAll deletes all tokens of the owner, e.g. when the user is deleted, and returns them
+func (t *TokenManager) RevokeAll(ctx context.Context, owner string) ([]*storage.Token, error) {
+	tokens, err := t.storage.WithContext(ctx).ListTokens(owner)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list tokens")
+	}
+	return t.revoke(ctx, tokens)
+}
+
+// RevokeChanged deletes the tokens of the identity that were created with other claims and returns them.
+// Tokens keep the claims of their creation, so they are revoked when the owner signs in with changed claims,
+// e.g. after they were removed from a group.
+func (t *TokenManager) RevokeChanged(ctx context.Context, identity *authsession.Identity) ([]*storage.Token, error) {
+	tokens, err := t.storage.WithContext(ctx).ListTokens(identity.Subject)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list tokens")
+	}
+	changed := []*storage.Token{}
+	for _, token := range tokens {
+		claims := authsession.Claims{}
+		if token.OwnerClaims != "" {
+			if err := json.Unmarshal([]byte(token.OwnerClaims), &claims); err != nil {
+				return nil, errors.Wrap(err, "failed to decode token claims")
+			}
+		}
+		if !claims.Equal(identity.Claims) {
+			changed = append(changed, token)
+		}
+	}
+	return t.revoke(ctx, changed)
+}
+
+func (t *TokenManager) revoke(ctx context.Context, tokens []*storage.Token) ([]*storage.Token, error) {
+	for i, token := range tokens {
+		if err := t.storage.WithContext(ctx).DeleteToken(token); err != nil {
+			return tokens[:i], errors.Wrapf(err, "failed to revoke token %s", token.ID)
+		}
+	}
+	return tokens, nil
+}
+
+// Verify implements authsession.TokenVerifier
+func (t *TokenManager) Verify(secret string) (*authsession.Identity, error) {
+	if !strings.HasPrefix(secret, prefix) {
+		return nil, errors.New("invalid token")
+	}
+	token, err := t.storage.GetTokenByHash(hash(secret))
+	if err != nil {
+		return nil, errors.New("invalid token")
+	}
+	if token.ExpiresAt != nil && token.ExpiresAt.Before(time.Now()) {
+		return nil, errors.New("token expired")
+	}
+
+	identity := &authsession.Identity{
+		Provider: token.OwnerProvider,
+		Subject:  token.Owner,
+		Name:     token.OwnerName,
+		Email:    token.OwnerEmail,
+	}
+	if token.OwnerClaims != "" {
+		if err := json.Unmarshal([]byte(token.OwnerClaims), &identity.Claims); err != nil {
+			return nil, errors.Wrap(err, "failed to decode token claims")
+		}
+	}
+	if token.ReadOnly {
+		identity.Claims.MakeReadOnly()
+	}
+	return identity, nil
+}
+
+func hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,123 @@
+package tokens
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/freifunkMUC/wg-access-server/internal/storage"
+	"github.com/freifunkMUC/wg-access-server/pkg/authnz/authsession"
+)
+
+func TestCreateAndVerify(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	manager := New(storage.NewMemoryStorage())
+	owner := &authsession.Identity{Provider: "oidc", Subject: "alice", Name: "Alice"}
+	owner.Claims.MakeAdmin()
+
+	_, secret, err := manager.Create(ctx, owner, "ci", false, nil)
+	require.NoError(err)
+
+	identity, err := manager.Verify(secret)
+	require.NoError(err)
+	require.Equal("alice", identity.Subject)
+	require.Equal("oidc", identity.Provider)
+	require.True(identity.Claims.IsAdmin())
+	require.False(identity.Claims.IsReadOnly())
+
+	_, err = manager.Verify(secret + "x")
+	require.Error(err)
+}
+
+func TestReadOnlyToken(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	manager := New(storage.NewMemoryStorage())
+	owner := &authsession.Identity{Subject: "alice"}
+
+	_, secret, err := manager.Create(ctx, owner, "monitoring", true, nil)
+	require.NoError(err)
+
+	identity, err := manager.Verify(secret)
+	require.NoError(err)
+	require.True(identity.Claims.IsReadOnly())
+
+	// read-only identities can't escalate by creating new tokens
+	_, _, err = manager.Create(ctx, identity, "escalate", false, nil)
+	require.ErrorIs(err, ErrReadOnly)
+}
+
+func TestExpiredAndRevokedTokens(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	s := storage.NewMemoryStorage()
+	manager := New(s)
+	owner := &authsession.Identity{Subject: "alice"}
+
+	expiry := time.Now().Add(time.Hour)
+	token, secret, err := manager.Create(ctx, owner, "expiring", false, &expiry)
+	require.NoError(err)
+
+	past := time.Now().Add(-time.Minute)
+	var validationErr *ValidationError
+	_, _, err = manager.Create(ctx, owner, "expired", false, &past)
+	require.ErrorAs(err, &validationErr)
+
+	token.ExpiresAt = &past
+	require.NoError(s.SaveToken(token))
+	_, err = manager.Verify(secret)
+	require.Error(err)
+
+	token, secret, err = manager.Create(ctx, owner, "revoked", false, nil)
+	require.NoError(err)
+	require.Error(manager.Revoke(ctx, "bob", token.ID))
+	require.NoError(manager.Revoke(ctx, "alice", token.ID))
+	_, err = manager.Verify(secret)
+	require.Error(err)
+}
+
+func TestRevokeChangedAndAll(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	manager := New(storage.NewMemoryStorage())
+	owner := &authsession.Identity{Subject: "alice"}
+	owner.Claims.Add("group", "staff")
+	owner.Claims.MakeAdmin()
+
+	_, secret, err := manager.Create(ctx, owner, "ci", false, nil)
+	require.NoError(err)
+
+	// the same claims in another order
+	current := &authsession.Identity{Subject: "alice"}
+	current.Claims.MakeAdmin()
+	current.Claims.Add("group", "staff")
+	revoked, err := manager.RevokeChanged(ctx, current)
+	require.NoError(err)
+	require.Empty(revoked)
+	_, err = manager.Verify(secret)
+	require.NoError(err)
+
+	// alice is no longer an admin
+	current = &authsession.Identity{Subject: "alice"}
+	current.Claims.Add("group", "staff")
+	revoked, err = manager.RevokeChanged(ctx, current)
+	require.NoError(err)
+	require.Len(revoked, 1)
+	_, err = manager.Verify(secret)
+	require.Error(err)
+
+	_, secret, err = manager.Create(ctx, current, "ci", false, nil)
+	require.NoError(err)
+	revoked, err = manager.RevokeAll(ctx, "alice")
+	require.NoError(err)
+	require.Len(revoked, 1)
+	_, err = manager.Verify(secret)
+	require.Error(err)
+}
@@ -0,0 +1,104 @@
+package traces
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/freifunkMUC/wg-access-server/buildinfo"
+)
+
+const instrumentationName = "github.com/freifunkMUC/wg-access-server"
+
+// Exporters of the spans
+const (
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+type Options struct {
+	// Exporter is empty to disable tracing
+	Exporter string
+	// Endpoint of the OTLP receiver, the OTEL_EXPORTER_OTLP_* environment variables apply if empty
+	Endpoint    string
+	Insecure    bool
+	SampleRatio float64
+}
+
+// Setup installs the OpenTelemetry exporter configured by opts. Spans are only recorded
+// if an exporter is configured. The returned function flushes the remaining spans on shutdown.
+func Setup(opts Options) (func(context.Context) error, error) {
+	return setup(opts, os.Stdout)
+}
+
+func setup(opts Options, stdout io.Writer) (func(context.Context) error, error) {
+	if opts.SampleRatio < 0 || opts.SampleRatio > 1 {
+		return nil, errors.New("tracing sample ratio must be between 0 and 1")
+	}
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch opts.Exporter {
+	case "":
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		options := []otlptracegrpc.Option{}
+		if opts.Endpoint != "" {
+			options = append(options, otlptracegrpc.WithEndpoint(opts.Endpoint))
+		}
+		if opts.Insecure {
+			options = append(options, otlptracegrpc.WithInsecure())
+		}
+		exporter, err = otlptracegrpc.New(context.Background(), options...)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(stdout))
+	default:
+		return nil, errors.Errorf("unknown tracing exporter '%s'", opts.Exporter)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the trace exporter")
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", "wg-access-server"),
+		attribute.String("service.version", buildinfo.Version()),
+	))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the trace resource")
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+// Start starts a span of wg-access-server. Operations without a request context,
+// e.g. of the background loops, pass context.Background() and start a new trace.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records the error, if any, and ends the span
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
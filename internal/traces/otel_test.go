@@ -0,0 +1,80 @@
+package traces
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/freifunkMUC/wg-embed/pkg/wgembed"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+func TestSetup(t *testing.T) {
+	require := require.New(t)
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	_, err := Setup(Options{Exporter: "jaeger", SampleRatio: 1})
+	require.Error(err)
+	_, err = Setup(Options{Exporter: ExporterStdout, SampleRatio: 2})
+	require.Error(err)
+
+	out := &bytes.Buffer{}
+	shutdown, err := setup(Options{Exporter: ExporterStdout, SampleRatio: 1}, out)
+	require.NoError(err)
+
+	ctx, span := Start(context.Background(), "test.operation")
+	// the logs use the id of the trace
+	require.Equal(span.SpanContext().TraceID().String(), TraceID(WithTraceID(ctx)))
+	End(span, errors.New("failed"))
+
+	require.NoError(shutdown(context.Background()))
+	require.Contains(out.String(), `"Name":"test.operation"`)
+	require.Contains(out.String(), `"Description":"failed"`)
+}
+
+func TestTraceIDWithoutTracing(t *testing.T) {
+	require := require.New(t)
+
+	ctx, span := Start(context.Background(), "test.operation")
+	defer span.End()
+	require.Len(TraceID(WithTraceID(ctx)), 36)
+}
+
+type fakeWireGuard struct {
+	wgembed.WireGuardInterface
+}
+
+func (f *fakeWireGuard) ListPeers() ([]wgtypes.Peer, error) {
+	return []wgtypes.Peer{{}}, nil
+}
+
+func TestWireGuardWithContext(t *testing.T) {
+	require := require.New(t)
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	wg := WireGuard(&fakeWireGuard{})
+	ctx, span := Start(context.Background(), "test.sync")
+	_, err := WireGuardWithContext(ctx, wg).ListPeers()
+	require.NoError(err)
+	span.End()
+	_, err = wg.ListPeers()
+	require.NoError(err)
+
+	ended := recorder.Ended()
+	require.Len(ended, 3)
+	require.Equal("wireguard.list_peers", ended[0].Name())
+	require.Equal(span.SpanContext().SpanID(), ended[0].Parent().SpanID())
+	require.False(ended[2].Parent().IsValid())
+
+	// interfaces that aren't traced are returned unchanged
+	fake := &fakeWireGuard{}
+	require.Same(fake, WireGuardWithContext(ctx, fake))
+}
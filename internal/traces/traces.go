@@ -6,6 +6,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type traceContextKey string
@@ -14,7 +15,12 @@ const (
 	TraceIDKey traceContextKey = "trace.id"
 )
 
+// WithTraceID adds the id of the current OpenTelemetry trace to the context,
+// a random id is generated if there is none, e.g. if tracing is disabled
 func WithTraceID(ctx context.Context) context.Context {
+	if span := trace.SpanContextFromContext(ctx); span.HasTraceID() {
+		return context.WithValue(ctx, TraceIDKey, span.TraceID().String())
+	}
 	id, err := uuid.NewRandom()
 	if err != nil {
 		logrus.Warn(errors.Wrap(err, "failed to generate trace id"))
@@ -0,0 +1,55 @@
+package traces
+
+import (
+	"context"
+
+	"github.com/freifunkMUC/wg-embed/pkg/wgembed"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+// wireGuard records a span for each peer operation of the interface,
+// as part of the trace of ctx or as a new trace if ctx has no span
+type wireGuard struct {
+	wgembed.WireGuardInterface
+	ctx context.Context
+}
+
+// WireGuard wraps the interface to trace its peer operations
+func WireGuard(wg wgembed.WireGuardInterface) wgembed.WireGuardInterface {
+	return &wireGuard{WireGuardInterface: wg, ctx: context.Background()}
+}
+
+// WireGuardWithContext returns the interface with its peer operations traced as part of the span in ctx,
+// interfaces that aren't wrapped by WireGuard are returned unchanged
+func WireGuardWithContext(ctx context.Context, wg wgembed.WireGuardInterface) wgembed.WireGuardInterface {
+	if w, ok := wg.(*wireGuard); ok {
+		return &wireGuard{WireGuardInterface: w.WireGuardInterface, ctx: ctx}
+	}
+	return wg
+}
+
+func (w *wireGuard) AddPeer(publicKey string, presharedKey string, addressCIDR []string) error {
+	_, span := Start(w.ctx, "wireguard.add_peer",
+		attribute.String("wireguard.peer", publicKey),
+		attribute.StringSlice("wireguard.allowed_ips", addressCIDR),
+	)
+	err := w.WireGuardInterface.AddPeer(publicKey, presharedKey, addressCIDR)
+	End(span, err)
+	return err
+}
+
+func (w *wireGuard) RemovePeer(publicKey string) error {
+	_, span := Start(w.ctx, "wireguard.remove_peer", attribute.String("wireguard.peer", publicKey))
+	err := w.WireGuardInterface.RemovePeer(publicKey)
+	End(span, err)
+	return err
+}
+
+func (w *wireGuard) ListPeers() ([]wgtypes.Peer, error) {
+	_, span := Start(w.ctx, "wireguard.list_peers")
+	peers, err := w.WireGuardInterface.ListPeers()
+	span.SetAttributes(attribute.Int("wireguard.peers", len(peers)))
+	End(span, err)
+	return peers, err
+}
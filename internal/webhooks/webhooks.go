@@ -0,0 +1,285 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/freifunkMUC/wg-access-server/internal/config"
+	"github.com/freifunkMUC/wg-access-server/internal/storage"
+)
+
+// Events sent to the webhook endpoints
+const (
+	DeviceCreated = "device.created"
+	DeviceDeleted = "device.deleted"
+	// sent in addition to device.deleted when a device is removed by the inactive device deletion
+	DeviceInactive = "device.inactive"
+)
+
+var events = []string{DeviceCreated, DeviceDeleted, DeviceInactive}
+
+const (
+	defaultQueueSize   = 100
+	defaultMaxAttempts = 5
+	requestTimeout     = 10 * time.Second
+	initialBackoff     = time.Second
+	maxBackoff         = time.Minute
+)
+
+// Headers of the webhook requests
+const (
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+	SignatureHeader = "X-Webhook-Signature"
+)
+
+type Payload struct {
+	// ID of the delivery, identical for all attempts
+	ID     string    `json:"id"`
+	Event  string    `json:"event"`
+	Time   time.Time `json:"time"`
+	Device Device    `json:"device"`
+}
+
+// Device contains the non-secret fields of a device, without personal data like the owner's email address
+type Device struct {
+	Owner             string     `json:"owner"`
+	OwnerName         string     `json:"owner_name"`
+	OwnerProvider     string     `json:"owner_provider"`
+	Name              string     `json:"name"`
+	PublicKey         string     `json:"public_key"`
+	Address           string     `json:"address"`
+	CreatedAt         time.Time  `json:"created_at"`
+	LastHandshakeTime *time.Time `json:"last_handshake_time,omitempty"`
+}
+
+// Dispatcher posts events to the webhook endpoints.
+// Every endpoint has its own bounded queue and worker, so neither a slow
+// endpoint nor a slow network delay the callers or the other endpoints.
+// A nil *Dispatcher is valid and discards all events.
+type Dispatcher struct {
+	endpoints   []*endpoint
+	maxAttempts int
+	client      *http.Client
+	// backoff returns the delay before the given retry (starting at 1)
+	backoff func(retry int) time.Duration
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+type endpoint struct {
+	url    string
+	secret []byte
+	events []string
+	queue  chan *Payload
+}
+
+// New validates the configuration and starts the delivery workers,
+// it returns nil if no endpoints are configured
+func New(hooks []config.Webhook, queueSize int, maxAttempts int) (*Dispatcher, error) {
+	if len(hooks) == 0 {
+		return nil, nil
+	}
+	if queueSize <= 0 {
+		queueSize = defaultQueueSize
+	}
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxAttempts
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &Dispatcher{
+		maxAttempts: maxAttempts,
+		client:      &http.Client{Timeout: requestTimeout},
+		backoff:     exponentialBackoff,
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+	for _, hook := range hooks {
+		u, err := url.Parse(hook.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			cancel()
+			return nil, fmt.Errorf("invalid webhook url '%s'", hook.URL)
+		}
+		for _, event := range hook.Events {
+			if !slices.Contains(events, event) {
+				cancel()
+				return nil, fmt.Errorf("webhook '%s' subscribes to the unknown event '%s'", hook.URL, event)
+			}
+		}
+		d.endpoints = append(d.endpoints, &endpoint{
+			url:    hook.URL,
+			secret: []byte(hook.Secret),
+			events: hook.Events,
+			queue:  make(chan *Payload, queueSize),
+		})
+	}
+	for _, e := range d.endpoints {
+		d.wg.Add(1)
+		go d.worker(e)
+	}
+	return d, nil
+}
+
+// Subscribe sends the storage events of devices to the endpoints
+func (d *Dispatcher) Subscribe(watcher storage.Watcher) {
+	if d == nil {
+		return
+	}
+	watcher.OnAdd(func(device *storage.Device) {
+		d.Send(DeviceCreated, device)
+	})
+	watcher.OnDelete(func(device *storage.Device) {
+		d.Send(DeviceDeleted, device)
+	})
+}
+
+// DeviceInactive is the callback of the inactive device deletion
+func (d *Dispatcher) DeviceInactive(device *storage.Device) {
+	d.Send(DeviceInactive, device)
+}
+
+// Send queues the event for all endpoints subscribed to it, it never blocks
+func (d *Dispatcher) Send(event string, device *storage.Device) {
+	if d == nil {
+		return
+	}
+	payload := &Payload{
+		ID:     uuid.NewString(),
+		Event:  event,
+		Time:   time.Now(),
+		Device: mapDevice(device),
+	}
+	for _, e := range d.endpoints {
+		if len(e.events) > 0 && !slices.Contains(e.events, event) {
+			continue
+		}
+		select {
+		case e.queue <- payload:
+		default:
+			logrus.Warnf("webhook queue of %s is full, dropping %s event of device %s/%s", e.url, event, device.Owner, device.Name)
+		}
+	}
+}
+
+// Close stops the workers, pending deliveries are discarded
+func (d *Dispatcher) Close() {
+	if d == nil {
+		return
+	}
+	d.cancel()
+	d.wg.Wait()
+}
+
+func (d *Dispatcher) worker(e *endpoint) {
+	defer d.wg.Done()
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case payload := <-e.queue:
+			d.deliver(e, payload)
+		}
+	}
+}
+
+func (d *Dispatcher) deliver(e *endpoint, payload *Payload) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		logrus.Error(errors.Wrap(err, "failed to encode webhook payload"))
+		return
+	}
+	for attempt := 1; attempt <= d.maxAttempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-d.ctx.Done():
+				return
+			case <-time.After(d.backoff(attempt - 1)):
+			}
+		}
+		retry, err := d.post(e, payload, body)
+		if d.ctx.Err() != nil {
+			// closed during the request
+			return
+		}
+		if err == nil {
+			logrus.Debugf("delivered webhook %s (%s) to %s", payload.ID, payload.Event, e.url)
+			return
+		}
+		if !retry || attempt == d.maxAttempts {
+			logrus.Error(errors.Wrapf(err, "failed to deliver webhook %s (%s) to %s after %d attempt(s)", payload.ID, payload.Event, e.url, attempt))
+			return
+		}
+		logrus.Debug(errors.Wrapf(err, "failed to deliver webhook %s (%s) to %s, retrying", payload.ID, payload.Event, e.url))
+	}
+}
+
+// post sends the payload once, it reports whether a failed delivery should be retried
+func (d *Dispatcher) post(e *endpoint, payload *Payload, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(d.ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "wg-access-server")
+	req.Header.Set(EventHeader, payload.Event)
+	req.Header.Set(DeliveryHeader, payload.ID)
+	if len(e.secret) > 0 {
+		req.Header.Set(SignatureHeader, Sign(e.secret, body))
+	}
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return false, nil
+	}
+	// client errors except for rate limits won't succeed on a retry
+	retry := res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests || res.StatusCode == http.StatusRequestTimeout
+	return retry, fmt.Errorf("unexpected status %s", res.Status)
+}
+
+// Sign returns the signature header value of the body, "sha256=<hex encoded HMAC-SHA256>"
+func Sign(secret []byte, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func exponentialBackoff(retry int) time.Duration {
+	backoff := initialBackoff << (retry - 1)
+	if backoff <= 0 || backoff > maxBackoff {
+		return maxBackoff
+	}
+	return backoff
+}
+
+func mapDevice(device *storage.Device) Device {
+	return Device{
+		Owner:             device.Owner,
+		OwnerName:         device.OwnerName,
+		OwnerProvider:     device.OwnerProvider,
+		Name:              device.Name,
+		PublicKey:         device.PublicKey,
+		Address:           device.Address,
+		CreatedAt:         device.CreatedAt,
+		LastHandshakeTime: device.LastHandshakeTime,
+	}
+}
@@ -0,0 +1,118 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/freifunkMUC/wg-access-server/internal/config"
+	"github.com/freifunkMUC/wg-access-server/internal/storage"
+)
+
+func TestDeliverSignedWithRetry(t *testing.T) {
+	require := require.New(t)
+
+	var attempts atomic.Int32
+	type request struct {
+		header http.Header
+		body   []byte
+	}
+	received := make(chan request, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		received <- request{r.Header, body}
+	}))
+	defer server.Close()
+
+	d, err := New([]config.Webhook{{URL: server.URL, Secret: "secret"}}, 0, 3)
+	require.NoError(err)
+	d.backoff = func(int) time.Duration { return time.Millisecond }
+	defer d.Close()
+
+	d.Send(DeviceCreated, &storage.Device{Owner: "alice", Name: "notebook", PresharedKey: "secret"})
+
+	select {
+	case r := <-received:
+		require.Equal(DeviceCreated, r.header.Get(EventHeader))
+		require.Equal(Sign([]byte("secret"), r.body), r.header.Get(SignatureHeader))
+		payload := Payload{}
+		require.NoError(json.Unmarshal(r.body, &payload))
+		require.Equal(DeviceCreated, payload.Event)
+		require.Equal("notebook", payload.Device.Name)
+		require.NotContains(string(r.body), "preshared")
+	case <-time.After(5 * time.Second):
+		require.Fail("webhook was not delivered")
+	}
+	require.EqualValues(3, attempts.Load())
+}
+
+func TestSendNeverBlocks(t *testing.T) {
+	require := require.New(t)
+
+	block := make(chan struct{})
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		<-block
+	}))
+	defer server.Close()
+	defer close(block)
+
+	d, err := New([]config.Webhook{{URL: server.URL}, {URL: server.URL, Events: []string{DeviceInactive}}}, 2, 1)
+	require.NoError(err)
+	defer d.Close()
+
+	// the worker of the first endpoint gets stuck on the first event
+	d.Send(DeviceDeleted, &storage.Device{Owner: "alice", Name: "notebook"})
+	require.Eventually(func() bool { return requests.Load() == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 10; i++ {
+			d.Send(DeviceDeleted, &storage.Device{Owner: "alice", Name: "notebook"})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		require.Fail("Send blocked on a slow endpoint")
+	}
+	require.Len(d.endpoints[0].queue, 2)
+	require.Len(d.endpoints[1].queue, 0)
+}
+
+func TestInvalidConfig(t *testing.T) {
+	require := require.New(t)
+
+	d, err := New(nil, 0, 0)
+	require.NoError(err)
+	require.Nil(d)
+	d.Send(DeviceCreated, &storage.Device{})
+	d.Close()
+
+	_, err = New([]config.Webhook{{URL: "ftp://example.com"}}, 0, 0)
+	require.Error(err)
+
+	_, err = New([]config.Webhook{{URL: "https://example.com", Events: []string{"device.exploded"}}}, 0, 0)
+	require.Error(err)
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	require := require.New(t)
+
+	require.Equal(time.Second, exponentialBackoff(1))
+	require.Equal(4*time.Second, exponentialBackoff(3))
+	require.Equal(maxBackoff, exponentialBackoff(10))
+	require.Equal(maxBackoff, exponentialBackoff(100))
+}
@@ -27,15 +27,22 @@ type ProviderBranding struct {
 }
 
 type ProviderRuntime struct {
-	store sessions.Store
+	store     sessions.Store
+	loginHook authsession.LoginHook
 }
 
-func NewProviderRuntime(store sessions.Store) *ProviderRuntime {
-	return &ProviderRuntime{store}
+func NewProviderRuntime(store sessions.Store, loginHook authsession.LoginHook) *ProviderRuntime {
+	return &ProviderRuntime{store, loginHook}
 }
 
 func (p *ProviderRuntime) SetSession(w http.ResponseWriter, r *http.Request, s *authsession.AuthSession) error {
-	return authsession.SetSession(p.store, r, w, s)
+	if err := authsession.SetSession(p.store, r, w, s); err != nil {
+		return err
+	}
+	if s.Identity != nil && p.loginHook != nil {
+		p.loginHook(r, s.Identity)
+	}
+	return nil
 }
 
 func (p *ProviderRuntime) GetSession(r *http.Request) (*authsession.AuthSession, error) {
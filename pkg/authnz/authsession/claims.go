@@ -2,6 +2,9 @@ package authsession
 
 const adminClaim = "admin"
 
+// readOnlyClaim is set for identities from read-only API tokens
+const readOnlyClaim = "read_only"
+
 type claim struct {
 	Name  string
 	Value string
@@ -36,6 +39,20 @@ func (c *Claims) Has(claim string, value string) bool {
 	return false
 }
 
+// Equal reports whether both contain the same claims, regardless of their order and duplicates
+func (c *Claims) Equal(other Claims) bool {
+	return c.contains(other) && other.contains(*c)
+}
+
+func (c *Claims) contains(other Claims) bool {
+	for _, curr := range other {
+		if !c.Has(curr.Name, curr.Value) {
+			return false
+		}
+	}
+	return true
+}
+
 func (c *Claims) IsAdmin() bool {
 	return c.Has(adminClaim, "true")
 }
@@ -46,3 +63,14 @@ func (c *Claims) MakeAdmin() {
 	}
 	c.Add(adminClaim, "true")
 }
+
+func (c *Claims) IsReadOnly() bool {
+	return c.Has(readOnlyClaim, "true")
+}
+
+func (c *Claims) MakeReadOnly() {
+	if c == nil {
+		return
+	}
+	c.Add(readOnlyClaim, "true")
+}
@@ -1,3 +1,11 @@
 package authsession
 
+import "net/http"
+
 type ClaimsMiddleware func(user *Identity) error
+
+// TokenVerifier resolves a bearer token to the identity of its owner
+type TokenVerifier func(token string) (*Identity, error)
+
+// LoginHook is called after a user signed in with any provider
+type LoginHook func(r *http.Request, user *Identity)
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/gorilla/sessions"
@@ -38,11 +39,12 @@ func (err *LoginError) Error() string {
 type AuthMiddleware struct {
 	config           authconfig.AuthConfig
 	claimsMiddleware authsession.ClaimsMiddleware
+	tokenVerifier    authsession.TokenVerifier
 	router           *mux.Router
 	runtime          *authruntime.ProviderRuntime
 }
 
-func New(config authconfig.AuthConfig, claimsMiddleware authsession.ClaimsMiddleware) (*AuthMiddleware, error) {
+func New(config authconfig.AuthConfig, claimsMiddleware authsession.ClaimsMiddleware, tokenVerifier authsession.TokenVerifier, loginHook authsession.LoginHook) (*AuthMiddleware, error) {
 	router := mux.NewRouter()
 	var storeSecret []byte
 	if config.SessionStore == nil || config.SessionStore.Secret == "" {
@@ -58,7 +60,7 @@ func New(config authconfig.AuthConfig, claimsMiddleware authsession.ClaimsMiddle
 		}
 	}
 	store := sessions.NewCookieStore(storeSecret)
-	runtime := authruntime.NewProviderRuntime(store)
+	runtime := authruntime.NewProviderRuntime(store, loginHook)
 	providers := config.Providers()
 
 	for _, p := range providers {
@@ -104,13 +106,14 @@ func New(config authconfig.AuthConfig, claimsMiddleware authsession.ClaimsMiddle
 	return &AuthMiddleware{
 		config,
 		claimsMiddleware,
+		tokenVerifier,
 		router,
 		runtime,
 	}, nil
 }
 
-func NewMiddleware(config authconfig.AuthConfig, claimsMiddleware authsession.ClaimsMiddleware) (mux.MiddlewareFunc, error) {
-	authMiddleware, err := New(config, claimsMiddleware)
+func NewMiddleware(config authconfig.AuthConfig, claimsMiddleware authsession.ClaimsMiddleware, tokenVerifier authsession.TokenVerifier, loginHook authsession.LoginHook) (mux.MiddlewareFunc, error) {
+	authMiddleware, err := New(config, claimsMiddleware, tokenVerifier, loginHook)
 	if err != nil {
 		return nil, err
 	}
@@ -153,6 +156,22 @@ func (m *AuthMiddleware) Middleware(next http.Handler) http.Handler {
 			return
 		}
 
+		// API clients authenticate with a bearer token instead of a session
+		if token, ok := bearerToken(r); ok && m.tokenVerifier != nil {
+			identity, err := m.tokenVerifier(token)
+			if err == nil && m.claimsMiddleware != nil {
+				err = m.claimsMiddleware(identity)
+			}
+			if err != nil {
+				traces.Logger(r.Context()).Warn(errors.Wrap(err, "bearer token authentication failed"))
+				w.Header().Set("WWW-Authenticate", `Bearer realm="wg-access-server"`)
+				http.Error(w, "invalid token", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(authsession.SetIdentityCtx(r.Context(), &authsession.AuthSession{Identity: identity})))
+			return
+		}
+
 		// otherwise we apply the standard middleware
 		// functionality i.e. annotate the request context
 		// with the request user (identity)
@@ -190,6 +209,14 @@ func (m *AuthMiddleware) Middleware(next http.Handler) http.Handler {
 	})
 }
 
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
 func RequireAuthentication(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if authsession.Authenticated(r.Context()) {
@@ -9,6 +9,7 @@ package proto
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
 	emptypb "google.golang.org/protobuf/types/known/emptypb"
 	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	wrapperspb "google.golang.org/protobuf/types/known/wrapperspb"
@@ -24,6 +25,64 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type DeviceEvent_Type int32
+
+const (
+	// sent for every device when the stream starts
+	DeviceEvent_EXISTING DeviceEvent_Type = 0
+	// sent once after the existing devices, without a device
+	DeviceEvent_SYNCED DeviceEvent_Type = 1
+	DeviceEvent_ADDED  DeviceEvent_Type = 2
+	// changes by users and admins as well as new metadata, e.g. the connection state and traffic
+	DeviceEvent_UPDATED DeviceEvent_Type = 3
+	DeviceEvent_DELETED DeviceEvent_Type = 4
+)
+
+// Enum value maps for DeviceEvent_Type.
+var (
+	DeviceEvent_Type_name = map[int32]string{
+		0: "EXISTING",
+		1: "SYNCED",
+		2: "ADDED",
+		3: "UPDATED",
+		4: "DELETED",
+	}
+	DeviceEvent_Type_value = map[string]int32{
+		"EXISTING": 0,
+		"SYNCED":   1,
+		"ADDED":    2,
+		"UPDATED":  3,
+		"DELETED":  4,
+	}
+)
+
+func (x DeviceEvent_Type) Enum() *DeviceEvent_Type {
+	p := new(DeviceEvent_Type)
+	*p = x
+	return p
+}
+
+func (x DeviceEvent_Type) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (DeviceEvent_Type) Descriptor() protoreflect.EnumDescriptor {
+	return file_devices_proto_enumTypes[0].Descriptor()
+}
+
+func (DeviceEvent_Type) Type() protoreflect.EnumType {
+	return &file_devices_proto_enumTypes[0]
+}
+
+func (x DeviceEvent_Type) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use DeviceEvent_Type.Descriptor instead.
+func (DeviceEvent_Type) EnumDescriptor() ([]byte, []int) {
+	return file_devices_proto_rawDescGZIP(), []int{9, 0}
+}
+
 type Device struct {
 	state             protoimpl.MessageState `protogen:"open.v1"`
 	Name              string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
@@ -40,8 +99,27 @@ type Device struct {
 	OwnerEmail        string                 `protobuf:"bytes,12,opt,name=owner_email,json=ownerEmail,proto3" json:"owner_email,omitempty"`
 	OwnerProvider     string                 `protobuf:"bytes,13,opt,name=owner_provider,json=ownerProvider,proto3" json:"owner_provider,omitempty"`
 	PresharedKey      string                 `protobuf:"bytes,14,opt,name=preshared_key,json=presharedKey,proto3" json:"preshared_key,omitempty"`
-	unknownFields     protoimpl.UnknownFields
-	sizeCache         protoimpl.SizeCache
+	// empty if the server wide allowed ips apply
+	AccessPolicy *AccessPolicy `protobuf:"bytes,15,opt,name=access_policy,json=accessPolicy,proto3" json:"access_policy,omitempty"`
+	// unset if the device never expires
+	ExpiresAt *timestamppb.Timestamp `protobuf:"bytes,16,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	Suspended bool                   `protobuf:"varint,17,opt,name=suspended,proto3" json:"suspended,omitempty"`
+	// set once the device has been inactive for longer than the warning threshold
+	StaleSince *timestamppb.Timestamp `protobuf:"bytes,18,opt,name=stale_since,json=staleSince,proto3" json:"stale_since,omitempty"`
+	// the device is deleted automatically at this time if it stays inactive or expired,
+	// unset if it won't be deleted
+	DeletionTime *timestamppb.Timestamp `protobuf:"bytes,19,opt,name=deletion_time,json=deletionTime,proto3" json:"deletion_time,omitempty"`
+	// the owner exceeded their monthly traffic quota,
+	// the device is suspended or throttled until the month ends
+	OverQuota bool `protobuf:"varint,20,opt,name=over_quota,json=overQuota,proto3" json:"over_quota,omitempty"`
+	// name of the client config profile, empty if the default client config applies
+	Profile string `protobuf:"bytes,21,opt,name=profile,proto3" json:"profile,omitempty"`
+	// gateway devices route the networks behind them
+	Gateway bool `protobuf:"varint,22,opt,name=gateway,proto3" json:"gateway,omitempty"`
+	// comma separated networks routed to a gateway device, e.g. 192.168.10.0/24
+	Routes        string `protobuf:"bytes,23,opt,name=routes,proto3" json:"routes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *Device) Reset() {
@@ -172,110 +250,156 @@ func (x *Device) GetPresharedKey() string {
 	return ""
 }
 
-type AddDeviceReq struct {
-	state              protoimpl.MessageState `protogen:"open.v1"`
-	Name               string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	PublicKey          string                 `protobuf:"bytes,2,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
-	PresharedKey       string                 `protobuf:"bytes,3,opt,name=preshared_key,json=presharedKey,proto3" json:"preshared_key,omitempty"`
-	ManualIpAssignment bool                   `protobuf:"varint,4,opt,name=manual_ip_assignment,json=manualIpAssignment,proto3" json:"manual_ip_assignment,omitempty"`
-	ManualIpv4Address  string                 `protobuf:"bytes,5,opt,name=manual_ipv4_address,json=manualIpv4Address,proto3" json:"manual_ipv4_address,omitempty"`
-	ManualIpv6Address  string                 `protobuf:"bytes,6,opt,name=manual_ipv6_address,json=manualIpv6Address,proto3" json:"manual_ipv6_address,omitempty"`
-	unknownFields      protoimpl.UnknownFields
-	sizeCache          protoimpl.SizeCache
+func (x *Device) GetAccessPolicy() *AccessPolicy {
+	if x != nil {
+		return x.AccessPolicy
+	}
+	return nil
 }
 
-func (x *AddDeviceReq) Reset() {
-	*x = AddDeviceReq{}
-	mi := &file_devices_proto_msgTypes[1]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *Device) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
 }
 
-func (x *AddDeviceReq) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *Device) GetSuspended() bool {
+	if x != nil {
+		return x.Suspended
+	}
+	return false
 }
 
-func (*AddDeviceReq) ProtoMessage() {}
+func (x *Device) GetStaleSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StaleSince
+	}
+	return nil
+}
 
-func (x *AddDeviceReq) ProtoReflect() protoreflect.Message {
-	mi := &file_devices_proto_msgTypes[1]
+func (x *Device) GetDeletionTime() *timestamppb.Timestamp {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.DeletionTime
 	}
-	return mi.MessageOf(x)
+	return nil
 }
 
-// Deprecated: Use AddDeviceReq.ProtoReflect.Descriptor instead.
-func (*AddDeviceReq) Descriptor() ([]byte, []int) {
-	return file_devices_proto_rawDescGZIP(), []int{1}
+func (x *Device) GetOverQuota() bool {
+	if x != nil {
+		return x.OverQuota
+	}
+	return false
 }
 
-func (x *AddDeviceReq) GetName() string {
+func (x *Device) GetProfile() string {
 	if x != nil {
-		return x.Name
+		return x.Profile
 	}
 	return ""
 }
 
-func (x *AddDeviceReq) GetPublicKey() string {
+func (x *Device) GetGateway() bool {
 	if x != nil {
-		return x.PublicKey
+		return x.Gateway
 	}
-	return ""
+	return false
 }
 
-func (x *AddDeviceReq) GetPresharedKey() string {
+func (x *Device) GetRoutes() string {
 	if x != nil {
-		return x.PresharedKey
+		return x.Routes
 	}
 	return ""
 }
 
-func (x *AddDeviceReq) GetManualIpAssignment() bool {
+type AccessPolicy struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Rules []*AccessRule          `protobuf:"bytes,1,rep,name=rules,proto3" json:"rules,omitempty"`
+	// name of the configured policy, empty for custom policies
+	Name            string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	ClientIsolation bool   `protobuf:"varint,3,opt,name=client_isolation,json=clientIsolation,proto3" json:"client_isolation,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *AccessPolicy) Reset() {
+	*x = AccessPolicy{}
+	mi := &file_devices_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AccessPolicy) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AccessPolicy) ProtoMessage() {}
+
+func (x *AccessPolicy) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_proto_msgTypes[1]
 	if x != nil {
-		return x.ManualIpAssignment
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return false
+	return mi.MessageOf(x)
 }
 
-func (x *AddDeviceReq) GetManualIpv4Address() string {
+// Deprecated: Use AccessPolicy.ProtoReflect.Descriptor instead.
+func (*AccessPolicy) Descriptor() ([]byte, []int) {
+	return file_devices_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *AccessPolicy) GetRules() []*AccessRule {
 	if x != nil {
-		return x.ManualIpv4Address
+		return x.Rules
 	}
-	return ""
+	return nil
 }
 
-func (x *AddDeviceReq) GetManualIpv6Address() string {
+func (x *AccessPolicy) GetName() string {
 	if x != nil {
-		return x.ManualIpv6Address
+		return x.Name
 	}
 	return ""
 }
 
-type ListDevicesReq struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
+func (x *AccessPolicy) GetClientIsolation() bool {
+	if x != nil {
+		return x.ClientIsolation
+	}
+	return false
+}
+
+type AccessRule struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// destination network, e.g. 10.0.0.0/24
+	Cidr string `protobuf:"bytes,1,opt,name=cidr,proto3" json:"cidr,omitempty"`
+	// optional: tcp, udp, icmp or icmpv6
+	Protocol string `protobuf:"bytes,2,opt,name=protocol,proto3" json:"protocol,omitempty"`
+	// optional: comma separated ports or port ranges, e.g. 22,8000-8080
+	Ports         string `protobuf:"bytes,3,opt,name=ports,proto3" json:"ports,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListDevicesReq) Reset() {
-	*x = ListDevicesReq{}
+func (x *AccessRule) Reset() {
+	*x = AccessRule{}
 	mi := &file_devices_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListDevicesReq) String() string {
+func (x *AccessRule) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListDevicesReq) ProtoMessage() {}
+func (*AccessRule) ProtoMessage() {}
 
-func (x *ListDevicesReq) ProtoReflect() protoreflect.Message {
+func (x *AccessRule) ProtoReflect() protoreflect.Message {
 	mi := &file_devices_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -287,32 +411,69 @@ func (x *ListDevicesReq) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListDevicesReq.ProtoReflect.Descriptor instead.
-func (*ListDevicesReq) Descriptor() ([]byte, []int) {
+// Deprecated: Use AccessRule.ProtoReflect.Descriptor instead.
+func (*AccessRule) Descriptor() ([]byte, []int) {
 	return file_devices_proto_rawDescGZIP(), []int{2}
 }
 
-type ListDevicesRes struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Items         []*Device              `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+func (x *AccessRule) GetCidr() string {
+	if x != nil {
+		return x.Cidr
+	}
+	return ""
+}
+
+func (x *AccessRule) GetProtocol() string {
+	if x != nil {
+		return x.Protocol
+	}
+	return ""
+}
+
+func (x *AccessRule) GetPorts() string {
+	if x != nil {
+		return x.Ports
+	}
+	return ""
+}
+
+type AddDeviceReq struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Name               string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	PublicKey          string                 `protobuf:"bytes,2,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	PresharedKey       string                 `protobuf:"bytes,3,opt,name=preshared_key,json=presharedKey,proto3" json:"preshared_key,omitempty"`
+	ManualIpAssignment bool                   `protobuf:"varint,4,opt,name=manual_ip_assignment,json=manualIpAssignment,proto3" json:"manual_ip_assignment,omitempty"`
+	ManualIpv4Address  string                 `protobuf:"bytes,5,opt,name=manual_ipv4_address,json=manualIpv4Address,proto3" json:"manual_ipv4_address,omitempty"`
+	ManualIpv6Address  string                 `protobuf:"bytes,6,opt,name=manual_ipv6_address,json=manualIpv6Address,proto3" json:"manual_ipv6_address,omitempty"`
+	// admin only
+	AccessPolicy *AccessPolicy `protobuf:"bytes,7,opt,name=access_policy,json=accessPolicy,proto3" json:"access_policy,omitempty"`
+	// defaults to the maximum device lifetime from now,
+	// unset without a maximum lifetime if the device never expires
+	ExpiresAt *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	// one of the profiles of the server info, empty for the default client config
+	Profile string `protobuf:"bytes,9,opt,name=profile,proto3" json:"profile,omitempty"`
+	// admin only: a gateway device and the comma separated networks routed to it,
+	// they must not overlap the VPN networks, other devices or the allowed ips
+	Gateway       bool   `protobuf:"varint,10,opt,name=gateway,proto3" json:"gateway,omitempty"`
+	Routes        string `protobuf:"bytes,11,opt,name=routes,proto3" json:"routes,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListDevicesRes) Reset() {
-	*x = ListDevicesRes{}
+func (x *AddDeviceReq) Reset() {
+	*x = AddDeviceReq{}
 	mi := &file_devices_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListDevicesRes) String() string {
+func (x *AddDeviceReq) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListDevicesRes) ProtoMessage() {}
+func (*AddDeviceReq) ProtoMessage() {}
 
-func (x *ListDevicesRes) ProtoReflect() protoreflect.Message {
+func (x *AddDeviceReq) ProtoReflect() protoreflect.Message {
 	mi := &file_devices_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -324,94 +485,127 @@ func (x *ListDevicesRes) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListDevicesRes.ProtoReflect.Descriptor instead.
-func (*ListDevicesRes) Descriptor() ([]byte, []int) {
+// Deprecated: Use AddDeviceReq.ProtoReflect.Descriptor instead.
+func (*AddDeviceReq) Descriptor() ([]byte, []int) {
 	return file_devices_proto_rawDescGZIP(), []int{3}
 }
 
-func (x *ListDevicesRes) GetItems() []*Device {
+func (x *AddDeviceReq) GetName() string {
 	if x != nil {
-		return x.Items
+		return x.Name
 	}
-	return nil
+	return ""
 }
 
-type DeleteDeviceReq struct {
-	state protoimpl.MessageState `protogen:"open.v1"`
-	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	// admin's may delete a device owned
-	// by someone other than the current user
-	// if empty, defaults to the current user
-	Owner         *wrapperspb.StringValue `protobuf:"bytes,2,opt,name=owner,proto3" json:"owner,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *AddDeviceReq) GetPublicKey() string {
+	if x != nil {
+		return x.PublicKey
+	}
+	return ""
 }
 
-func (x *DeleteDeviceReq) Reset() {
-	*x = DeleteDeviceReq{}
-	mi := &file_devices_proto_msgTypes[4]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *AddDeviceReq) GetPresharedKey() string {
+	if x != nil {
+		return x.PresharedKey
+	}
+	return ""
 }
 
-func (x *DeleteDeviceReq) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *AddDeviceReq) GetManualIpAssignment() bool {
+	if x != nil {
+		return x.ManualIpAssignment
+	}
+	return false
 }
 
-func (*DeleteDeviceReq) ProtoMessage() {}
+func (x *AddDeviceReq) GetManualIpv4Address() string {
+	if x != nil {
+		return x.ManualIpv4Address
+	}
+	return ""
+}
 
-func (x *DeleteDeviceReq) ProtoReflect() protoreflect.Message {
-	mi := &file_devices_proto_msgTypes[4]
+func (x *AddDeviceReq) GetManualIpv6Address() string {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.ManualIpv6Address
 	}
-	return mi.MessageOf(x)
+	return ""
 }
 
-// Deprecated: Use DeleteDeviceReq.ProtoReflect.Descriptor instead.
-func (*DeleteDeviceReq) Descriptor() ([]byte, []int) {
-	return file_devices_proto_rawDescGZIP(), []int{4}
+func (x *AddDeviceReq) GetAccessPolicy() *AccessPolicy {
+	if x != nil {
+		return x.AccessPolicy
+	}
+	return nil
 }
 
-func (x *DeleteDeviceReq) GetName() string {
+func (x *AddDeviceReq) GetExpiresAt() *timestamppb.Timestamp {
 	if x != nil {
-		return x.Name
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *AddDeviceReq) GetProfile() string {
+	if x != nil {
+		return x.Profile
 	}
 	return ""
 }
 
-func (x *DeleteDeviceReq) GetOwner() *wrapperspb.StringValue {
+func (x *AddDeviceReq) GetGateway() bool {
 	if x != nil {
-		return x.Owner
+		return x.Gateway
 	}
-	return nil
+	return false
 }
 
-type ListAllDevicesReq struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
+func (x *AddDeviceReq) GetRoutes() string {
+	if x != nil {
+		return x.Routes
+	}
+	return ""
+}
+
+type CreateDeviceWithConfigReq struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Name               string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	UsePresharedKey    bool                   `protobuf:"varint,2,opt,name=use_preshared_key,json=usePresharedKey,proto3" json:"use_preshared_key,omitempty"`
+	ManualIpAssignment bool                   `protobuf:"varint,3,opt,name=manual_ip_assignment,json=manualIpAssignment,proto3" json:"manual_ip_assignment,omitempty"`
+	ManualIpv4Address  string                 `protobuf:"bytes,4,opt,name=manual_ipv4_address,json=manualIpv4Address,proto3" json:"manual_ipv4_address,omitempty"`
+	ManualIpv6Address  string                 `protobuf:"bytes,5,opt,name=manual_ipv6_address,json=manualIpv6Address,proto3" json:"manual_ipv6_address,omitempty"`
+	// defaults to the server's client config persistent keepalive
+	PersistentKeepalive *wrapperspb.Int32Value `protobuf:"bytes,6,opt,name=persistent_keepalive,json=persistentKeepalive,proto3" json:"persistent_keepalive,omitempty"`
+	// admin only
+	AccessPolicy *AccessPolicy `protobuf:"bytes,7,opt,name=access_policy,json=accessPolicy,proto3" json:"access_policy,omitempty"`
+	// defaults to the maximum device lifetime from now,
+	// unset without a maximum lifetime if the device never expires
+	ExpiresAt *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	// one of the profiles of the server info, empty for the default client config
+	Profile string `protobuf:"bytes,9,opt,name=profile,proto3" json:"profile,omitempty"`
+	// admin only: a gateway device and the comma separated networks routed to it,
+	// they must not overlap the VPN networks, other devices or the allowed ips
+	Gateway       bool   `protobuf:"varint,10,opt,name=gateway,proto3" json:"gateway,omitempty"`
+	Routes        string `protobuf:"bytes,11,opt,name=routes,proto3" json:"routes,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListAllDevicesReq) Reset() {
-	*x = ListAllDevicesReq{}
-	mi := &file_devices_proto_msgTypes[5]
+func (x *CreateDeviceWithConfigReq) Reset() {
+	*x = CreateDeviceWithConfigReq{}
+	mi := &file_devices_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListAllDevicesReq) String() string {
+func (x *CreateDeviceWithConfigReq) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListAllDevicesReq) ProtoMessage() {}
+func (*CreateDeviceWithConfigReq) ProtoMessage() {}
 
-func (x *ListAllDevicesReq) ProtoReflect() protoreflect.Message {
-	mi := &file_devices_proto_msgTypes[5]
+func (x *CreateDeviceWithConfigReq) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -422,11 +616,873 @@ func (x *ListAllDevicesReq) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListAllDevicesReq.ProtoReflect.Descriptor instead.
-func (*ListAllDevicesReq) Descriptor() ([]byte, []int) {
+// Deprecated: Use CreateDeviceWithConfigReq.ProtoReflect.Descriptor instead.
+func (*CreateDeviceWithConfigReq) Descriptor() ([]byte, []int) {
+	return file_devices_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CreateDeviceWithConfigReq) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateDeviceWithConfigReq) GetUsePresharedKey() bool {
+	if x != nil {
+		return x.UsePresharedKey
+	}
+	return false
+}
+
+func (x *CreateDeviceWithConfigReq) GetManualIpAssignment() bool {
+	if x != nil {
+		return x.ManualIpAssignment
+	}
+	return false
+}
+
+func (x *CreateDeviceWithConfigReq) GetManualIpv4Address() string {
+	if x != nil {
+		return x.ManualIpv4Address
+	}
+	return ""
+}
+
+func (x *CreateDeviceWithConfigReq) GetManualIpv6Address() string {
+	if x != nil {
+		return x.ManualIpv6Address
+	}
+	return ""
+}
+
+func (x *CreateDeviceWithConfigReq) GetPersistentKeepalive() *wrapperspb.Int32Value {
+	if x != nil {
+		return x.PersistentKeepalive
+	}
+	return nil
+}
+
+func (x *CreateDeviceWithConfigReq) GetAccessPolicy() *AccessPolicy {
+	if x != nil {
+		return x.AccessPolicy
+	}
+	return nil
+}
+
+func (x *CreateDeviceWithConfigReq) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *CreateDeviceWithConfigReq) GetProfile() string {
+	if x != nil {
+		return x.Profile
+	}
+	return ""
+}
+
+func (x *CreateDeviceWithConfigReq) GetGateway() bool {
+	if x != nil {
+		return x.Gateway
+	}
+	return false
+}
+
+func (x *CreateDeviceWithConfigReq) GetRoutes() string {
+	if x != nil {
+		return x.Routes
+	}
+	return ""
+}
+
+type CreateDeviceWithConfigRes struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Device *Device                `protobuf:"bytes,1,opt,name=device,proto3" json:"device,omitempty"`
+	// the wg-quick config file including the private key,
+	// the private key is not stored on the server
+	Config        string `protobuf:"bytes,2,opt,name=config,proto3" json:"config,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateDeviceWithConfigRes) Reset() {
+	*x = CreateDeviceWithConfigRes{}
+	mi := &file_devices_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateDeviceWithConfigRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateDeviceWithConfigRes) ProtoMessage() {}
+
+func (x *CreateDeviceWithConfigRes) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateDeviceWithConfigRes.ProtoReflect.Descriptor instead.
+func (*CreateDeviceWithConfigRes) Descriptor() ([]byte, []int) {
 	return file_devices_proto_rawDescGZIP(), []int{5}
 }
 
+func (x *CreateDeviceWithConfigRes) GetDevice() *Device {
+	if x != nil {
+		return x.Device
+	}
+	return nil
+}
+
+func (x *CreateDeviceWithConfigRes) GetConfig() string {
+	if x != nil {
+		return x.Config
+	}
+	return ""
+}
+
+type ListDevicesReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDevicesReq) Reset() {
+	*x = ListDevicesReq{}
+	mi := &file_devices_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDevicesReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDevicesReq) ProtoMessage() {}
+
+func (x *ListDevicesReq) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDevicesReq.ProtoReflect.Descriptor instead.
+func (*ListDevicesReq) Descriptor() ([]byte, []int) {
+	return file_devices_proto_rawDescGZIP(), []int{6}
+}
+
+type ListDevicesRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Items         []*Device              `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDevicesRes) Reset() {
+	*x = ListDevicesRes{}
+	mi := &file_devices_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDevicesRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDevicesRes) ProtoMessage() {}
+
+func (x *ListDevicesRes) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDevicesRes.ProtoReflect.Descriptor instead.
+func (*ListDevicesRes) Descriptor() ([]byte, []int) {
+	return file_devices_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ListDevicesRes) GetItems() []*Device {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+type WatchDevicesReq struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// admin only: watch the devices of all users
+	All           bool `protobuf:"varint,1,opt,name=all,proto3" json:"all,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchDevicesReq) Reset() {
+	*x = WatchDevicesReq{}
+	mi := &file_devices_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchDevicesReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchDevicesReq) ProtoMessage() {}
+
+func (x *WatchDevicesReq) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchDevicesReq.ProtoReflect.Descriptor instead.
+func (*WatchDevicesReq) Descriptor() ([]byte, []int) {
+	return file_devices_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *WatchDevicesReq) GetAll() bool {
+	if x != nil {
+		return x.All
+	}
+	return false
+}
+
+type DeviceEvent struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Type   DeviceEvent_Type       `protobuf:"varint,1,opt,name=type,proto3,enum=proto.DeviceEvent_Type" json:"type,omitempty"`
+	Device *Device                `protobuf:"bytes,2,opt,name=device,proto3" json:"device,omitempty"`
+	// set if the device was renamed
+	PreviousName  string `protobuf:"bytes,3,opt,name=previous_name,json=previousName,proto3" json:"previous_name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeviceEvent) Reset() {
+	*x = DeviceEvent{}
+	mi := &file_devices_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeviceEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeviceEvent) ProtoMessage() {}
+
+func (x *DeviceEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeviceEvent.ProtoReflect.Descriptor instead.
+func (*DeviceEvent) Descriptor() ([]byte, []int) {
+	return file_devices_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DeviceEvent) GetType() DeviceEvent_Type {
+	if x != nil {
+		return x.Type
+	}
+	return DeviceEvent_EXISTING
+}
+
+func (x *DeviceEvent) GetDevice() *Device {
+	if x != nil {
+		return x.Device
+	}
+	return nil
+}
+
+func (x *DeviceEvent) GetPreviousName() string {
+	if x != nil {
+		return x.PreviousName
+	}
+	return ""
+}
+
+type DeleteDeviceReq struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// admin's may delete a device owned
+	// by someone other than the current user
+	// if empty, defaults to the current user
+	Owner         *wrapperspb.StringValue `protobuf:"bytes,2,opt,name=owner,proto3" json:"owner,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteDeviceReq) Reset() {
+	*x = DeleteDeviceReq{}
+	mi := &file_devices_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteDeviceReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteDeviceReq) ProtoMessage() {}
+
+func (x *DeleteDeviceReq) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteDeviceReq.ProtoReflect.Descriptor instead.
+func (*DeleteDeviceReq) Descriptor() ([]byte, []int) {
+	return file_devices_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *DeleteDeviceReq) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *DeleteDeviceReq) GetOwner() *wrapperspb.StringValue {
+	if x != nil {
+		return x.Owner
+	}
+	return nil
+}
+
+type UpdateDeviceReq struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// admin's may update a device owned
+	// by someone other than the current user
+	// if empty, defaults to the current user
+	Owner *wrapperspb.StringValue `protobuf:"bytes,2,opt,name=owner,proto3" json:"owner,omitempty"`
+	// unset fields are left unchanged
+	NewName   *wrapperspb.StringValue `protobuf:"bytes,3,opt,name=new_name,json=newName,proto3" json:"new_name,omitempty"`
+	PublicKey *wrapperspb.StringValue `protobuf:"bytes,4,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	// an empty value removes the pre-shared key
+	PresharedKey      *wrapperspb.StringValue `protobuf:"bytes,5,opt,name=preshared_key,json=presharedKey,proto3" json:"preshared_key,omitempty"`
+	ManualIpv4Address *wrapperspb.StringValue `protobuf:"bytes,6,opt,name=manual_ipv4_address,json=manualIpv4Address,proto3" json:"manual_ipv4_address,omitempty"`
+	ManualIpv6Address *wrapperspb.StringValue `protobuf:"bytes,7,opt,name=manual_ipv6_address,json=manualIpv6Address,proto3" json:"manual_ipv6_address,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *UpdateDeviceReq) Reset() {
+	*x = UpdateDeviceReq{}
+	mi := &file_devices_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateDeviceReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateDeviceReq) ProtoMessage() {}
+
+func (x *UpdateDeviceReq) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateDeviceReq.ProtoReflect.Descriptor instead.
+func (*UpdateDeviceReq) Descriptor() ([]byte, []int) {
+	return file_devices_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *UpdateDeviceReq) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *UpdateDeviceReq) GetOwner() *wrapperspb.StringValue {
+	if x != nil {
+		return x.Owner
+	}
+	return nil
+}
+
+func (x *UpdateDeviceReq) GetNewName() *wrapperspb.StringValue {
+	if x != nil {
+		return x.NewName
+	}
+	return nil
+}
+
+func (x *UpdateDeviceReq) GetPublicKey() *wrapperspb.StringValue {
+	if x != nil {
+		return x.PublicKey
+	}
+	return nil
+}
+
+func (x *UpdateDeviceReq) GetPresharedKey() *wrapperspb.StringValue {
+	if x != nil {
+		return x.PresharedKey
+	}
+	return nil
+}
+
+func (x *UpdateDeviceReq) GetManualIpv4Address() *wrapperspb.StringValue {
+	if x != nil {
+		return x.ManualIpv4Address
+	}
+	return nil
+}
+
+func (x *UpdateDeviceReq) GetManualIpv6Address() *wrapperspb.StringValue {
+	if x != nil {
+		return x.ManualIpv6Address
+	}
+	return nil
+}
+
+type RenewDeviceReq struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// admin's may renew a device owned
+	// by someone other than the current user
+	// if empty, defaults to the current user
+	Owner *wrapperspb.StringValue `protobuf:"bytes,2,opt,name=owner,proto3" json:"owner,omitempty"`
+	// defaults to the maximum device lifetime from now,
+	// unset without a maximum lifetime removes the expiry date
+	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RenewDeviceReq) Reset() {
+	*x = RenewDeviceReq{}
+	mi := &file_devices_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RenewDeviceReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenewDeviceReq) ProtoMessage() {}
+
+func (x *RenewDeviceReq) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenewDeviceReq.ProtoReflect.Descriptor instead.
+func (*RenewDeviceReq) Descriptor() ([]byte, []int) {
+	return file_devices_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *RenewDeviceReq) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *RenewDeviceReq) GetOwner() *wrapperspb.StringValue {
+	if x != nil {
+		return x.Owner
+	}
+	return nil
+}
+
+func (x *RenewDeviceReq) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+type GetUsageReq struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// admin's may read the usage of a device owned
+	// by someone other than the current user
+	// if empty, defaults to the current user
+	Owner *wrapperspb.StringValue `protobuf:"bytes,2,opt,name=owner,proto3" json:"owner,omitempty"`
+	// the range of the history, from is inclusive and to exclusive
+	From *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=from,proto3" json:"from,omitempty"`
+	To   *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=to,proto3" json:"to,omitempty"`
+	// the length of a point, defaults to the recorded resolution
+	Step          *durationpb.Duration `protobuf:"bytes,5,opt,name=step,proto3" json:"step,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUsageReq) Reset() {
+	*x = GetUsageReq{}
+	mi := &file_devices_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUsageReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUsageReq) ProtoMessage() {}
+
+func (x *GetUsageReq) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUsageReq.ProtoReflect.Descriptor instead.
+func (*GetUsageReq) Descriptor() ([]byte, []int) {
+	return file_devices_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *GetUsageReq) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *GetUsageReq) GetOwner() *wrapperspb.StringValue {
+	if x != nil {
+		return x.Owner
+	}
+	return nil
+}
+
+func (x *GetUsageReq) GetFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *GetUsageReq) GetTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+func (x *GetUsageReq) GetStep() *durationpb.Duration {
+	if x != nil {
+		return x.Step
+	}
+	return nil
+}
+
+type GetUsageRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Items         []*UsagePoint          `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUsageRes) Reset() {
+	*x = GetUsageRes{}
+	mi := &file_devices_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUsageRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUsageRes) ProtoMessage() {}
+
+func (x *GetUsageRes) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUsageRes.ProtoReflect.Descriptor instead.
+func (*GetUsageRes) Descriptor() ([]byte, []int) {
+	return file_devices_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *GetUsageRes) GetItems() []*UsagePoint {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+type UsagePoint struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// the start of the step
+	Time          *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=time,proto3" json:"time,omitempty"`
+	ReceiveBytes  int64                  `protobuf:"varint,2,opt,name=receive_bytes,json=receiveBytes,proto3" json:"receive_bytes,omitempty"`
+	TransmitBytes int64                  `protobuf:"varint,3,opt,name=transmit_bytes,json=transmitBytes,proto3" json:"transmit_bytes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UsagePoint) Reset() {
+	*x = UsagePoint{}
+	mi := &file_devices_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UsagePoint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UsagePoint) ProtoMessage() {}
+
+func (x *UsagePoint) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UsagePoint.ProtoReflect.Descriptor instead.
+func (*UsagePoint) Descriptor() ([]byte, []int) {
+	return file_devices_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *UsagePoint) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *UsagePoint) GetReceiveBytes() int64 {
+	if x != nil {
+		return x.ReceiveBytes
+	}
+	return 0
+}
+
+func (x *UsagePoint) GetTransmitBytes() int64 {
+	if x != nil {
+		return x.TransmitBytes
+	}
+	return 0
+}
+
+type SuspendDeviceReq struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// if empty, defaults to the current user
+	Owner         *wrapperspb.StringValue `protobuf:"bytes,2,opt,name=owner,proto3" json:"owner,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SuspendDeviceReq) Reset() {
+	*x = SuspendDeviceReq{}
+	mi := &file_devices_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SuspendDeviceReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SuspendDeviceReq) ProtoMessage() {}
+
+func (x *SuspendDeviceReq) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SuspendDeviceReq.ProtoReflect.Descriptor instead.
+func (*SuspendDeviceReq) Descriptor() ([]byte, []int) {
+	return file_devices_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *SuspendDeviceReq) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *SuspendDeviceReq) GetOwner() *wrapperspb.StringValue {
+	if x != nil {
+		return x.Owner
+	}
+	return nil
+}
+
+type ResumeDeviceReq struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// if empty, defaults to the current user
+	Owner         *wrapperspb.StringValue `protobuf:"bytes,2,opt,name=owner,proto3" json:"owner,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResumeDeviceReq) Reset() {
+	*x = ResumeDeviceReq{}
+	mi := &file_devices_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResumeDeviceReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResumeDeviceReq) ProtoMessage() {}
+
+func (x *ResumeDeviceReq) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResumeDeviceReq.ProtoReflect.Descriptor instead.
+func (*ResumeDeviceReq) Descriptor() ([]byte, []int) {
+	return file_devices_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ResumeDeviceReq) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ResumeDeviceReq) GetOwner() *wrapperspb.StringValue {
+	if x != nil {
+		return x.Owner
+	}
+	return nil
+}
+
+type ListAllDevicesReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAllDevicesReq) Reset() {
+	*x = ListAllDevicesReq{}
+	mi := &file_devices_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAllDevicesReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAllDevicesReq) ProtoMessage() {}
+
+func (x *ListAllDevicesReq) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAllDevicesReq.ProtoReflect.Descriptor instead.
+func (*ListAllDevicesReq) Descriptor() ([]byte, []int) {
+	return file_devices_proto_rawDescGZIP(), []int{18}
+}
+
 type ListAllDevicesRes struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Items         []*Device              `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
@@ -436,7 +1492,7 @@ type ListAllDevicesRes struct {
 
 func (x *ListAllDevicesRes) Reset() {
 	*x = ListAllDevicesRes{}
-	mi := &file_devices_proto_msgTypes[6]
+	mi := &file_devices_proto_msgTypes[19]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -448,7 +1504,7 @@ func (x *ListAllDevicesRes) String() string {
 func (*ListAllDevicesRes) ProtoMessage() {}
 
 func (x *ListAllDevicesRes) ProtoReflect() protoreflect.Message {
-	mi := &file_devices_proto_msgTypes[6]
+	mi := &file_devices_proto_msgTypes[19]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -461,7 +1517,7 @@ func (x *ListAllDevicesRes) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListAllDevicesRes.ProtoReflect.Descriptor instead.
 func (*ListAllDevicesRes) Descriptor() ([]byte, []int) {
-	return file_devices_proto_rawDescGZIP(), []int{6}
+	return file_devices_proto_rawDescGZIP(), []int{19}
 }
 
 func (x *ListAllDevicesRes) GetItems() []*Device {
@@ -471,11 +1527,100 @@ func (x *ListAllDevicesRes) GetItems() []*Device {
 	return nil
 }
 
+type ListPendingDeletionsReq struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// only devices deleted within this duration from now, all if unset
+	Within        *durationpb.Duration `protobuf:"bytes,1,opt,name=within,proto3" json:"within,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPendingDeletionsReq) Reset() {
+	*x = ListPendingDeletionsReq{}
+	mi := &file_devices_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPendingDeletionsReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPendingDeletionsReq) ProtoMessage() {}
+
+func (x *ListPendingDeletionsReq) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPendingDeletionsReq.ProtoReflect.Descriptor instead.
+func (*ListPendingDeletionsReq) Descriptor() ([]byte, []int) {
+	return file_devices_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ListPendingDeletionsReq) GetWithin() *durationpb.Duration {
+	if x != nil {
+		return x.Within
+	}
+	return nil
+}
+
+type ListPendingDeletionsRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Items         []*Device              `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPendingDeletionsRes) Reset() {
+	*x = ListPendingDeletionsRes{}
+	mi := &file_devices_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPendingDeletionsRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPendingDeletionsRes) ProtoMessage() {}
+
+func (x *ListPendingDeletionsRes) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPendingDeletionsRes.ProtoReflect.Descriptor instead.
+func (*ListPendingDeletionsRes) Descriptor() ([]byte, []int) {
+	return file_devices_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ListPendingDeletionsRes) GetItems() []*Device {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
 var File_devices_proto protoreflect.FileDescriptor
 
 const file_devices_proto_rawDesc = "" +
 	"\n" +
-	"\rdevices.proto\x12\x05proto\x1a\x1egoogle/protobuf/wrappers.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1bgoogle/protobuf/empty.proto\"\x84\x04\n" +
+	"\rdevices.proto\x12\x05proto\x1a\x1egoogle/protobuf/wrappers.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1egoogle/protobuf/duration.proto\"\x80\a\n" +
 	"\x06Device\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
 	"\x05owner\x18\x02 \x01(\tR\x05owner\x12\x1d\n" +
@@ -495,7 +1640,28 @@ const file_devices_proto_rawDesc = "" +
 	"\vowner_email\x18\f \x01(\tR\n" +
 	"ownerEmail\x12%\n" +
 	"\x0eowner_provider\x18\r \x01(\tR\rownerProvider\x12#\n" +
-	"\rpreshared_key\x18\x0e \x01(\tR\fpresharedKey\"\xf8\x01\n" +
+	"\rpreshared_key\x18\x0e \x01(\tR\fpresharedKey\x128\n" +
+	"\raccess_policy\x18\x0f \x01(\v2\x13.proto.AccessPolicyR\faccessPolicy\x129\n" +
+	"\n" +
+	"expires_at\x18\x10 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12\x1c\n" +
+	"\tsuspended\x18\x11 \x01(\bR\tsuspended\x12;\n" +
+	"\vstale_since\x18\x12 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"staleSince\x12?\n" +
+	"\rdeletion_time\x18\x13 \x01(\v2\x1a.google.protobuf.TimestampR\fdeletionTime\x12\x1d\n" +
+	"\n" +
+	"over_quota\x18\x14 \x01(\bR\toverQuota\x12\x18\n" +
+	"\aprofile\x18\x15 \x01(\tR\aprofile\x12\x18\n" +
+	"\agateway\x18\x16 \x01(\bR\agateway\x12\x16\n" +
+	"\x06routes\x18\x17 \x01(\tR\x06routes\"v\n" +
+	"\fAccessPolicy\x12'\n" +
+	"\x05rules\x18\x01 \x03(\v2\x11.proto.AccessRuleR\x05rules\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12)\n" +
+	"\x10client_isolation\x18\x03 \x01(\bR\x0fclientIsolation\"R\n" +
+	"\n" +
+	"AccessRule\x12\x12\n" +
+	"\x04cidr\x18\x01 \x01(\tR\x04cidr\x12\x1a\n" +
+	"\bprotocol\x18\x02 \x01(\tR\bprotocol\x12\x14\n" +
+	"\x05ports\x18\x03 \x01(\tR\x05ports\"\xb9\x03\n" +
 	"\fAddDeviceReq\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1d\n" +
 	"\n" +
@@ -503,21 +1669,103 @@ const file_devices_proto_rawDesc = "" +
 	"\rpreshared_key\x18\x03 \x01(\tR\fpresharedKey\x120\n" +
 	"\x14manual_ip_assignment\x18\x04 \x01(\bR\x12manualIpAssignment\x12.\n" +
 	"\x13manual_ipv4_address\x18\x05 \x01(\tR\x11manualIpv4Address\x12.\n" +
-	"\x13manual_ipv6_address\x18\x06 \x01(\tR\x11manualIpv6Address\"\x10\n" +
+	"\x13manual_ipv6_address\x18\x06 \x01(\tR\x11manualIpv6Address\x128\n" +
+	"\raccess_policy\x18\a \x01(\v2\x13.proto.AccessPolicyR\faccessPolicy\x129\n" +
+	"\n" +
+	"expires_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12\x18\n" +
+	"\aprofile\x18\t \x01(\tR\aprofile\x12\x18\n" +
+	"\agateway\x18\n" +
+	" \x01(\bR\agateway\x12\x16\n" +
+	"\x06routes\x18\v \x01(\tR\x06routes\"\xfe\x03\n" +
+	"\x19CreateDeviceWithConfigReq\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12*\n" +
+	"\x11use_preshared_key\x18\x02 \x01(\bR\x0fusePresharedKey\x120\n" +
+	"\x14manual_ip_assignment\x18\x03 \x01(\bR\x12manualIpAssignment\x12.\n" +
+	"\x13manual_ipv4_address\x18\x04 \x01(\tR\x11manualIpv4Address\x12.\n" +
+	"\x13manual_ipv6_address\x18\x05 \x01(\tR\x11manualIpv6Address\x12N\n" +
+	"\x14persistent_keepalive\x18\x06 \x01(\v2\x1b.google.protobuf.Int32ValueR\x13persistentKeepalive\x128\n" +
+	"\raccess_policy\x18\a \x01(\v2\x13.proto.AccessPolicyR\faccessPolicy\x129\n" +
+	"\n" +
+	"expires_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12\x18\n" +
+	"\aprofile\x18\t \x01(\tR\aprofile\x12\x18\n" +
+	"\agateway\x18\n" +
+	" \x01(\bR\agateway\x12\x16\n" +
+	"\x06routes\x18\v \x01(\tR\x06routes\"Z\n" +
+	"\x19CreateDeviceWithConfigRes\x12%\n" +
+	"\x06device\x18\x01 \x01(\v2\r.proto.DeviceR\x06device\x12\x16\n" +
+	"\x06config\x18\x02 \x01(\tR\x06config\"\x10\n" +
 	"\x0eListDevicesReq\"5\n" +
 	"\x0eListDevicesRes\x12#\n" +
-	"\x05items\x18\x01 \x03(\v2\r.proto.DeviceR\x05items\"Y\n" +
+	"\x05items\x18\x01 \x03(\v2\r.proto.DeviceR\x05items\"#\n" +
+	"\x0fWatchDevicesReq\x12\x10\n" +
+	"\x03all\x18\x01 \x01(\bR\x03all\"\xcd\x01\n" +
+	"\vDeviceEvent\x12+\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x17.proto.DeviceEvent.TypeR\x04type\x12%\n" +
+	"\x06device\x18\x02 \x01(\v2\r.proto.DeviceR\x06device\x12#\n" +
+	"\rprevious_name\x18\x03 \x01(\tR\fpreviousName\"E\n" +
+	"\x04Type\x12\f\n" +
+	"\bEXISTING\x10\x00\x12\n" +
+	"\n" +
+	"\x06SYNCED\x10\x01\x12\t\n" +
+	"\x05ADDED\x10\x02\x12\v\n" +
+	"\aUPDATED\x10\x03\x12\v\n" +
+	"\aDELETED\x10\x04\"Y\n" +
 	"\x0fDeleteDeviceReq\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x122\n" +
+	"\x05owner\x18\x02 \x01(\v2\x1c.google.protobuf.StringValueR\x05owner\"\xae\x03\n" +
+	"\x0fUpdateDeviceReq\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x122\n" +
+	"\x05owner\x18\x02 \x01(\v2\x1c.google.protobuf.StringValueR\x05owner\x127\n" +
+	"\bnew_name\x18\x03 \x01(\v2\x1c.google.protobuf.StringValueR\anewName\x12;\n" +
+	"\n" +
+	"public_key\x18\x04 \x01(\v2\x1c.google.protobuf.StringValueR\tpublicKey\x12A\n" +
+	"\rpreshared_key\x18\x05 \x01(\v2\x1c.google.protobuf.StringValueR\fpresharedKey\x12L\n" +
+	"\x13manual_ipv4_address\x18\x06 \x01(\v2\x1c.google.protobuf.StringValueR\x11manualIpv4Address\x12L\n" +
+	"\x13manual_ipv6_address\x18\a \x01(\v2\x1c.google.protobuf.StringValueR\x11manualIpv6Address\"\x93\x01\n" +
+	"\x0eRenewDeviceReq\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x122\n" +
+	"\x05owner\x18\x02 \x01(\v2\x1c.google.protobuf.StringValueR\x05owner\x129\n" +
+	"\n" +
+	"expires_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\"\xe0\x01\n" +
+	"\vGetUsageReq\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x122\n" +
+	"\x05owner\x18\x02 \x01(\v2\x1c.google.protobuf.StringValueR\x05owner\x12.\n" +
+	"\x04from\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
+	"\x02to\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\x12-\n" +
+	"\x04step\x18\x05 \x01(\v2\x19.google.protobuf.DurationR\x04step\"6\n" +
+	"\vGetUsageRes\x12'\n" +
+	"\x05items\x18\x01 \x03(\v2\x11.proto.UsagePointR\x05items\"\x88\x01\n" +
+	"\n" +
+	"UsagePoint\x12.\n" +
+	"\x04time\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12#\n" +
+	"\rreceive_bytes\x18\x02 \x01(\x03R\freceiveBytes\x12%\n" +
+	"\x0etransmit_bytes\x18\x03 \x01(\x03R\rtransmitBytes\"Z\n" +
+	"\x10SuspendDeviceReq\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x122\n" +
+	"\x05owner\x18\x02 \x01(\v2\x1c.google.protobuf.StringValueR\x05owner\"Y\n" +
+	"\x0fResumeDeviceReq\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x122\n" +
 	"\x05owner\x18\x02 \x01(\v2\x1c.google.protobuf.StringValueR\x05owner\"\x13\n" +
 	"\x11ListAllDevicesReq\"8\n" +
 	"\x11ListAllDevicesRes\x12#\n" +
-	"\x05items\x18\x01 \x03(\v2\r.proto.DeviceR\x05items2\x85\x02\n" +
+	"\x05items\x18\x01 \x03(\v2\r.proto.DeviceR\x05items\"L\n" +
+	"\x17ListPendingDeletionsReq\x121\n" +
+	"\x06within\x18\x01 \x01(\v2\x19.google.protobuf.DurationR\x06within\">\n" +
+	"\x17ListPendingDeletionsRes\x12#\n" +
+	"\x05items\x18\x01 \x03(\v2\r.proto.DeviceR\x05items2\x99\x06\n" +
 	"\aDevices\x121\n" +
-	"\tAddDevice\x12\x13.proto.AddDeviceReq\x1a\r.proto.Device\"\x00\x12=\n" +
-	"\vListDevices\x12\x15.proto.ListDevicesReq\x1a\x15.proto.ListDevicesRes\"\x00\x12@\n" +
-	"\fDeleteDevice\x12\x16.proto.DeleteDeviceReq\x1a\x16.google.protobuf.Empty\"\x00\x12F\n" +
-	"\x0eListAllDevices\x12\x18.proto.ListAllDevicesReq\x1a\x18.proto.ListAllDevicesRes\"\x00B5Z3github.com/freifunkMUC/wg-access-server/proto/protob\x06proto3"
+	"\tAddDevice\x12\x13.proto.AddDeviceReq\x1a\r.proto.Device\"\x00\x12^\n" +
+	"\x16CreateDeviceWithConfig\x12 .proto.CreateDeviceWithConfigReq\x1a .proto.CreateDeviceWithConfigRes\"\x00\x12=\n" +
+	"\vListDevices\x12\x15.proto.ListDevicesReq\x1a\x15.proto.ListDevicesRes\"\x00\x12>\n" +
+	"\fWatchDevices\x12\x16.proto.WatchDevicesReq\x1a\x12.proto.DeviceEvent\"\x000\x01\x12@\n" +
+	"\fDeleteDevice\x12\x16.proto.DeleteDeviceReq\x1a\x16.google.protobuf.Empty\"\x00\x127\n" +
+	"\fUpdateDevice\x12\x16.proto.UpdateDeviceReq\x1a\r.proto.Device\"\x00\x125\n" +
+	"\vRenewDevice\x12\x15.proto.RenewDeviceReq\x1a\r.proto.Device\"\x00\x124\n" +
+	"\bGetUsage\x12\x12.proto.GetUsageReq\x1a\x12.proto.GetUsageRes\"\x00\x129\n" +
+	"\rSuspendDevice\x12\x17.proto.SuspendDeviceReq\x1a\r.proto.Device\"\x00\x127\n" +
+	"\fResumeDevice\x12\x16.proto.ResumeDeviceReq\x1a\r.proto.Device\"\x00\x12F\n" +
+	"\x0eListAllDevices\x12\x18.proto.ListAllDevicesReq\x1a\x18.proto.ListAllDevicesRes\"\x00\x12X\n" +
+	"\x14ListPendingDeletions\x12\x1e.proto.ListPendingDeletionsReq\x1a\x1e.proto.ListPendingDeletionsRes\"\x00B5Z3github.com/freifunkMUC/wg-access-server/proto/protob\x06proto3"
 
 var (
 	file_devices_proto_rawDescOnce sync.Once
@@ -531,38 +1779,104 @@ func file_devices_proto_rawDescGZIP() []byte {
 	return file_devices_proto_rawDescData
 }
 
-var file_devices_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_devices_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_devices_proto_msgTypes = make([]protoimpl.MessageInfo, 22)
 var file_devices_proto_goTypes = []any{
-	(*Device)(nil),                 // 0: proto.Device
-	(*AddDeviceReq)(nil),           // 1: proto.AddDeviceReq
-	(*ListDevicesReq)(nil),         // 2: proto.ListDevicesReq
-	(*ListDevicesRes)(nil),         // 3: proto.ListDevicesRes
-	(*DeleteDeviceReq)(nil),        // 4: proto.DeleteDeviceReq
-	(*ListAllDevicesReq)(nil),      // 5: proto.ListAllDevicesReq
-	(*ListAllDevicesRes)(nil),      // 6: proto.ListAllDevicesRes
-	(*timestamppb.Timestamp)(nil),  // 7: google.protobuf.Timestamp
-	(*wrapperspb.StringValue)(nil), // 8: google.protobuf.StringValue
-	(*emptypb.Empty)(nil),          // 9: google.protobuf.Empty
+	(DeviceEvent_Type)(0),             // 0: proto.DeviceEvent.Type
+	(*Device)(nil),                    // 1: proto.Device
+	(*AccessPolicy)(nil),              // 2: proto.AccessPolicy
+	(*AccessRule)(nil),                // 3: proto.AccessRule
+	(*AddDeviceReq)(nil),              // 4: proto.AddDeviceReq
+	(*CreateDeviceWithConfigReq)(nil), // 5: proto.CreateDeviceWithConfigReq
+	(*CreateDeviceWithConfigRes)(nil), // 6: proto.CreateDeviceWithConfigRes
+	(*ListDevicesReq)(nil),            // 7: proto.ListDevicesReq
+	(*ListDevicesRes)(nil),            // 8: proto.ListDevicesRes
+	(*WatchDevicesReq)(nil),           // 9: proto.WatchDevicesReq
+	(*DeviceEvent)(nil),               // 10: proto.DeviceEvent
+	(*DeleteDeviceReq)(nil),           // 11: proto.DeleteDeviceReq
+	(*UpdateDeviceReq)(nil),           // 12: proto.UpdateDeviceReq
+	(*RenewDeviceReq)(nil),            // 13: proto.RenewDeviceReq
+	(*GetUsageReq)(nil),               // 14: proto.GetUsageReq
+	(*GetUsageRes)(nil),               // 15: proto.GetUsageRes
+	(*UsagePoint)(nil),                // 16: proto.UsagePoint
+	(*SuspendDeviceReq)(nil),          // 17: proto.SuspendDeviceReq
+	(*ResumeDeviceReq)(nil),           // 18: proto.ResumeDeviceReq
+	(*ListAllDevicesReq)(nil),         // 19: proto.ListAllDevicesReq
+	(*ListAllDevicesRes)(nil),         // 20: proto.ListAllDevicesRes
+	(*ListPendingDeletionsReq)(nil),   // 21: proto.ListPendingDeletionsReq
+	(*ListPendingDeletionsRes)(nil),   // 22: proto.ListPendingDeletionsRes
+	(*timestamppb.Timestamp)(nil),     // 23: google.protobuf.Timestamp
+	(*wrapperspb.Int32Value)(nil),     // 24: google.protobuf.Int32Value
+	(*wrapperspb.StringValue)(nil),    // 25: google.protobuf.StringValue
+	(*durationpb.Duration)(nil),       // 26: google.protobuf.Duration
+	(*emptypb.Empty)(nil),             // 27: google.protobuf.Empty
 }
 var file_devices_proto_depIdxs = []int32{
-	7, // 0: proto.Device.created_at:type_name -> google.protobuf.Timestamp
-	7, // 1: proto.Device.last_handshake_time:type_name -> google.protobuf.Timestamp
-	0, // 2: proto.ListDevicesRes.items:type_name -> proto.Device
-	8, // 3: proto.DeleteDeviceReq.owner:type_name -> google.protobuf.StringValue
-	0, // 4: proto.ListAllDevicesRes.items:type_name -> proto.Device
-	1, // 5: proto.Devices.AddDevice:input_type -> proto.AddDeviceReq
-	2, // 6: proto.Devices.ListDevices:input_type -> proto.ListDevicesReq
-	4, // 7: proto.Devices.DeleteDevice:input_type -> proto.DeleteDeviceReq
-	5, // 8: proto.Devices.ListAllDevices:input_type -> proto.ListAllDevicesReq
-	0, // 9: proto.Devices.AddDevice:output_type -> proto.Device
-	3, // 10: proto.Devices.ListDevices:output_type -> proto.ListDevicesRes
-	9, // 11: proto.Devices.DeleteDevice:output_type -> google.protobuf.Empty
-	6, // 12: proto.Devices.ListAllDevices:output_type -> proto.ListAllDevicesRes
-	9, // [9:13] is the sub-list for method output_type
-	5, // [5:9] is the sub-list for method input_type
-	5, // [5:5] is the sub-list for extension type_name
-	5, // [5:5] is the sub-list for extension extendee
-	0, // [0:5] is the sub-list for field type_name
+	23, // 0: proto.Device.created_at:type_name -> google.protobuf.Timestamp
+	23, // 1: proto.Device.last_handshake_time:type_name -> google.protobuf.Timestamp
+	2,  // 2: proto.Device.access_policy:type_name -> proto.AccessPolicy
+	23, // 3: proto.Device.expires_at:type_name -> google.protobuf.Timestamp
+	23, // 4: proto.Device.stale_since:type_name -> google.protobuf.Timestamp
+	23, // 5: proto.Device.deletion_time:type_name -> google.protobuf.Timestamp
+	3,  // 6: proto.AccessPolicy.rules:type_name -> proto.AccessRule
+	2,  // 7: proto.AddDeviceReq.access_policy:type_name -> proto.AccessPolicy
+	23, // 8: proto.AddDeviceReq.expires_at:type_name -> google.protobuf.Timestamp
+	24, // 9: proto.CreateDeviceWithConfigReq.persistent_keepalive:type_name -> google.protobuf.Int32Value
+	2,  // 10: proto.CreateDeviceWithConfigReq.access_policy:type_name -> proto.AccessPolicy
+	23, // 11: proto.CreateDeviceWithConfigReq.expires_at:type_name -> google.protobuf.Timestamp
+	1,  // 12: proto.CreateDeviceWithConfigRes.device:type_name -> proto.Device
+	1,  // 13: proto.ListDevicesRes.items:type_name -> proto.Device
+	0,  // 14: proto.DeviceEvent.type:type_name -> proto.DeviceEvent.Type
+	1,  // 15: proto.DeviceEvent.device:type_name -> proto.Device
+	25, // 16: proto.DeleteDeviceReq.owner:type_name -> google.protobuf.StringValue
+	25, // 17: proto.UpdateDeviceReq.owner:type_name -> google.protobuf.StringValue
+	25, // 18: proto.UpdateDeviceReq.new_name:type_name -> google.protobuf.StringValue
+	25, // 19: proto.UpdateDeviceReq.public_key:type_name -> google.protobuf.StringValue
+	25, // 20: proto.UpdateDeviceReq.preshared_key:type_name -> google.protobuf.StringValue
+	25, // 21: proto.UpdateDeviceReq.manual_ipv4_address:type_name -> google.protobuf.StringValue
+	25, // 22: proto.UpdateDeviceReq.manual_ipv6_address:type_name -> google.protobuf.StringValue
+	25, // 23: proto.RenewDeviceReq.owner:type_name -> google.protobuf.StringValue
+	23, // 24: proto.RenewDeviceReq.expires_at:type_name -> google.protobuf.Timestamp
+	25, // 25: proto.GetUsageReq.owner:type_name -> google.protobuf.StringValue
+	23, // 26: proto.GetUsageReq.from:type_name -> google.protobuf.Timestamp
+	23, // 27: proto.GetUsageReq.to:type_name -> google.protobuf.Timestamp
+	26, // 28: proto.GetUsageReq.step:type_name -> google.protobuf.Duration
+	16, // 29: proto.GetUsageRes.items:type_name -> proto.UsagePoint
+	23, // 30: proto.UsagePoint.time:type_name -> google.protobuf.Timestamp
+	25, // 31: proto.SuspendDeviceReq.owner:type_name -> google.protobuf.StringValue
+	25, // 32: proto.ResumeDeviceReq.owner:type_name -> google.protobuf.StringValue
+	1,  // 33: proto.ListAllDevicesRes.items:type_name -> proto.Device
+	26, // 34: proto.ListPendingDeletionsReq.within:type_name -> google.protobuf.Duration
+	1,  // 35: proto.ListPendingDeletionsRes.items:type_name -> proto.Device
+	4,  // 36: proto.Devices.AddDevice:input_type -> proto.AddDeviceReq
+	5,  // 37: proto.Devices.CreateDeviceWithConfig:input_type -> proto.CreateDeviceWithConfigReq
+	7,  // 38: proto.Devices.ListDevices:input_type -> proto.ListDevicesReq
+	9,  // 39: proto.Devices.WatchDevices:input_type -> proto.WatchDevicesReq
+	11, // 40: proto.Devices.DeleteDevice:input_type -> proto.DeleteDeviceReq
+	12, // 41: proto.Devices.UpdateDevice:input_type -> proto.UpdateDeviceReq
+	13, // 42: proto.Devices.RenewDevice:input_type -> proto.RenewDeviceReq
+	14, // 43: proto.Devices.GetUsage:input_type -> proto.GetUsageReq
+	17, // 44: proto.Devices.SuspendDevice:input_type -> proto.SuspendDeviceReq
+	18, // 45: proto.Devices.ResumeDevice:input_type -> proto.ResumeDeviceReq
+	19, // 46: proto.Devices.ListAllDevices:input_type -> proto.ListAllDevicesReq
+	21, // 47: proto.Devices.ListPendingDeletions:input_type -> proto.ListPendingDeletionsReq
+	1,  // 48: proto.Devices.AddDevice:output_type -> proto.Device
+	6,  // 49: proto.Devices.CreateDeviceWithConfig:output_type -> proto.CreateDeviceWithConfigRes
+	8,  // 50: proto.Devices.ListDevices:output_type -> proto.ListDevicesRes
+	10, // 51: proto.Devices.WatchDevices:output_type -> proto.DeviceEvent
+	27, // 52: proto.Devices.DeleteDevice:output_type -> google.protobuf.Empty
+	1,  // 53: proto.Devices.UpdateDevice:output_type -> proto.Device
+	1,  // 54: proto.Devices.RenewDevice:output_type -> proto.Device
+	15, // 55: proto.Devices.GetUsage:output_type -> proto.GetUsageRes
+	1,  // 56: proto.Devices.SuspendDevice:output_type -> proto.Device
+	1,  // 57: proto.Devices.ResumeDevice:output_type -> proto.Device
+	20, // 58: proto.Devices.ListAllDevices:output_type -> proto.ListAllDevicesRes
+	22, // 59: proto.Devices.ListPendingDeletions:output_type -> proto.ListPendingDeletionsRes
+	48, // [48:60] is the sub-list for method output_type
+	36, // [36:48] is the sub-list for method input_type
+	36, // [36:36] is the sub-list for extension type_name
+	36, // [36:36] is the sub-list for extension extendee
+	0,  // [0:36] is the sub-list for field type_name
 }
 
 func init() { file_devices_proto_init() }
@@ -575,13 +1889,14 @@ func file_devices_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_devices_proto_rawDesc), len(file_devices_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   7,
+			NumEnums:      1,
+			NumMessages:   22,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_devices_proto_goTypes,
 		DependencyIndexes: file_devices_proto_depIdxs,
+		EnumInfos:         file_devices_proto_enumTypes,
 		MessageInfos:      file_devices_proto_msgTypes,
 	}.Build()
 	File_devices_proto = out.File
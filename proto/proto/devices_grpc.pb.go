@@ -20,10 +20,18 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	Devices_AddDevice_FullMethodName      = "/proto.Devices/AddDevice"
-	Devices_ListDevices_FullMethodName    = "/proto.Devices/ListDevices"
-	Devices_DeleteDevice_FullMethodName   = "/proto.Devices/DeleteDevice"
-	Devices_ListAllDevices_FullMethodName = "/proto.Devices/ListAllDevices"
+	Devices_AddDevice_FullMethodName              = "/proto.Devices/AddDevice"
+	Devices_CreateDeviceWithConfig_FullMethodName = "/proto.Devices/CreateDeviceWithConfig"
+	Devices_ListDevices_FullMethodName            = "/proto.Devices/ListDevices"
+	Devices_WatchDevices_FullMethodName           = "/proto.Devices/WatchDevices"
+	Devices_DeleteDevice_FullMethodName           = "/proto.Devices/DeleteDevice"
+	Devices_UpdateDevice_FullMethodName           = "/proto.Devices/UpdateDevice"
+	Devices_RenewDevice_FullMethodName            = "/proto.Devices/RenewDevice"
+	Devices_GetUsage_FullMethodName               = "/proto.Devices/GetUsage"
+	Devices_SuspendDevice_FullMethodName          = "/proto.Devices/SuspendDevice"
+	Devices_ResumeDevice_FullMethodName           = "/proto.Devices/ResumeDevice"
+	Devices_ListAllDevices_FullMethodName         = "/proto.Devices/ListAllDevices"
+	Devices_ListPendingDeletions_FullMethodName   = "/proto.Devices/ListPendingDeletions"
 )
 
 // DevicesClient is the client API for Devices service.
@@ -31,10 +39,31 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type DevicesClient interface {
 	AddDevice(ctx context.Context, in *AddDeviceReq, opts ...grpc.CallOption) (*Device, error)
+	// generates the keys on the server and returns the client config,
+	// which can also be downloaded once from /api/devices/{name}/config
+	CreateDeviceWithConfig(ctx context.Context, in *CreateDeviceWithConfigReq, opts ...grpc.CallOption) (*CreateDeviceWithConfigRes, error)
 	ListDevices(ctx context.Context, in *ListDevicesReq, opts ...grpc.CallOption) (*ListDevicesRes, error)
+	// streams the changes of the caller's devices, starting with the current devices.
+	// Only available through grpc and grpc-web.
+	WatchDevices(ctx context.Context, in *WatchDevicesReq, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DeviceEvent], error)
 	DeleteDevice(ctx context.Context, in *DeleteDeviceReq, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// renames a device, replaces its keys or changes its manual IP addresses
+	UpdateDevice(ctx context.Context, in *UpdateDeviceReq, opts ...grpc.CallOption) (*Device, error)
+	// extends the expiry date of a device within the maximum lifetime,
+	// expired devices are enabled again
+	RenewDevice(ctx context.Context, in *RenewDeviceReq, opts ...grpc.CallOption) (*Device, error)
+	// returns the traffic history of a device
+	GetUsage(ctx context.Context, in *GetUsageReq, opts ...grpc.CallOption) (*GetUsageRes, error)
+	// admin only
+	// removes the WireGuard peer of a device until it is resumed,
+	// the device keeps its addresses
+	SuspendDevice(ctx context.Context, in *SuspendDeviceReq, opts ...grpc.CallOption) (*Device, error)
+	ResumeDevice(ctx context.Context, in *ResumeDeviceReq, opts ...grpc.CallOption) (*Device, error)
 	// admin only
 	ListAllDevices(ctx context.Context, in *ListAllDevicesReq, opts ...grpc.CallOption) (*ListAllDevicesRes, error)
+	// dry run of the automatic deletion of inactive and expired devices,
+	// ordered by the deletion time
+	ListPendingDeletions(ctx context.Context, in *ListPendingDeletionsReq, opts ...grpc.CallOption) (*ListPendingDeletionsRes, error)
 }
 
 type devicesClient struct {
@@ -55,6 +84,16 @@ func (c *devicesClient) AddDevice(ctx context.Context, in *AddDeviceReq, opts ..
 	return out, nil
 }
 
+func (c *devicesClient) CreateDeviceWithConfig(ctx context.Context, in *CreateDeviceWithConfigReq, opts ...grpc.CallOption) (*CreateDeviceWithConfigRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateDeviceWithConfigRes)
+	err := c.cc.Invoke(ctx, Devices_CreateDeviceWithConfig_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *devicesClient) ListDevices(ctx context.Context, in *ListDevicesReq, opts ...grpc.CallOption) (*ListDevicesRes, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(ListDevicesRes)
@@ -65,6 +104,25 @@ func (c *devicesClient) ListDevices(ctx context.Context, in *ListDevicesReq, opt
 	return out, nil
 }
 
+func (c *devicesClient) WatchDevices(ctx context.Context, in *WatchDevicesReq, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DeviceEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Devices_ServiceDesc.Streams[0], Devices_WatchDevices_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchDevicesReq, DeviceEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Devices_WatchDevicesClient = grpc.ServerStreamingClient[DeviceEvent]
+
 func (c *devicesClient) DeleteDevice(ctx context.Context, in *DeleteDeviceReq, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(emptypb.Empty)
@@ -75,6 +133,56 @@ func (c *devicesClient) DeleteDevice(ctx context.Context, in *DeleteDeviceReq, o
 	return out, nil
 }
 
+func (c *devicesClient) UpdateDevice(ctx context.Context, in *UpdateDeviceReq, opts ...grpc.CallOption) (*Device, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Device)
+	err := c.cc.Invoke(ctx, Devices_UpdateDevice_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *devicesClient) RenewDevice(ctx context.Context, in *RenewDeviceReq, opts ...grpc.CallOption) (*Device, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Device)
+	err := c.cc.Invoke(ctx, Devices_RenewDevice_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *devicesClient) GetUsage(ctx context.Context, in *GetUsageReq, opts ...grpc.CallOption) (*GetUsageRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetUsageRes)
+	err := c.cc.Invoke(ctx, Devices_GetUsage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *devicesClient) SuspendDevice(ctx context.Context, in *SuspendDeviceReq, opts ...grpc.CallOption) (*Device, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Device)
+	err := c.cc.Invoke(ctx, Devices_SuspendDevice_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *devicesClient) ResumeDevice(ctx context.Context, in *ResumeDeviceReq, opts ...grpc.CallOption) (*Device, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Device)
+	err := c.cc.Invoke(ctx, Devices_ResumeDevice_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *devicesClient) ListAllDevices(ctx context.Context, in *ListAllDevicesReq, opts ...grpc.CallOption) (*ListAllDevicesRes, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(ListAllDevicesRes)
@@ -85,15 +193,46 @@ func (c *devicesClient) ListAllDevices(ctx context.Context, in *ListAllDevicesRe
 	return out, nil
 }
 
+func (c *devicesClient) ListPendingDeletions(ctx context.Context, in *ListPendingDeletionsReq, opts ...grpc.CallOption) (*ListPendingDeletionsRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListPendingDeletionsRes)
+	err := c.cc.Invoke(ctx, Devices_ListPendingDeletions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // DevicesServer is the server API for Devices service.
 // All implementations must embed UnimplementedDevicesServer
 // for forward compatibility.
 type DevicesServer interface {
 	AddDevice(context.Context, *AddDeviceReq) (*Device, error)
+	// generates the keys on the server and returns the client config,
+	// which can also be downloaded once from /api/devices/{name}/config
+	CreateDeviceWithConfig(context.Context, *CreateDeviceWithConfigReq) (*CreateDeviceWithConfigRes, error)
 	ListDevices(context.Context, *ListDevicesReq) (*ListDevicesRes, error)
+	// streams the changes of the caller's devices, starting with the current devices.
+	// Only available through grpc and grpc-web.
+	WatchDevices(*WatchDevicesReq, grpc.ServerStreamingServer[DeviceEvent]) error
 	DeleteDevice(context.Context, *DeleteDeviceReq) (*emptypb.Empty, error)
+	// renames a device, replaces its keys or changes its manual IP addresses
+	UpdateDevice(context.Context, *UpdateDeviceReq) (*Device, error)
+	// extends the expiry date of a device within the maximum lifetime,
+	// expired devices are enabled again
+	RenewDevice(context.Context, *RenewDeviceReq) (*Device, error)
+	// returns the traffic history of a device
+	GetUsage(context.Context, *GetUsageReq) (*GetUsageRes, error)
+	// admin only
+	// removes the WireGuard peer of a device until it is resumed,
+	// the device keeps its addresses
+	SuspendDevice(context.Context, *SuspendDeviceReq) (*Device, error)
+	ResumeDevice(context.Context, *ResumeDeviceReq) (*Device, error)
 	// admin only
 	ListAllDevices(context.Context, *ListAllDevicesReq) (*ListAllDevicesRes, error)
+	// dry run of the automatic deletion of inactive and expired devices,
+	// ordered by the deletion time
+	ListPendingDeletions(context.Context, *ListPendingDeletionsReq) (*ListPendingDeletionsRes, error)
 	mustEmbedUnimplementedDevicesServer()
 }
 
@@ -107,15 +246,39 @@ type UnimplementedDevicesServer struct{}
 func (UnimplementedDevicesServer) AddDevice(context.Context, *AddDeviceReq) (*Device, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method AddDevice not implemented")
 }
+func (UnimplementedDevicesServer) CreateDeviceWithConfig(context.Context, *CreateDeviceWithConfigReq) (*CreateDeviceWithConfigRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateDeviceWithConfig not implemented")
+}
 func (UnimplementedDevicesServer) ListDevices(context.Context, *ListDevicesReq) (*ListDevicesRes, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListDevices not implemented")
 }
+func (UnimplementedDevicesServer) WatchDevices(*WatchDevicesReq, grpc.ServerStreamingServer[DeviceEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchDevices not implemented")
+}
 func (UnimplementedDevicesServer) DeleteDevice(context.Context, *DeleteDeviceReq) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteDevice not implemented")
 }
+func (UnimplementedDevicesServer) UpdateDevice(context.Context, *UpdateDeviceReq) (*Device, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateDevice not implemented")
+}
+func (UnimplementedDevicesServer) RenewDevice(context.Context, *RenewDeviceReq) (*Device, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RenewDevice not implemented")
+}
+func (UnimplementedDevicesServer) GetUsage(context.Context, *GetUsageReq) (*GetUsageRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUsage not implemented")
+}
+func (UnimplementedDevicesServer) SuspendDevice(context.Context, *SuspendDeviceReq) (*Device, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SuspendDevice not implemented")
+}
+func (UnimplementedDevicesServer) ResumeDevice(context.Context, *ResumeDeviceReq) (*Device, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResumeDevice not implemented")
+}
 func (UnimplementedDevicesServer) ListAllDevices(context.Context, *ListAllDevicesReq) (*ListAllDevicesRes, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListAllDevices not implemented")
 }
+func (UnimplementedDevicesServer) ListPendingDeletions(context.Context, *ListPendingDeletionsReq) (*ListPendingDeletionsRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPendingDeletions not implemented")
+}
 func (UnimplementedDevicesServer) mustEmbedUnimplementedDevicesServer() {}
 func (UnimplementedDevicesServer) testEmbeddedByValue()                 {}
 
@@ -155,6 +318,24 @@ func _Devices_AddDevice_Handler(srv interface{}, ctx context.Context, dec func(i
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Devices_CreateDeviceWithConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateDeviceWithConfigReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DevicesServer).CreateDeviceWithConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Devices_CreateDeviceWithConfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DevicesServer).CreateDeviceWithConfig(ctx, req.(*CreateDeviceWithConfigReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Devices_ListDevices_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(ListDevicesReq)
 	if err := dec(in); err != nil {
@@ -173,6 +354,17 @@ func _Devices_ListDevices_Handler(srv interface{}, ctx context.Context, dec func
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Devices_WatchDevices_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchDevicesReq)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DevicesServer).WatchDevices(m, &grpc.GenericServerStream[WatchDevicesReq, DeviceEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Devices_WatchDevicesServer = grpc.ServerStreamingServer[DeviceEvent]
+
 func _Devices_DeleteDevice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(DeleteDeviceReq)
 	if err := dec(in); err != nil {
@@ -191,6 +383,96 @@ func _Devices_DeleteDevice_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Devices_UpdateDevice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateDeviceReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DevicesServer).UpdateDevice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Devices_UpdateDevice_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DevicesServer).UpdateDevice(ctx, req.(*UpdateDeviceReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Devices_RenewDevice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenewDeviceReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DevicesServer).RenewDevice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Devices_RenewDevice_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DevicesServer).RenewDevice(ctx, req.(*RenewDeviceReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Devices_GetUsage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUsageReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DevicesServer).GetUsage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Devices_GetUsage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DevicesServer).GetUsage(ctx, req.(*GetUsageReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Devices_SuspendDevice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SuspendDeviceReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DevicesServer).SuspendDevice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Devices_SuspendDevice_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DevicesServer).SuspendDevice(ctx, req.(*SuspendDeviceReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Devices_ResumeDevice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResumeDeviceReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DevicesServer).ResumeDevice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Devices_ResumeDevice_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DevicesServer).ResumeDevice(ctx, req.(*ResumeDeviceReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Devices_ListAllDevices_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(ListAllDevicesReq)
 	if err := dec(in); err != nil {
@@ -209,6 +491,24 @@ func _Devices_ListAllDevices_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Devices_ListPendingDeletions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPendingDeletionsReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DevicesServer).ListPendingDeletions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Devices_ListPendingDeletions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DevicesServer).ListPendingDeletions(ctx, req.(*ListPendingDeletionsReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Devices_ServiceDesc is the grpc.ServiceDesc for Devices service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -220,6 +520,10 @@ var Devices_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "AddDevice",
 			Handler:    _Devices_AddDevice_Handler,
 		},
+		{
+			MethodName: "CreateDeviceWithConfig",
+			Handler:    _Devices_CreateDeviceWithConfig_Handler,
+		},
 		{
 			MethodName: "ListDevices",
 			Handler:    _Devices_ListDevices_Handler,
@@ -228,11 +532,41 @@ var Devices_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteDevice",
 			Handler:    _Devices_DeleteDevice_Handler,
 		},
+		{
+			MethodName: "UpdateDevice",
+			Handler:    _Devices_UpdateDevice_Handler,
+		},
+		{
+			MethodName: "RenewDevice",
+			Handler:    _Devices_RenewDevice_Handler,
+		},
+		{
+			MethodName: "GetUsage",
+			Handler:    _Devices_GetUsage_Handler,
+		},
+		{
+			MethodName: "SuspendDevice",
+			Handler:    _Devices_SuspendDevice_Handler,
+		},
+		{
+			MethodName: "ResumeDevice",
+			Handler:    _Devices_ResumeDevice_Handler,
+		},
 		{
 			MethodName: "ListAllDevices",
 			Handler:    _Devices_ListAllDevices_Handler,
 		},
+		{
+			MethodName: "ListPendingDeletions",
+			Handler:    _Devices_ListPendingDeletions_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchDevices",
+			Handler:       _Devices_WatchDevices_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "devices.proto",
 }
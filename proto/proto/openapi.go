@@ -0,0 +1,11 @@
+package proto
+
+import (
+	_ "embed"
+)
+
+// OpenAPI is the OpenAPI (Swagger 2.0) document of the REST/JSON api,
+// generated by protoc-gen-openapiv2 from ../api_config.yaml
+//
+//go:embed api.swagger.json
+var OpenAPI []byte
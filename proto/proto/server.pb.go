@@ -10,6 +10,7 @@ import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	wrapperspb "google.golang.org/protobuf/types/known/wrapperspb"
 	reflect "reflect"
 	sync "sync"
@@ -79,8 +80,20 @@ type InfoRes struct {
 	BuildInfo                       *BuildInfo              `protobuf:"bytes,16,opt,name=build_info,json=buildInfo,proto3" json:"build_info,omitempty"`
 	Mtu                             int32                   `protobuf:"varint,17,opt,name=mtu,proto3" json:"mtu,omitempty"`
 	ClientConfigPersistentKeepalive int32                   `protobuf:"varint,18,opt,name=client_config_persistent_keepalive,json=clientConfigPersistentKeepalive,proto3" json:"client_config_persistent_keepalive,omitempty"`
-	unknownFields                   protoimpl.UnknownFields
-	sizeCache                       protoimpl.SizeCache
+	// maximum number of devices of the current user, 0 if unlimited
+	DeviceQuota int32 `protobuf:"varint,19,opt,name=device_quota,json=deviceQuota,proto3" json:"device_quota,omitempty"`
+	// number of devices of the current user
+	DeviceCount int32 `protobuf:"varint,20,opt,name=device_count,json=deviceCount,proto3" json:"device_count,omitempty"`
+	// bytes the current user may transfer per month, 0 if unlimited
+	TrafficQuota int64 `protobuf:"varint,21,opt,name=traffic_quota,json=trafficQuota,proto3" json:"traffic_quota,omitempty"`
+	// bytes the current user transferred this month
+	TrafficUsed int64 `protobuf:"varint,22,opt,name=traffic_used,json=trafficUsed,proto3" json:"traffic_used,omitempty"`
+	// the start of the next month, when the used traffic is reset
+	TrafficQuotaReset *timestamppb.Timestamp `protobuf:"bytes,23,opt,name=traffic_quota_reset,json=trafficQuotaReset,proto3" json:"traffic_quota_reset,omitempty"`
+	// client config profiles users can pick when adding a device
+	Profiles      []*ClientProfile `protobuf:"bytes,24,rep,name=profiles,proto3" json:"profiles,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *InfoRes) Reset() {
@@ -239,12 +252,125 @@ func (x *InfoRes) GetClientConfigPersistentKeepalive() int32 {
 	return 0
 }
 
+func (x *InfoRes) GetDeviceQuota() int32 {
+	if x != nil {
+		return x.DeviceQuota
+	}
+	return 0
+}
+
+func (x *InfoRes) GetDeviceCount() int32 {
+	if x != nil {
+		return x.DeviceCount
+	}
+	return 0
+}
+
+func (x *InfoRes) GetTrafficQuota() int64 {
+	if x != nil {
+		return x.TrafficQuota
+	}
+	return 0
+}
+
+func (x *InfoRes) GetTrafficUsed() int64 {
+	if x != nil {
+		return x.TrafficUsed
+	}
+	return 0
+}
+
+func (x *InfoRes) GetTrafficQuotaReset() *timestamppb.Timestamp {
+	if x != nil {
+		return x.TrafficQuotaReset
+	}
+	return nil
+}
+
+func (x *InfoRes) GetProfiles() []*ClientProfile {
+	if x != nil {
+		return x.Profiles
+	}
+	return nil
+}
+
+type ClientProfile struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// replaces allowed_ips
+	AllowedIps string `protobuf:"bytes,2,opt,name=allowed_ips,json=allowedIps,proto3" json:"allowed_ips,omitempty"`
+	// replaces client_config_dns_servers if not empty
+	DnsServers string `protobuf:"bytes,3,opt,name=dns_servers,json=dnsServers,proto3" json:"dns_servers,omitempty"`
+	// replaces client_config_mtu if not 0
+	Mtu           int32 `protobuf:"varint,4,opt,name=mtu,proto3" json:"mtu,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClientProfile) Reset() {
+	*x = ClientProfile{}
+	mi := &file_server_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClientProfile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClientProfile) ProtoMessage() {}
+
+func (x *ClientProfile) ProtoReflect() protoreflect.Message {
+	mi := &file_server_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClientProfile.ProtoReflect.Descriptor instead.
+func (*ClientProfile) Descriptor() ([]byte, []int) {
+	return file_server_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ClientProfile) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ClientProfile) GetAllowedIps() string {
+	if x != nil {
+		return x.AllowedIps
+	}
+	return ""
+}
+
+func (x *ClientProfile) GetDnsServers() string {
+	if x != nil {
+		return x.DnsServers
+	}
+	return ""
+}
+
+func (x *ClientProfile) GetMtu() int32 {
+	if x != nil {
+		return x.Mtu
+	}
+	return 0
+}
+
 var File_server_proto protoreflect.FileDescriptor
 
 const file_server_proto_rawDesc = "" +
 	"\n" +
-	"\fserver.proto\x12\x05proto\x1a\x1egoogle/protobuf/wrappers.proto\x1a\x1egoogle/protobuf/duration.proto\x1a\x0fbuildinfo.proto\"\t\n" +
-	"\aInfoReq\"\xb5\x06\n" +
+	"\fserver.proto\x12\x05proto\x1a\x1egoogle/protobuf/wrappers.proto\x1a\x1egoogle/protobuf/duration.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x0fbuildinfo.proto\"\t\n" +
+	"\aInfoReq\"\xc1\b\n" +
 	"\aInfoRes\x12\x1d\n" +
 	"\n" +
 	"public_key\x18\x01 \x01(\tR\tpublicKey\x120\n" +
@@ -269,7 +395,20 @@ const file_server_proto_rawDesc = "" +
 	"\n" +
 	"build_info\x18\x10 \x01(\v2\x10.proto.BuildInfoR\tbuildInfo\x12\x10\n" +
 	"\x03mtu\x18\x11 \x01(\x05R\x03mtu\x12K\n" +
-	"\"client_config_persistent_keepalive\x18\x12 \x01(\x05R\x1fclientConfigPersistentKeepalive22\n" +
+	"\"client_config_persistent_keepalive\x18\x12 \x01(\x05R\x1fclientConfigPersistentKeepalive\x12!\n" +
+	"\fdevice_quota\x18\x13 \x01(\x05R\vdeviceQuota\x12!\n" +
+	"\fdevice_count\x18\x14 \x01(\x05R\vdeviceCount\x12#\n" +
+	"\rtraffic_quota\x18\x15 \x01(\x03R\ftrafficQuota\x12!\n" +
+	"\ftraffic_used\x18\x16 \x01(\x03R\vtrafficUsed\x12J\n" +
+	"\x13traffic_quota_reset\x18\x17 \x01(\v2\x1a.google.protobuf.TimestampR\x11trafficQuotaReset\x120\n" +
+	"\bprofiles\x18\x18 \x03(\v2\x14.proto.ClientProfileR\bprofiles\"w\n" +
+	"\rClientProfile\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1f\n" +
+	"\vallowed_ips\x18\x02 \x01(\tR\n" +
+	"allowedIps\x12\x1f\n" +
+	"\vdns_servers\x18\x03 \x01(\tR\n" +
+	"dnsServers\x12\x10\n" +
+	"\x03mtu\x18\x04 \x01(\x05R\x03mtu22\n" +
 	"\x06Server\x12(\n" +
 	"\x04Info\x12\x0e.proto.InfoReq\x1a\x0e.proto.InfoRes\"\x00B5Z3github.com/freifunkMUC/wg-access-server/proto/protob\x06proto3"
 
@@ -285,25 +424,29 @@ func file_server_proto_rawDescGZIP() []byte {
 	return file_server_proto_rawDescData
 }
 
-var file_server_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_server_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
 var file_server_proto_goTypes = []any{
 	(*InfoReq)(nil),                // 0: proto.InfoReq
 	(*InfoRes)(nil),                // 1: proto.InfoRes
-	(*wrapperspb.StringValue)(nil), // 2: google.protobuf.StringValue
-	(*durationpb.Duration)(nil),    // 3: google.protobuf.Duration
-	(*BuildInfo)(nil),              // 4: proto.BuildInfo
+	(*ClientProfile)(nil),          // 2: proto.ClientProfile
+	(*wrapperspb.StringValue)(nil), // 3: google.protobuf.StringValue
+	(*durationpb.Duration)(nil),    // 4: google.protobuf.Duration
+	(*BuildInfo)(nil),              // 5: proto.BuildInfo
+	(*timestamppb.Timestamp)(nil),  // 6: google.protobuf.Timestamp
 }
 var file_server_proto_depIdxs = []int32{
-	2, // 0: proto.InfoRes.host:type_name -> google.protobuf.StringValue
-	3, // 1: proto.InfoRes.inactive_device_grace_period:type_name -> google.protobuf.Duration
-	4, // 2: proto.InfoRes.build_info:type_name -> proto.BuildInfo
-	0, // 3: proto.Server.Info:input_type -> proto.InfoReq
-	1, // 4: proto.Server.Info:output_type -> proto.InfoRes
-	4, // [4:5] is the sub-list for method output_type
-	3, // [3:4] is the sub-list for method input_type
-	3, // [3:3] is the sub-list for extension type_name
-	3, // [3:3] is the sub-list for extension extendee
-	0, // [0:3] is the sub-list for field type_name
+	3, // 0: proto.InfoRes.host:type_name -> google.protobuf.StringValue
+	4, // 1: proto.InfoRes.inactive_device_grace_period:type_name -> google.protobuf.Duration
+	5, // 2: proto.InfoRes.build_info:type_name -> proto.BuildInfo
+	6, // 3: proto.InfoRes.traffic_quota_reset:type_name -> google.protobuf.Timestamp
+	2, // 4: proto.InfoRes.profiles:type_name -> proto.ClientProfile
+	0, // 5: proto.Server.Info:input_type -> proto.InfoReq
+	1, // 6: proto.Server.Info:output_type -> proto.InfoRes
+	6, // [6:7] is the sub-list for method output_type
+	5, // [5:6] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
 }
 
 func init() { file_server_proto_init() }
@@ -318,7 +461,7 @@ func file_server_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_server_proto_rawDesc), len(file_server_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   3,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -0,0 +1,447 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.4
+// source: tokens.proto
+
+package proto
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Token struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Id        string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name      string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Owner     string                 `protobuf:"bytes,3,opt,name=owner,proto3" json:"owner,omitempty"`
+	ReadOnly  bool                   `protobuf:"varint,4,opt,name=read_only,json=readOnly,proto3" json:"read_only,omitempty"`
+	CreatedAt *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// empty if the token doesn't expire
+	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Token) Reset() {
+	*x = Token{}
+	mi := &file_tokens_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Token) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Token) ProtoMessage() {}
+
+func (x *Token) ProtoReflect() protoreflect.Message {
+	mi := &file_tokens_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Token.ProtoReflect.Descriptor instead.
+func (*Token) Descriptor() ([]byte, []int) {
+	return file_tokens_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Token) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Token) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Token) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *Token) GetReadOnly() bool {
+	if x != nil {
+		return x.ReadOnly
+	}
+	return false
+}
+
+func (x *Token) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Token) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+type CreateTokenReq struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// read-only tokens can only call List and Info methods
+	ReadOnly bool `protobuf:"varint,2,opt,name=read_only,json=readOnly,proto3" json:"read_only,omitempty"`
+	// optional
+	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateTokenReq) Reset() {
+	*x = CreateTokenReq{}
+	mi := &file_tokens_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateTokenReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateTokenReq) ProtoMessage() {}
+
+func (x *CreateTokenReq) ProtoReflect() protoreflect.Message {
+	mi := &file_tokens_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateTokenReq.ProtoReflect.Descriptor instead.
+func (*CreateTokenReq) Descriptor() ([]byte, []int) {
+	return file_tokens_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CreateTokenReq) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateTokenReq) GetReadOnly() bool {
+	if x != nil {
+		return x.ReadOnly
+	}
+	return false
+}
+
+func (x *CreateTokenReq) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+type CreateTokenRes struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Token *Token                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	// the secret is only returned once
+	Secret        string `protobuf:"bytes,2,opt,name=secret,proto3" json:"secret,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateTokenRes) Reset() {
+	*x = CreateTokenRes{}
+	mi := &file_tokens_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateTokenRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateTokenRes) ProtoMessage() {}
+
+func (x *CreateTokenRes) ProtoReflect() protoreflect.Message {
+	mi := &file_tokens_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateTokenRes.ProtoReflect.Descriptor instead.
+func (*CreateTokenRes) Descriptor() ([]byte, []int) {
+	return file_tokens_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CreateTokenRes) GetToken() *Token {
+	if x != nil {
+		return x.Token
+	}
+	return nil
+}
+
+func (x *CreateTokenRes) GetSecret() string {
+	if x != nil {
+		return x.Secret
+	}
+	return ""
+}
+
+type ListTokensReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTokensReq) Reset() {
+	*x = ListTokensReq{}
+	mi := &file_tokens_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTokensReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTokensReq) ProtoMessage() {}
+
+func (x *ListTokensReq) ProtoReflect() protoreflect.Message {
+	mi := &file_tokens_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTokensReq.ProtoReflect.Descriptor instead.
+func (*ListTokensReq) Descriptor() ([]byte, []int) {
+	return file_tokens_proto_rawDescGZIP(), []int{3}
+}
+
+type ListTokensRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Items         []*Token               `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTokensRes) Reset() {
+	*x = ListTokensRes{}
+	mi := &file_tokens_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTokensRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTokensRes) ProtoMessage() {}
+
+func (x *ListTokensRes) ProtoReflect() protoreflect.Message {
+	mi := &file_tokens_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTokensRes.ProtoReflect.Descriptor instead.
+func (*ListTokensRes) Descriptor() ([]byte, []int) {
+	return file_tokens_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListTokensRes) GetItems() []*Token {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+type RevokeTokenReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RevokeTokenReq) Reset() {
+	*x = RevokeTokenReq{}
+	mi := &file_tokens_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevokeTokenReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokeTokenReq) ProtoMessage() {}
+
+func (x *RevokeTokenReq) ProtoReflect() protoreflect.Message {
+	mi := &file_tokens_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokeTokenReq.ProtoReflect.Descriptor instead.
+func (*RevokeTokenReq) Descriptor() ([]byte, []int) {
+	return file_tokens_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *RevokeTokenReq) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+var File_tokens_proto protoreflect.FileDescriptor
+
+const file_tokens_proto_rawDesc = "" +
+	"\n" +
+	"\ftokens.proto\x12\x05proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1bgoogle/protobuf/empty.proto\"\xd4\x01\n" +
+	"\x05Token\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
+	"\x05owner\x18\x03 \x01(\tR\x05owner\x12\x1b\n" +
+	"\tread_only\x18\x04 \x01(\bR\breadOnly\x129\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"expires_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\"|\n" +
+	"\x0eCreateTokenReq\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1b\n" +
+	"\tread_only\x18\x02 \x01(\bR\breadOnly\x129\n" +
+	"\n" +
+	"expires_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\"L\n" +
+	"\x0eCreateTokenRes\x12\"\n" +
+	"\x05token\x18\x01 \x01(\v2\f.proto.TokenR\x05token\x12\x16\n" +
+	"\x06secret\x18\x02 \x01(\tR\x06secret\"\x0f\n" +
+	"\rListTokensReq\"3\n" +
+	"\rListTokensRes\x12\"\n" +
+	"\x05items\x18\x01 \x03(\v2\f.proto.TokenR\x05items\" \n" +
+	"\x0eRevokeTokenReq\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id2\xc3\x01\n" +
+	"\x06Tokens\x12=\n" +
+	"\vCreateToken\x12\x15.proto.CreateTokenReq\x1a\x15.proto.CreateTokenRes\"\x00\x12:\n" +
+	"\n" +
+	"ListTokens\x12\x14.proto.ListTokensReq\x1a\x14.proto.ListTokensRes\"\x00\x12>\n" +
+	"\vRevokeToken\x12\x15.proto.RevokeTokenReq\x1a\x16.google.protobuf.Empty\"\x00B5Z3github.com/freifunkMUC/wg-access-server/proto/protob\x06proto3"
+
+var (
+	file_tokens_proto_rawDescOnce sync.Once
+	file_tokens_proto_rawDescData []byte
+)
+
+func file_tokens_proto_rawDescGZIP() []byte {
+	file_tokens_proto_rawDescOnce.Do(func() {
+		file_tokens_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_tokens_proto_rawDesc), len(file_tokens_proto_rawDesc)))
+	})
+	return file_tokens_proto_rawDescData
+}
+
+var file_tokens_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_tokens_proto_goTypes = []any{
+	(*Token)(nil),                 // 0: proto.Token
+	(*CreateTokenReq)(nil),        // 1: proto.CreateTokenReq
+	(*CreateTokenRes)(nil),        // 2: proto.CreateTokenRes
+	(*ListTokensReq)(nil),         // 3: proto.ListTokensReq
+	(*ListTokensRes)(nil),         // 4: proto.ListTokensRes
+	(*RevokeTokenReq)(nil),        // 5: proto.RevokeTokenReq
+	(*timestamppb.Timestamp)(nil), // 6: google.protobuf.Timestamp
+	(*emptypb.Empty)(nil),         // 7: google.protobuf.Empty
+}
+var file_tokens_proto_depIdxs = []int32{
+	6, // 0: proto.Token.created_at:type_name -> google.protobuf.Timestamp
+	6, // 1: proto.Token.expires_at:type_name -> google.protobuf.Timestamp
+	6, // 2: proto.CreateTokenReq.expires_at:type_name -> google.protobuf.Timestamp
+	0, // 3: proto.CreateTokenRes.token:type_name -> proto.Token
+	0, // 4: proto.ListTokensRes.items:type_name -> proto.Token
+	1, // 5: proto.Tokens.CreateToken:input_type -> proto.CreateTokenReq
+	3, // 6: proto.Tokens.ListTokens:input_type -> proto.ListTokensReq
+	5, // 7: proto.Tokens.RevokeToken:input_type -> proto.RevokeTokenReq
+	2, // 8: proto.Tokens.CreateToken:output_type -> proto.CreateTokenRes
+	4, // 9: proto.Tokens.ListTokens:output_type -> proto.ListTokensRes
+	7, // 10: proto.Tokens.RevokeToken:output_type -> google.protobuf.Empty
+	8, // [8:11] is the sub-list for method output_type
+	5, // [5:8] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_tokens_proto_init() }
+func file_tokens_proto_init() {
+	if File_tokens_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_tokens_proto_rawDesc), len(file_tokens_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_tokens_proto_goTypes,
+		DependencyIndexes: file_tokens_proto_depIdxs,
+		MessageInfos:      file_tokens_proto_msgTypes,
+	}.Build()
+	File_tokens_proto = out.File
+	file_tokens_proto_goTypes = nil
+	file_tokens_proto_depIdxs = nil
+}
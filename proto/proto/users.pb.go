@@ -198,6 +198,94 @@ func (x *DeleteUserReq) GetName() string {
 	return ""
 }
 
+type SuspendUserReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SuspendUserReq) Reset() {
+	*x = SuspendUserReq{}
+	mi := &file_users_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SuspendUserReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SuspendUserReq) ProtoMessage() {}
+
+func (x *SuspendUserReq) ProtoReflect() protoreflect.Message {
+	mi := &file_users_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SuspendUserReq.ProtoReflect.Descriptor instead.
+func (*SuspendUserReq) Descriptor() ([]byte, []int) {
+	return file_users_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *SuspendUserReq) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type ResumeUserReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResumeUserReq) Reset() {
+	*x = ResumeUserReq{}
+	mi := &file_users_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResumeUserReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResumeUserReq) ProtoMessage() {}
+
+func (x *ResumeUserReq) ProtoReflect() protoreflect.Message {
+	mi := &file_users_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResumeUserReq.ProtoReflect.Descriptor instead.
+func (*ResumeUserReq) Descriptor() ([]byte, []int) {
+	return file_users_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ResumeUserReq) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
 var File_users_proto protoreflect.FileDescriptor
 
 const file_users_proto_rawDesc = "" +
@@ -210,11 +298,18 @@ const file_users_proto_rawDesc = "" +
 	"\fListUsersRes\x12!\n" +
 	"\x05items\x18\x01 \x03(\v2\v.proto.UserR\x05items\"#\n" +
 	"\rDeleteUserReq\x12\x12\n" +
-	"\x04name\x18\x01 \x01(\tR\x04name2~\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"$\n" +
+	"\x0eSuspendUserReq\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"#\n" +
+	"\rResumeUserReq\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name2\xfc\x01\n" +
 	"\x05Users\x127\n" +
 	"\tListUsers\x12\x13.proto.ListUsersReq\x1a\x13.proto.ListUsersRes\"\x00\x12<\n" +
 	"\n" +
-	"DeleteUser\x12\x14.proto.DeleteUserReq\x1a\x16.google.protobuf.Empty\"\x00B5Z3github.com/freifunkMUC/wg-access-server/proto/protob\x06proto3"
+	"DeleteUser\x12\x14.proto.DeleteUserReq\x1a\x16.google.protobuf.Empty\"\x00\x12>\n" +
+	"\vSuspendUser\x12\x15.proto.SuspendUserReq\x1a\x16.google.protobuf.Empty\"\x00\x12<\n" +
+	"\n" +
+	"ResumeUser\x12\x14.proto.ResumeUserReq\x1a\x16.google.protobuf.Empty\"\x00B5Z3github.com/freifunkMUC/wg-access-server/proto/protob\x06proto3"
 
 var (
 	file_users_proto_rawDescOnce sync.Once
@@ -228,22 +323,28 @@ func file_users_proto_rawDescGZIP() []byte {
 	return file_users_proto_rawDescData
 }
 
-var file_users_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_users_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
 var file_users_proto_goTypes = []any{
-	(*User)(nil),          // 0: proto.User
-	(*ListUsersReq)(nil),  // 1: proto.ListUsersReq
-	(*ListUsersRes)(nil),  // 2: proto.ListUsersRes
-	(*DeleteUserReq)(nil), // 3: proto.DeleteUserReq
-	(*emptypb.Empty)(nil), // 4: google.protobuf.Empty
+	(*User)(nil),           // 0: proto.User
+	(*ListUsersReq)(nil),   // 1: proto.ListUsersReq
+	(*ListUsersRes)(nil),   // 2: proto.ListUsersRes
+	(*DeleteUserReq)(nil),  // 3: proto.DeleteUserReq
+	(*SuspendUserReq)(nil), // 4: proto.SuspendUserReq
+	(*ResumeUserReq)(nil),  // 5: proto.ResumeUserReq
+	(*emptypb.Empty)(nil),  // 6: google.protobuf.Empty
 }
 var file_users_proto_depIdxs = []int32{
 	0, // 0: proto.ListUsersRes.items:type_name -> proto.User
 	1, // 1: proto.Users.ListUsers:input_type -> proto.ListUsersReq
 	3, // 2: proto.Users.DeleteUser:input_type -> proto.DeleteUserReq
-	2, // 3: proto.Users.ListUsers:output_type -> proto.ListUsersRes
-	4, // 4: proto.Users.DeleteUser:output_type -> google.protobuf.Empty
-	3, // [3:5] is the sub-list for method output_type
-	1, // [1:3] is the sub-list for method input_type
+	4, // 3: proto.Users.SuspendUser:input_type -> proto.SuspendUserReq
+	5, // 4: proto.Users.ResumeUser:input_type -> proto.ResumeUserReq
+	2, // 5: proto.Users.ListUsers:output_type -> proto.ListUsersRes
+	6, // 6: proto.Users.DeleteUser:output_type -> google.protobuf.Empty
+	6, // 7: proto.Users.SuspendUser:output_type -> google.protobuf.Empty
+	6, // 8: proto.Users.ResumeUser:output_type -> google.protobuf.Empty
+	5, // [5:9] is the sub-list for method output_type
+	1, // [1:5] is the sub-list for method input_type
 	1, // [1:1] is the sub-list for extension type_name
 	1, // [1:1] is the sub-list for extension extendee
 	0, // [0:1] is the sub-list for field type_name
@@ -260,7 +361,7 @@ func file_users_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_users_proto_rawDesc), len(file_users_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   4,
+			NumMessages:   6,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -20,8 +20,10 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	Users_ListUsers_FullMethodName  = "/proto.Users/ListUsers"
-	Users_DeleteUser_FullMethodName = "/proto.Users/DeleteUser"
+	Users_ListUsers_FullMethodName   = "/proto.Users/ListUsers"
+	Users_DeleteUser_FullMethodName  = "/proto.Users/DeleteUser"
+	Users_SuspendUser_FullMethodName = "/proto.Users/SuspendUser"
+	Users_ResumeUser_FullMethodName  = "/proto.Users/ResumeUser"
 )
 
 // UsersClient is the client API for Users service.
@@ -31,6 +33,9 @@ type UsersClient interface {
 	// admin only
 	ListUsers(ctx context.Context, in *ListUsersReq, opts ...grpc.CallOption) (*ListUsersRes, error)
 	DeleteUser(ctx context.Context, in *DeleteUserReq, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// suspends or resumes all devices of the user
+	SuspendUser(ctx context.Context, in *SuspendUserReq, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	ResumeUser(ctx context.Context, in *ResumeUserReq, opts ...grpc.CallOption) (*emptypb.Empty, error)
 }
 
 type usersClient struct {
@@ -61,6 +66,26 @@ func (c *usersClient) DeleteUser(ctx context.Context, in *DeleteUserReq, opts ..
 	return out, nil
 }
 
+func (c *usersClient) SuspendUser(ctx context.Context, in *SuspendUserReq, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Users_SuspendUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *usersClient) ResumeUser(ctx context.Context, in *ResumeUserReq, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Users_ResumeUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // UsersServer is the server API for Users service.
 // All implementations must embed UnimplementedUsersServer
 // for forward compatibility.
@@ -68,6 +93,9 @@ type UsersServer interface {
 	// admin only
 	ListUsers(context.Context, *ListUsersReq) (*ListUsersRes, error)
 	DeleteUser(context.Context, *DeleteUserReq) (*emptypb.Empty, error)
+	// suspends or resumes all devices of the user
+	SuspendUser(context.Context, *SuspendUserReq) (*emptypb.Empty, error)
+	ResumeUser(context.Context, *ResumeUserReq) (*emptypb.Empty, error)
 	mustEmbedUnimplementedUsersServer()
 }
 
@@ -84,6 +112,12 @@ func (UnimplementedUsersServer) ListUsers(context.Context, *ListUsersReq) (*List
 func (UnimplementedUsersServer) DeleteUser(context.Context, *DeleteUserReq) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteUser not implemented")
 }
+func (UnimplementedUsersServer) SuspendUser(context.Context, *SuspendUserReq) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SuspendUser not implemented")
+}
+func (UnimplementedUsersServer) ResumeUser(context.Context, *ResumeUserReq) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResumeUser not implemented")
+}
 func (UnimplementedUsersServer) mustEmbedUnimplementedUsersServer() {}
 func (UnimplementedUsersServer) testEmbeddedByValue()               {}
 
@@ -141,6 +175,42 @@ func _Users_DeleteUser_Handler(srv interface{}, ctx context.Context, dec func(in
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Users_SuspendUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SuspendUserReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UsersServer).SuspendUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Users_SuspendUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UsersServer).SuspendUser(ctx, req.(*SuspendUserReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Users_ResumeUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResumeUserReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UsersServer).ResumeUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Users_ResumeUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UsersServer).ResumeUser(ctx, req.(*ResumeUserReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Users_ServiceDesc is the grpc.ServiceDesc for Users service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -156,6 +226,14 @@ var Users_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteUser",
 			Handler:    _Users_DeleteUser_Handler,
 		},
+		{
+			MethodName: "SuspendUser",
+			Handler:    _Users_SuspendUser_Handler,
+		},
+		{
+			MethodName: "ResumeUser",
+			Handler:    _Users_ResumeUser_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "users.proto",
@@ -1,8 +1,11 @@
 import { formatDistance } from 'date-fns';
 import timestamp_pb from 'google-protobuf/google/protobuf/timestamp_pb';
-import { toDate } from './Api';
+import { ClientReadableStream } from 'grpc-web';
+import { grpc, toDate } from './Api';
 import { fromResource, lazyObservable } from 'mobx-utils';
 import { toast } from './components/Toast';
+import { AppState } from './AppState';
+import { Device, DeviceEvent } from './sdk/devices_pb';
 
 export function sleep(seconds: number) {
   return new Promise<void>((resolve) => {
@@ -21,6 +24,18 @@ export function lastSeen(timestamp: timestamp_pb.Timestamp.AsObject | undefined)
   });
 }
 
+export function expiry(timestamp: timestamp_pb.Timestamp.AsObject): string {
+  const date = toDate(timestamp);
+  if (date <= new Date()) {
+    return 'Expired ' + formatDistance(date, new Date(), { addSuffix: true });
+  }
+  return 'Expires ' + formatDistance(date, new Date(), { addSuffix: true });
+}
+
+export function deletion(timestamp: timestamp_pb.Timestamp.AsObject): string {
+  return 'Deleted ' + formatDistance(toDate(timestamp), new Date(), { addSuffix: true }) + ' unless it connects';
+}
+
 export function lazy<T>(cb: () => Promise<T>) {
   const resource = lazyObservable<T>(async (sink) => {
     sink(await cb());
@@ -69,6 +84,101 @@ export function autorefresh<T>(seconds: number, cb: () => Promise<T>) {
   };
 }
 
+// DeviceEvent.Type of devices.proto
+const DeviceEventType = {
+  EXISTING: 0,
+  SYNCED: 1,
+  ADDED: 2,
+  UPDATED: 3,
+  DELETED: 4,
+};
+
+// watchDevices keeps the devices up to date with the WatchDevices stream instead of polling,
+// it reconnects if the stream ends. all watches the devices of all users (admin only).
+export function watchDevices(all: boolean) {
+  let stream: ClientReadableStream<DeviceEvent> | undefined;
+  let retry: ReturnType<typeof setTimeout> | undefined;
+  let sink: ((next: Device.AsObject[]) => void) | undefined;
+  let devices = new Map<string, Device.AsObject>();
+
+  const key = (owner: string, name: string) => owner + '/' + name;
+
+  const stop = () => {
+    clearTimeout(retry);
+    stream?.cancel();
+    stream = undefined;
+  };
+
+  const connect = () => {
+    stop();
+    // the current devices are replaced once the existing devices have been received
+    let synced = false;
+    let existing = new Map<string, Device.AsObject>();
+    stream = grpc.devices.watchDevices(
+      { all },
+      (event) => {
+        if (event.type === DeviceEventType.SYNCED) {
+          synced = true;
+          devices = existing;
+        } else if (event.device) {
+          const target = synced ? devices : existing;
+          if (event.previousName) {
+            target.delete(key(event.device.owner, event.previousName));
+          }
+          if (event.type === DeviceEventType.DELETED) {
+            target.delete(key(event.device.owner, event.device.name));
+          } else {
+            target.set(key(event.device.owner, event.device.name), event.device);
+          }
+        }
+        if (synced && sink) {
+          sink([...devices.values()]);
+        }
+      },
+      (error) => {
+        console.error('Watching devices failed:', error);
+        if (!sink) {
+          return;
+        }
+        if (error.code === 7) {
+          // PERMISSION_DENIED
+          AppState.setLoadingError(error.message);
+          return;
+        }
+        retry = setTimeout(connect, 5000);
+      },
+      () => {
+        if (sink) {
+          retry = setTimeout(connect, 5000);
+        }
+      },
+    );
+  };
+
+  const resource = fromResource<Device.AsObject[]>(
+    (s) => {
+      sink = s;
+      connect();
+    },
+    () => {
+      sink = undefined;
+      stop();
+    },
+  );
+
+  return {
+    get current() {
+      return resource.current();
+    },
+    refresh: async () => {
+      connect();
+    },
+    dispose: () => {
+      resource.dispose();
+    },
+  };
+}
+
 export function setClipboard(text: string) {
   const textarea = document.createElement('textarea');
   textarea.value = text;
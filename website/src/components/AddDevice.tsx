@@ -12,11 +12,14 @@ import FormControlLabel from '@mui/material/FormControlLabel';
 import FormHelperText from '@mui/material/FormHelperText';
 import Input from '@mui/material/Input';
 import InputLabel from '@mui/material/InputLabel';
+import MenuItem from '@mui/material/MenuItem';
+import Select from '@mui/material/Select';
 import Typography from '@mui/material/Typography';
 import AddIcon from '@mui/icons-material/Add';
 import { codeBlock } from 'common-tags';
 import { makeObservable, observable, runInAction } from 'mobx';
 import { observer } from 'mobx-react';
+import numeral from 'numeral';
 import React from 'react';
 import { box_keyPair, randomBytes } from 'tweetnacl-ts';
 import { grpc } from '../Api';
@@ -45,6 +48,8 @@ export const AddDevice = observer(
 
     deviceName = '';
 
+    profile = '';
+
     devicePublickey = '';
 
     manualIPAssignment = false;
@@ -53,6 +58,10 @@ export const AddDevice = observer(
 
     manualIPv6Address = '';
 
+    gateway = false;
+
+    routes = '';
+
     useDevicePresharekey = false;
     
     persistentKeepalive = AppState.info?.clientConfigPersistentKeepalive || 0;
@@ -81,6 +90,12 @@ export const AddDevice = observer(
       });
     }
 
+    setProfile(profile: string){
+      runInAction(() => {
+        this.profile = profile;
+      });
+    }
+
     setDevicePublickey(devicePublickey: string){
       runInAction(() => {
         this.devicePublickey = devicePublickey;
@@ -105,6 +120,18 @@ export const AddDevice = observer(
       });
     }
 
+    setGateway(gateway: boolean){
+      runInAction(() => {
+        this.gateway = gateway;
+      });
+    }
+
+    setRoutes(routes: string){
+      runInAction(() => {
+        this.routes = routes;
+      });
+    }
+
     setUseDevicePresharekey(useDevicePresharekey: boolean){
       runInAction(() => {
         this.useDevicePresharekey = useDevicePresharekey;
@@ -163,15 +190,24 @@ export const AddDevice = observer(
           manualIpAssignment: this.manualIPAssignment,
           manualIpv4Address: this.manualIPv4Address,
           manualIpv6Address: this.manualIPv6Address,
+          profile: this.profile,
+          gateway: this.gateway,
+          routes: this.routes,
         });
         this.props.onAdd();
+        // refresh the device quota usage
+        AppState.setInfo(await grpc.server.info({}));
 
         const info = AppState.info!;
+        // A profile replaces the routes, and the DNS servers and MTU if set
+        const profile = info.profiles.find((p) => p.name === device.profile);
+        const dnsServers = profile?.dnsServers || info.clientConfigDnsServers;
+        const mtu = profile?.mtu || info.clientConfigMtu;
 
         const dnsInfo = [];
-        if (info.clientConfigDnsServers) {
+        if (dnsServers) {
           // If custom DNS entries are specified via client config, prefer them over the calculated ones.
-          dnsInfo.push(info.clientConfigDnsServers);
+          dnsInfo.push(dnsServers);
         } else if (info.dnsEnabled) {
           // Otherwise, and if DNS is enabled, use the ones from the server.
           dnsInfo.push(info.dnsAddress);
@@ -187,11 +223,11 @@ export const AddDevice = observer(
         PrivateKey = ${privateKey}
         Address = ${device.address}
         ${0 < dnsInfo.length && `DNS = ${dnsInfo.join(', ')}`}
-        ${info.clientConfigMtu != 0 && `MTU = ${info.clientConfigMtu}`}
+        ${mtu != 0 && `MTU = ${mtu}`}
 
         [Peer]
         PublicKey = ${info.publicKey}
-        AllowedIPs = ${info.allowedIps}
+        AllowedIPs = ${profile?.allowedIps || info.allowedIps}
         Endpoint = ${`${info.host?.value || window.location.hostname}:${info.port || '51820'}`}
         ${this.useDevicePresharekey ? `PresharedKey = ${presharedKey}` : ``}
         ${this.persistentKeepalive > 0 ? `PersistentKeepalive = ${this.persistentKeepalive}` : ``}
@@ -208,6 +244,7 @@ export const AddDevice = observer(
 
     reset = () => {
       this.setDeviceName('')
+      this.setProfile('')
       this.setDevicePublickey('')
       this.setUseDevicePresharekey(false)
       this.setPersistentKeepalive(AppState.info?.clientConfigPersistentKeepalive || 0);
@@ -216,6 +253,8 @@ export const AddDevice = observer(
       this.setManualIPAssignment(false);
       this.setManualIPv4Address('');
       this.setManualIPv6Address('');
+      this.setGateway(false);
+      this.setRoutes('');
     };
 
 
@@ -226,6 +265,7 @@ export const AddDevice = observer(
         dialogOpen: observable,
         error: observable,
         deviceName: observable,
+        profile: observable,
         devicePublickey: observable,
         useDevicePresharekey: observable,
         persistentKeepalive: observable,
@@ -234,6 +274,8 @@ export const AddDevice = observer(
         manualIPAssignment: observable,
         manualIPv4Address: observable,
         manualIPv6Address: observable,
+        gateway: observable,
+        routes: observable,
       });
     }
 
@@ -250,10 +292,19 @@ export const AddDevice = observer(
         return true;
       };
 
+      const quotas: string[] = [];
+      if (AppState.info?.deviceQuota) {
+        quotas.push(`${AppState.info.deviceCount} of ${AppState.info.deviceQuota} devices used`);
+      }
+      if (AppState.info?.trafficQuota) {
+        quotas.push(`${numeral(AppState.info.trafficUsed).format('0b')} of ${numeral(AppState.info.trafficQuota).format('0b')} traffic used this month`);
+      }
+
       return (
         <>
           <Card>
             <CardHeader title="Add A Device" 
+              subheader={quotas.length > 0 ? quotas.join(', ') : undefined}
               action={<ImportExportDelete onRefresh={() => this.props.onRefresh()} />}
             />
             <CardContent>
@@ -267,6 +318,29 @@ export const AddDevice = observer(
                     aria-describedby="device-name-text"
                   />
                 </FormControl>
+                {AppState.info && AppState.info.profiles.length > 0 && (
+                  <FormControl fullWidth sx={{ mt: 2 }}>
+                    <InputLabel id="device-profile-label">Profile</InputLabel>
+                    <Select
+                      labelId="device-profile-label"
+                      id="device-profile"
+                      label="Profile"
+                      value={this.profile}
+                      onChange={(event) => this.setProfile(event.target.value)}
+                      aria-describedby="device-profile-text"
+                    >
+                      <MenuItem value="">Default</MenuItem>
+                      {AppState.info.profiles.map((p) => (
+                        <MenuItem key={p.name} value={p.name}>
+                          {p.name}
+                        </MenuItem>
+                      ))}
+                    </Select>
+                    <FormHelperText id="device-profile-text">
+                      Selects which networks are routed through the VPN
+                    </FormHelperText>
+                  </FormControl>
+                )}
                 <Box mt={2} mb={2}>
                   <Accordion>
                     <AccordionSummary
@@ -359,6 +433,38 @@ export const AddDevice = observer(
                           </FormControl>
                         </>
                       )}
+                      {AppState.info?.isAdmin && (
+                        <FormControlLabel
+                          control={
+                            <Checkbox
+                              id="device-gateway"
+                              checked={this.gateway}
+                              onChange={(event) => {
+                                this.setGateway(event.currentTarget.checked);
+                                if (!event.currentTarget.checked) {
+                                  this.setRoutes('');
+                                }
+                              }}
+                            />
+                          }
+                          label="Gateway device"
+                        />
+                      )}
+                      {this.gateway && (
+                        <FormControl fullWidth>
+                          <InputLabel htmlFor="device-routes">Routed Networks</InputLabel>
+                          <Input
+                            id="device-routes"
+                            value={this.routes}
+                            onChange={(event) => (this.setRoutes(event.currentTarget.value) )}
+                            aria-describedby="device-routes-text"
+                            placeholder="e.g. 192.168.10.0/24, fd00:10::/64"
+                          />
+                          <FormHelperText id="device-routes-text">
+                            Networks behind this device, e.g. the LAN of a branch office.
+                          </FormHelperText>
+                        </FormControl>
+                      )}
                     </AccordionDetails>
                   </Accordion>
                 </Box>
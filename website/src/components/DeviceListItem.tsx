@@ -7,18 +7,19 @@ import WifiIcon from '@mui/icons-material/Wifi';
 import WifiOffIcon from '@mui/icons-material/WifiOff';
 import DeleteIcon from '@mui/icons-material/Delete';
 import numeral from 'numeral';
-import { lastSeen } from '../Util';
+import { deletion, expiry, lastSeen } from '../Util';
 import { AppState } from '../AppState';
 import { PopoverDisplay } from './PopoverDisplay';
 import { Device } from '../sdk/devices_pb';
 import { grpc } from '../Api';
 import { observer } from 'mobx-react';
 import { confirm } from './Present';
-import { IconButton, Typography } from '@mui/material';
+import { Button, IconButton, Typography } from '@mui/material';
 
 interface Props {
   device: Device.AsObject;
   onRemove: () => void;
+  onRenew: () => void;
 }
 
 export const DeviceListItem = observer(
@@ -36,13 +37,24 @@ export const DeviceListItem = observer(
       }
     };
 
+    renewDevice = async () => {
+      try {
+        await grpc.devices.renewDevice({
+          name: this.props.device.name,
+        });
+        this.props.onRenew();
+      } catch {
+        window.alert('api request failed');
+      }
+    };
+
     render() {
       const device = this.props.device;
       return (
         <Card>
           <CardHeader
             title={<Typography style={{ wordBreak: 'break-word' }}>{device.name}</Typography>}
-            subheader={'Last seen: ' + lastSeen(device.lastHandshakeTime)}
+            subheader={device.suspended ? 'Suspended' : device.overQuota ? 'Traffic quota exceeded' : 'Last seen: ' + lastSeen(device.lastHandshakeTime)}
             avatar={
               <Avatar style={{ backgroundColor: device.connected ? '#76de8a' : '#bdbdbd' }}>
                 {/* <DonutSmallIcon /> */}
@@ -79,6 +91,29 @@ export const DeviceListItem = observer(
                     <td>Disconnected</td>
                   </tr>
                 )}
+                {device.expiresAt && (
+                  <tr>
+                    <td>Validity</td>
+                    <td>
+                      {expiry(device.expiresAt)}{' '}
+                      <Button size="small" onClick={this.renewDevice} title="Extend the validity of the device">
+                        Renew
+                      </Button>
+                    </td>
+                  </tr>
+                )}
+                {device.staleSince && device.deletionTime && (
+                  <tr>
+                    <td>Inactive</td>
+                    <td style={{ color: 'red' }}>{deletion(device.deletionTime)}</td>
+                  </tr>
+                )}
+                {device.gateway && (
+                  <tr>
+                    <td>Routes</td>
+                    <td>{device.routes || 'None'}</td>
+                  </tr>
+                )}
                 <tr>
                   <td>Public key</td>
                   <td>
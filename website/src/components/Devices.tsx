@@ -2,8 +2,7 @@ import React from 'react';
 import { Box } from '@mui/material';
 import { observable, makeObservable, runInAction } from 'mobx';
 import { observer } from 'mobx-react';
-import { grpc } from '../Api';
-import { autorefresh } from '../Util';
+import { watchDevices } from '../Util';
 import { DeviceListItem } from './DeviceListItem';
 import { Device } from '../sdk/devices_pb';
 import { AddDevice } from './AddDevice';
@@ -34,16 +33,7 @@ export const Devices = observer(
     }
 
     componentDidMount() {
-      this.setDevices(autorefresh(30, async () => {
-        try {
-          const res = await grpc.devices.listDevices({});
-          return res.items;
-        } catch (error: any) {
-          console.log('An error occurred:', error);
-          AppState.loadingError = error.message;
-          return null;
-        }
-      }));
+      this.setDevices(watchDevices(false));
 
       // listen for global refresh events (e.g. import/delete from other UI locations)
       this.refreshHandler = async () => {
@@ -91,7 +81,11 @@ export const Devices = observer(
             <Box sx={{ display: 'grid', gap: 3, gridTemplateColumns: { xs: '1fr', sm: '1fr 1fr', md: 'repeat(3, 1fr)', lg: 'repeat(4, 1fr)' } }}>
               {this.devices.current.map((device: Device.AsObject, i: React.Key) => (
                 <Box key={i}>
-                  <DeviceListItem device={device} onRemove={() => this.devices.refresh()} />
+                  <DeviceListItem
+                    device={device}
+                    onRemove={() => this.devices.refresh()}
+                    onRenew={() => this.devices.refresh()}
+                  />
                 </Box>
               ))}
             </Box>
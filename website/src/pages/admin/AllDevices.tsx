@@ -18,7 +18,7 @@ import { AppState } from '../../AppState';
 import { confirm } from '../../components/Present';
 import { Device } from '../../sdk/devices_pb';
 import { User } from '../../sdk/users_pb';
-import { lastSeen, lazy } from '../../Util';
+import { lastSeen, lazy, watchDevices } from '../../Util';
 import numeral from 'numeral';
 import { Loading } from '../../components/Loading';
 import { Error } from '../../components/Error';
@@ -50,16 +50,11 @@ export const AllDevices = observer(
       }
     });
 
-    devices = lazy(async () => {
-      try {
-        const res = await grpc.devices.listAllDevices({});
-        return res.items;
-      } catch (error: any) {
-        console.error('An error occurred:', error);
-        AppState.loadingError = error.message;
-        return null;
-      }
-    });
+    devices = watchDevices(true);
+
+    componentWillUnmount() {
+      this.devices.dispose();
+    }
 
     handleRequestSort = (property: keyof Device.AsObject | 'download' | 'upload' | 'connected') => {
       const isAsc = this.sortBy === property && this.sortOrder === 'asc';
@@ -133,6 +128,32 @@ export const AllDevices = observer(
       }
     };
 
+    toggleSuspended = async (device: Device.AsObject) => {
+      const req = { name: device.name, owner: { value: device.owner } };
+      if (device.suspended) {
+        await grpc.devices.resumeDevice(req);
+      } else {
+        await grpc.devices.suspendDevice(req);
+      }
+      await this.devices.refresh();
+    };
+
+    suspendUser = async (user: User.AsObject) => {
+      if (await confirm('Are you sure you want to suspend all devices of ' + (user.displayName || user.name) + '?')) {
+        await grpc.users.suspendUser({
+          name: user.name,
+        });
+        await this.devices.refresh();
+      }
+    };
+
+    resumeUser = async (user: User.AsObject) => {
+      await grpc.users.resumeUser({
+        name: user.name,
+      });
+      await this.devices.refresh();
+    };
+
     render() {
       if (!this.devices.current || !this.users.current) {
         return <Loading />;
@@ -269,6 +290,9 @@ export const AllDevices = observer(
                     </TableCell>
                     <TableCell>{lastSeen(device.lastHandshakeTime)}</TableCell>
                     <TableCell>
+                      <Button variant="outlined" onClick={() => this.toggleSuspended(device)}>
+                        {device.suspended ? 'Resume' : 'Suspend'}
+                      </Button>{' '}
                       <Button variant="outlined" color="secondary" onClick={() => this.deleteDevice(device)}>
                         Delete
                       </Button>
@@ -298,6 +322,12 @@ export const AllDevices = observer(
                       {user.displayName || user.name}
                     </TableCell>
                     <TableCell>
+                      <Button variant="outlined" onClick={() => this.suspendUser(user)}>
+                        Suspend
+                      </Button>{' '}
+                      <Button variant="outlined" onClick={() => this.resumeUser(user)}>
+                        Resume
+                      </Button>{' '}
                       <Button variant="outlined" color="secondary" onClick={() => this.deleteUser(user)}>
                         Delete
                       </Button>
@@ -0,0 +1,625 @@
+// Generated by protoc-gen-grpc-ts-web. DO NOT EDIT!
+/* eslint-disable */
+/* tslint:disable */
+
+import * as jspb from 'google-protobuf';
+import * as grpcWeb from 'grpc-web';
+
+import * as googleProtobufTimestamp from 'google-protobuf/google/protobuf/timestamp_pb';
+
+export class Audit {
+
+	private client_ = new grpcWeb.GrpcWebClientBase({
+		format: 'text',
+	});
+
+	private methodInfoList = new grpcWeb.MethodDescriptor<ListAuditEventsReq, ListAuditEventsRes>(
+		"List",
+		null,
+		ListAuditEventsReq,
+		ListAuditEventsRes,
+		(req: ListAuditEventsReq) => req.serializeBinary(),
+		ListAuditEventsRes.deserializeBinary
+	);
+
+	constructor(
+		private hostname: string,
+		private defaultMetadata?: () => grpcWeb.Metadata,
+	) { }
+
+	list(req: ListAuditEventsReq.AsObject, metadata?: grpcWeb.Metadata): Promise<ListAuditEventsRes.AsObject> {
+		return new Promise((resolve, reject) => {
+			const message = ListAuditEventsReqFromObject(req);
+			this.client_.rpcCall(
+				this.hostname + '/proto.Audit/List',
+				message,
+				Object.assign({}, this.defaultMetadata ? this.defaultMetadata() : {}, metadata),
+				this.methodInfoList,
+				(err: grpcWeb.Error, res: ListAuditEventsRes) => {
+					if (err) {
+						reject(err);
+					} else {
+						resolve(res.toObject());
+					}
+				},
+			);
+		});
+	}
+
+}
+
+
+
+
+export declare namespace AuditEvent {
+	export type AsObject = {
+		id: number,
+		time?: googleProtobufTimestamp.Timestamp.AsObject,
+		actor: string,
+		actorName: string,
+		actorProvider: string,
+		action: string,
+		target: string,
+		sourceIp: string,
+		details: string,
+		actorEmail: string,
+	}
+}
+
+export class AuditEvent extends jspb.Message {
+
+	private static repeatedFields_ = [
+		
+	];
+
+	constructor(data?: jspb.Message.MessageArray) {
+		super();
+		jspb.Message.initialize(this, data || [], 0, -1, AuditEvent.repeatedFields_, null);
+	}
+
+
+	getId(): number {return jspb.Message.getFieldWithDefault(this, 1, 0);
+	}
+
+	setId(value: number): void {
+		(jspb.Message as any).setProto3IntField(this, 1, value);
+	}
+
+	getTime(): googleProtobufTimestamp.Timestamp {
+		return jspb.Message.getWrapperField(this, googleProtobufTimestamp.Timestamp, 2);
+	}
+
+	setTime(value?: googleProtobufTimestamp.Timestamp): void {
+		(jspb.Message as any).setWrapperField(this, 2, value);
+	}
+
+	getActor(): string {return jspb.Message.getFieldWithDefault(this, 3, "");
+	}
+
+	setActor(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 3, value);
+	}
+
+	getActorName(): string {return jspb.Message.getFieldWithDefault(this, 4, "");
+	}
+
+	setActorName(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 4, value);
+	}
+
+	getActorProvider(): string {return jspb.Message.getFieldWithDefault(this, 5, "");
+	}
+
+	setActorProvider(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 5, value);
+	}
+
+	getAction(): string {return jspb.Message.getFieldWithDefault(this, 6, "");
+	}
+
+	setAction(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 6, value);
+	}
+
+	getTarget(): string {return jspb.Message.getFieldWithDefault(this, 7, "");
+	}
+
+	setTarget(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 7, value);
+	}
+
+	getSourceIp(): string {return jspb.Message.getFieldWithDefault(this, 8, "");
+	}
+
+	setSourceIp(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 8, value);
+	}
+
+	getDetails(): string {return jspb.Message.getFieldWithDefault(this, 9, "");
+	}
+
+	setDetails(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 9, value);
+	}
+
+	getActorEmail(): string {return jspb.Message.getFieldWithDefault(this, 10, "");
+	}
+
+	setActorEmail(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 10, value);
+	}
+
+	serializeBinary(): Uint8Array {
+		const writer = new jspb.BinaryWriter();
+		AuditEvent.serializeBinaryToWriter(this, writer);
+		return writer.getResultBuffer();
+	}
+
+	toObject(): AuditEvent.AsObject {
+		let f: any;
+		return {
+			id: this.getId(),
+			time: (f = this.getTime()) && f.toObject(),
+			actor: this.getActor(),
+			actorName: this.getActorName(),
+			actorProvider: this.getActorProvider(),
+			action: this.getAction(),
+			target: this.getTarget(),
+			sourceIp: this.getSourceIp(),
+			details: this.getDetails(),
+			actorEmail: this.getActorEmail(),
+		};
+	}
+
+	static serializeBinaryToWriter(message: AuditEvent, writer: jspb.BinaryWriter): void {
+		const field1 = message.getId();
+		if (field1 != 0) {
+			writer.writeUint64(1, field1);
+		}
+		const field2 = message.getTime();
+		if (field2 != null) {
+			writer.writeMessage(2, field2, googleProtobufTimestamp.Timestamp.serializeBinaryToWriter);
+		}
+		const field3 = message.getActor();
+		if (field3.length > 0) {
+			writer.writeString(3, field3);
+		}
+		const field4 = message.getActorName();
+		if (field4.length > 0) {
+			writer.writeString(4, field4);
+		}
+		const field5 = message.getActorProvider();
+		if (field5.length > 0) {
+			writer.writeString(5, field5);
+		}
+		const field6 = message.getAction();
+		if (field6.length > 0) {
+			writer.writeString(6, field6);
+		}
+		const field7 = message.getTarget();
+		if (field7.length > 0) {
+			writer.writeString(7, field7);
+		}
+		const field8 = message.getSourceIp();
+		if (field8.length > 0) {
+			writer.writeString(8, field8);
+		}
+		const field9 = message.getDetails();
+		if (field9.length > 0) {
+			writer.writeString(9, field9);
+		}
+		const field10 = message.getActorEmail();
+		if (field10.length > 0) {
+			writer.writeString(10, field10);
+		}
+	}
+
+	static deserializeBinary(bytes: Uint8Array): AuditEvent {
+		var reader = new jspb.BinaryReader(bytes);
+		var message = new AuditEvent();
+		return AuditEvent.deserializeBinaryFromReader(message, reader);
+	}
+
+	static deserializeBinaryFromReader(message: AuditEvent, reader: jspb.BinaryReader): AuditEvent {
+		while (reader.nextField()) {
+			if (reader.isEndGroup()) {
+				break;
+			}
+			const field = reader.getFieldNumber();
+			switch (field) {
+			case 1:
+				const field1 = reader.readUint64()
+				message.setId(field1);
+				break;
+			case 2:
+				const field2 = new googleProtobufTimestamp.Timestamp();
+				reader.readMessage(field2, googleProtobufTimestamp.Timestamp.deserializeBinaryFromReader);
+				message.setTime(field2);
+				break;
+			case 3:
+				const field3 = reader.readString()
+				message.setActor(field3);
+				break;
+			case 4:
+				const field4 = reader.readString()
+				message.setActorName(field4);
+				break;
+			case 5:
+				const field5 = reader.readString()
+				message.setActorProvider(field5);
+				break;
+			case 6:
+				const field6 = reader.readString()
+				message.setAction(field6);
+				break;
+			case 7:
+				const field7 = reader.readString()
+				message.setTarget(field7);
+				break;
+			case 8:
+				const field8 = reader.readString()
+				message.setSourceIp(field8);
+				break;
+			case 9:
+				const field9 = reader.readString()
+				message.setDetails(field9);
+				break;
+			case 10:
+				const field10 = reader.readString()
+				message.setActorEmail(field10);
+				break;
+			default:
+				reader.skipField();
+				break;
+			}
+		}
+		return message;
+	}
+
+}
+export declare namespace ListAuditEventsReq {
+	export type AsObject = {
+		actor: string,
+		action: string,
+		target: string,
+		since?: googleProtobufTimestamp.Timestamp.AsObject,
+		until?: googleProtobufTimestamp.Timestamp.AsObject,
+		actorEmail: string,
+		pageSize: number,
+		pageToken: string,
+	}
+}
+
+export class ListAuditEventsReq extends jspb.Message {
+
+	private static repeatedFields_ = [
+		
+	];
+
+	constructor(data?: jspb.Message.MessageArray) {
+		super();
+		jspb.Message.initialize(this, data || [], 0, -1, ListAuditEventsReq.repeatedFields_, null);
+	}
+
+
+	getActor(): string {return jspb.Message.getFieldWithDefault(this, 1, "");
+	}
+
+	setActor(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 1, value);
+	}
+
+	getAction(): string {return jspb.Message.getFieldWithDefault(this, 2, "");
+	}
+
+	setAction(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 2, value);
+	}
+
+	getTarget(): string {return jspb.Message.getFieldWithDefault(this, 3, "");
+	}
+
+	setTarget(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 3, value);
+	}
+
+	getSince(): googleProtobufTimestamp.Timestamp {
+		return jspb.Message.getWrapperField(this, googleProtobufTimestamp.Timestamp, 4);
+	}
+
+	setSince(value?: googleProtobufTimestamp.Timestamp): void {
+		(jspb.Message as any).setWrapperField(this, 4, value);
+	}
+
+	getUntil(): googleProtobufTimestamp.Timestamp {
+		return jspb.Message.getWrapperField(this, googleProtobufTimestamp.Timestamp, 5);
+	}
+
+	setUntil(value?: googleProtobufTimestamp.Timestamp): void {
+		(jspb.Message as any).setWrapperField(this, 5, value);
+	}
+
+	getActorEmail(): string {return jspb.Message.getFieldWithDefault(this, 8, "");
+	}
+
+	setActorEmail(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 8, value);
+	}
+
+	getPageSize(): number {return jspb.Message.getFieldWithDefault(this, 6, 0);
+	}
+
+	setPageSize(value: number): void {
+		(jspb.Message as any).setProto3IntField(this, 6, value);
+	}
+
+	getPageToken(): string {return jspb.Message.getFieldWithDefault(this, 7, "");
+	}
+
+	setPageToken(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 7, value);
+	}
+
+	serializeBinary(): Uint8Array {
+		const writer = new jspb.BinaryWriter();
+		ListAuditEventsReq.serializeBinaryToWriter(this, writer);
+		return writer.getResultBuffer();
+	}
+
+	toObject(): ListAuditEventsReq.AsObject {
+		let f: any;
+		return {
+			actor: this.getActor(),
+			action: this.getAction(),
+			target: this.getTarget(),
+			since: (f = this.getSince()) && f.toObject(),
+			until: (f = this.getUntil()) && f.toObject(),
+			actorEmail: this.getActorEmail(),
+			pageSize: this.getPageSize(),
+			pageToken: this.getPageToken(),
+		};
+	}
+
+	static serializeBinaryToWriter(message: ListAuditEventsReq, writer: jspb.BinaryWriter): void {
+		const field1 = message.getActor();
+		if (field1.length > 0) {
+			writer.writeString(1, field1);
+		}
+		const field2 = message.getAction();
+		if (field2.length > 0) {
+			writer.writeString(2, field2);
+		}
+		const field3 = message.getTarget();
+		if (field3.length > 0) {
+			writer.writeString(3, field3);
+		}
+		const field4 = message.getSince();
+		if (field4 != null) {
+			writer.writeMessage(4, field4, googleProtobufTimestamp.Timestamp.serializeBinaryToWriter);
+		}
+		const field5 = message.getUntil();
+		if (field5 != null) {
+			writer.writeMessage(5, field5, googleProtobufTimestamp.Timestamp.serializeBinaryToWriter);
+		}
+		const field8 = message.getActorEmail();
+		if (field8.length > 0) {
+			writer.writeString(8, field8);
+		}
+		const field6 = message.getPageSize();
+		if (field6 != 0) {
+			writer.writeInt32(6, field6);
+		}
+		const field7 = message.getPageToken();
+		if (field7.length > 0) {
+			writer.writeString(7, field7);
+		}
+	}
+
+	static deserializeBinary(bytes: Uint8Array): ListAuditEventsReq {
+		var reader = new jspb.BinaryReader(bytes);
+		var message = new ListAuditEventsReq();
+		return ListAuditEventsReq.deserializeBinaryFromReader(message, reader);
+	}
+
+	static deserializeBinaryFromReader(message: ListAuditEventsReq, reader: jspb.BinaryReader): ListAuditEventsReq {
+		while (reader.nextField()) {
+			if (reader.isEndGroup()) {
+				break;
+			}
+			const field = reader.getFieldNumber();
+			switch (field) {
+			case 1:
+				const field1 = reader.readString()
+				message.setActor(field1);
+				break;
+			case 2:
+				const field2 = reader.readString()
+				message.setAction(field2);
+				break;
+			case 3:
+				const field3 = reader.readString()
+				message.setTarget(field3);
+				break;
+			case 4:
+				const field4 = new googleProtobufTimestamp.Timestamp();
+				reader.readMessage(field4, googleProtobufTimestamp.Timestamp.deserializeBinaryFromReader);
+				message.setSince(field4);
+				break;
+			case 5:
+				const field5 = new googleProtobufTimestamp.Timestamp();
+				reader.readMessage(field5, googleProtobufTimestamp.Timestamp.deserializeBinaryFromReader);
+				message.setUntil(field5);
+				break;
+			case 8:
+				const field8 = reader.readString()
+				message.setActorEmail(field8);
+				break;
+			case 6:
+				const field6 = reader.readInt32()
+				message.setPageSize(field6);
+				break;
+			case 7:
+				const field7 = reader.readString()
+				message.setPageToken(field7);
+				break;
+			default:
+				reader.skipField();
+				break;
+			}
+		}
+		return message;
+	}
+
+}
+export declare namespace ListAuditEventsRes {
+	export type AsObject = {
+		items: Array<AuditEvent.AsObject>,
+		nextPageToken: string,
+	}
+}
+
+export class ListAuditEventsRes extends jspb.Message {
+
+	private static repeatedFields_ = [
+		1,
+	];
+
+	constructor(data?: jspb.Message.MessageArray) {
+		super();
+		jspb.Message.initialize(this, data || [], 0, -1, ListAuditEventsRes.repeatedFields_, null);
+	}
+
+
+	getItems(): Array<AuditEvent> {
+		return jspb.Message.getRepeatedWrapperField(this, AuditEvent, 1);
+	}
+
+	setItems(value: Array<AuditEvent>): void {
+		(jspb.Message as any).setRepeatedWrapperField(this, 1, value);
+	}
+
+	addItems(value?: AuditEvent, index?: number): AuditEvent {
+		return jspb.Message.addToRepeatedWrapperField(this, 1, value, AuditEvent, index);
+	}
+
+	getNextPageToken(): string {return jspb.Message.getFieldWithDefault(this, 2, "");
+	}
+
+	setNextPageToken(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 2, value);
+	}
+
+	serializeBinary(): Uint8Array {
+		const writer = new jspb.BinaryWriter();
+		ListAuditEventsRes.serializeBinaryToWriter(this, writer);
+		return writer.getResultBuffer();
+	}
+
+	toObject(): ListAuditEventsRes.AsObject {
+		let f: any;
+		return {
+			items: this.getItems().map((item) => item.toObject()),
+			nextPageToken: this.getNextPageToken(),
+		};
+	}
+
+	static serializeBinaryToWriter(message: ListAuditEventsRes, writer: jspb.BinaryWriter): void {
+		const field1 = message.getItems();
+		if (field1.length > 0) {
+			writer.writeRepeatedMessage(1, field1, AuditEvent.serializeBinaryToWriter);
+		}
+		const field2 = message.getNextPageToken();
+		if (field2.length > 0) {
+			writer.writeString(2, field2);
+		}
+	}
+
+	static deserializeBinary(bytes: Uint8Array): ListAuditEventsRes {
+		var reader = new jspb.BinaryReader(bytes);
+		var message = new ListAuditEventsRes();
+		return ListAuditEventsRes.deserializeBinaryFromReader(message, reader);
+	}
+
+	static deserializeBinaryFromReader(message: ListAuditEventsRes, reader: jspb.BinaryReader): ListAuditEventsRes {
+		while (reader.nextField()) {
+			if (reader.isEndGroup()) {
+				break;
+			}
+			const field = reader.getFieldNumber();
+			switch (field) {
+			case 1:
+				const field1 = new AuditEvent();
+				reader.readMessage(field1, AuditEvent.deserializeBinaryFromReader);
+				message.addItems(field1);
+				break;
+			case 2:
+				const field2 = reader.readString()
+				message.setNextPageToken(field2);
+				break;
+			default:
+				reader.skipField();
+				break;
+			}
+		}
+		return message;
+	}
+
+}
+
+
+function AuditEventFromObject(obj: AuditEvent.AsObject | undefined): AuditEvent | undefined {
+	if (obj === undefined) {
+		return undefined;
+	}
+	const message = new AuditEvent();
+	message.setId(obj.id);
+	message.setTime(TimestampFromObject(obj.time));
+	message.setActor(obj.actor);
+	message.setActorName(obj.actorName);
+	message.setActorProvider(obj.actorProvider);
+	message.setAction(obj.action);
+	message.setTarget(obj.target);
+	message.setSourceIp(obj.sourceIp);
+	message.setDetails(obj.details);
+	message.setActorEmail(obj.actorEmail);
+	return message;
+}
+
+function TimestampFromObject(obj: googleProtobufTimestamp.Timestamp.AsObject | undefined): googleProtobufTimestamp.Timestamp | undefined {
+	if (obj === undefined) {
+		return undefined;
+	}
+	const message = new googleProtobufTimestamp.Timestamp();
+	message.setSeconds(obj.seconds);
+	message.setNanos(obj.nanos);
+	return message;
+}
+
+function ListAuditEventsReqFromObject(obj: ListAuditEventsReq.AsObject | undefined): ListAuditEventsReq | undefined {
+	if (obj === undefined) {
+		return undefined;
+	}
+	const message = new ListAuditEventsReq();
+	message.setActor(obj.actor);
+	message.setAction(obj.action);
+	message.setTarget(obj.target);
+	message.setSince(TimestampFromObject(obj.since));
+	message.setUntil(TimestampFromObject(obj.until));
+	message.setActorEmail(obj.actorEmail);
+	message.setPageSize(obj.pageSize);
+	message.setPageToken(obj.pageToken);
+	return message;
+}
+
+function ListAuditEventsResFromObject(obj: ListAuditEventsRes.AsObject | undefined): ListAuditEventsRes | undefined {
+	if (obj === undefined) {
+		return undefined;
+	}
+	const message = new ListAuditEventsRes();
+	(obj.items || [])
+		.map((item) => AuditEventFromObject(item))
+		.forEach((item) => message.addItems(item));
+	message.setNextPageToken(obj.nextPageToken);
+	return message;
+}
+
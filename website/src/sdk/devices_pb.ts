@@ -8,6 +8,7 @@ import * as grpcWeb from 'grpc-web';
 import * as googleProtobufWrappers from 'google-protobuf/google/protobuf/wrappers_pb';
 import * as googleProtobufTimestamp from 'google-protobuf/google/protobuf/timestamp_pb';
 import * as googleProtobufEmpty from 'google-protobuf/google/protobuf/empty_pb';
+import * as googleProtobufDuration from 'google-protobuf/google/protobuf/duration_pb';
 
 export class Devices {
 
@@ -24,6 +25,15 @@ export class Devices {
 		Device.deserializeBinary
 	);
 
+	private methodInfoCreateDeviceWithConfig = new grpcWeb.MethodDescriptor<CreateDeviceWithConfigReq, CreateDeviceWithConfigRes>(
+		"CreateDeviceWithConfig",
+		null,
+		CreateDeviceWithConfigReq,
+		CreateDeviceWithConfigRes,
+		(req: CreateDeviceWithConfigReq) => req.serializeBinary(),
+		CreateDeviceWithConfigRes.deserializeBinary
+	);
+
 	private methodInfoListDevices = new grpcWeb.MethodDescriptor<ListDevicesReq, ListDevicesRes>(
 		"ListDevices",
 		null,
@@ -33,6 +43,15 @@ export class Devices {
 		ListDevicesRes.deserializeBinary
 	);
 
+	private methodInfoWatchDevices = new grpcWeb.MethodDescriptor<WatchDevicesReq, DeviceEvent>(
+		"WatchDevices",
+		null,
+		WatchDevicesReq,
+		DeviceEvent,
+		(req: WatchDevicesReq) => req.serializeBinary(),
+		DeviceEvent.deserializeBinary
+	);
+
 	private methodInfoDeleteDevice = new grpcWeb.MethodDescriptor<DeleteDeviceReq, googleProtobufEmpty.Empty>(
 		"DeleteDevice",
 		null,
@@ -42,6 +61,51 @@ export class Devices {
 		googleProtobufEmpty.Empty.deserializeBinary
 	);
 
+	private methodInfoUpdateDevice = new grpcWeb.MethodDescriptor<UpdateDeviceReq, Device>(
+		"UpdateDevice",
+		null,
+		UpdateDeviceReq,
+		Device,
+		(req: UpdateDeviceReq) => req.serializeBinary(),
+		Device.deserializeBinary
+	);
+
+	private methodInfoRenewDevice = new grpcWeb.MethodDescriptor<RenewDeviceReq, Device>(
+		"RenewDevice",
+		null,
+		RenewDeviceReq,
+		Device,
+		(req: RenewDeviceReq) => req.serializeBinary(),
+		Device.deserializeBinary
+	);
+
+	private methodInfoGetUsage = new grpcWeb.MethodDescriptor<GetUsageReq, GetUsageRes>(
+		"GetUsage",
+		null,
+		GetUsageReq,
+		GetUsageRes,
+		(req: GetUsageReq) => req.serializeBinary(),
+		GetUsageRes.deserializeBinary
+	);
+
+	private methodInfoSuspendDevice = new grpcWeb.MethodDescriptor<SuspendDeviceReq, Device>(
+		"SuspendDevice",
+		null,
+		SuspendDeviceReq,
+		Device,
+		(req: SuspendDeviceReq) => req.serializeBinary(),
+		Device.deserializeBinary
+	);
+
+	private methodInfoResumeDevice = new grpcWeb.MethodDescriptor<ResumeDeviceReq, Device>(
+		"ResumeDevice",
+		null,
+		ResumeDeviceReq,
+		Device,
+		(req: ResumeDeviceReq) => req.serializeBinary(),
+		Device.deserializeBinary
+	);
+
 	private methodInfoListAllDevices = new grpcWeb.MethodDescriptor<ListAllDevicesReq, ListAllDevicesRes>(
 		"ListAllDevices",
 		null,
@@ -51,6 +115,15 @@ export class Devices {
 		ListAllDevicesRes.deserializeBinary
 	);
 
+	private methodInfoListPendingDeletions = new grpcWeb.MethodDescriptor<ListPendingDeletionsReq, ListPendingDeletionsRes>(
+		"ListPendingDeletions",
+		null,
+		ListPendingDeletionsReq,
+		ListPendingDeletionsRes,
+		(req: ListPendingDeletionsReq) => req.serializeBinary(),
+		ListPendingDeletionsRes.deserializeBinary
+	);
+
 	constructor(
 		private hostname: string,
 		private defaultMetadata?: () => grpcWeb.Metadata,
@@ -75,6 +148,25 @@ export class Devices {
 		});
 	}
 
+	createDeviceWithConfig(req: CreateDeviceWithConfigReq.AsObject, metadata?: grpcWeb.Metadata): Promise<CreateDeviceWithConfigRes.AsObject> {
+		return new Promise((resolve, reject) => {
+			const message = CreateDeviceWithConfigReqFromObject(req);
+			this.client_.rpcCall(
+				this.hostname + '/proto.Devices/CreateDeviceWithConfig',
+				message,
+				Object.assign({}, this.defaultMetadata ? this.defaultMetadata() : {}, metadata),
+				this.methodInfoCreateDeviceWithConfig,
+				(err: grpcWeb.Error, res: CreateDeviceWithConfigRes) => {
+					if (err) {
+						reject(err);
+					} else {
+						resolve(res.toObject());
+					}
+				},
+			);
+		});
+	}
+
 	listDevices(req: ListDevicesReq.AsObject, metadata?: grpcWeb.Metadata): Promise<ListDevicesRes.AsObject> {
 		return new Promise((resolve, reject) => {
 			const message = ListDevicesReqFromObject(req);
@@ -94,6 +186,22 @@ export class Devices {
 		});
 	}
 
+	watchDevices(req: WatchDevicesReq.AsObject, onMessage: (res: DeviceEvent.AsObject) => void, onError: (err: grpcWeb.Error) => void, onEnd?: () => void, metadata?: grpcWeb.Metadata): grpcWeb.ClientReadableStream<DeviceEvent> {
+		const message = WatchDevicesReqFromObject(req);
+		const stream = this.client_.serverStreaming(
+			this.hostname + '/proto.Devices/WatchDevices',
+			message,
+			Object.assign({}, this.defaultMetadata ? this.defaultMetadata() : {}, metadata),
+			this.methodInfoWatchDevices,
+		);
+		stream.on('data', (res: DeviceEvent) => onMessage(res.toObject()));
+		stream.on('error', onError);
+		if (onEnd) {
+			stream.on('end', onEnd);
+		}
+		return stream;
+	}
+
 	deleteDevice(req: DeleteDeviceReq.AsObject, metadata?: grpcWeb.Metadata): Promise<googleProtobufEmpty.Empty.AsObject> {
 		return new Promise((resolve, reject) => {
 			const message = DeleteDeviceReqFromObject(req);
@@ -113,6 +221,101 @@ export class Devices {
 		});
 	}
 
+	updateDevice(req: UpdateDeviceReq.AsObject, metadata?: grpcWeb.Metadata): Promise<Device.AsObject> {
+		return new Promise((resolve, reject) => {
+			const message = UpdateDeviceReqFromObject(req);
+			this.client_.rpcCall(
+				this.hostname + '/proto.Devices/UpdateDevice',
+				message,
+				Object.assign({}, this.defaultMetadata ? this.defaultMetadata() : {}, metadata),
+				this.methodInfoUpdateDevice,
+				(err: grpcWeb.Error, res: Device) => {
+					if (err) {
+						reject(err);
+					} else {
+						resolve(res.toObject());
+					}
+				},
+			);
+		});
+	}
+
+	renewDevice(req: RenewDeviceReq.AsObject, metadata?: grpcWeb.Metadata): Promise<Device.AsObject> {
+		return new Promise((resolve, reject) => {
+			const message = RenewDeviceReqFromObject(req);
+			this.client_.rpcCall(
+				this.hostname + '/proto.Devices/RenewDevice',
+				message,
+				Object.assign({}, this.defaultMetadata ? this.defaultMetadata() : {}, metadata),
+				this.methodInfoRenewDevice,
+				(err: grpcWeb.Error, res: Device) => {
+					if (err) {
+						reject(err);
+					} else {
+						resolve(res.toObject());
+					}
+				},
+			);
+		});
+	}
+
+	getUsage(req: GetUsageReq.AsObject, metadata?: grpcWeb.Metadata): Promise<GetUsageRes.AsObject> {
+		return new Promise((resolve, reject) => {
+			const message = GetUsageReqFromObject(req);
+			this.client_.rpcCall(
+				this.hostname + '/proto.Devices/GetUsage',
+				message,
+				Object.assign({}, this.defaultMetadata ? this.defaultMetadata() : {}, metadata),
+				this.methodInfoGetUsage,
+				(err: grpcWeb.Error, res: GetUsageRes) => {
+					if (err) {
+						reject(err);
+					} else {
+						resolve(res.toObject());
+					}
+				},
+			);
+		});
+	}
+
+	suspendDevice(req: SuspendDeviceReq.AsObject, metadata?: grpcWeb.Metadata): Promise<Device.AsObject> {
+		return new Promise((resolve, reject) => {
+			const message = SuspendDeviceReqFromObject(req);
+			this.client_.rpcCall(
+				this.hostname + '/proto.Devices/SuspendDevice',
+				message,
+				Object.assign({}, this.defaultMetadata ? this.defaultMetadata() : {}, metadata),
+				this.methodInfoSuspendDevice,
+				(err: grpcWeb.Error, res: Device) => {
+					if (err) {
+						reject(err);
+					} else {
+						resolve(res.toObject());
+					}
+				},
+			);
+		});
+	}
+
+	resumeDevice(req: ResumeDeviceReq.AsObject, metadata?: grpcWeb.Metadata): Promise<Device.AsObject> {
+		return new Promise((resolve, reject) => {
+			const message = ResumeDeviceReqFromObject(req);
+			this.client_.rpcCall(
+				this.hostname + '/proto.Devices/ResumeDevice',
+				message,
+				Object.assign({}, this.defaultMetadata ? this.defaultMetadata() : {}, metadata),
+				this.methodInfoResumeDevice,
+				(err: grpcWeb.Error, res: Device) => {
+					if (err) {
+						reject(err);
+					} else {
+						resolve(res.toObject());
+					}
+				},
+			);
+		});
+	}
+
 	listAllDevices(req: ListAllDevicesReq.AsObject, metadata?: grpcWeb.Metadata): Promise<ListAllDevicesRes.AsObject> {
 		return new Promise((resolve, reject) => {
 			const message = ListAllDevicesReqFromObject(req);
@@ -132,6 +335,25 @@ export class Devices {
 		});
 	}
 
+	listPendingDeletions(req: ListPendingDeletionsReq.AsObject, metadata?: grpcWeb.Metadata): Promise<ListPendingDeletionsRes.AsObject> {
+		return new Promise((resolve, reject) => {
+			const message = ListPendingDeletionsReqFromObject(req);
+			this.client_.rpcCall(
+				this.hostname + '/proto.Devices/ListPendingDeletions',
+				message,
+				Object.assign({}, this.defaultMetadata ? this.defaultMetadata() : {}, metadata),
+				this.methodInfoListPendingDeletions,
+				(err: grpcWeb.Error, res: ListPendingDeletionsRes) => {
+					if (err) {
+						reject(err);
+					} else {
+						resolve(res.toObject());
+					}
+				},
+			);
+		});
+	}
+
 }
 
 
@@ -153,6 +375,15 @@ export declare namespace Device {
 		ownerEmail: string,
 		ownerProvider: string,
 		presharedKey: string,
+		accessPolicy?: AccessPolicy.AsObject,
+		expiresAt?: googleProtobufTimestamp.Timestamp.AsObject,
+		suspended: boolean,
+		staleSince?: googleProtobufTimestamp.Timestamp.AsObject,
+		deletionTime?: googleProtobufTimestamp.Timestamp.AsObject,
+		overQuota: boolean,
+		profile: string,
+		gateway: boolean,
+		routes: string,
 	}
 }
 
@@ -268,6 +499,73 @@ export class Device extends jspb.Message {
 		(jspb.Message as any).setProto3StringField(this, 14, value);
 	}
 
+	getAccessPolicy(): AccessPolicy {
+		return jspb.Message.getWrapperField(this, AccessPolicy, 15);
+	}
+
+	setAccessPolicy(value?: AccessPolicy): void {
+		(jspb.Message as any).setWrapperField(this, 15, value);
+	}
+
+	getExpiresAt(): googleProtobufTimestamp.Timestamp {
+		return jspb.Message.getWrapperField(this, googleProtobufTimestamp.Timestamp, 16);
+	}
+
+	setExpiresAt(value?: googleProtobufTimestamp.Timestamp): void {
+		(jspb.Message as any).setWrapperField(this, 16, value);
+	}
+
+	getSuspended(): boolean {return jspb.Message.getFieldWithDefault(this, 17, false);
+	}
+
+	setSuspended(value: boolean): void {
+		(jspb.Message as any).setProto3BooleanField(this, 17, value);
+	}
+
+	getStaleSince(): googleProtobufTimestamp.Timestamp {
+		return jspb.Message.getWrapperField(this, googleProtobufTimestamp.Timestamp, 18);
+	}
+
+	setStaleSince(value?: googleProtobufTimestamp.Timestamp): void {
+		(jspb.Message as any).setWrapperField(this, 18, value);
+	}
+
+	getDeletionTime(): googleProtobufTimestamp.Timestamp {
+		return jspb.Message.getWrapperField(this, googleProtobufTimestamp.Timestamp, 19);
+	}
+
+	setDeletionTime(value?: googleProtobufTimestamp.Timestamp): void {
+		(jspb.Message as any).setWrapperField(this, 19, value);
+	}
+
+	getOverQuota(): boolean {return jspb.Message.getFieldWithDefault(this, 20, false);
+	}
+
+	setOverQuota(value: boolean): void {
+		(jspb.Message as any).setProto3BooleanField(this, 20, value);
+	}
+
+	getProfile(): string {return jspb.Message.getFieldWithDefault(this, 21, "");
+	}
+
+	setProfile(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 21, value);
+	}
+
+	getGateway(): boolean {return jspb.Message.getFieldWithDefault(this, 22, false);
+	}
+
+	setGateway(value: boolean): void {
+		(jspb.Message as any).setProto3BooleanField(this, 22, value);
+	}
+
+	getRoutes(): string {return jspb.Message.getFieldWithDefault(this, 23, "");
+	}
+
+	setRoutes(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 23, value);
+	}
+
 	serializeBinary(): Uint8Array {
 		const writer = new jspb.BinaryWriter();
 		Device.serializeBinaryToWriter(this, writer);
@@ -291,6 +589,15 @@ export class Device extends jspb.Message {
 			ownerEmail: this.getOwnerEmail(),
 			ownerProvider: this.getOwnerProvider(),
 			presharedKey: this.getPresharedKey(),
+			accessPolicy: (f = this.getAccessPolicy()) && f.toObject(),
+			expiresAt: (f = this.getExpiresAt()) && f.toObject(),
+			suspended: this.getSuspended(),
+			staleSince: (f = this.getStaleSince()) && f.toObject(),
+			deletionTime: (f = this.getDeletionTime()) && f.toObject(),
+			overQuota: this.getOverQuota(),
+			profile: this.getProfile(),
+			gateway: this.getGateway(),
+			routes: this.getRoutes(),
 		};
 	}
 
@@ -351,6 +658,42 @@ export class Device extends jspb.Message {
 		if (field14.length > 0) {
 			writer.writeString(14, field14);
 		}
+		const field15 = message.getAccessPolicy();
+		if (field15 != null) {
+			writer.writeMessage(15, field15, AccessPolicy.serializeBinaryToWriter);
+		}
+		const field16 = message.getExpiresAt();
+		if (field16 != null) {
+			writer.writeMessage(16, field16, googleProtobufTimestamp.Timestamp.serializeBinaryToWriter);
+		}
+		const field17 = message.getSuspended();
+		if (field17 != false) {
+			writer.writeBool(17, field17);
+		}
+		const field18 = message.getStaleSince();
+		if (field18 != null) {
+			writer.writeMessage(18, field18, googleProtobufTimestamp.Timestamp.serializeBinaryToWriter);
+		}
+		const field19 = message.getDeletionTime();
+		if (field19 != null) {
+			writer.writeMessage(19, field19, googleProtobufTimestamp.Timestamp.serializeBinaryToWriter);
+		}
+		const field20 = message.getOverQuota();
+		if (field20 != false) {
+			writer.writeBool(20, field20);
+		}
+		const field21 = message.getProfile();
+		if (field21.length > 0) {
+			writer.writeString(21, field21);
+		}
+		const field22 = message.getGateway();
+		if (field22 != false) {
+			writer.writeBool(22, field22);
+		}
+		const field23 = message.getRoutes();
+		if (field23.length > 0) {
+			writer.writeString(23, field23);
+		}
 	}
 
 	static deserializeBinary(bytes: Uint8Array): Device {
@@ -424,6 +767,46 @@ export class Device extends jspb.Message {
 				const field14 = reader.readString()
 				message.setPresharedKey(field14);
 				break;
+			case 15:
+				const field15 = new AccessPolicy();
+				reader.readMessage(field15, AccessPolicy.deserializeBinaryFromReader);
+				message.setAccessPolicy(field15);
+				break;
+			case 16:
+				const field16 = new googleProtobufTimestamp.Timestamp();
+				reader.readMessage(field16, googleProtobufTimestamp.Timestamp.deserializeBinaryFromReader);
+				message.setExpiresAt(field16);
+				break;
+			case 17:
+				const field17 = reader.readBool()
+				message.setSuspended(field17);
+				break;
+			case 18:
+				const field18 = new googleProtobufTimestamp.Timestamp();
+				reader.readMessage(field18, googleProtobufTimestamp.Timestamp.deserializeBinaryFromReader);
+				message.setStaleSince(field18);
+				break;
+			case 19:
+				const field19 = new googleProtobufTimestamp.Timestamp();
+				reader.readMessage(field19, googleProtobufTimestamp.Timestamp.deserializeBinaryFromReader);
+				message.setDeletionTime(field19);
+				break;
+			case 20:
+				const field20 = reader.readBool()
+				message.setOverQuota(field20);
+				break;
+			case 21:
+				const field21 = reader.readString()
+				message.setProfile(field21);
+				break;
+			case 22:
+				const field22 = reader.readBool()
+				message.setGateway(field22);
+				break;
+			case 23:
+				const field23 = reader.readString()
+				message.setRoutes(field23);
+				break;
 			default:
 				reader.skipField();
 				break;
@@ -433,123 +816,1605 @@ export class Device extends jspb.Message {
 	}
 
 }
-export declare namespace AddDeviceReq {
+export declare namespace AccessPolicy {
 	export type AsObject = {
+		rules: Array<AccessRule.AsObject>,
 		name: string,
-		publicKey: string,
-		presharedKey: string,
-		manualIpAssignment: boolean,
-		manualIpv4Address: string,
-		manualIpv6Address: string,
+		clientIsolation: boolean,
 	}
 }
 
-export class AddDeviceReq extends jspb.Message {
+export class AccessPolicy extends jspb.Message {
 
 	private static repeatedFields_ = [
-		
+		1,
 	];
 
 	constructor(data?: jspb.Message.MessageArray) {
 		super();
-		jspb.Message.initialize(this, data || [], 0, -1, AddDeviceReq.repeatedFields_, null);
-	}
-
-
-	getName(): string {return jspb.Message.getFieldWithDefault(this, 1, "");
-	}
-
-	setName(value: string): void {
-		(jspb.Message as any).setProto3StringField(this, 1, value);
-	}
-
-	getPublicKey(): string {return jspb.Message.getFieldWithDefault(this, 2, "");
-	}
-
-	setPublicKey(value: string): void {
-		(jspb.Message as any).setProto3StringField(this, 2, value);
+		jspb.Message.initialize(this, data || [], 0, -1, AccessPolicy.repeatedFields_, null);
 	}
 
-	getPresharedKey(): string {return jspb.Message.getFieldWithDefault(this, 3, "");
-	}
 
-	setPresharedKey(value: string): void {
-		(jspb.Message as any).setProto3StringField(this, 3, value);
+	getRules(): Array<AccessRule> {
+		return jspb.Message.getRepeatedWrapperField(this, AccessRule, 1);
 	}
 
-	getManualIpAssignment(): boolean {return jspb.Message.getFieldWithDefault(this, 4, false);
+	setRules(value: Array<AccessRule>): void {
+		(jspb.Message as any).setRepeatedWrapperField(this, 1, value);
 	}
 
-	setManualIpAssignment(value: boolean): void {
-		(jspb.Message as any).setProto3BooleanField(this, 4, value);
+	addRules(value?: AccessRule, index?: number): AccessRule {
+		return jspb.Message.addToRepeatedWrapperField(this, 1, value, AccessRule, index);
 	}
 
-	getManualIpv4Address(): string {return jspb.Message.getFieldWithDefault(this, 5, "");
+	getName(): string {return jspb.Message.getFieldWithDefault(this, 2, "");
 	}
 
-	setManualIpv4Address(value: string): void {
-		(jspb.Message as any).setProto3StringField(this, 5, value);
+	setName(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 2, value);
 	}
 
-	getManualIpv6Address(): string {return jspb.Message.getFieldWithDefault(this, 6, "");
+	getClientIsolation(): boolean {return jspb.Message.getFieldWithDefault(this, 3, false);
 	}
 
-	setManualIpv6Address(value: string): void {
-		(jspb.Message as any).setProto3StringField(this, 6, value);
+	setClientIsolation(value: boolean): void {
+		(jspb.Message as any).setProto3BooleanField(this, 3, value);
 	}
 
 	serializeBinary(): Uint8Array {
 		const writer = new jspb.BinaryWriter();
-		AddDeviceReq.serializeBinaryToWriter(this, writer);
+		AccessPolicy.serializeBinaryToWriter(this, writer);
 		return writer.getResultBuffer();
 	}
 
-	toObject(): AddDeviceReq.AsObject {
+	toObject(): AccessPolicy.AsObject {
 		let f: any;
 		return {
+			rules: this.getRules().map((item) => item.toObject()),
 			name: this.getName(),
-			publicKey: this.getPublicKey(),
-			presharedKey: this.getPresharedKey(),
-			manualIpAssignment: this.getManualIpAssignment(),
-			manualIpv4Address: this.getManualIpv4Address(),
-			manualIpv6Address: this.getManualIpv6Address(),
+			clientIsolation: this.getClientIsolation(),
 		};
 	}
 
-	static serializeBinaryToWriter(message: AddDeviceReq, writer: jspb.BinaryWriter): void {
-		const field1 = message.getName();
+	static serializeBinaryToWriter(message: AccessPolicy, writer: jspb.BinaryWriter): void {
+		const field1 = message.getRules();
 		if (field1.length > 0) {
-			writer.writeString(1, field1);
+			writer.writeRepeatedMessage(1, field1, AccessRule.serializeBinaryToWriter);
 		}
-		const field2 = message.getPublicKey();
+		const field2 = message.getName();
 		if (field2.length > 0) {
 			writer.writeString(2, field2);
 		}
-		const field3 = message.getPresharedKey();
-		if (field3.length > 0) {
-			writer.writeString(3, field3);
-		}
-		const field4 = message.getManualIpAssignment();
-		if (field4 != false) {
-			writer.writeBool(4, field4);
-		}
-		const field5 = message.getManualIpv4Address();
-		if (field5.length > 0) {
-			writer.writeString(5, field5);
+		const field3 = message.getClientIsolation();
+		if (field3 != false) {
+			writer.writeBool(3, field3);
 		}
-		const field6 = message.getManualIpv6Address();
-		if (field6.length > 0) {
+	}
+
+	static deserializeBinary(bytes: Uint8Array): AccessPolicy {
+		var reader = new jspb.BinaryReader(bytes);
+		var message = new AccessPolicy();
+		return AccessPolicy.deserializeBinaryFromReader(message, reader);
+	}
+
+	static deserializeBinaryFromReader(message: AccessPolicy, reader: jspb.BinaryReader): AccessPolicy {
+		while (reader.nextField()) {
+			if (reader.isEndGroup()) {
+				break;
+			}
+			const field = reader.getFieldNumber();
+			switch (field) {
+			case 1:
+				const field1 = new AccessRule();
+				reader.readMessage(field1, AccessRule.deserializeBinaryFromReader);
+				message.addRules(field1);
+				break;
+			case 2:
+				const field2 = reader.readString()
+				message.setName(field2);
+				break;
+			case 3:
+				const field3 = reader.readBool()
+				message.setClientIsolation(field3);
+				break;
+			default:
+				reader.skipField();
+				break;
+			}
+		}
+		return message;
+	}
+
+}
+export declare namespace AccessRule {
+	export type AsObject = {
+		cidr: string,
+		protocol: string,
+		ports: string,
+	}
+}
+
+export class AccessRule extends jspb.Message {
+
+	private static repeatedFields_ = [
+		
+	];
+
+	constructor(data?: jspb.Message.MessageArray) {
+		super();
+		jspb.Message.initialize(this, data || [], 0, -1, AccessRule.repeatedFields_, null);
+	}
+
+
+	getCidr(): string {return jspb.Message.getFieldWithDefault(this, 1, "");
+	}
+
+	setCidr(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 1, value);
+	}
+
+	getProtocol(): string {return jspb.Message.getFieldWithDefault(this, 2, "");
+	}
+
+	setProtocol(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 2, value);
+	}
+
+	getPorts(): string {return jspb.Message.getFieldWithDefault(this, 3, "");
+	}
+
+	setPorts(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 3, value);
+	}
+
+	serializeBinary(): Uint8Array {
+		const writer = new jspb.BinaryWriter();
+		AccessRule.serializeBinaryToWriter(this, writer);
+		return writer.getResultBuffer();
+	}
+
+	toObject(): AccessRule.AsObject {
+		let f: any;
+		return {
+			cidr: this.getCidr(),
+			protocol: this.getProtocol(),
+			ports: this.getPorts(),
+		};
+	}
+
+	static serializeBinaryToWriter(message: AccessRule, writer: jspb.BinaryWriter): void {
+		const field1 = message.getCidr();
+		if (field1.length > 0) {
+			writer.writeString(1, field1);
+		}
+		const field2 = message.getProtocol();
+		if (field2.length > 0) {
+			writer.writeString(2, field2);
+		}
+		const field3 = message.getPorts();
+		if (field3.length > 0) {
+			writer.writeString(3, field3);
+		}
+	}
+
+	static deserializeBinary(bytes: Uint8Array): AccessRule {
+		var reader = new jspb.BinaryReader(bytes);
+		var message = new AccessRule();
+		return AccessRule.deserializeBinaryFromReader(message, reader);
+	}
+
+	static deserializeBinaryFromReader(message: AccessRule, reader: jspb.BinaryReader): AccessRule {
+		while (reader.nextField()) {
+			if (reader.isEndGroup()) {
+				break;
+			}
+			const field = reader.getFieldNumber();
+			switch (field) {
+			case 1:
+				const field1 = reader.readString()
+				message.setCidr(field1);
+				break;
+			case 2:
+				const field2 = reader.readString()
+				message.setProtocol(field2);
+				break;
+			case 3:
+				const field3 = reader.readString()
+				message.setPorts(field3);
+				break;
+			default:
+				reader.skipField();
+				break;
+			}
+		}
+		return message;
+	}
+
+}
+export declare namespace AddDeviceReq {
+	export type AsObject = {
+		name: string,
+		publicKey: string,
+		presharedKey: string,
+		manualIpAssignment: boolean,
+		manualIpv4Address: string,
+		manualIpv6Address: string,
+		accessPolicy?: AccessPolicy.AsObject,
+		expiresAt?: googleProtobufTimestamp.Timestamp.AsObject,
+		profile: string,
+		gateway: boolean,
+		routes: string,
+	}
+}
+
+export class AddDeviceReq extends jspb.Message {
+
+	private static repeatedFields_ = [
+		
+	];
+
+	constructor(data?: jspb.Message.MessageArray) {
+		super();
+		jspb.Message.initialize(this, data || [], 0, -1, AddDeviceReq.repeatedFields_, null);
+	}
+
+
+	getName(): string {return jspb.Message.getFieldWithDefault(this, 1, "");
+	}
+
+	setName(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 1, value);
+	}
+
+	getPublicKey(): string {return jspb.Message.getFieldWithDefault(this, 2, "");
+	}
+
+	setPublicKey(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 2, value);
+	}
+
+	getPresharedKey(): string {return jspb.Message.getFieldWithDefault(this, 3, "");
+	}
+
+	setPresharedKey(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 3, value);
+	}
+
+	getManualIpAssignment(): boolean {return jspb.Message.getFieldWithDefault(this, 4, false);
+	}
+
+	setManualIpAssignment(value: boolean): void {
+		(jspb.Message as any).setProto3BooleanField(this, 4, value);
+	}
+
+	getManualIpv4Address(): string {return jspb.Message.getFieldWithDefault(this, 5, "");
+	}
+
+	setManualIpv4Address(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 5, value);
+	}
+
+	getManualIpv6Address(): string {return jspb.Message.getFieldWithDefault(this, 6, "");
+	}
+
+	setManualIpv6Address(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 6, value);
+	}
+
+	getAccessPolicy(): AccessPolicy {
+		return jspb.Message.getWrapperField(this, AccessPolicy, 7);
+	}
+
+	setAccessPolicy(value?: AccessPolicy): void {
+		(jspb.Message as any).setWrapperField(this, 7, value);
+	}
+
+	getExpiresAt(): googleProtobufTimestamp.Timestamp {
+		return jspb.Message.getWrapperField(this, googleProtobufTimestamp.Timestamp, 8);
+	}
+
+	setExpiresAt(value?: googleProtobufTimestamp.Timestamp): void {
+		(jspb.Message as any).setWrapperField(this, 8, value);
+	}
+
+	getProfile(): string {return jspb.Message.getFieldWithDefault(this, 9, "");
+	}
+
+	setProfile(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 9, value);
+	}
+
+	getGateway(): boolean {return jspb.Message.getFieldWithDefault(this, 10, false);
+	}
+
+	setGateway(value: boolean): void {
+		(jspb.Message as any).setProto3BooleanField(this, 10, value);
+	}
+
+	getRoutes(): string {return jspb.Message.getFieldWithDefault(this, 11, "");
+	}
+
+	setRoutes(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 11, value);
+	}
+
+	serializeBinary(): Uint8Array {
+		const writer = new jspb.BinaryWriter();
+		AddDeviceReq.serializeBinaryToWriter(this, writer);
+		return writer.getResultBuffer();
+	}
+
+	toObject(): AddDeviceReq.AsObject {
+		let f: any;
+		return {
+			name: this.getName(),
+			publicKey: this.getPublicKey(),
+			presharedKey: this.getPresharedKey(),
+			manualIpAssignment: this.getManualIpAssignment(),
+			manualIpv4Address: this.getManualIpv4Address(),
+			manualIpv6Address: this.getManualIpv6Address(),
+			accessPolicy: (f = this.getAccessPolicy()) && f.toObject(),
+			expiresAt: (f = this.getExpiresAt()) && f.toObject(),
+			profile: this.getProfile(),
+			gateway: this.getGateway(),
+			routes: this.getRoutes(),
+		};
+	}
+
+	static serializeBinaryToWriter(message: AddDeviceReq, writer: jspb.BinaryWriter): void {
+		const field1 = message.getName();
+		if (field1.length > 0) {
+			writer.writeString(1, field1);
+		}
+		const field2 = message.getPublicKey();
+		if (field2.length > 0) {
+			writer.writeString(2, field2);
+		}
+		const field3 = message.getPresharedKey();
+		if (field3.length > 0) {
+			writer.writeString(3, field3);
+		}
+		const field4 = message.getManualIpAssignment();
+		if (field4 != false) {
+			writer.writeBool(4, field4);
+		}
+		const field5 = message.getManualIpv4Address();
+		if (field5.length > 0) {
+			writer.writeString(5, field5);
+		}
+		const field6 = message.getManualIpv6Address();
+		if (field6.length > 0) {
 			writer.writeString(6, field6);
 		}
+		const field7 = message.getAccessPolicy();
+		if (field7 != null) {
+			writer.writeMessage(7, field7, AccessPolicy.serializeBinaryToWriter);
+		}
+		const field8 = message.getExpiresAt();
+		if (field8 != null) {
+			writer.writeMessage(8, field8, googleProtobufTimestamp.Timestamp.serializeBinaryToWriter);
+		}
+		const field9 = message.getProfile();
+		if (field9.length > 0) {
+			writer.writeString(9, field9);
+		}
+		const field10 = message.getGateway();
+		if (field10 != false) {
+			writer.writeBool(10, field10);
+		}
+		const field11 = message.getRoutes();
+		if (field11.length > 0) {
+			writer.writeString(11, field11);
+		}
+	}
+
+	static deserializeBinary(bytes: Uint8Array): AddDeviceReq {
+		var reader = new jspb.BinaryReader(bytes);
+		var message = new AddDeviceReq();
+		return AddDeviceReq.deserializeBinaryFromReader(message, reader);
+	}
+
+	static deserializeBinaryFromReader(message: AddDeviceReq, reader: jspb.BinaryReader): AddDeviceReq {
+		while (reader.nextField()) {
+			if (reader.isEndGroup()) {
+				break;
+			}
+			const field = reader.getFieldNumber();
+			switch (field) {
+			case 1:
+				const field1 = reader.readString()
+				message.setName(field1);
+				break;
+			case 2:
+				const field2 = reader.readString()
+				message.setPublicKey(field2);
+				break;
+			case 3:
+				const field3 = reader.readString()
+				message.setPresharedKey(field3);
+				break;
+			case 4:
+				const field4 = reader.readBool()
+				message.setManualIpAssignment(field4);
+				break;
+			case 5:
+				const field5 = reader.readString()
+				message.setManualIpv4Address(field5);
+				break;
+			case 6:
+				const field6 = reader.readString()
+				message.setManualIpv6Address(field6);
+				break;
+			case 7:
+				const field7 = new AccessPolicy();
+				reader.readMessage(field7, AccessPolicy.deserializeBinaryFromReader);
+				message.setAccessPolicy(field7);
+				break;
+			case 8:
+				const field8 = new googleProtobufTimestamp.Timestamp();
+				reader.readMessage(field8, googleProtobufTimestamp.Timestamp.deserializeBinaryFromReader);
+				message.setExpiresAt(field8);
+				break;
+			case 9:
+				const field9 = reader.readString()
+				message.setProfile(field9);
+				break;
+			case 10:
+				const field10 = reader.readBool()
+				message.setGateway(field10);
+				break;
+			case 11:
+				const field11 = reader.readString()
+				message.setRoutes(field11);
+				break;
+			default:
+				reader.skipField();
+				break;
+			}
+		}
+		return message;
+	}
+
+}
+export declare namespace CreateDeviceWithConfigReq {
+	export type AsObject = {
+		name: string,
+		usePresharedKey: boolean,
+		manualIpAssignment: boolean,
+		manualIpv4Address: string,
+		manualIpv6Address: string,
+		persistentKeepalive?: googleProtobufWrappers.Int32Value.AsObject,
+		accessPolicy?: AccessPolicy.AsObject,
+		expiresAt?: googleProtobufTimestamp.Timestamp.AsObject,
+		profile: string,
+		gateway: boolean,
+		routes: string,
+	}
+}
+
+export class CreateDeviceWithConfigReq extends jspb.Message {
+
+	private static repeatedFields_ = [
+		
+	];
+
+	constructor(data?: jspb.Message.MessageArray) {
+		super();
+		jspb.Message.initialize(this, data || [], 0, -1, CreateDeviceWithConfigReq.repeatedFields_, null);
+	}
+
+
+	getName(): string {return jspb.Message.getFieldWithDefault(this, 1, "");
+	}
+
+	setName(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 1, value);
+	}
+
+	getUsePresharedKey(): boolean {return jspb.Message.getFieldWithDefault(this, 2, false);
+	}
+
+	setUsePresharedKey(value: boolean): void {
+		(jspb.Message as any).setProto3BooleanField(this, 2, value);
+	}
+
+	getManualIpAssignment(): boolean {return jspb.Message.getFieldWithDefault(this, 3, false);
+	}
+
+	setManualIpAssignment(value: boolean): void {
+		(jspb.Message as any).setProto3BooleanField(this, 3, value);
+	}
+
+	getManualIpv4Address(): string {return jspb.Message.getFieldWithDefault(this, 4, "");
+	}
+
+	setManualIpv4Address(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 4, value);
+	}
+
+	getManualIpv6Address(): string {return jspb.Message.getFieldWithDefault(this, 5, "");
+	}
+
+	setManualIpv6Address(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 5, value);
+	}
+
+	getPersistentKeepalive(): googleProtobufWrappers.Int32Value {
+		return jspb.Message.getWrapperField(this, googleProtobufWrappers.Int32Value, 6);
+	}
+
+	setPersistentKeepalive(value?: googleProtobufWrappers.Int32Value): void {
+		(jspb.Message as any).setWrapperField(this, 6, value);
+	}
+
+	getAccessPolicy(): AccessPolicy {
+		return jspb.Message.getWrapperField(this, AccessPolicy, 7);
+	}
+
+	setAccessPolicy(value?: AccessPolicy): void {
+		(jspb.Message as any).setWrapperField(this, 7, value);
+	}
+
+	getExpiresAt(): googleProtobufTimestamp.Timestamp {
+		return jspb.Message.getWrapperField(this, googleProtobufTimestamp.Timestamp, 8);
+	}
+
+	setExpiresAt(value?: googleProtobufTimestamp.Timestamp): void {
+		(jspb.Message as any).setWrapperField(this, 8, value);
+	}
+
+	getProfile(): string {return jspb.Message.getFieldWithDefault(this, 9, "");
+	}
+
+	setProfile(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 9, value);
+	}
+
+	getGateway(): boolean {return jspb.Message.getFieldWithDefault(this, 10, false);
+	}
+
+	setGateway(value: boolean): void {
+		(jspb.Message as any).setProto3BooleanField(this, 10, value);
+	}
+
+	getRoutes(): string {return jspb.Message.getFieldWithDefault(this, 11, "");
+	}
+
+	setRoutes(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 11, value);
+	}
+
+	serializeBinary(): Uint8Array {
+		const writer = new jspb.BinaryWriter();
+		CreateDeviceWithConfigReq.serializeBinaryToWriter(this, writer);
+		return writer.getResultBuffer();
+	}
+
+	toObject(): CreateDeviceWithConfigReq.AsObject {
+		let f: any;
+		return {
+			name: this.getName(),
+			usePresharedKey: this.getUsePresharedKey(),
+			manualIpAssignment: this.getManualIpAssignment(),
+			manualIpv4Address: this.getManualIpv4Address(),
+			manualIpv6Address: this.getManualIpv6Address(),
+			persistentKeepalive: (f = this.getPersistentKeepalive()) && f.toObject(),
+			accessPolicy: (f = this.getAccessPolicy()) && f.toObject(),
+			expiresAt: (f = this.getExpiresAt()) && f.toObject(),
+			profile: this.getProfile(),
+			gateway: this.getGateway(),
+			routes: this.getRoutes(),
+		};
+	}
+
+	static serializeBinaryToWriter(message: CreateDeviceWithConfigReq, writer: jspb.BinaryWriter): void {
+		const field1 = message.getName();
+		if (field1.length > 0) {
+			writer.writeString(1, field1);
+		}
+		const field2 = message.getUsePresharedKey();
+		if (field2 != false) {
+			writer.writeBool(2, field2);
+		}
+		const field3 = message.getManualIpAssignment();
+		if (field3 != false) {
+			writer.writeBool(3, field3);
+		}
+		const field4 = message.getManualIpv4Address();
+		if (field4.length > 0) {
+			writer.writeString(4, field4);
+		}
+		const field5 = message.getManualIpv6Address();
+		if (field5.length > 0) {
+			writer.writeString(5, field5);
+		}
+		const field6 = message.getPersistentKeepalive();
+		if (field6 != null) {
+			writer.writeMessage(6, field6, googleProtobufWrappers.Int32Value.serializeBinaryToWriter);
+		}
+		const field7 = message.getAccessPolicy();
+		if (field7 != null) {
+			writer.writeMessage(7, field7, AccessPolicy.serializeBinaryToWriter);
+		}
+		const field8 = message.getExpiresAt();
+		if (field8 != null) {
+			writer.writeMessage(8, field8, googleProtobufTimestamp.Timestamp.serializeBinaryToWriter);
+		}
+		const field9 = message.getProfile();
+		if (field9.length > 0) {
+			writer.writeString(9, field9);
+		}
+		const field10 = message.getGateway();
+		if (field10 != false) {
+			writer.writeBool(10, field10);
+		}
+		const field11 = message.getRoutes();
+		if (field11.length > 0) {
+			writer.writeString(11, field11);
+		}
+	}
+
+	static deserializeBinary(bytes: Uint8Array): CreateDeviceWithConfigReq {
+		var reader = new jspb.BinaryReader(bytes);
+		var message = new CreateDeviceWithConfigReq();
+		return CreateDeviceWithConfigReq.deserializeBinaryFromReader(message, reader);
+	}
+
+	static deserializeBinaryFromReader(message: CreateDeviceWithConfigReq, reader: jspb.BinaryReader): CreateDeviceWithConfigReq {
+		while (reader.nextField()) {
+			if (reader.isEndGroup()) {
+				break;
+			}
+			const field = reader.getFieldNumber();
+			switch (field) {
+			case 1:
+				const field1 = reader.readString()
+				message.setName(field1);
+				break;
+			case 2:
+				const field2 = reader.readBool()
+				message.setUsePresharedKey(field2);
+				break;
+			case 3:
+				const field3 = reader.readBool()
+				message.setManualIpAssignment(field3);
+				break;
+			case 4:
+				const field4 = reader.readString()
+				message.setManualIpv4Address(field4);
+				break;
+			case 5:
+				const field5 = reader.readString()
+				message.setManualIpv6Address(field5);
+				break;
+			case 6:
+				const field6 = new googleProtobufWrappers.Int32Value();
+				reader.readMessage(field6, googleProtobufWrappers.Int32Value.deserializeBinaryFromReader);
+				message.setPersistentKeepalive(field6);
+				break;
+			case 7:
+				const field7 = new AccessPolicy();
+				reader.readMessage(field7, AccessPolicy.deserializeBinaryFromReader);
+				message.setAccessPolicy(field7);
+				break;
+			case 8:
+				const field8 = new googleProtobufTimestamp.Timestamp();
+				reader.readMessage(field8, googleProtobufTimestamp.Timestamp.deserializeBinaryFromReader);
+				message.setExpiresAt(field8);
+				break;
+			case 9:
+				const field9 = reader.readString()
+				message.setProfile(field9);
+				break;
+			case 10:
+				const field10 = reader.readBool()
+				message.setGateway(field10);
+				break;
+			case 11:
+				const field11 = reader.readString()
+				message.setRoutes(field11);
+				break;
+			default:
+				reader.skipField();
+				break;
+			}
+		}
+		return message;
+	}
+
+}
+export declare namespace CreateDeviceWithConfigRes {
+	export type AsObject = {
+		device?: Device.AsObject,
+		config: string,
+	}
+}
+
+export class CreateDeviceWithConfigRes extends jspb.Message {
+
+	private static repeatedFields_ = [
+		
+	];
+
+	constructor(data?: jspb.Message.MessageArray) {
+		super();
+		jspb.Message.initialize(this, data || [], 0, -1, CreateDeviceWithConfigRes.repeatedFields_, null);
+	}
+
+
+	getDevice(): Device {
+		return jspb.Message.getWrapperField(this, Device, 1);
+	}
+
+	setDevice(value?: Device): void {
+		(jspb.Message as any).setWrapperField(this, 1, value);
+	}
+
+	getConfig(): string {return jspb.Message.getFieldWithDefault(this, 2, "");
+	}
+
+	setConfig(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 2, value);
+	}
+
+	serializeBinary(): Uint8Array {
+		const writer = new jspb.BinaryWriter();
+		CreateDeviceWithConfigRes.serializeBinaryToWriter(this, writer);
+		return writer.getResultBuffer();
+	}
+
+	toObject(): CreateDeviceWithConfigRes.AsObject {
+		let f: any;
+		return {
+			device: (f = this.getDevice()) && f.toObject(),
+			config: this.getConfig(),
+		};
+	}
+
+	static serializeBinaryToWriter(message: CreateDeviceWithConfigRes, writer: jspb.BinaryWriter): void {
+		const field1 = message.getDevice();
+		if (field1 != null) {
+			writer.writeMessage(1, field1, Device.serializeBinaryToWriter);
+		}
+		const field2 = message.getConfig();
+		if (field2.length > 0) {
+			writer.writeString(2, field2);
+		}
+	}
+
+	static deserializeBinary(bytes: Uint8Array): CreateDeviceWithConfigRes {
+		var reader = new jspb.BinaryReader(bytes);
+		var message = new CreateDeviceWithConfigRes();
+		return CreateDeviceWithConfigRes.deserializeBinaryFromReader(message, reader);
+	}
+
+	static deserializeBinaryFromReader(message: CreateDeviceWithConfigRes, reader: jspb.BinaryReader): CreateDeviceWithConfigRes {
+		while (reader.nextField()) {
+			if (reader.isEndGroup()) {
+				break;
+			}
+			const field = reader.getFieldNumber();
+			switch (field) {
+			case 1:
+				const field1 = new Device();
+				reader.readMessage(field1, Device.deserializeBinaryFromReader);
+				message.setDevice(field1);
+				break;
+			case 2:
+				const field2 = reader.readString()
+				message.setConfig(field2);
+				break;
+			default:
+				reader.skipField();
+				break;
+			}
+		}
+		return message;
+	}
+
+}
+export declare namespace ListDevicesReq {
+	export type AsObject = {
+	}
+}
+
+export class ListDevicesReq extends jspb.Message {
+
+	private static repeatedFields_ = [
+		
+	];
+
+	constructor(data?: jspb.Message.MessageArray) {
+		super();
+		jspb.Message.initialize(this, data || [], 0, -1, ListDevicesReq.repeatedFields_, null);
+	}
+
+
+	serializeBinary(): Uint8Array {
+		const writer = new jspb.BinaryWriter();
+		ListDevicesReq.serializeBinaryToWriter(this, writer);
+		return writer.getResultBuffer();
+	}
+
+	toObject(): ListDevicesReq.AsObject {
+		let f: any;
+		return {
+		};
+	}
+
+	static serializeBinaryToWriter(message: ListDevicesReq, writer: jspb.BinaryWriter): void {
+	}
+
+	static deserializeBinary(bytes: Uint8Array): ListDevicesReq {
+		var reader = new jspb.BinaryReader(bytes);
+		var message = new ListDevicesReq();
+		return ListDevicesReq.deserializeBinaryFromReader(message, reader);
+	}
+
+	static deserializeBinaryFromReader(message: ListDevicesReq, reader: jspb.BinaryReader): ListDevicesReq {
+		while (reader.nextField()) {
+			if (reader.isEndGroup()) {
+				break;
+			}
+			const field = reader.getFieldNumber();
+			switch (field) {
+			default:
+				reader.skipField();
+				break;
+			}
+		}
+		return message;
+	}
+
+}
+export declare namespace ListDevicesRes {
+	export type AsObject = {
+		items: Array<Device.AsObject>,
+	}
+}
+
+export class ListDevicesRes extends jspb.Message {
+
+	private static repeatedFields_ = [
+		1,
+	];
+
+	constructor(data?: jspb.Message.MessageArray) {
+		super();
+		jspb.Message.initialize(this, data || [], 0, -1, ListDevicesRes.repeatedFields_, null);
+	}
+
+
+	getItems(): Array<Device> {
+		return jspb.Message.getRepeatedWrapperField(this, Device, 1);
+	}
+
+	setItems(value: Array<Device>): void {
+		(jspb.Message as any).setRepeatedWrapperField(this, 1, value);
+	}
+
+	addItems(value?: Device, index?: number): Device {
+		return jspb.Message.addToRepeatedWrapperField(this, 1, value, Device, index);
+	}
+
+	serializeBinary(): Uint8Array {
+		const writer = new jspb.BinaryWriter();
+		ListDevicesRes.serializeBinaryToWriter(this, writer);
+		return writer.getResultBuffer();
+	}
+
+	toObject(): ListDevicesRes.AsObject {
+		let f: any;
+		return {
+			items: this.getItems().map((item) => item.toObject()),
+		};
+	}
+
+	static serializeBinaryToWriter(message: ListDevicesRes, writer: jspb.BinaryWriter): void {
+		const field1 = message.getItems();
+		if (field1.length > 0) {
+			writer.writeRepeatedMessage(1, field1, Device.serializeBinaryToWriter);
+		}
+	}
+
+	static deserializeBinary(bytes: Uint8Array): ListDevicesRes {
+		var reader = new jspb.BinaryReader(bytes);
+		var message = new ListDevicesRes();
+		return ListDevicesRes.deserializeBinaryFromReader(message, reader);
+	}
+
+	static deserializeBinaryFromReader(message: ListDevicesRes, reader: jspb.BinaryReader): ListDevicesRes {
+		while (reader.nextField()) {
+			if (reader.isEndGroup()) {
+				break;
+			}
+			const field = reader.getFieldNumber();
+			switch (field) {
+			case 1:
+				const field1 = new Device();
+				reader.readMessage(field1, Device.deserializeBinaryFromReader);
+				message.addItems(field1);
+				break;
+			default:
+				reader.skipField();
+				break;
+			}
+		}
+		return message;
+	}
+
+}
+export declare namespace WatchDevicesReq {
+	export type AsObject = {
+		all: boolean,
+	}
+}
+
+export class WatchDevicesReq extends jspb.Message {
+
+	private static repeatedFields_ = [
+		
+	];
+
+	constructor(data?: jspb.Message.MessageArray) {
+		super();
+		jspb.Message.initialize(this, data || [], 0, -1, WatchDevicesReq.repeatedFields_, null);
+	}
+
+
+	getAll(): boolean {return jspb.Message.getFieldWithDefault(this, 1, false);
+	}
+
+	setAll(value: boolean): void {
+		(jspb.Message as any).setProto3BooleanField(this, 1, value);
+	}
+
+	serializeBinary(): Uint8Array {
+		const writer = new jspb.BinaryWriter();
+		WatchDevicesReq.serializeBinaryToWriter(this, writer);
+		return writer.getResultBuffer();
+	}
+
+	toObject(): WatchDevicesReq.AsObject {
+		let f: any;
+		return {
+			all: this.getAll(),
+		};
+	}
+
+	static serializeBinaryToWriter(message: WatchDevicesReq, writer: jspb.BinaryWriter): void {
+		const field1 = message.getAll();
+		if (field1 != false) {
+			writer.writeBool(1, field1);
+		}
+	}
+
+	static deserializeBinary(bytes: Uint8Array): WatchDevicesReq {
+		var reader = new jspb.BinaryReader(bytes);
+		var message = new WatchDevicesReq();
+		return WatchDevicesReq.deserializeBinaryFromReader(message, reader);
+	}
+
+	static deserializeBinaryFromReader(message: WatchDevicesReq, reader: jspb.BinaryReader): WatchDevicesReq {
+		while (reader.nextField()) {
+			if (reader.isEndGroup()) {
+				break;
+			}
+			const field = reader.getFieldNumber();
+			switch (field) {
+			case 1:
+				const field1 = reader.readBool()
+				message.setAll(field1);
+				break;
+			default:
+				reader.skipField();
+				break;
+			}
+		}
+		return message;
+	}
+
+}
+export declare namespace DeviceEvent {
+	export type AsObject = {
+		type: number,
+		device?: Device.AsObject,
+		previousName: string,
+	}
+}
+
+export class DeviceEvent extends jspb.Message {
+
+	private static repeatedFields_ = [
+		
+	];
+
+	constructor(data?: jspb.Message.MessageArray) {
+		super();
+		jspb.Message.initialize(this, data || [], 0, -1, DeviceEvent.repeatedFields_, null);
+	}
+
+
+	getType(): number {return jspb.Message.getFieldWithDefault(this, 1, 0);
+	}
+
+	setType(value: number): void {
+		(jspb.Message as any).setProto3EnumField(this, 1, value);
+	}
+
+	getDevice(): Device {
+		return jspb.Message.getWrapperField(this, Device, 2);
+	}
+
+	setDevice(value?: Device): void {
+		(jspb.Message as any).setWrapperField(this, 2, value);
+	}
+
+	getPreviousName(): string {return jspb.Message.getFieldWithDefault(this, 3, "");
+	}
+
+	setPreviousName(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 3, value);
+	}
+
+	serializeBinary(): Uint8Array {
+		const writer = new jspb.BinaryWriter();
+		DeviceEvent.serializeBinaryToWriter(this, writer);
+		return writer.getResultBuffer();
+	}
+
+	toObject(): DeviceEvent.AsObject {
+		let f: any;
+		return {
+			type: this.getType(),
+			device: (f = this.getDevice()) && f.toObject(),
+			previousName: this.getPreviousName(),
+		};
+	}
+
+	static serializeBinaryToWriter(message: DeviceEvent, writer: jspb.BinaryWriter): void {
+		const field1 = message.getType();
+		if (field1 != 0) {
+			writer.writeEnum(1, field1);
+		}
+		const field2 = message.getDevice();
+		if (field2 != null) {
+			writer.writeMessage(2, field2, Device.serializeBinaryToWriter);
+		}
+		const field3 = message.getPreviousName();
+		if (field3.length > 0) {
+			writer.writeString(3, field3);
+		}
+	}
+
+	static deserializeBinary(bytes: Uint8Array): DeviceEvent {
+		var reader = new jspb.BinaryReader(bytes);
+		var message = new DeviceEvent();
+		return DeviceEvent.deserializeBinaryFromReader(message, reader);
+	}
+
+	static deserializeBinaryFromReader(message: DeviceEvent, reader: jspb.BinaryReader): DeviceEvent {
+		while (reader.nextField()) {
+			if (reader.isEndGroup()) {
+				break;
+			}
+			const field = reader.getFieldNumber();
+			switch (field) {
+			case 1:
+				const field1 = reader.readEnum()
+				message.setType(field1);
+				break;
+			case 2:
+				const field2 = new Device();
+				reader.readMessage(field2, Device.deserializeBinaryFromReader);
+				message.setDevice(field2);
+				break;
+			case 3:
+				const field3 = reader.readString()
+				message.setPreviousName(field3);
+				break;
+			default:
+				reader.skipField();
+				break;
+			}
+		}
+		return message;
+	}
+
+}
+export declare namespace DeleteDeviceReq {
+	export type AsObject = {
+		name: string,
+		owner?: googleProtobufWrappers.StringValue.AsObject,
+	}
+}
+
+export class DeleteDeviceReq extends jspb.Message {
+
+	private static repeatedFields_ = [
+		
+	];
+
+	constructor(data?: jspb.Message.MessageArray) {
+		super();
+		jspb.Message.initialize(this, data || [], 0, -1, DeleteDeviceReq.repeatedFields_, null);
+	}
+
+
+	getName(): string {return jspb.Message.getFieldWithDefault(this, 1, "");
+	}
+
+	setName(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 1, value);
+	}
+
+	getOwner(): googleProtobufWrappers.StringValue {
+		return jspb.Message.getWrapperField(this, googleProtobufWrappers.StringValue, 2);
+	}
+
+	setOwner(value?: googleProtobufWrappers.StringValue): void {
+		(jspb.Message as any).setWrapperField(this, 2, value);
+	}
+
+	serializeBinary(): Uint8Array {
+		const writer = new jspb.BinaryWriter();
+		DeleteDeviceReq.serializeBinaryToWriter(this, writer);
+		return writer.getResultBuffer();
+	}
+
+	toObject(): DeleteDeviceReq.AsObject {
+		let f: any;
+		return {
+			name: this.getName(),
+			owner: (f = this.getOwner()) && f.toObject(),
+		};
+	}
+
+	static serializeBinaryToWriter(message: DeleteDeviceReq, writer: jspb.BinaryWriter): void {
+		const field1 = message.getName();
+		if (field1.length > 0) {
+			writer.writeString(1, field1);
+		}
+		const field2 = message.getOwner();
+		if (field2 != null) {
+			writer.writeMessage(2, field2, googleProtobufWrappers.StringValue.serializeBinaryToWriter);
+		}
+	}
+
+	static deserializeBinary(bytes: Uint8Array): DeleteDeviceReq {
+		var reader = new jspb.BinaryReader(bytes);
+		var message = new DeleteDeviceReq();
+		return DeleteDeviceReq.deserializeBinaryFromReader(message, reader);
+	}
+
+	static deserializeBinaryFromReader(message: DeleteDeviceReq, reader: jspb.BinaryReader): DeleteDeviceReq {
+		while (reader.nextField()) {
+			if (reader.isEndGroup()) {
+				break;
+			}
+			const field = reader.getFieldNumber();
+			switch (field) {
+			case 1:
+				const field1 = reader.readString()
+				message.setName(field1);
+				break;
+			case 2:
+				const field2 = new googleProtobufWrappers.StringValue();
+				reader.readMessage(field2, googleProtobufWrappers.StringValue.deserializeBinaryFromReader);
+				message.setOwner(field2);
+				break;
+			default:
+				reader.skipField();
+				break;
+			}
+		}
+		return message;
+	}
+
+}
+export declare namespace UpdateDeviceReq {
+	export type AsObject = {
+		name: string,
+		owner?: googleProtobufWrappers.StringValue.AsObject,
+		newName?: googleProtobufWrappers.StringValue.AsObject,
+		publicKey?: googleProtobufWrappers.StringValue.AsObject,
+		presharedKey?: googleProtobufWrappers.StringValue.AsObject,
+		manualIpv4Address?: googleProtobufWrappers.StringValue.AsObject,
+		manualIpv6Address?: googleProtobufWrappers.StringValue.AsObject,
+	}
+}
+
+export class UpdateDeviceReq extends jspb.Message {
+
+	private static repeatedFields_ = [
+		
+	];
+
+	constructor(data?: jspb.Message.MessageArray) {
+		super();
+		jspb.Message.initialize(this, data || [], 0, -1, UpdateDeviceReq.repeatedFields_, null);
+	}
+
+
+	getName(): string {return jspb.Message.getFieldWithDefault(this, 1, "");
+	}
+
+	setName(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 1, value);
+	}
+
+	getOwner(): googleProtobufWrappers.StringValue {
+		return jspb.Message.getWrapperField(this, googleProtobufWrappers.StringValue, 2);
+	}
+
+	setOwner(value?: googleProtobufWrappers.StringValue): void {
+		(jspb.Message as any).setWrapperField(this, 2, value);
+	}
+
+	getNewName(): googleProtobufWrappers.StringValue {
+		return jspb.Message.getWrapperField(this, googleProtobufWrappers.StringValue, 3);
+	}
+
+	setNewName(value?: googleProtobufWrappers.StringValue): void {
+		(jspb.Message as any).setWrapperField(this, 3, value);
+	}
+
+	getPublicKey(): googleProtobufWrappers.StringValue {
+		return jspb.Message.getWrapperField(this, googleProtobufWrappers.StringValue, 4);
+	}
+
+	setPublicKey(value?: googleProtobufWrappers.StringValue): void {
+		(jspb.Message as any).setWrapperField(this, 4, value);
+	}
+
+	getPresharedKey(): googleProtobufWrappers.StringValue {
+		return jspb.Message.getWrapperField(this, googleProtobufWrappers.StringValue, 5);
+	}
+
+	setPresharedKey(value?: googleProtobufWrappers.StringValue): void {
+		(jspb.Message as any).setWrapperField(this, 5, value);
+	}
+
+	getManualIpv4Address(): googleProtobufWrappers.StringValue {
+		return jspb.Message.getWrapperField(this, googleProtobufWrappers.StringValue, 6);
+	}
+
+	setManualIpv4Address(value?: googleProtobufWrappers.StringValue): void {
+		(jspb.Message as any).setWrapperField(this, 6, value);
+	}
+
+	getManualIpv6Address(): googleProtobufWrappers.StringValue {
+		return jspb.Message.getWrapperField(this, googleProtobufWrappers.StringValue, 7);
+	}
+
+	setManualIpv6Address(value?: googleProtobufWrappers.StringValue): void {
+		(jspb.Message as any).setWrapperField(this, 7, value);
+	}
+
+	serializeBinary(): Uint8Array {
+		const writer = new jspb.BinaryWriter();
+		UpdateDeviceReq.serializeBinaryToWriter(this, writer);
+		return writer.getResultBuffer();
+	}
+
+	toObject(): UpdateDeviceReq.AsObject {
+		let f: any;
+		return {
+			name: this.getName(),
+			owner: (f = this.getOwner()) && f.toObject(),
+			newName: (f = this.getNewName()) && f.toObject(),
+			publicKey: (f = this.getPublicKey()) && f.toObject(),
+			presharedKey: (f = this.getPresharedKey()) && f.toObject(),
+			manualIpv4Address: (f = this.getManualIpv4Address()) && f.toObject(),
+			manualIpv6Address: (f = this.getManualIpv6Address()) && f.toObject(),
+		};
+	}
+
+	static serializeBinaryToWriter(message: UpdateDeviceReq, writer: jspb.BinaryWriter): void {
+		const field1 = message.getName();
+		if (field1.length > 0) {
+			writer.writeString(1, field1);
+		}
+		const field2 = message.getOwner();
+		if (field2 != null) {
+			writer.writeMessage(2, field2, googleProtobufWrappers.StringValue.serializeBinaryToWriter);
+		}
+		const field3 = message.getNewName();
+		if (field3 != null) {
+			writer.writeMessage(3, field3, googleProtobufWrappers.StringValue.serializeBinaryToWriter);
+		}
+		const field4 = message.getPublicKey();
+		if (field4 != null) {
+			writer.writeMessage(4, field4, googleProtobufWrappers.StringValue.serializeBinaryToWriter);
+		}
+		const field5 = message.getPresharedKey();
+		if (field5 != null) {
+			writer.writeMessage(5, field5, googleProtobufWrappers.StringValue.serializeBinaryToWriter);
+		}
+		const field6 = message.getManualIpv4Address();
+		if (field6 != null) {
+			writer.writeMessage(6, field6, googleProtobufWrappers.StringValue.serializeBinaryToWriter);
+		}
+		const field7 = message.getManualIpv6Address();
+		if (field7 != null) {
+			writer.writeMessage(7, field7, googleProtobufWrappers.StringValue.serializeBinaryToWriter);
+		}
+	}
+
+	static deserializeBinary(bytes: Uint8Array): UpdateDeviceReq {
+		var reader = new jspb.BinaryReader(bytes);
+		var message = new UpdateDeviceReq();
+		return UpdateDeviceReq.deserializeBinaryFromReader(message, reader);
+	}
+
+	static deserializeBinaryFromReader(message: UpdateDeviceReq, reader: jspb.BinaryReader): UpdateDeviceReq {
+		while (reader.nextField()) {
+			if (reader.isEndGroup()) {
+				break;
+			}
+			const field = reader.getFieldNumber();
+			switch (field) {
+			case 1:
+				const field1 = reader.readString()
+				message.setName(field1);
+				break;
+			case 2:
+				const field2 = new googleProtobufWrappers.StringValue();
+				reader.readMessage(field2, googleProtobufWrappers.StringValue.deserializeBinaryFromReader);
+				message.setOwner(field2);
+				break;
+			case 3:
+				const field3 = new googleProtobufWrappers.StringValue();
+				reader.readMessage(field3, googleProtobufWrappers.StringValue.deserializeBinaryFromReader);
+				message.setNewName(field3);
+				break;
+			case 4:
+				const field4 = new googleProtobufWrappers.StringValue();
+				reader.readMessage(field4, googleProtobufWrappers.StringValue.deserializeBinaryFromReader);
+				message.setPublicKey(field4);
+				break;
+			case 5:
+				const field5 = new googleProtobufWrappers.StringValue();
+				reader.readMessage(field5, googleProtobufWrappers.StringValue.deserializeBinaryFromReader);
+				message.setPresharedKey(field5);
+				break;
+			case 6:
+				const field6 = new googleProtobufWrappers.StringValue();
+				reader.readMessage(field6, googleProtobufWrappers.StringValue.deserializeBinaryFromReader);
+				message.setManualIpv4Address(field6);
+				break;
+			case 7:
+				const field7 = new googleProtobufWrappers.StringValue();
+				reader.readMessage(field7, googleProtobufWrappers.StringValue.deserializeBinaryFromReader);
+				message.setManualIpv6Address(field7);
+				break;
+			default:
+				reader.skipField();
+				break;
+			}
+		}
+		return message;
+	}
+
+}
+export declare namespace RenewDeviceReq {
+	export type AsObject = {
+		name: string,
+		owner?: googleProtobufWrappers.StringValue.AsObject,
+		expiresAt?: googleProtobufTimestamp.Timestamp.AsObject,
+	}
+}
+
+export class RenewDeviceReq extends jspb.Message {
+
+	private static repeatedFields_ = [
+		
+	];
+
+	constructor(data?: jspb.Message.MessageArray) {
+		super();
+		jspb.Message.initialize(this, data || [], 0, -1, RenewDeviceReq.repeatedFields_, null);
+	}
+
+
+	getName(): string {return jspb.Message.getFieldWithDefault(this, 1, "");
+	}
+
+	setName(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 1, value);
+	}
+
+	getOwner(): googleProtobufWrappers.StringValue {
+		return jspb.Message.getWrapperField(this, googleProtobufWrappers.StringValue, 2);
+	}
+
+	setOwner(value?: googleProtobufWrappers.StringValue): void {
+		(jspb.Message as any).setWrapperField(this, 2, value);
+	}
+
+	getExpiresAt(): googleProtobufTimestamp.Timestamp {
+		return jspb.Message.getWrapperField(this, googleProtobufTimestamp.Timestamp, 3);
+	}
+
+	setExpiresAt(value?: googleProtobufTimestamp.Timestamp): void {
+		(jspb.Message as any).setWrapperField(this, 3, value);
+	}
+
+	serializeBinary(): Uint8Array {
+		const writer = new jspb.BinaryWriter();
+		RenewDeviceReq.serializeBinaryToWriter(this, writer);
+		return writer.getResultBuffer();
+	}
+
+	toObject(): RenewDeviceReq.AsObject {
+		let f: any;
+		return {
+			name: this.getName(),
+			owner: (f = this.getOwner()) && f.toObject(),
+			expiresAt: (f = this.getExpiresAt()) && f.toObject(),
+		};
+	}
+
+	static serializeBinaryToWriter(message: RenewDeviceReq, writer: jspb.BinaryWriter): void {
+		const field1 = message.getName();
+		if (field1.length > 0) {
+			writer.writeString(1, field1);
+		}
+		const field2 = message.getOwner();
+		if (field2 != null) {
+			writer.writeMessage(2, field2, googleProtobufWrappers.StringValue.serializeBinaryToWriter);
+		}
+		const field3 = message.getExpiresAt();
+		if (field3 != null) {
+			writer.writeMessage(3, field3, googleProtobufTimestamp.Timestamp.serializeBinaryToWriter);
+		}
+	}
+
+	static deserializeBinary(bytes: Uint8Array): RenewDeviceReq {
+		var reader = new jspb.BinaryReader(bytes);
+		var message = new RenewDeviceReq();
+		return RenewDeviceReq.deserializeBinaryFromReader(message, reader);
+	}
+
+	static deserializeBinaryFromReader(message: RenewDeviceReq, reader: jspb.BinaryReader): RenewDeviceReq {
+		while (reader.nextField()) {
+			if (reader.isEndGroup()) {
+				break;
+			}
+			const field = reader.getFieldNumber();
+			switch (field) {
+			case 1:
+				const field1 = reader.readString()
+				message.setName(field1);
+				break;
+			case 2:
+				const field2 = new googleProtobufWrappers.StringValue();
+				reader.readMessage(field2, googleProtobufWrappers.StringValue.deserializeBinaryFromReader);
+				message.setOwner(field2);
+				break;
+			case 3:
+				const field3 = new googleProtobufTimestamp.Timestamp();
+				reader.readMessage(field3, googleProtobufTimestamp.Timestamp.deserializeBinaryFromReader);
+				message.setExpiresAt(field3);
+				break;
+			default:
+				reader.skipField();
+				break;
+			}
+		}
+		return message;
 	}
 
-	static deserializeBinary(bytes: Uint8Array): AddDeviceReq {
+}
+export declare namespace GetUsageReq {
+	export type AsObject = {
+		name: string,
+		owner?: googleProtobufWrappers.StringValue.AsObject,
+		from?: googleProtobufTimestamp.Timestamp.AsObject,
+		to?: googleProtobufTimestamp.Timestamp.AsObject,
+		step?: googleProtobufDuration.Duration.AsObject,
+	}
+}
+
+export class GetUsageReq extends jspb.Message {
+
+	private static repeatedFields_ = [
+		
+	];
+
+	constructor(data?: jspb.Message.MessageArray) {
+		super();
+		jspb.Message.initialize(this, data || [], 0, -1, GetUsageReq.repeatedFields_, null);
+	}
+
+
+	getName(): string {return jspb.Message.getFieldWithDefault(this, 1, "");
+	}
+
+	setName(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 1, value);
+	}
+
+	getOwner(): googleProtobufWrappers.StringValue {
+		return jspb.Message.getWrapperField(this, googleProtobufWrappers.StringValue, 2);
+	}
+
+	setOwner(value?: googleProtobufWrappers.StringValue): void {
+		(jspb.Message as any).setWrapperField(this, 2, value);
+	}
+
+	getFrom(): googleProtobufTimestamp.Timestamp {
+		return jspb.Message.getWrapperField(this, googleProtobufTimestamp.Timestamp, 3);
+	}
+
+	setFrom(value?: googleProtobufTimestamp.Timestamp): void {
+		(jspb.Message as any).setWrapperField(this, 3, value);
+	}
+
+	getTo(): googleProtobufTimestamp.Timestamp {
+		return jspb.Message.getWrapperField(this, googleProtobufTimestamp.Timestamp, 4);
+	}
+
+	setTo(value?: googleProtobufTimestamp.Timestamp): void {
+		(jspb.Message as any).setWrapperField(this, 4, value);
+	}
+
+	getStep(): googleProtobufDuration.Duration {
+		return jspb.Message.getWrapperField(this, googleProtobufDuration.Duration, 5);
+	}
+
+	setStep(value?: googleProtobufDuration.Duration): void {
+		(jspb.Message as any).setWrapperField(this, 5, value);
+	}
+
+	serializeBinary(): Uint8Array {
+		const writer = new jspb.BinaryWriter();
+		GetUsageReq.serializeBinaryToWriter(this, writer);
+		return writer.getResultBuffer();
+	}
+
+	toObject(): GetUsageReq.AsObject {
+		let f: any;
+		return {
+			name: this.getName(),
+			owner: (f = this.getOwner()) && f.toObject(),
+			from: (f = this.getFrom()) && f.toObject(),
+			to: (f = this.getTo()) && f.toObject(),
+			step: (f = this.getStep()) && f.toObject(),
+		};
+	}
+
+	static serializeBinaryToWriter(message: GetUsageReq, writer: jspb.BinaryWriter): void {
+		const field1 = message.getName();
+		if (field1.length > 0) {
+			writer.writeString(1, field1);
+		}
+		const field2 = message.getOwner();
+		if (field2 != null) {
+			writer.writeMessage(2, field2, googleProtobufWrappers.StringValue.serializeBinaryToWriter);
+		}
+		const field3 = message.getFrom();
+		if (field3 != null) {
+			writer.writeMessage(3, field3, googleProtobufTimestamp.Timestamp.serializeBinaryToWriter);
+		}
+		const field4 = message.getTo();
+		if (field4 != null) {
+			writer.writeMessage(4, field4, googleProtobufTimestamp.Timestamp.serializeBinaryToWriter);
+		}
+		const field5 = message.getStep();
+		if (field5 != null) {
+			writer.writeMessage(5, field5, googleProtobufDuration.Duration.serializeBinaryToWriter);
+		}
+	}
+
+	static deserializeBinary(bytes: Uint8Array): GetUsageReq {
 		var reader = new jspb.BinaryReader(bytes);
-		var message = new AddDeviceReq();
-		return AddDeviceReq.deserializeBinaryFromReader(message, reader);
+		var message = new GetUsageReq();
+		return GetUsageReq.deserializeBinaryFromReader(message, reader);
 	}
 
-	static deserializeBinaryFromReader(message: AddDeviceReq, reader: jspb.BinaryReader): AddDeviceReq {
+	static deserializeBinaryFromReader(message: GetUsageReq, reader: jspb.BinaryReader): GetUsageReq {
 		while (reader.nextField()) {
 			if (reader.isEndGroup()) {
 				break;
@@ -561,24 +2426,101 @@ export class AddDeviceReq extends jspb.Message {
 				message.setName(field1);
 				break;
 			case 2:
-				const field2 = reader.readString()
-				message.setPublicKey(field2);
+				const field2 = new googleProtobufWrappers.StringValue();
+				reader.readMessage(field2, googleProtobufWrappers.StringValue.deserializeBinaryFromReader);
+				message.setOwner(field2);
 				break;
 			case 3:
-				const field3 = reader.readString()
-				message.setPresharedKey(field3);
+				const field3 = new googleProtobufTimestamp.Timestamp();
+				reader.readMessage(field3, googleProtobufTimestamp.Timestamp.deserializeBinaryFromReader);
+				message.setFrom(field3);
 				break;
 			case 4:
-				const field4 = reader.readBool()
-				message.setManualIpAssignment(field4);
+				const field4 = new googleProtobufTimestamp.Timestamp();
+				reader.readMessage(field4, googleProtobufTimestamp.Timestamp.deserializeBinaryFromReader);
+				message.setTo(field4);
 				break;
 			case 5:
-				const field5 = reader.readString()
-				message.setManualIpv4Address(field5);
+				const field5 = new googleProtobufDuration.Duration();
+				reader.readMessage(field5, googleProtobufDuration.Duration.deserializeBinaryFromReader);
+				message.setStep(field5);
 				break;
-			case 6:
-				const field6 = reader.readString()
-				message.setManualIpv6Address(field6);
+			default:
+				reader.skipField();
+				break;
+			}
+		}
+		return message;
+	}
+
+}
+export declare namespace GetUsageRes {
+	export type AsObject = {
+		items: Array<UsagePoint.AsObject>,
+	}
+}
+
+export class GetUsageRes extends jspb.Message {
+
+	private static repeatedFields_ = [
+		1,
+	];
+
+	constructor(data?: jspb.Message.MessageArray) {
+		super();
+		jspb.Message.initialize(this, data || [], 0, -1, GetUsageRes.repeatedFields_, null);
+	}
+
+
+	getItems(): Array<UsagePoint> {
+		return jspb.Message.getRepeatedWrapperField(this, UsagePoint, 1);
+	}
+
+	setItems(value: Array<UsagePoint>): void {
+		(jspb.Message as any).setRepeatedWrapperField(this, 1, value);
+	}
+
+	addItems(value?: UsagePoint, index?: number): UsagePoint {
+		return jspb.Message.addToRepeatedWrapperField(this, 1, value, UsagePoint, index);
+	}
+
+	serializeBinary(): Uint8Array {
+		const writer = new jspb.BinaryWriter();
+		GetUsageRes.serializeBinaryToWriter(this, writer);
+		return writer.getResultBuffer();
+	}
+
+	toObject(): GetUsageRes.AsObject {
+		let f: any;
+		return {
+			items: this.getItems().map((item) => item.toObject()),
+		};
+	}
+
+	static serializeBinaryToWriter(message: GetUsageRes, writer: jspb.BinaryWriter): void {
+		const field1 = message.getItems();
+		if (field1.length > 0) {
+			writer.writeRepeatedMessage(1, field1, UsagePoint.serializeBinaryToWriter);
+		}
+	}
+
+	static deserializeBinary(bytes: Uint8Array): GetUsageRes {
+		var reader = new jspb.BinaryReader(bytes);
+		var message = new GetUsageRes();
+		return GetUsageRes.deserializeBinaryFromReader(message, reader);
+	}
+
+	static deserializeBinaryFromReader(message: GetUsageRes, reader: jspb.BinaryReader): GetUsageRes {
+		while (reader.nextField()) {
+			if (reader.isEndGroup()) {
+				break;
+			}
+			const field = reader.getFieldNumber();
+			switch (field) {
+			case 1:
+				const field1 = new UsagePoint();
+				reader.readMessage(field1, UsagePoint.deserializeBinaryFromReader);
+				message.addItems(field1);
 				break;
 			default:
 				reader.skipField();
@@ -589,12 +2531,15 @@ export class AddDeviceReq extends jspb.Message {
 	}
 
 }
-export declare namespace ListDevicesReq {
+export declare namespace UsagePoint {
 	export type AsObject = {
+		time?: googleProtobufTimestamp.Timestamp.AsObject,
+		receiveBytes: number,
+		transmitBytes: number,
 	}
 }
 
-export class ListDevicesReq extends jspb.Message {
+export class UsagePoint extends jspb.Message {
 
 	private static repeatedFields_ = [
 		
@@ -602,38 +2547,88 @@ export class ListDevicesReq extends jspb.Message {
 
 	constructor(data?: jspb.Message.MessageArray) {
 		super();
-		jspb.Message.initialize(this, data || [], 0, -1, ListDevicesReq.repeatedFields_, null);
+		jspb.Message.initialize(this, data || [], 0, -1, UsagePoint.repeatedFields_, null);
+	}
+
+
+	getTime(): googleProtobufTimestamp.Timestamp {
+		return jspb.Message.getWrapperField(this, googleProtobufTimestamp.Timestamp, 1);
+	}
+
+	setTime(value?: googleProtobufTimestamp.Timestamp): void {
+		(jspb.Message as any).setWrapperField(this, 1, value);
+	}
+
+	getReceiveBytes(): number {return jspb.Message.getFieldWithDefault(this, 2, 0);
+	}
+
+	setReceiveBytes(value: number): void {
+		(jspb.Message as any).setProto3IntField(this, 2, value);
 	}
 
+	getTransmitBytes(): number {return jspb.Message.getFieldWithDefault(this, 3, 0);
+	}
+
+	setTransmitBytes(value: number): void {
+		(jspb.Message as any).setProto3IntField(this, 3, value);
+	}
 
 	serializeBinary(): Uint8Array {
 		const writer = new jspb.BinaryWriter();
-		ListDevicesReq.serializeBinaryToWriter(this, writer);
+		UsagePoint.serializeBinaryToWriter(this, writer);
 		return writer.getResultBuffer();
 	}
 
-	toObject(): ListDevicesReq.AsObject {
+	toObject(): UsagePoint.AsObject {
 		let f: any;
 		return {
+			time: (f = this.getTime()) && f.toObject(),
+			receiveBytes: this.getReceiveBytes(),
+			transmitBytes: this.getTransmitBytes(),
 		};
 	}
 
-	static serializeBinaryToWriter(message: ListDevicesReq, writer: jspb.BinaryWriter): void {
+	static serializeBinaryToWriter(message: UsagePoint, writer: jspb.BinaryWriter): void {
+		const field1 = message.getTime();
+		if (field1 != null) {
+			writer.writeMessage(1, field1, googleProtobufTimestamp.Timestamp.serializeBinaryToWriter);
+		}
+		const field2 = message.getReceiveBytes();
+		if (field2 != 0) {
+			writer.writeInt64(2, field2);
+		}
+		const field3 = message.getTransmitBytes();
+		if (field3 != 0) {
+			writer.writeInt64(3, field3);
+		}
 	}
 
-	static deserializeBinary(bytes: Uint8Array): ListDevicesReq {
+	static deserializeBinary(bytes: Uint8Array): UsagePoint {
 		var reader = new jspb.BinaryReader(bytes);
-		var message = new ListDevicesReq();
-		return ListDevicesReq.deserializeBinaryFromReader(message, reader);
+		var message = new UsagePoint();
+		return UsagePoint.deserializeBinaryFromReader(message, reader);
 	}
 
-	static deserializeBinaryFromReader(message: ListDevicesReq, reader: jspb.BinaryReader): ListDevicesReq {
+	static deserializeBinaryFromReader(message: UsagePoint, reader: jspb.BinaryReader): UsagePoint {
 		while (reader.nextField()) {
 			if (reader.isEndGroup()) {
 				break;
 			}
 			const field = reader.getFieldNumber();
 			switch (field) {
+			case 1:
+				const field1 = new googleProtobufTimestamp.Timestamp();
+				reader.readMessage(field1, googleProtobufTimestamp.Timestamp.deserializeBinaryFromReader);
+				message.setTime(field1);
+				break;
+			case 2:
+				const field2 = reader.readInt64()
+				message.setReceiveBytes(field2);
+				break;
+			case 3:
+				const field3 = reader.readInt64()
+				message.setTransmitBytes(field3);
+				break;
 			default:
 				reader.skipField();
 				break;
@@ -643,63 +2638,72 @@ export class ListDevicesReq extends jspb.Message {
 	}
 
 }
-export declare namespace ListDevicesRes {
+export declare namespace SuspendDeviceReq {
 	export type AsObject = {
-		items: Array<Device.AsObject>,
+		name: string,
+		owner?: googleProtobufWrappers.StringValue.AsObject,
 	}
 }
 
-export class ListDevicesRes extends jspb.Message {
+export class SuspendDeviceReq extends jspb.Message {
 
 	private static repeatedFields_ = [
-		1,
+		
 	];
 
 	constructor(data?: jspb.Message.MessageArray) {
 		super();
-		jspb.Message.initialize(this, data || [], 0, -1, ListDevicesRes.repeatedFields_, null);
+		jspb.Message.initialize(this, data || [], 0, -1, SuspendDeviceReq.repeatedFields_, null);
 	}
 
 
-	getItems(): Array<Device> {
-		return jspb.Message.getRepeatedWrapperField(this, Device, 1);
+	getName(): string {return jspb.Message.getFieldWithDefault(this, 1, "");
 	}
 
-	setItems(value: Array<Device>): void {
-		(jspb.Message as any).setRepeatedWrapperField(this, 1, value);
+	setName(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 1, value);
 	}
 
-	addItems(value?: Device, index?: number): Device {
-		return jspb.Message.addToRepeatedWrapperField(this, 1, value, Device, index);
+	getOwner(): googleProtobufWrappers.StringValue {
+		return jspb.Message.getWrapperField(this, googleProtobufWrappers.StringValue, 2);
+	}
+
+	setOwner(value?: googleProtobufWrappers.StringValue): void {
+		(jspb.Message as any).setWrapperField(this, 2, value);
 	}
 
 	serializeBinary(): Uint8Array {
 		const writer = new jspb.BinaryWriter();
-		ListDevicesRes.serializeBinaryToWriter(this, writer);
+		SuspendDeviceReq.serializeBinaryToWriter(this, writer);
 		return writer.getResultBuffer();
 	}
 
-	toObject(): ListDevicesRes.AsObject {
+	toObject(): SuspendDeviceReq.AsObject {
 		let f: any;
 		return {
-			items: this.getItems().map((item) => item.toObject()),
+			name: this.getName(),
+			owner: (f = this.getOwner()) && f.toObject(),
 		};
 	}
 
-	static serializeBinaryToWriter(message: ListDevicesRes, writer: jspb.BinaryWriter): void {
-		const field1 = message.getItems();
+	static serializeBinaryToWriter(message: SuspendDeviceReq, writer: jspb.BinaryWriter): void {
+		const field1 = message.getName();
 		if (field1.length > 0) {
-			writer.writeRepeatedMessage(1, field1, Device.serializeBinaryToWriter);
+			writer.writeString(1, field1);
+		}
+		const field2 = message.getOwner();
+		if (field2 != null) {
+			writer.writeMessage(2, field2, googleProtobufWrappers.StringValue.serializeBinaryToWriter);
 		}
 	}
 
-	static deserializeBinary(bytes: Uint8Array): ListDevicesRes {
+	static deserializeBinary(bytes: Uint8Array): SuspendDeviceReq {
 		var reader = new jspb.BinaryReader(bytes);
-		var message = new ListDevicesRes();
-		return ListDevicesRes.deserializeBinaryFromReader(message, reader);
+		var message = new SuspendDeviceReq();
+		return SuspendDeviceReq.deserializeBinaryFromReader(message, reader);
 	}
 
-	static deserializeBinaryFromReader(message: ListDevicesRes, reader: jspb.BinaryReader): ListDevicesRes {
+	static deserializeBinaryFromReader(message: SuspendDeviceReq, reader: jspb.BinaryReader): SuspendDeviceReq {
 		while (reader.nextField()) {
 			if (reader.isEndGroup()) {
 				break;
@@ -707,9 +2711,13 @@ export class ListDevicesRes extends jspb.Message {
 			const field = reader.getFieldNumber();
 			switch (field) {
 			case 1:
-				const field1 = new Device();
-				reader.readMessage(field1, Device.deserializeBinaryFromReader);
-				message.addItems(field1);
+				const field1 = reader.readString()
+				message.setName(field1);
+				break;
+			case 2:
+				const field2 = new googleProtobufWrappers.StringValue();
+				reader.readMessage(field2, googleProtobufWrappers.StringValue.deserializeBinaryFromReader);
+				message.setOwner(field2);
 				break;
 			default:
 				reader.skipField();
@@ -720,14 +2728,14 @@ export class ListDevicesRes extends jspb.Message {
 	}
 
 }
-export declare namespace DeleteDeviceReq {
+export declare namespace ResumeDeviceReq {
 	export type AsObject = {
 		name: string,
 		owner?: googleProtobufWrappers.StringValue.AsObject,
 	}
 }
 
-export class DeleteDeviceReq extends jspb.Message {
+export class ResumeDeviceReq extends jspb.Message {
 
 	private static repeatedFields_ = [
 		
@@ -735,7 +2743,7 @@ export class DeleteDeviceReq extends jspb.Message {
 
 	constructor(data?: jspb.Message.MessageArray) {
 		super();
-		jspb.Message.initialize(this, data || [], 0, -1, DeleteDeviceReq.repeatedFields_, null);
+		jspb.Message.initialize(this, data || [], 0, -1, ResumeDeviceReq.repeatedFields_, null);
 	}
 
 
@@ -756,11 +2764,11 @@ export class DeleteDeviceReq extends jspb.Message {
 
 	serializeBinary(): Uint8Array {
 		const writer = new jspb.BinaryWriter();
-		DeleteDeviceReq.serializeBinaryToWriter(this, writer);
+		ResumeDeviceReq.serializeBinaryToWriter(this, writer);
 		return writer.getResultBuffer();
 	}
 
-	toObject(): DeleteDeviceReq.AsObject {
+	toObject(): ResumeDeviceReq.AsObject {
 		let f: any;
 		return {
 			name: this.getName(),
@@ -768,7 +2776,7 @@ export class DeleteDeviceReq extends jspb.Message {
 		};
 	}
 
-	static serializeBinaryToWriter(message: DeleteDeviceReq, writer: jspb.BinaryWriter): void {
+	static serializeBinaryToWriter(message: ResumeDeviceReq, writer: jspb.BinaryWriter): void {
 		const field1 = message.getName();
 		if (field1.length > 0) {
 			writer.writeString(1, field1);
@@ -779,13 +2787,13 @@ export class DeleteDeviceReq extends jspb.Message {
 		}
 	}
 
-	static deserializeBinary(bytes: Uint8Array): DeleteDeviceReq {
+	static deserializeBinary(bytes: Uint8Array): ResumeDeviceReq {
 		var reader = new jspb.BinaryReader(bytes);
-		var message = new DeleteDeviceReq();
-		return DeleteDeviceReq.deserializeBinaryFromReader(message, reader);
+		var message = new ResumeDeviceReq();
+		return ResumeDeviceReq.deserializeBinaryFromReader(message, reader);
 	}
 
-	static deserializeBinaryFromReader(message: DeleteDeviceReq, reader: jspb.BinaryReader): DeleteDeviceReq {
+	static deserializeBinaryFromReader(message: ResumeDeviceReq, reader: jspb.BinaryReader): ResumeDeviceReq {
 		while (reader.nextField()) {
 			if (reader.isEndGroup()) {
 				break;
@@ -941,6 +2949,156 @@ export class ListAllDevicesRes extends jspb.Message {
 	}
 
 }
+export declare namespace ListPendingDeletionsReq {
+	export type AsObject = {
+		within?: googleProtobufDuration.Duration.AsObject,
+	}
+}
+
+export class ListPendingDeletionsReq extends jspb.Message {
+
+	private static repeatedFields_ = [
+		
+	];
+
+	constructor(data?: jspb.Message.MessageArray) {
+		super();
+		jspb.Message.initialize(this, data || [], 0, -1, ListPendingDeletionsReq.repeatedFields_, null);
+	}
+
+
+	getWithin(): googleProtobufDuration.Duration {
+		return jspb.Message.getWrapperField(this, googleProtobufDuration.Duration, 1);
+	}
+
+	setWithin(value?: googleProtobufDuration.Duration): void {
+		(jspb.Message as any).setWrapperField(this, 1, value);
+	}
+
+	serializeBinary(): Uint8Array {
+		const writer = new jspb.BinaryWriter();
+		ListPendingDeletionsReq.serializeBinaryToWriter(this, writer);
+		return writer.getResultBuffer();
+	}
+
+	toObject(): ListPendingDeletionsReq.AsObject {
+		let f: any;
+		return {
+			within: (f = this.getWithin()) && f.toObject(),
+		};
+	}
+
+	static serializeBinaryToWriter(message: ListPendingDeletionsReq, writer: jspb.BinaryWriter): void {
+		const field1 = message.getWithin();
+		if (field1 != null) {
+			writer.writeMessage(1, field1, googleProtobufDuration.Duration.serializeBinaryToWriter);
+		}
+	}
+
+	static deserializeBinary(bytes: Uint8Array): ListPendingDeletionsReq {
+		var reader = new jspb.BinaryReader(bytes);
+		var message = new ListPendingDeletionsReq();
+		return ListPendingDeletionsReq.deserializeBinaryFromReader(message, reader);
+	}
+
+	static deserializeBinaryFromReader(message: ListPendingDeletionsReq, reader: jspb.BinaryReader): ListPendingDeletionsReq {
+		while (reader.nextField()) {
+			if (reader.isEndGroup()) {
+				break;
+			}
+			const field = reader.getFieldNumber();
+			switch (field) {
+			case 1:
+				const field1 = new googleProtobufDuration.Duration();
+				reader.readMessage(field1, googleProtobufDuration.Duration.deserializeBinaryFromReader);
+				message.setWithin(field1);
+				break;
+			default:
+				reader.skipField();
+				break;
+			}
+		}
+		return message;
+	}
+
+}
+export declare namespace ListPendingDeletionsRes {
+	export type AsObject = {
+		items: Array<Device.AsObject>,
+	}
+}
+
+export class ListPendingDeletionsRes extends jspb.Message {
+
+	private static repeatedFields_ = [
+		1,
+	];
+
+	constructor(data?: jspb.Message.MessageArray) {
+		super();
+		jspb.Message.initialize(this, data || [], 0, -1, ListPendingDeletionsRes.repeatedFields_, null);
+	}
+
+
+	getItems(): Array<Device> {
+		return jspb.Message.getRepeatedWrapperField(this, Device, 1);
+	}
+
+	setItems(value: Array<Device>): void {
+		(jspb.Message as any).setRepeatedWrapperField(this, 1, value);
+	}
+
+	addItems(value?: Device, index?: number): Device {
+		return jspb.Message.addToRepeatedWrapperField(this, 1, value, Device, index);
+	}
+
+	serializeBinary(): Uint8Array {
+		const writer = new jspb.BinaryWriter();
+		ListPendingDeletionsRes.serializeBinaryToWriter(this, writer);
+		return writer.getResultBuffer();
+	}
+
+	toObject(): ListPendingDeletionsRes.AsObject {
+		let f: any;
+		return {
+			items: this.getItems().map((item) => item.toObject()),
+		};
+	}
+
+	static serializeBinaryToWriter(message: ListPendingDeletionsRes, writer: jspb.BinaryWriter): void {
+		const field1 = message.getItems();
+		if (field1.length > 0) {
+			writer.writeRepeatedMessage(1, field1, Device.serializeBinaryToWriter);
+		}
+	}
+
+	static deserializeBinary(bytes: Uint8Array): ListPendingDeletionsRes {
+		var reader = new jspb.BinaryReader(bytes);
+		var message = new ListPendingDeletionsRes();
+		return ListPendingDeletionsRes.deserializeBinaryFromReader(message, reader);
+	}
+
+	static deserializeBinaryFromReader(message: ListPendingDeletionsRes, reader: jspb.BinaryReader): ListPendingDeletionsRes {
+		while (reader.nextField()) {
+			if (reader.isEndGroup()) {
+				break;
+			}
+			const field = reader.getFieldNumber();
+			switch (field) {
+			case 1:
+				const field1 = new Device();
+				reader.readMessage(field1, Device.deserializeBinaryFromReader);
+				message.addItems(field1);
+				break;
+			default:
+				reader.skipField();
+				break;
+			}
+		}
+		return message;
+	}
+
+}
 
 
 function DeviceFromObject(obj: Device.AsObject | undefined): Device | undefined {
@@ -962,6 +3120,15 @@ function DeviceFromObject(obj: Device.AsObject | undefined): Device | undefined
 	message.setOwnerEmail(obj.ownerEmail);
 	message.setOwnerProvider(obj.ownerProvider);
 	message.setPresharedKey(obj.presharedKey);
+	message.setAccessPolicy(AccessPolicyFromObject(obj.accessPolicy));
+	message.setExpiresAt(TimestampFromObject(obj.expiresAt));
+	message.setSuspended(obj.suspended);
+	message.setStaleSince(TimestampFromObject(obj.staleSince));
+	message.setDeletionTime(TimestampFromObject(obj.deletionTime));
+	message.setOverQuota(obj.overQuota);
+	message.setProfile(obj.profile);
+	message.setGateway(obj.gateway);
+	message.setRoutes(obj.routes);
 	return message;
 }
 
@@ -975,6 +3142,30 @@ function TimestampFromObject(obj: googleProtobufTimestamp.Timestamp.AsObject | u
 	return message;
 }
 
+function AccessPolicyFromObject(obj: AccessPolicy.AsObject | undefined): AccessPolicy | undefined {
+	if (obj === undefined) {
+		return undefined;
+	}
+	const message = new AccessPolicy();
+	(obj.rules || [])
+		.map((item) => AccessRuleFromObject(item))
+		.forEach((item) => message.addRules(item));
+	message.setName(obj.name);
+	message.setClientIsolation(obj.clientIsolation);
+	return message;
+}
+
+function AccessRuleFromObject(obj: AccessRule.AsObject | undefined): AccessRule | undefined {
+	if (obj === undefined) {
+		return undefined;
+	}
+	const message = new AccessRule();
+	message.setCidr(obj.cidr);
+	message.setProtocol(obj.protocol);
+	message.setPorts(obj.ports);
+	return message;
+}
+
 function AddDeviceReqFromObject(obj: AddDeviceReq.AsObject | undefined): AddDeviceReq | undefined {
 	if (obj === undefined) {
 		return undefined;
@@ -986,6 +3177,49 @@ function AddDeviceReqFromObject(obj: AddDeviceReq.AsObject | undefined): AddDevi
 	message.setManualIpAssignment(obj.manualIpAssignment);
 	message.setManualIpv4Address(obj.manualIpv4Address);
 	message.setManualIpv6Address(obj.manualIpv6Address);
+	message.setAccessPolicy(AccessPolicyFromObject(obj.accessPolicy));
+	message.setExpiresAt(TimestampFromObject(obj.expiresAt));
+	message.setProfile(obj.profile);
+	message.setGateway(obj.gateway);
+	message.setRoutes(obj.routes);
+	return message;
+}
+
+function CreateDeviceWithConfigReqFromObject(obj: CreateDeviceWithConfigReq.AsObject | undefined): CreateDeviceWithConfigReq | undefined {
+	if (obj === undefined) {
+		return undefined;
+	}
+	const message = new CreateDeviceWithConfigReq();
+	message.setName(obj.name);
+	message.setUsePresharedKey(obj.usePresharedKey);
+	message.setManualIpAssignment(obj.manualIpAssignment);
+	message.setManualIpv4Address(obj.manualIpv4Address);
+	message.setManualIpv6Address(obj.manualIpv6Address);
+	message.setPersistentKeepalive(Int32ValueFromObject(obj.persistentKeepalive));
+	message.setAccessPolicy(AccessPolicyFromObject(obj.accessPolicy));
+	message.setExpiresAt(TimestampFromObject(obj.expiresAt));
+	message.setProfile(obj.profile);
+	message.setGateway(obj.gateway);
+	message.setRoutes(obj.routes);
+	return message;
+}
+
+function Int32ValueFromObject(obj: googleProtobufWrappers.Int32Value.AsObject | undefined): googleProtobufWrappers.Int32Value | undefined {
+	if (obj === undefined) {
+		return undefined;
+	}
+	const message = new googleProtobufWrappers.Int32Value();
+	message.setValue(obj.value);
+	return message;
+}
+
+function CreateDeviceWithConfigResFromObject(obj: CreateDeviceWithConfigRes.AsObject | undefined): CreateDeviceWithConfigRes | undefined {
+	if (obj === undefined) {
+		return undefined;
+	}
+	const message = new CreateDeviceWithConfigRes();
+	message.setDevice(DeviceFromObject(obj.device));
+	message.setConfig(obj.config);
 	return message;
 }
 
@@ -1008,6 +3242,26 @@ function ListDevicesResFromObject(obj: ListDevicesRes.AsObject | undefined): Lis
 	return message;
 }
 
+function WatchDevicesReqFromObject(obj: WatchDevicesReq.AsObject | undefined): WatchDevicesReq | undefined {
+	if (obj === undefined) {
+		return undefined;
+	}
+	const message = new WatchDevicesReq();
+	message.setAll(obj.all);
+	return message;
+}
+
+function DeviceEventFromObject(obj: DeviceEvent.AsObject | undefined): DeviceEvent | undefined {
+	if (obj === undefined) {
+		return undefined;
+	}
+	const message = new DeviceEvent();
+	message.setType(obj.type);
+	message.setDevice(DeviceFromObject(obj.device));
+	message.setPreviousName(obj.previousName);
+	return message;
+}
+
 function DeleteDeviceReqFromObject(obj: DeleteDeviceReq.AsObject | undefined): DeleteDeviceReq | undefined {
 	if (obj === undefined) {
 		return undefined;
@@ -1027,6 +3281,97 @@ function StringValueFromObject(obj: googleProtobufWrappers.StringValue.AsObject
 	return message;
 }
 
+function UpdateDeviceReqFromObject(obj: UpdateDeviceReq.AsObject | undefined): UpdateDeviceReq | undefined {
+	if (obj === undefined) {
+		return undefined;
+	}
+	const message = new UpdateDeviceReq();
+	message.setName(obj.name);
+	message.setOwner(StringValueFromObject(obj.owner));
+	message.setNewName(StringValueFromObject(obj.newName));
+	message.setPublicKey(StringValueFromObject(obj.publicKey));
+	message.setPresharedKey(StringValueFromObject(obj.presharedKey));
+	message.setManualIpv4Address(StringValueFromObject(obj.manualIpv4Address));
+	message.setManualIpv6Address(StringValueFromObject(obj.manualIpv6Address));
+	return message;
+}
+
+function RenewDeviceReqFromObject(obj: RenewDeviceReq.AsObject | undefined): RenewDeviceReq | undefined {
+	if (obj === undefined) {
+		return undefined;
+	}
+	const message = new RenewDeviceReq();
+	message.setName(obj.name);
+	message.setOwner(StringValueFromObject(obj.owner));
+	message.setExpiresAt(TimestampFromObject(obj.expiresAt));
+	return message;
+}
+
+function GetUsageReqFromObject(obj: GetUsageReq.AsObject | undefined): GetUsageReq | undefined {
+	if (obj === undefined) {
+		return undefined;
+	}
+	const message = new GetUsageReq();
+	message.setName(obj.name);
+	message.setOwner(StringValueFromObject(obj.owner));
+	message.setFrom(TimestampFromObject(obj.from));
+	message.setTo(TimestampFromObject(obj.to));
+	message.setStep(DurationFromObject(obj.step));
+	return message;
+}
+
+function DurationFromObject(obj: googleProtobufDuration.Duration.AsObject | undefined): googleProtobufDuration.Duration | undefined {
+	if (obj === undefined) {
+		return undefined;
+	}
+	const message = new googleProtobufDuration.Duration();
+	message.setSeconds(obj.seconds);
+	message.setNanos(obj.nanos);
+	return message;
+}
+
+function GetUsageResFromObject(obj: GetUsageRes.AsObject | undefined): GetUsageRes | undefined {
+	if (obj === undefined) {
+		return undefined;
+	}
+	const message = new GetUsageRes();
+	(obj.items || [])
+		.map((item) => UsagePointFromObject(item))
+		.forEach((item) => message.addItems(item));
+	return message;
+}
+
+function UsagePointFromObject(obj: UsagePoint.AsObject | undefined): UsagePoint | undefined {
+	if (obj === undefined) {
+		return undefined;
+	}
+	const message = new UsagePoint();
+	message.setTime(TimestampFromObject(obj.time));
+	message.setReceiveBytes(obj.receiveBytes);
+	message.setTransmitBytes(obj.transmitBytes);
+	return message;
+}
+
+function SuspendDeviceReqFromObject(obj: SuspendDeviceReq.AsObject | undefined): SuspendDeviceReq | undefined {
+	if (obj === undefined) {
+		return undefined;
+	}
+	const message = new SuspendDeviceReq();
+	message.setName(obj.name);
+	message.setOwner(StringValueFromObject(obj.owner));
+	return message;
+}
+
+function ResumeDeviceReqFromObject(obj: ResumeDeviceReq.AsObject | undefined): ResumeDeviceReq | undefined {
+	if (obj === undefined) {
+		return undefined;
+	}
+	const message = new ResumeDeviceReq();
+	message.setName(obj.name);
+	message.setOwner(StringValueFromObject(obj.owner));
+	return message;
+}
+
 function ListAllDevicesReqFromObject(obj: ListAllDevicesReq.AsObject | undefined): ListAllDevicesReq | undefined {
 	if (obj === undefined) {
 		return undefined;
@@ -1046,6 +3391,26 @@ function ListAllDevicesResFromObject(obj: ListAllDevicesRes.AsObject | undefined
 	return message;
 }
 
+function ListPendingDeletionsReqFromObject(obj: ListPendingDeletionsReq.AsObject | undefined): ListPendingDeletionsReq | undefined {
+	if (obj === undefined) {
+		return undefined;
+	}
+	const message = new ListPendingDeletionsReq();
+	message.setWithin(DurationFromObject(obj.within));
+	return message;
+}
+
+function ListPendingDeletionsResFromObject(obj: ListPendingDeletionsRes.AsObject | undefined): ListPendingDeletionsRes | undefined {
+	if (obj === undefined) {
+		return undefined;
+	}
+	const message = new ListPendingDeletionsRes();
+	(obj.items || [])
+		.map((item) => DeviceFromObject(item))
+		.forEach((item) => message.addItems(item));
+	return message;
+}
+
 function EmptyFromObject(obj: googleProtobufEmpty.Empty.AsObject | undefined): googleProtobufEmpty.Empty | undefined {
 	if (obj === undefined) {
 		return undefined;
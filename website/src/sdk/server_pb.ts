@@ -7,6 +7,7 @@ import * as grpcWeb from 'grpc-web';
 
 import * as googleProtobufWrappers from 'google-protobuf/google/protobuf/wrappers_pb';
 import * as googleProtobufDuration from 'google-protobuf/google/protobuf/duration_pb';
+import * as googleProtobufTimestamp from 'google-protobuf/google/protobuf/timestamp_pb';
 import * as buildinfo from './buildinfo_pb';
 
 export class Server {
@@ -127,13 +128,19 @@ export declare namespace InfoRes {
 		buildInfo?: buildinfo.BuildInfo.AsObject,
 		mtu: number,
 		clientConfigPersistentKeepalive: number,
+		deviceQuota: number,
+		deviceCount: number,
+		trafficQuota: number,
+		trafficUsed: number,
+		trafficQuotaReset?: googleProtobufTimestamp.Timestamp.AsObject,
+		profiles: Array<ClientProfile.AsObject>,
 	}
 }
 
 export class InfoRes extends jspb.Message {
 
 	private static repeatedFields_ = [
-		
+		24,
 	];
 
 	constructor(data?: jspb.Message.MessageArray) {
@@ -271,6 +278,54 @@ export class InfoRes extends jspb.Message {
 		(jspb.Message as any).setProto3IntField(this, 18, value);
 	}
 
+	getDeviceQuota(): number {return jspb.Message.getFieldWithDefault(this, 19, 0);
+	}
+
+	setDeviceQuota(value: number): void {
+		(jspb.Message as any).setProto3IntField(this, 19, value);
+	}
+
+	getDeviceCount(): number {return jspb.Message.getFieldWithDefault(this, 20, 0);
+	}
+
+	setDeviceCount(value: number): void {
+		(jspb.Message as any).setProto3IntField(this, 20, value);
+	}
+
+	getTrafficQuota(): number {return jspb.Message.getFieldWithDefault(this, 21, 0);
+	}
+
+	setTrafficQuota(value: number): void {
+		(jspb.Message as any).setProto3IntField(this, 21, value);
+	}
+
+	getTrafficUsed(): number {return jspb.Message.getFieldWithDefault(this, 22, 0);
+	}
+
+	setTrafficUsed(value: number): void {
+		(jspb.Message as any).setProto3IntField(this, 22, value);
+	}
+
+	getTrafficQuotaReset(): googleProtobufTimestamp.Timestamp {
+		return jspb.Message.getWrapperField(this, googleProtobufTimestamp.Timestamp, 23);
+	}
+
+	setTrafficQuotaReset(value?: googleProtobufTimestamp.Timestamp): void {
+		(jspb.Message as any).setWrapperField(this, 23, value);
+	}
+
+	getProfiles(): Array<ClientProfile> {
+		return jspb.Message.getRepeatedWrapperField(this, ClientProfile, 24);
+	}
+
+	setProfiles(value: Array<ClientProfile>): void {
+		(jspb.Message as any).setRepeatedWrapperField(this, 24, value);
+	}
+
+	addProfiles(value?: ClientProfile, index?: number): ClientProfile {
+		return jspb.Message.addToRepeatedWrapperField(this, 24, value, ClientProfile, index);
+	}
+
 	serializeBinary(): Uint8Array {
 		const writer = new jspb.BinaryWriter();
 		InfoRes.serializeBinaryToWriter(this, writer);
@@ -298,6 +353,12 @@ export class InfoRes extends jspb.Message {
 			buildInfo: (f = this.getBuildInfo()) && f.toObject(),
 			mtu: this.getMtu(),
 			clientConfigPersistentKeepalive: this.getClientConfigPersistentKeepalive(),
+			deviceQuota: this.getDeviceQuota(),
+			deviceCount: this.getDeviceCount(),
+			trafficQuota: this.getTrafficQuota(),
+			trafficUsed: this.getTrafficUsed(),
+			trafficQuotaReset: (f = this.getTrafficQuotaReset()) && f.toObject(),
+			profiles: this.getProfiles().map((item) => item.toObject()),
 		};
 	}
 
@@ -374,6 +435,30 @@ export class InfoRes extends jspb.Message {
 		if (field18 != 0) {
 			writer.writeInt32(18, field18);
 		}
+		const field19 = message.getDeviceQuota();
+		if (field19 != 0) {
+			writer.writeInt32(19, field19);
+		}
+		const field20 = message.getDeviceCount();
+		if (field20 != 0) {
+			writer.writeInt32(20, field20);
+		}
+		const field21 = message.getTrafficQuota();
+		if (field21 != 0) {
+			writer.writeInt64(21, field21);
+		}
+		const field22 = message.getTrafficUsed();
+		if (field22 != 0) {
+			writer.writeInt64(22, field22);
+		}
+		const field23 = message.getTrafficQuotaReset();
+		if (field23 != null) {
+			writer.writeMessage(23, field23, googleProtobufTimestamp.Timestamp.serializeBinaryToWriter);
+		}
+		const field24 = message.getProfiles();
+		if (field24.length > 0) {
+			writer.writeRepeatedMessage(24, field24, ClientProfile.serializeBinaryToWriter);
+		}
 	}
 
 	static deserializeBinary(bytes: Uint8Array): InfoRes {
@@ -464,6 +549,154 @@ export class InfoRes extends jspb.Message {
 				const field18 = reader.readInt32()
 				message.setClientConfigPersistentKeepalive(field18);
 				break;
+			case 19:
+				const field19 = reader.readInt32()
+				message.setDeviceQuota(field19);
+				break;
+			case 20:
+				const field20 = reader.readInt32()
+				message.setDeviceCount(field20);
+				break;
+			case 21:
+				const field21 = reader.readInt64()
+				message.setTrafficQuota(field21);
+				break;
+			case 22:
+				const field22 = reader.readInt64()
+				message.setTrafficUsed(field22);
+				break;
+			case 23:
+				const field23 = new googleProtobufTimestamp.Timestamp();
+				reader.readMessage(field23, googleProtobufTimestamp.Timestamp.deserializeBinaryFromReader);
+				message.setTrafficQuotaReset(field23);
+				break;
+			case 24:
+				const field24 = new ClientProfile();
+				reader.readMessage(field24, ClientProfile.deserializeBinaryFromReader);
+				message.addProfiles(field24);
+				break;
+			default:
+				reader.skipField();
+				break;
+			}
+		}
+		return message;
+	}
+
+}
+export declare namespace ClientProfile {
+	export type AsObject = {
+		name: string,
+		allowedIps: string,
+		dnsServers: string,
+		mtu: number,
+	}
+}
+
+export class ClientProfile extends jspb.Message {
+
+	private static repeatedFields_ = [
+		
+	];
+
+	constructor(data?: jspb.Message.MessageArray) {
+		super();
+		jspb.Message.initialize(this, data || [], 0, -1, ClientProfile.repeatedFields_, null);
+	}
+
+
+	getName(): string {return jspb.Message.getFieldWithDefault(this, 1, "");
+	}
+
+	setName(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 1, value);
+	}
+
+	getAllowedIps(): string {return jspb.Message.getFieldWithDefault(this, 2, "");
+	}
+
+	setAllowedIps(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 2, value);
+	}
+
+	getDnsServers(): string {return jspb.Message.getFieldWithDefault(this, 3, "");
+	}
+
+	setDnsServers(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 3, value);
+	}
+
+	getMtu(): number {return jspb.Message.getFieldWithDefault(this, 4, 0);
+	}
+
+	setMtu(value: number): void {
+		(jspb.Message as any).setProto3IntField(this, 4, value);
+	}
+
+	serializeBinary(): Uint8Array {
+		const writer = new jspb.BinaryWriter();
+		ClientProfile.serializeBinaryToWriter(this, writer);
+		return writer.getResultBuffer();
+	}
+
+	toObject(): ClientProfile.AsObject {
+		let f: any;
+		return {
+			name: this.getName(),
+			allowedIps: this.getAllowedIps(),
+			dnsServers: this.getDnsServers(),
+			mtu: this.getMtu(),
+		};
+	}
+
+	static serializeBinaryToWriter(message: ClientProfile, writer: jspb.BinaryWriter): void {
+		const field1 = message.getName();
+		if (field1.length > 0) {
+			writer.writeString(1, field1);
+		}
+		const field2 = message.getAllowedIps();
+		if (field2.length > 0) {
+			writer.writeString(2, field2);
+		}
+		const field3 = message.getDnsServers();
+		if (field3.length > 0) {
+			writer.writeString(3, field3);
+		}
+		const field4 = message.getMtu();
+		if (field4 != 0) {
+			writer.writeInt32(4, field4);
+		}
+	}
+
+	static deserializeBinary(bytes: Uint8Array): ClientProfile {
+		var reader = new jspb.BinaryReader(bytes);
+		var message = new ClientProfile();
+		return ClientProfile.deserializeBinaryFromReader(message, reader);
+	}
+
+	static deserializeBinaryFromReader(message: ClientProfile, reader: jspb.BinaryReader): ClientProfile {
+		while (reader.nextField()) {
+			if (reader.isEndGroup()) {
+				break;
+			}
+			const field = reader.getFieldNumber();
+			switch (field) {
+			case 1:
+				const field1 = reader.readString()
+				message.setName(field1);
+				break;
+			case 2:
+				const field2 = reader.readString()
+				message.setAllowedIps(field2);
+				break;
+			case 3:
+				const field3 = reader.readString()
+				message.setDnsServers(field3);
+				break;
+			case 4:
+				const field4 = reader.readInt32()
+				message.setMtu(field4);
+				break;
 			default:
 				reader.skipField();
 				break;
@@ -506,6 +739,14 @@ function InfoResFromObject(obj: InfoRes.AsObject | undefined): InfoRes | undefin
 	message.setBuildInfo(BuildInfoFromObject(obj.buildInfo));
 	message.setMtu(obj.mtu);
 	message.setClientConfigPersistentKeepalive(obj.clientConfigPersistentKeepalive);
+	message.setDeviceQuota(obj.deviceQuota);
+	message.setDeviceCount(obj.deviceCount);
+	message.setTrafficQuota(obj.trafficQuota);
+	message.setTrafficUsed(obj.trafficUsed);
+	message.setTrafficQuotaReset(TimestampFromObject(obj.trafficQuotaReset));
+	(obj.profiles || [])
+		.map((item) => ClientProfileFromObject(item))
+		.forEach((item) => message.addProfiles(item));
 	return message;
 }
 
@@ -538,3 +779,25 @@ function BuildInfoFromObject(obj: buildinfo.BuildInfo.AsObject | undefined): bui
 	return message;
 }
 
+function TimestampFromObject(obj: googleProtobufTimestamp.Timestamp.AsObject | undefined): googleProtobufTimestamp.Timestamp | undefined {
+	if (obj === undefined) {
+		return undefined;
+	}
+	const message = new googleProtobufTimestamp.Timestamp();
+	message.setSeconds(obj.seconds);
+	message.setNanos(obj.nanos);
+	return message;
+}
+
+function ClientProfileFromObject(obj: ClientProfile.AsObject | undefined): ClientProfile | undefined {
+	if (obj === undefined) {
+		return undefined;
+	}
+	const message = new ClientProfile();
+	message.setName(obj.name);
+	message.setAllowedIps(obj.allowedIps);
+	message.setDnsServers(obj.dnsServers);
+	message.setMtu(obj.mtu);
+	return message;
+}
+
@@ -31,6 +31,24 @@ export class Users {
 		googleProtobufEmpty.Empty.deserializeBinary
 	);
 
+	private methodInfoSuspendUser = new grpcWeb.MethodDescriptor<SuspendUserReq, googleProtobufEmpty.Empty>(
+		"SuspendUser",
+		null,
+		SuspendUserReq,
+		googleProtobufEmpty.Empty,
+		(req: SuspendUserReq) => req.serializeBinary(),
+		googleProtobufEmpty.Empty.deserializeBinary
+	);
+
+	private methodInfoResumeUser = new grpcWeb.MethodDescriptor<ResumeUserReq, googleProtobufEmpty.Empty>(
+		"ResumeUser",
+		null,
+		ResumeUserReq,
+		googleProtobufEmpty.Empty,
+		(req: ResumeUserReq) => req.serializeBinary(),
+		googleProtobufEmpty.Empty.deserializeBinary
+	);
+
 	constructor(
 		private hostname: string,
 		private defaultMetadata?: () => grpcWeb.Metadata,
@@ -74,6 +92,44 @@ export class Users {
 		});
 	}
 
+	suspendUser(req: SuspendUserReq.AsObject, metadata?: grpcWeb.Metadata): Promise<googleProtobufEmpty.Empty.AsObject> {
+		return new Promise((resolve, reject) => {
+			const message = SuspendUserReqFromObject(req);
+			this.client_.rpcCall(
+				this.hostname + '/proto.Users/SuspendUser',
+				message,
+				Object.assign({}, this.defaultMetadata ? this.defaultMetadata() : {}, metadata),
+				this.methodInfoSuspendUser,
+				(err: grpcWeb.Error, res: googleProtobufEmpty.Empty) => {
+					if (err) {
+						reject(err);
+					} else {
+						resolve(res.toObject());
+					}
+				},
+			);
+		});
+	}
+
+	resumeUser(req: ResumeUserReq.AsObject, metadata?: grpcWeb.Metadata): Promise<googleProtobufEmpty.Empty.AsObject> {
+		return new Promise((resolve, reject) => {
+			const message = ResumeUserReqFromObject(req);
+			this.client_.rpcCall(
+				this.hostname + '/proto.Users/ResumeUser',
+				message,
+				Object.assign({}, this.defaultMetadata ? this.defaultMetadata() : {}, metadata),
+				this.methodInfoResumeUser,
+				(err: grpcWeb.Error, res: googleProtobufEmpty.Empty) => {
+					if (err) {
+						reject(err);
+					} else {
+						resolve(res.toObject());
+					}
+				},
+			);
+		});
+	}
+
 }
 
 
@@ -369,6 +425,148 @@ export class DeleteUserReq extends jspb.Message {
 	}
 
 }
+export declare namespace SuspendUserReq {
+	export type AsObject = {
+		name: string,
+	}
+}
+
+export class SuspendUserReq extends jspb.Message {
+
+	private static repeatedFields_ = [
+		
+	];
+
+	constructor(data?: jspb.Message.MessageArray) {
+		super();
+		jspb.Message.initialize(this, data || [], 0, -1, SuspendUserReq.repeatedFields_, null);
+	}
+
+
+	getName(): string {return jspb.Message.getFieldWithDefault(this, 1, "");
+	}
+
+	setName(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 1, value);
+	}
+
+	serializeBinary(): Uint8Array {
+		const writer = new jspb.BinaryWriter();
+		SuspendUserReq.serializeBinaryToWriter(this, writer);
+		return writer.getResultBuffer();
+	}
+
+	toObject(): SuspendUserReq.AsObject {
+		let f: any;
+		return {
+			name: this.getName(),
+		};
+	}
+
+	static serializeBinaryToWriter(message: SuspendUserReq, writer: jspb.BinaryWriter): void {
+		const field1 = message.getName();
+		if (field1.length > 0) {
+			writer.writeString(1, field1);
+		}
+	}
+
+	static deserializeBinary(bytes: Uint8Array): SuspendUserReq {
+		var reader = new jspb.BinaryReader(bytes);
+		var message = new SuspendUserReq();
+		return SuspendUserReq.deserializeBinaryFromReader(message, reader);
+	}
+
+	static deserializeBinaryFromReader(message: SuspendUserReq, reader: jspb.BinaryReader): SuspendUserReq {
+		while (reader.nextField()) {
+			if (reader.isEndGroup()) {
+				break;
+			}
+			const field = reader.getFieldNumber();
+			switch (field) {
+			case 1:
+				const field1 = reader.readString()
+				message.setName(field1);
+				break;
+			default:
+				reader.skipField();
+				break;
+			}
+		}
+		return message;
+	}
+
+}
+export declare namespace ResumeUserReq {
+	export type AsObject = {
+		name: string,
+	}
+}
+
+export class ResumeUserReq extends jspb.Message {
+
+	private static repeatedFields_ = [
+		
+	];
+
+	constructor(data?: jspb.Message.MessageArray) {
+		super();
+		jspb.Message.initialize(this, data || [], 0, -1, ResumeUserReq.repeatedFields_, null);
+	}
+
+
+	getName(): string {return jspb.Message.getFieldWithDefault(this, 1, "");
+	}
+
+	setName(value: string): void {
+		(jspb.Message as any).setProto3StringField(this, 1, value);
+	}
+
+	serializeBinary(): Uint8Array {
+		const writer = new jspb.BinaryWriter();
+		ResumeUserReq.serializeBinaryToWriter(this, writer);
+		return writer.getResultBuffer();
+	}
+
+	toObject(): ResumeUserReq.AsObject {
+		let f: any;
+		return {
+			name: this.getName(),
+		};
+	}
+
+	static serializeBinaryToWriter(message: ResumeUserReq, writer: jspb.BinaryWriter): void {
+		const field1 = message.getName();
+		if (field1.length > 0) {
+			writer.writeString(1, field1);
+		}
+	}
+
+	static deserializeBinary(bytes: Uint8Array): ResumeUserReq {
+		var reader = new jspb.BinaryReader(bytes);
+		var message = new ResumeUserReq();
+		return ResumeUserReq.deserializeBinaryFromReader(message, reader);
+	}
+
+	static deserializeBinaryFromReader(message: ResumeUserReq, reader: jspb.BinaryReader): ResumeUserReq {
+		while (reader.nextField()) {
+			if (reader.isEndGroup()) {
+				break;
+			}
+			const field = reader.getFieldNumber();
+			switch (field) {
+			case 1:
+				const field1 = reader.readString()
+				message.setName(field1);
+				break;
+			default:
+				reader.skipField();
+				break;
+			}
+		}
+		return message;
+	}
+
+}
 
 
 function UserFromObject(obj: User.AsObject | undefined): User | undefined {
@@ -409,6 +607,24 @@ function DeleteUserReqFromObject(obj: DeleteUserReq.AsObject | undefined): Delet
 	return message;
 }
 
+function SuspendUserReqFromObject(obj: SuspendUserReq.AsObject | undefined): SuspendUserReq | undefined {
+	if (obj === undefined) {
+		return undefined;
+	}
+	const message = new SuspendUserReq();
+	message.setName(obj.name);
+	return message;
+}
+
+function ResumeUserReqFromObject(obj: ResumeUserReq.AsObject | undefined): ResumeUserReq | undefined {
+	if (obj === undefined) {
+		return undefined;
+	}
+	const message = new ResumeUserReq();
+	message.setName(obj.name);
+	return message;
+}
+
 function EmptyFromObject(obj: googleProtobufEmpty.Empty.AsObject | undefined): googleProtobufEmpty.Empty | undefined {
 	if (obj === undefined) {
 		return undefined;